@@ -17,6 +17,7 @@ import (
 	"github.com/harvester/harvester/pkg/cmd"
 	"github.com/harvester/harvester/pkg/config"
 	"github.com/harvester/harvester/pkg/server"
+	"github.com/harvester/harvester/pkg/util"
 )
 
 func main() {
@@ -63,6 +64,19 @@ func main() {
 			Usage:       "Specify whether the Harvester is running with embedded Rancher mode, default to false",
 			Destination: &options.RancherEmbedded,
 		},
+		cli.StringFlag{
+			Name:        "image-conversion-dir",
+			EnvVar:      "IMAGE_CONVERSION_DIR",
+			Usage:       "The scratch directory the VM image files are converted in",
+			Destination: &options.ImageConversionDir,
+		},
+		cli.StringFlag{
+			Name:        "image-conversion-size-limit",
+			EnvVar:      "IMAGE_CONVERSION_SIZE_LIMIT",
+			Usage:       "The size limit of the scratch directory the VM image files are converted in",
+			Value:       util.DefaultImageConversionSizeLimit,
+			Destination: &options.ImageConversionSizeLimit,
+		},
 		cli.StringFlag{
 			Name:        "rancher-server-url",
 			EnvVar:      "RANCHER_SERVER_URL",
@@ -82,6 +96,10 @@ func run(commonOptions *config.CommonOptions, options config.Options) error {
 	logrus.Info("Starting controller")
 	ctx := signals.SetupSignalContext()
 
+	if err := util.SetImageConversionScratch(options.ImageConversionDir, options.ImageConversionSizeLimit); err != nil {
+		return err
+	}
+
 	kubeConfig, err := server.GetConfig(commonOptions.KubeConfig)
 	if err != nil {
 		return fmt.Errorf("failed to find kubeconfig: %v", err)
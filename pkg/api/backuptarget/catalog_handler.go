@@ -0,0 +1,277 @@
+package backuptarget
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rancher/steve/pkg/accesscontrol"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	"github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	defaultCatalogLimit = 50
+	maxCatalogLimit     = 500
+	// catalogCacheTTL is how long the vm backup metadata loaded from a backup target is reused
+	catalogCacheTTL = time.Minute
+)
+
+var vmBackupGroupResource = schema.GroupResource{
+	Group:    harvesterv1.SchemeGroupVersion.Group,
+	Resource: harvesterv1.VirtualMachineBackupResourceName,
+}
+
+// Catalog is a page of the vm backups stored in a backup target
+type Catalog struct {
+	BackupTarget string `json:"backupTarget"`
+	// Total is the number of the vm backups matching the filters
+	Total   int             `json:"total"`
+	Offset  int             `json:"offset"`
+	Limit   int             `json:"limit"`
+	VMs     []CatalogVM     `json:"vms"`
+	Backups []CatalogBackup `json:"backups"`
+}
+
+// CatalogVM summarizes the vm backups of a VM matching the filters
+type CatalogVM struct {
+	Name             string       `json:"name"`
+	Namespace        string       `json:"namespace"`
+	BackupCount      int          `json:"backupCount"`
+	LastCreationTime *metav1.Time `json:"lastCreationTime,omitempty"`
+}
+
+type CatalogBackup struct {
+	Name          string          `json:"name"`
+	Namespace     string          `json:"namespace"`
+	VMName        string          `json:"vmName"`
+	SourceCluster string          `json:"sourceCluster,omitempty"`
+	CreationTime  *metav1.Time    `json:"creationTime,omitempty"`
+	Size          int64           `json:"size"`
+	Volumes       []CatalogVolume `json:"volumes"`
+	// Imported is true if the vm backup exists in this cluster
+	Imported bool `json:"imported"`
+}
+
+type CatalogVolume struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	LonghornBackupName string `json:"longhornBackupName,omitempty"`
+}
+
+type CatalogHandler struct {
+	backupTargetCache v1beta1.NamedBackupTargetCache
+	secretCache       ctlcorev1.SecretCache
+	vmBackupCache     v1beta1.VirtualMachineBackupCache
+	accessSetLookup   accesscontrol.AccessSetLookup
+	// listBackupMetadata is replaced in tests
+	listBackupMetadata func(backupTargetName string) ([]*backup.VirtualMachineBackupMetadata, error)
+
+	mutex   sync.Mutex
+	entries map[string]*catalogCacheEntry
+}
+
+// catalogCacheEntry is the vm backup metadata loaded from a backup target,
+// its mutex is held while loading so that concurrent requests don't load the same backup target again.
+type catalogCacheEntry struct {
+	mutex           sync.Mutex
+	loadTime        time.Time
+	backupMetadatas []*backup.VirtualMachineBackupMetadata
+}
+
+func NewCatalogHandler(scaled *config.Scaled, accessSetLookup accesscontrol.AccessSetLookup) *CatalogHandler {
+	h := &CatalogHandler{
+		backupTargetCache: scaled.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache(),
+		secretCache:       scaled.CoreFactory.Core().V1().Secret().Cache(),
+		vmBackupCache:     scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+		accessSetLookup:   accessSetLookup,
+		entries:           map[string]*catalogCacheEntry{},
+	}
+	h.listBackupMetadata = func(backupTargetName string) ([]*backup.VirtualMachineBackupMetadata, error) {
+		return backup.ListBackupMetadata(h.backupTargetCache, h.secretCache, backupTargetName)
+	}
+	return h
+}
+
+// ServeHTTP lists the vm backups in the backup target, it supports the following query parameters:
+// backupTarget, namespace, vm, sourceCluster, offset and limit.
+// Only the vm backups in the namespaces where the user can list vm backups are returned.
+func (h *CatalogHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	user, ok := request.UserFrom(r.Context())
+	if !ok {
+		util.ResponseErrorMsg(rw, http.StatusUnauthorized, "failed to get user from request")
+		return
+	}
+	accessSet := h.accessSetLookup.AccessFor(user)
+
+	query := r.URL.Query()
+	backupTargetName := query.Get("backupTarget")
+	if backupTargetName == "" {
+		backupTargetName = harvesterv1.DefaultBackupTargetName
+	}
+	offset, err := parseCatalogQueryInt(query.Get("offset"), 0)
+	if err != nil {
+		util.ResponseError(rw, http.StatusBadRequest, fmt.Errorf("invalid offset: %w", err))
+		return
+	}
+	limit, err := parseCatalogQueryInt(query.Get("limit"), defaultCatalogLimit)
+	if err != nil || limit == 0 || limit > maxCatalogLimit {
+		util.ResponseErrorMsg(rw, http.StatusBadRequest, fmt.Sprintf("limit should be between 1 and %d", maxCatalogLimit))
+		return
+	}
+
+	backupMetadatas, err := h.getBackupMetadata(backupTargetName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			util.ResponseError(rw, http.StatusNotFound, err)
+			return
+		}
+		util.ResponseError(rw, http.StatusServiceUnavailable, fmt.Errorf("can't list vm backups in backup target %s, error: %w", backupTargetName, err))
+		return
+	}
+
+	catalog := &Catalog{
+		BackupTarget: backupTargetName,
+		Offset:       offset,
+		Limit:        limit,
+		VMs:          []CatalogVM{},
+		Backups:      []CatalogBackup{},
+	}
+	var backups []CatalogBackup
+	for _, backupMetadata := range backupMetadatas {
+		catalogBackup := h.toCatalogBackup(backupMetadata)
+		if !accessSet.Grants("list", vmBackupGroupResource, catalogBackup.Namespace, "") {
+			continue
+		}
+		if matchCatalogFilters(catalogBackup, query.Get("namespace"), query.Get("vm"), query.Get("sourceCluster")) {
+			backups = append(backups, catalogBackup)
+		}
+	}
+
+	// the latest backups first
+	sort.SliceStable(backups, func(i, j int) bool {
+		if backups[i].CreationTime == nil || backups[j].CreationTime == nil {
+			return backups[j].CreationTime == nil && backups[i].CreationTime != nil
+		}
+		return backups[j].CreationTime.Before(backups[i].CreationTime)
+	})
+
+	catalog.Total = len(backups)
+	catalog.VMs = summarizeCatalogVMs(backups)
+	if offset < len(backups) {
+		end := offset + limit
+		if end > len(backups) {
+			end = len(backups)
+		}
+		catalog.Backups = backups[offset:end]
+	}
+	util.ResponseOKWithBody(rw, catalog)
+}
+
+// getBackupMetadata returns the vm backup metadata of the backup target loaded in the last catalogCacheTTL,
+// or loads it again from the backup target.
+func (h *CatalogHandler) getBackupMetadata(backupTargetName string) ([]*backup.VirtualMachineBackupMetadata, error) {
+	h.mutex.Lock()
+	entry, ok := h.entries[backupTargetName]
+	if !ok {
+		entry = &catalogCacheEntry{}
+		h.entries[backupTargetName] = entry
+	}
+	h.mutex.Unlock()
+
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+	if time.Since(entry.loadTime) < catalogCacheTTL {
+		return entry.backupMetadatas, nil
+	}
+	backupMetadatas, err := h.listBackupMetadata(backupTargetName)
+	if err != nil {
+		return nil, err
+	}
+	entry.backupMetadatas = backupMetadatas
+	entry.loadTime = time.Now()
+	return backupMetadatas, nil
+}
+
+func (h *CatalogHandler) toCatalogBackup(backupMetadata *backup.VirtualMachineBackupMetadata) CatalogBackup {
+	namespace := backupMetadata.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	catalogBackup := CatalogBackup{
+		Name:          backupMetadata.Name,
+		Namespace:     namespace,
+		VMName:        backupMetadata.BackupSpec.Source.Name,
+		SourceCluster: backupMetadata.SourceCluster,
+		CreationTime:  backupMetadata.CreationTime,
+		Volumes:       []CatalogVolume{},
+	}
+	for _, volumeBackup := range backupMetadata.VolumeBackups {
+		volume := CatalogVolume{
+			Name: volumeBackup.VolumeName,
+			Size: volumeBackup.PersistentVolumeClaim.Spec.Resources.Requests.Storage().Value(),
+		}
+		if volumeBackup.LonghornBackupName != nil {
+			volume.LonghornBackupName = *volumeBackup.LonghornBackupName
+		}
+		catalogBackup.Size += volume.Size
+		catalogBackup.Volumes = append(catalogBackup.Volumes, volume)
+	}
+	if _, err := h.vmBackupCache.Get(namespace, backupMetadata.Name); err == nil {
+		catalogBackup.Imported = true
+	}
+	return catalogBackup
+}
+
+func matchCatalogFilters(catalogBackup CatalogBackup, namespace, vmName, sourceCluster string) bool {
+	return (namespace == "" || catalogBackup.Namespace == namespace) &&
+		(vmName == "" || catalogBackup.VMName == vmName) &&
+		(sourceCluster == "" || catalogBackup.SourceCluster == sourceCluster)
+}
+
+// summarizeCatalogVMs groups the sorted backups by VM, the VMs with the latest backups come first
+func summarizeCatalogVMs(backups []CatalogBackup) []CatalogVM {
+	vms := []CatalogVM{}
+	indexes := map[string]int{}
+	for _, catalogBackup := range backups {
+		key := catalogBackup.Namespace + "/" + catalogBackup.VMName
+		i, ok := indexes[key]
+		if !ok {
+			i = len(vms)
+			indexes[key] = i
+			vms = append(vms, CatalogVM{
+				Name:             catalogBackup.VMName,
+				Namespace:        catalogBackup.Namespace,
+				LastCreationTime: catalogBackup.CreationTime,
+			})
+		}
+		vms[i].BackupCount++
+	}
+	return vms
+}
+
+func parseCatalogQueryInt(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("%d is negative", i)
+	}
+	return i, nil
+}
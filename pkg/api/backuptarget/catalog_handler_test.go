@@ -0,0 +1,80 @@
+package backuptarget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+type fakeAccessSetLookup struct {
+	accessSet *accesscontrol.AccessSet
+}
+
+func (l fakeAccessSetLookup) AccessFor(_ user.Info) *accesscontrol.AccessSet {
+	return l.accessSet
+}
+
+func TestCatalogHandler(t *testing.T) {
+	accessSet := &accesscontrol.AccessSet{}
+	accessSet.Add("list", vmBackupGroupResource, accesscontrol.Access{Namespace: "allowed", ResourceName: accesscontrol.All})
+
+	clientSet := fake.NewSimpleClientset()
+	var loads int
+	handler := &CatalogHandler{
+		vmBackupCache:   fakeclients.VMBackupCache(clientSet.HarvesterhciV1beta1().VirtualMachineBackups),
+		accessSetLookup: fakeAccessSetLookup{accessSet: accessSet},
+		entries:         map[string]*catalogCacheEntry{},
+		listBackupMetadata: func(backupTargetName string) ([]*backup.VirtualMachineBackupMetadata, error) {
+			loads++
+			return []*backup.VirtualMachineBackupMetadata{
+				{
+					Name:       "backup-1",
+					Namespace:  "allowed",
+					BackupSpec: harvesterv1.VirtualMachineBackupSpec{Source: corev1.TypedLocalObjectReference{Name: "vm-1"}},
+				},
+				{
+					Name:       "backup-2",
+					Namespace:  "denied",
+					BackupSpec: harvesterv1.VirtualMachineBackupSpec{Source: corev1.TypedLocalObjectReference{Name: "vm-2"}},
+				},
+			}, nil
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/v1/harvester/backuptarget/catalog", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "user"}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		catalog := &Catalog{}
+		assert.Nil(t, json.Unmarshal(rw.Body.Bytes(), catalog))
+		assert.Equal(t, 1, catalog.Total)
+		if assert.Len(t, catalog.Backups, 1) {
+			assert.Equal(t, "backup-1", catalog.Backups[0].Name)
+		}
+		if assert.Len(t, catalog.VMs, 1) {
+			assert.Equal(t, "vm-1", catalog.VMs[0].Name)
+		}
+	}
+	// the second request is served from the cache
+	assert.Equal(t, 1, loads)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/harvester/backuptarget/catalog", nil)
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+}
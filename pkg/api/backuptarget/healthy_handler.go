@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
 
-	"github.com/longhorn/backupstore"
 	// Although we don't use following drivers directly, we need to import them to register drivers.
 	// NFS Ref: https://github.com/longhorn/backupstore/blob/3912081eb7c5708f0027ebbb0da4934537eb9d72/nfs/nfs.go#L47-L51
 	// S3 Ref: https://github.com/longhorn/backupstore/blob/3912081eb7c5708f0027ebbb0da4934537eb9d72/s3/s3.go#L33-L37
@@ -62,14 +60,14 @@ func (h *HealthyHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			util.ResponseError(rw, http.StatusInternalServerError, fmt.Errorf("can't get backup target secret: %s/%s, error: %w", util.LonghornSystemNamespaceName, util.BackupTargetSecretName, err))
 			return
 		}
-		os.Setenv(backup.AWSAccessKey, string(secret.Data[backup.AWSAccessKey]))
-		os.Setenv(backup.AWSSecretKey, string(secret.Data[backup.AWSSecretKey]))
-		os.Setenv(backup.AWSEndpoints, string(secret.Data[backup.AWSEndpoints]))
-		os.Setenv(backup.AWSCERT, string(secret.Data[backup.AWSCERT]))
+		target.AccessKeyID = string(secret.Data[backup.AWSAccessKey])
+		target.SecretAccessKey = string(secret.Data[backup.AWSSecretKey])
+		target.Endpoint = string(secret.Data[backup.AWSEndpoints])
+		target.Cert = string(secret.Data[backup.AWSCERT])
 	}
 
-	_, err = backupstore.GetBackupStoreDriver(backup.ConstructEndpoint(target))
-	if err != nil {
+	// the S3 credentials are set with the backupstore mutex of the backup controllers held
+	if err := backup.CheckBackupStore(target); err != nil {
 		util.ResponseError(rw, http.StatusServiceUnavailable, fmt.Errorf("can't connect to backup target %+v, error: %w", target, err))
 		return
 	}
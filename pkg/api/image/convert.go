@@ -0,0 +1,70 @@
+package image
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlimage "github.com/harvester/harvester/pkg/controller/master/image"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// uploadToBackingImage detects the format of the uploaded file and uploads it to the backing image of the image.
+// The files which can't be consumed by Longhorn as-is are converted to raw, and verified before the conversion.
+func (h Handler) uploadToBackingImage(ctx context.Context, image *apisv1beta1.VirtualMachineImage, fileName string, data io.Reader, size int64) error {
+	reader := bufio.NewReaderSize(data, util.ImageFormatHeaderSize)
+	header, err := reader.Peek(util.ImageFormatHeaderSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	format := util.DetectImageFormat(header)
+	if err := h.updateImage(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+		toUpdate.Status.Format = format
+	}); err != nil {
+		return err
+	}
+
+	backingImageName := util.GetBackingImageName(image)
+	if !util.NeedImageConversion(format) {
+		return util.UploadBackingImage(ctx, &h.httpClient, backingImageName, fileName, reader, size)
+	}
+
+	var verify func(io.Reader) error
+	if util.NeedImageVerification(image) {
+		verify = func(source io.Reader) error {
+			verifyErr := h.verifier.Verify(image, source)
+			// the failures to read the uploaded data fail the upload
+			if verifyErr != nil && !ctlimage.IsVerificationError(verifyErr) {
+				return verifyErr
+			}
+			if err := h.updateImage(image, func(toUpdate *apisv1beta1.VirtualMachineImage) {
+				ctlimage.SetVerifiedCondition(toUpdate, verifyErr)
+			}); err != nil {
+				return err
+			}
+			return verifyErr
+		}
+	}
+	return util.ConvertAndUploadBackingImage(ctx, &h.httpClient, backingImageName, fileName, format, io.LimitReader(reader, size), verify)
+}
+
+// updateImage updates the status of the image with the mutate function, it's skipped if the image is being deleted
+func (h Handler) updateImage(image *apisv1beta1.VirtualMachineImage, mutate func(*apisv1beta1.VirtualMachineImage)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.Images.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current.UID != image.UID || current.DeletionTimestamp != nil {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		mutate(toUpdate)
+		_, err = h.Images.Update(toUpdate)
+		return err
+	})
+}
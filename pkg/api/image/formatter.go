@@ -3,10 +3,11 @@ package image
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -20,14 +21,17 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlimage "github.com/harvester/harvester/pkg/controller/master/image"
 	"github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctllhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
 	"github.com/harvester/harvester/pkg/util"
 )
 
 const (
-	actionUpload   = "upload"
-	actionDownload = "download"
+	actionUpload      = "upload"
+	actionUploadStart = "uploadStart"
+	actionUploadChunk = "uploadChunk"
+	actionDownload    = "download"
 )
 
 func Formatter(request *types.APIRequest, resource *types.RawResource) {
@@ -38,6 +42,10 @@ func Formatter(request *types.APIRequest, resource *types.RawResource) {
 
 	if resource.APIObject.Data().String("spec", "sourceType") == apisv1beta1.VirtualMachineImageSourceTypeUpload {
 		resource.AddAction(request, actionUpload)
+		resource.AddAction(request, actionUploadStart)
+		if resource.APIObject.Data().Map("status", "uploadSession") != nil {
+			resource.AddAction(request, actionUploadChunk)
+		}
 	}
 }
 
@@ -47,6 +55,12 @@ type Handler struct {
 	ImageCache                  v1beta1.VirtualMachineImageCache
 	BackingImageDataSources     ctllhv1beta1.BackingImageDataSourceClient
 	BackingImageDataSourceCache ctllhv1beta1.BackingImageDataSourceCache
+	// verifier verifies the uploaded files before they are converted
+	verifier *ctlimage.Verifier
+
+	// server is the address of this server, the chunks of upload sessions started on other servers are rejected
+	server         string
+	uploadSessions *uploadSessions
 }
 
 func (h Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -86,6 +100,10 @@ func (h Handler) doPost(action string, rw http.ResponseWriter, req *http.Request
 	switch action {
 	case actionUpload:
 		return h.uploadImage(rw, req)
+	case actionUploadStart:
+		return h.uploadStart(rw, req)
+	case actionUploadChunk:
+		return h.uploadChunk(rw, req)
 	default:
 		return apierror.NewAPIError(validation.InvalidAction, fmt.Sprintf("Unsupported POST action %s", action))
 	}
@@ -139,6 +157,10 @@ func (h Handler) uploadImage(rw http.ResponseWriter, req *http.Request) error {
 	if err != nil {
 		return err
 	}
+	size, err := strconv.ParseInt(req.URL.Query().Get("size"), 10, 64)
+	if err != nil || size <= 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `size` must be greater than 0")
+	}
 
 	defer func() {
 		if err != nil {
@@ -154,37 +176,32 @@ func (h Handler) uploadImage(rw http.ResponseWriter, req *http.Request) error {
 		return err
 	}
 
-	uploadURL := fmt.Sprintf("%s/backingimages/%s-%s", util.LonghornDefaultManagerURL, namespace, name)
-	uploadReq, err := http.NewRequestWithContext(req.Context(), http.MethodPost, uploadURL, req.Body)
+	// the file is taken from the form field "chunk" like the backing image data source does
+	mr, err := req.MultipartReader()
 	if err != nil {
-		return fmt.Errorf("failed to create the upload request: %w", err)
+		err = fmt.Errorf("failed to read the upload request: %w", err)
+		return err
+	}
+	var part *multipart.Part
+	for {
+		if part, err = mr.NextPart(); err != nil {
+			err = fmt.Errorf("failed to read the uploaded file: %w", err)
+			return err
+		}
+		if part.FormName() == "chunk" {
+			break
+		}
 	}
-	uploadReq.Header = req.Header
-	uploadReq.URL.RawQuery = req.URL.RawQuery
 
 	var urlErr *url.Error
-	uploadResp, err := h.httpClient.Do(uploadReq)
+	err = h.uploadToBackingImage(req.Context(), image, part.FileName(), part, size)
 	if errors.As(err, &urlErr) {
 		// Trim the "POST http://xxx" implementation detail for the error
 		// set the err var and it will be recorded in image condition in the defer function
 		err = errors.Unwrap(urlErr)
 		return err
-	} else if err != nil {
-		return fmt.Errorf("failed to send the upload request: %w", err)
 	}
-	defer uploadResp.Body.Close()
-
-	body, err := ioutil.ReadAll(uploadResp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-	if uploadResp.StatusCode >= http.StatusBadRequest {
-		// err will be recorded in image condition in the defer function
-		err = fmt.Errorf("upload failed: %s", string(body))
-		return err
-	}
-
-	return nil
+	return err
 }
 
 func (h Handler) waitForBackingImageDataSourceReady(name string) error {
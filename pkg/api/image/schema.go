@@ -1,23 +1,38 @@
 package image
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/steve/pkg/server"
 	"github.com/rancher/wrangler/pkg/schemas"
 
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/config"
+	ctlimage "github.com/harvester/harvester/pkg/controller/master/image"
 )
 
+const podIPEnv = "POD_IP"
+
 func RegisterSchema(scaled *config.Scaled, server *server.Server, options config.Options) error {
+	server.BaseSchemas.MustImportAndCustomize(UploadStartInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(apisv1beta1.VirtualMachineImageUploadSession{}, nil)
 	imgHandler := Handler{
 		httpClient:                  http.Client{},
 		Images:                      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage(),
 		ImageCache:                  scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache(),
 		BackingImageDataSources:     scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource(),
 		BackingImageDataSourceCache: scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource().Cache(),
+		verifier: ctlimage.NewVerifier(&http.Client{Timeout: 15 * time.Second},
+			scaled.CoreFactory.Core().V1().Secret().Cache()),
+		uploadSessions: newUploadSessions(),
+	}
+	if podIP := os.Getenv(podIPEnv); podIP != "" {
+		imgHandler.server = fmt.Sprintf("%s:%d", podIP, options.HTTPSListenPort)
 	}
 
 	t := schema.Template{
@@ -26,6 +41,13 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 			s.Formatter = Formatter
 			s.ResourceActions = map[string]schemas.Action{
 				actionUpload: {},
+				actionUploadStart: {
+					Input:  "uploadStartInput",
+					Output: "virtualMachineImageUploadSession",
+				},
+				actionUploadChunk: {
+					Output: "virtualMachineImageUploadSession",
+				},
 			}
 			/*
 			 * ActionHandlers would let people define their own `POST` method.
@@ -33,7 +55,9 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 			 * pair in the current HTTP requests.
 			 */
 			s.ActionHandlers = map[string]http.Handler{
-				actionUpload: imgHandler,
+				actionUpload:      imgHandler,
+				actionUploadStart: imgHandler,
+				actionUploadChunk: imgHandler,
 			}
 			/*
 			 * LinkHandlers would let people define their own `GET` method.
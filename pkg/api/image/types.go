@@ -0,0 +1,8 @@
+package image
+
+type UploadStartInput struct {
+	// Size of the file to upload in bytes
+	Size int64 `json:"size"`
+	// FileName is the name of the file to upload, it's the display name of the image if it's empty
+	FileName string `json:"fileName,omitempty"`
+}
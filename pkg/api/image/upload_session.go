@@ -0,0 +1,267 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/util/retry"
+
+	apisv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// uploadSessionIdleTimeout aborts the upload sessions which don't receive chunks
+const uploadSessionIdleTimeout = time.Hour
+
+var errUploadSessionIdle = errors.New("upload session is idle for too long")
+
+// uploadSessions holds the resumable upload sessions served by this server. The chunks of a session
+// are streamed to a single upload request to the backing image in the Longhorn manager, so a session
+// is served by the server it's started on, and the chunks received by other servers are rejected for
+// the client to retry. The sessions are in memory only, they are lost when the server restarts and
+// the upload has to be started again.
+type uploadSessions struct {
+	sync.Mutex
+	sessions map[string]*uploadSession
+}
+
+type uploadSession struct {
+	sync.Mutex
+	id        string
+	size      int64
+	offset    int64
+	writer    *io.PipeWriter
+	idleTimer *time.Timer
+	// done is closed when the upload request to the backing image data source returns with err
+	done chan struct{}
+	err  error
+}
+
+func newUploadSessions() *uploadSessions {
+	return &uploadSessions{sessions: map[string]*uploadSession{}}
+}
+
+func (s *uploadSessions) get(key string) *uploadSession {
+	s.Lock()
+	defer s.Unlock()
+	return s.sessions[key]
+}
+
+func (s *uploadSessions) set(key string, session *uploadSession) {
+	s.Lock()
+	defer s.Unlock()
+	s.sessions[key] = session
+}
+
+func (s *uploadSessions) remove(key string, session *uploadSession) {
+	s.Lock()
+	defer s.Unlock()
+	if s.sessions[key] == session {
+		delete(s.sessions, key)
+	}
+}
+
+// write writes the chunk at the offset to the upload request, and returns the offset of the next chunk.
+// The upload request is completed after the last chunk is written.
+func (s *uploadSession) write(offset int64, chunk io.Reader) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+	if offset != s.offset {
+		return s.offset, apierror.NewAPIError(validation.Conflict, fmt.Sprintf("offset %d doesn't match offset %d of upload session %s", offset, s.offset, s.id))
+	}
+	s.idleTimer.Reset(uploadSessionIdleTimeout)
+
+	n, err := io.Copy(s.writer, io.LimitReader(chunk, s.size-s.offset))
+	s.offset += n
+	if err != nil {
+		return s.offset, fmt.Errorf("upload session %s is interrupted at offset %d: %w", s.id, s.offset, err)
+	}
+	if s.offset < s.size {
+		return s.offset, nil
+	}
+
+	s.idleTimer.Stop()
+	if err := s.writer.Close(); err != nil {
+		return s.offset, err
+	}
+	<-s.done
+	return s.offset, s.err
+}
+
+func (h Handler) uploadStart(rw http.ResponseWriter, req *http.Request) error {
+	vars := util.EncodeVars(mux.Vars(req))
+	namespace, name := vars["namespace"], vars["name"]
+
+	var input UploadStartInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: "+err.Error())
+	}
+	if input.Size <= 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `size` must be greater than 0")
+	}
+
+	image, err := h.Images.Get(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if image.Spec.SourceType != apisv1beta1.VirtualMachineImageSourceTypeUpload {
+		return apierror.NewAPIError(validation.InvalidAction, "Only images of the upload source type can be uploaded")
+	}
+	if apisv1beta1.ImageImported.IsTrue(image) {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("image %s/%s is already uploaded", namespace, name))
+	}
+
+	// the data source isn't pending once the upload request of a session is sent,
+	// it's recreated to be pending again if the upload fails.
+	dsName := util.GetBackingImageName(image)
+	if session := image.Status.UploadSession; session != nil {
+		ds, err := h.BackingImageDataSourceCache.Get(util.LonghornSystemNamespaceName, dsName)
+		if err == nil && ds.Status.CurrentState != lhv1beta1.BackingImageStatePending {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("upload session %s is in progress, resume it from offset %d", session.ID, session.Offset))
+		}
+	}
+	if err := h.waitForBackingImageDataSourceReady(dsName); err != nil {
+		return err
+	}
+
+	session := &apisv1beta1.VirtualMachineImageUploadSession{
+		ID:     string(uuid.NewUUID()),
+		Size:   input.Size,
+		Server: h.server,
+	}
+	toUpdate := image.DeepCopy()
+	toUpdate.Status.UploadSession = session
+	toUpdate.Status.Progress = 0
+	// the update fails if another session is started concurrently
+	if image, err = h.Images.Update(toUpdate); err != nil {
+		if apierrors.IsConflict(err) {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("image %s/%s is being updated, please retry", namespace, name))
+		}
+		return err
+	}
+
+	fileName := input.FileName
+	if fileName == "" {
+		fileName = image.Spec.DisplayName
+	}
+	h.startUploadSession(image, session, fileName)
+	util.ResponseOKWithBody(rw, session)
+	return nil
+}
+
+func (h Handler) startUploadSession(image *apisv1beta1.VirtualMachineImage, session *apisv1beta1.VirtualMachineImageUploadSession, fileName string) {
+	pr, pw := io.Pipe()
+	s := &uploadSession{
+		id:        session.ID,
+		size:      session.Size,
+		writer:    pw,
+		idleTimer: time.AfterFunc(uploadSessionIdleTimeout, func() { pw.CloseWithError(errUploadSessionIdle) }),
+		done:      make(chan struct{}),
+	}
+	key := ref.Construct(image.Namespace, image.Name)
+	h.uploadSessions.set(key, s)
+
+	go func() {
+		err := h.uploadToBackingImage(context.Background(), image, fileName, pr, session.Size)
+		if err != nil {
+			// unblock the chunk being written
+			pr.CloseWithError(err)
+			logrus.Errorf("upload session %s of image %s/%s failed, err: %v", session.ID, image.Namespace, image.Name, err)
+			if updateErr := h.updateImportedConditionOnConflict(image, "False", "UploadFailed", err.Error()); updateErr != nil {
+				logrus.Error(updateErr)
+			}
+		}
+		s.idleTimer.Stop()
+		s.err = err
+		close(s.done)
+		h.uploadSessions.remove(key, s)
+	}()
+}
+
+func (h Handler) uploadChunk(rw http.ResponseWriter, req *http.Request) error {
+	vars := util.EncodeVars(mux.Vars(req))
+	namespace, name := vars["namespace"], vars["name"]
+	sessionID := req.URL.Query().Get("session")
+	offset, err := strconv.ParseInt(req.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `offset` must be a non-negative integer")
+	}
+
+	image, err := h.Images.Get(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	session := image.Status.UploadSession
+	if session == nil || session.ID != sessionID {
+		return apierror.NewAPIError(validation.NotFound, fmt.Sprintf("upload session %s of image %s/%s is not found", sessionID, namespace, name))
+	}
+	// the chunks are never forwarded to the server in the status, it's only compared with the address of this server
+	if h.server != "" && session.Server != h.server {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("upload session %s is served by another server, please retry", sessionID))
+	}
+
+	s := h.uploadSessions.get(ref.Construct(namespace, name))
+	if s == nil || s.id != sessionID {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("upload session %s is lost, please start a new upload", sessionID))
+	}
+	written, writeErr := s.write(offset, req.Body)
+	if written != offset {
+		if err := h.updateUploadSessionOffset(image, sessionID, written); err != nil {
+			logrus.Errorf("failed to update upload session %s of image %s/%s, err: %v", sessionID, namespace, name, err)
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	session = session.DeepCopy()
+	session.Offset = written
+	util.ResponseOKWithBody(rw, session)
+	return nil
+}
+
+// updateUploadSessionOffset records the offset the upload session is resumed from and the progress,
+// the session is removed once all chunks are received.
+func (h Handler) updateUploadSessionOffset(image *apisv1beta1.VirtualMachineImage, sessionID string, offset int64) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.Images.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		session := current.Status.UploadSession
+		if session == nil || session.ID != sessionID || current.DeletionTimestamp != nil {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		toUpdate.Status.UploadSession.Offset = offset
+		if offset >= session.Size {
+			toUpdate.Status.UploadSession = nil
+		}
+		// the progress is 100 when the backing image is ready
+		progress := int(offset * 100 / session.Size)
+		if progress > 99 {
+			progress = 99
+		}
+		if progress > toUpdate.Status.Progress {
+			toUpdate.Status.Progress = progress
+		}
+		_, err = h.Images.Update(toUpdate)
+		return err
+	})
+}
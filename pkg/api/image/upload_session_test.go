@@ -0,0 +1,43 @@
+package image
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadSessionWrite(t *testing.T) {
+	pr, pw := io.Pipe()
+	s := &uploadSession{
+		id:        "session",
+		size:      10,
+		writer:    pw,
+		idleTimer: time.NewTimer(time.Hour),
+		done:      make(chan struct{}),
+	}
+	// the upload request reads the whole file
+	var received bytes.Buffer
+	go func() {
+		_, s.err = io.Copy(&received, pr)
+		close(s.done)
+	}()
+
+	offset, err := s.write(0, strings.NewReader("01234"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), offset)
+
+	// the chunk is resent from a wrong offset
+	offset, err = s.write(0, strings.NewReader("01234"))
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(5), offset)
+
+	// the bytes after the size are ignored
+	offset, err = s.write(5, strings.NewReader("56789extra"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), offset)
+	assert.Equal(t, "0123456789", received.String())
+}
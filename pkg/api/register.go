@@ -10,6 +10,8 @@ import (
 	"github.com/harvester/harvester/pkg/api/node"
 	"github.com/harvester/harvester/pkg/api/upgradelog"
 	"github.com/harvester/harvester/pkg/api/vm"
+	"github.com/harvester/harvester/pkg/api/vmbackup"
+	"github.com/harvester/harvester/pkg/api/vmbackupgroup"
 	"github.com/harvester/harvester/pkg/api/vmtemplate"
 	"github.com/harvester/harvester/pkg/api/volume"
 	"github.com/harvester/harvester/pkg/api/volumesnapshot"
@@ -34,6 +36,8 @@ func Setup(ctx context.Context, server *server.Server, controllers *server.Contr
 		keypair.RegisterSchema,
 		vmtemplate.RegisterSchema,
 		vm.RegisterSchema,
+		vmbackup.RegisterSchema,
+		vmbackupgroup.RegisterSchema,
 		node.RegisterSchema,
 		upgradelog.RegisterSchema,
 		volume.RegisterSchema,
@@ -0,0 +1,235 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/client-go/util/retry"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	defaultBulkConcurrency = 5
+	maxBulkConcurrency     = 50
+)
+
+// bulkActions maps the bulk collection actions to the VM actions run on each VM
+var bulkActions = map[string]string{
+	bulkStart:    startVM,
+	bulkStop:     stopVM,
+	bulkRestart:  restartVM,
+	bulkMigrate:  migrate,
+	bulkBackup:   backupVM,
+	bulkAddLabel: addLabel,
+}
+
+// bulkActionHandler runs a VM action on the VMs selected by a label selector or names in a namespace,
+// the VMs are processed by the handlers of the single VM actions concurrently.
+type bulkActionHandler struct {
+	actionHandler *vmActionHandler
+}
+
+func (h bulkActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	output, err := h.doBulkAction(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(output)
+}
+
+func (h bulkActionHandler) doBulkAction(req *http.Request) (*BulkActionOutput, error) {
+	// the action isn't in the route variables of the collection without a namespace
+	action, ok := bulkActions[req.URL.Query().Get("action")]
+	if !ok {
+		return nil, apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
+	}
+	namespace := util.EncodeVars(mux.Vars(req))["namespace"]
+	if namespace == "" {
+		namespace = req.URL.Query().Get("namespace")
+	}
+	if namespace == "" {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `namespace` is required")
+	}
+
+	var input BulkActionInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+	}
+	if err := h.validateInput(action, input); err != nil {
+		return nil, err
+	}
+
+	names, err := h.selectVMs(namespace, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// the VM actions are run by the harvester service account, so check the permission of the user on each VM
+	apiOp := types.GetAPIContext(req.Context())
+	return runBulkAction(names, input.Concurrency, func(name string) error {
+		if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, vmGroupResource, "update", namespace, name) != nil {
+			return fmt.Errorf("update on virtual machine %s/%s is not allowed", namespace, name)
+		}
+		return h.runAction(req.Context(), action, namespace, name, input)
+	}), nil
+}
+
+func (h bulkActionHandler) validateInput(action string, input BulkActionInput) error {
+	if (input.Selector == "") == (len(input.Names) == 0) {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "One of parameter `selector` and `names` is required")
+	}
+	if input.Concurrency < 0 || input.Concurrency > maxBulkConcurrency {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Parameter `concurrency` must be between 0 and %d", maxBulkConcurrency))
+	}
+
+	switch action {
+	case backupVM:
+		// the named backup target is checked by the webhook
+		if input.BackupTargetName == "" || input.BackupTargetName == harvesterv1.DefaultBackupTargetName {
+			return h.actionHandler.checkBackupTargetConfigured()
+		}
+	case addLabel:
+		if len(input.Labels) == 0 {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `labels` is required")
+		}
+		for key, value := range input.Labels {
+			if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+				return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid label key %q: %s", key, strings.Join(errs, "; ")))
+			}
+			if errs := k8svalidation.IsValidLabelValue(value); len(errs) > 0 {
+				return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid label value %q: %s", value, strings.Join(errs, "; ")))
+			}
+		}
+	}
+	return nil
+}
+
+// selectVMs returns the names of the VMs selected by the label selector or the names in the input,
+// the names not found are kept to be reported in the results.
+func (h bulkActionHandler) selectVMs(namespace string, input BulkActionInput) ([]string, error) {
+	if input.Selector == "" {
+		return input.Names, nil
+	}
+
+	selector, err := labels.Parse(input.Selector)
+	if err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid selector %q: %v", input.Selector, err))
+	}
+	vms, err := h.actionHandler.vmCache.List(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(vms))
+	for _, vm := range vms {
+		names = append(names, vm.Name)
+	}
+	return names, nil
+}
+
+func (h bulkActionHandler) runAction(ctx context.Context, action, namespace, name string, input BulkActionInput) error {
+	if _, err := h.actionHandler.vmCache.Get(namespace, name); err != nil {
+		return err
+	}
+
+	switch action {
+	case startVM, stopVM, restartVM:
+		_, err := h.actionHandler.subresourceOperate(ctx, vmResource, namespace, name, action)
+		return err
+	case migrate:
+		return h.actionHandler.migrate(ctx, namespace, name, input.NodeName)
+	case backupVM:
+		return h.actionHandler.createVMBackup(name, namespace, BackupInput{
+			Name:             names.SimpleNameGenerator.GenerateName(name + "-"),
+			BackupTargetName: input.BackupTargetName,
+		})
+	case addLabel:
+		return h.actionHandler.addLabels(namespace, name, input.Labels)
+	}
+	return fmt.Errorf("unsupported action %s", action)
+}
+
+// runBulkAction runs the function on the VMs with the concurrency and returns the results sorted by the VM names
+func runBulkAction(names []string, concurrency int, run func(name string) error) *BulkActionOutput {
+	if concurrency == 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make([]BulkActionResult, len(names))
+	)
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = BulkActionResult{Name: name, Success: true}
+			if err := run(name); err != nil {
+				results[i].Success = false
+				results[i].Error = err.Error()
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return &BulkActionOutput{Results: results}
+}
+
+func (h *vmActionHandler) addLabels(namespace, name string, vmLabels map[string]string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vm, err := h.vms.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		vmCopy := vm.DeepCopy()
+		if vmCopy.Labels == nil {
+			vmCopy.Labels = map[string]string{}
+		}
+		for key, value := range vmLabels {
+			vmCopy.Labels[key] = value
+		}
+		if labels.Equals(vm.Labels, vmCopy.Labels) {
+			return nil
+		}
+		_, err = h.vms.Update(vmCopy)
+		return err
+	})
+}
+
+func bulkCollectionFormatter(request *types.APIRequest, collection *types.GenericCollection) {
+	if request.AccessControl.CanUpdate(request, types.APIObject{}, request.Schema) != nil {
+		return
+	}
+	for action := range bulkActions {
+		collection.AddAction(request, action)
+	}
+}
@@ -0,0 +1,39 @@
+package vm
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBulkAction(t *testing.T) {
+	var running, maxRunning int32
+	output := runBulkAction([]string{"vm3", "vm1", "vm2", "vm4"}, 2, func(name string) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if name == "vm2" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	assert.LessOrEqual(t, maxRunning, int32(2))
+	assert.Equal(t, &BulkActionOutput{
+		Results: []BulkActionResult{
+			{Name: "vm1", Success: true},
+			{Name: "vm2", Success: false, Error: "failed"},
+			{Name: "vm3", Success: true},
+			{Name: "vm4", Success: true},
+		},
+	}, output)
+}
@@ -0,0 +1,209 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	cloudConfigHeader = "#cloud-config"
+)
+
+var (
+	// KubeVirt accepts both cases of the keys in the cloud-init secrets
+	userDataSecretKeys    = []string{"userdata", "userData"}
+	networkDataSecretKeys = []string{"networkdata", "networkData"}
+	// network devices of the network config version 2 that may have static addresses
+	networkDeviceTypes = []string{"ethernets", "bonds", "bridges", "vlans"}
+)
+
+// regenerateCloudInitSecretData regenerates the user data and network data in a cloud-init secret of a cloned VM.
+func regenerateCloudInitSecretData(data map[string][]byte, hostname string) (map[string][]byte, error) {
+	newData := make(map[string][]byte, len(data))
+	for key, value := range data {
+		newData[key] = value
+	}
+	for _, key := range userDataSecretKeys {
+		if value, ok := data[key]; ok {
+			userData, err := regenerateUserData(string(value), hostname)
+			if err != nil {
+				return nil, fmt.Errorf("can't regenerate %s, err: %w", key, err)
+			}
+			newData[key] = []byte(userData)
+		}
+	}
+	for _, key := range networkDataSecretKeys {
+		if value, ok := data[key]; ok {
+			networkData, err := regenerateNetworkData(string(value))
+			if err != nil {
+				return nil, fmt.Errorf("can't regenerate %s, err: %w", key, err)
+			}
+			newData[key] = []byte(networkData)
+		}
+	}
+	return newData, nil
+}
+
+// regenerateUserData updates the cloud-config of a cloned VM, so that the clone doesn't take the identity of the source VM.
+// The hostname and fqdn are replaced and the pinned SSH host keys are dropped, so new host keys are generated.
+// The instance-id in the NoCloud meta-data is derived from the VM name and namespace by KubeVirt,
+// a clone always gets a new one and cloud-init reruns the per-instance modules on the first boot.
+// User data that isn't a cloud-config, e.g. a shell script, is kept as it is.
+func regenerateUserData(userData, hostname string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(userData), cloudConfigHeader) {
+		return userData, nil
+	}
+
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(userData), &config); err != nil {
+		return "", err
+	}
+
+	changed := false
+	if _, ok := config["hostname"]; ok && hostname != "" {
+		config["hostname"] = hostname
+		changed = true
+	}
+	if fqdn, ok := config["fqdn"].(string); ok && hostname != "" {
+		config["fqdn"] = hostname
+		if i := strings.Index(fqdn, "."); i >= 0 {
+			config["fqdn"] = hostname + fqdn[i:]
+		}
+		changed = true
+	}
+	if _, ok := config["ssh_keys"]; ok {
+		delete(config, "ssh_keys")
+		changed = true
+	}
+	if !changed {
+		return userData, nil
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return cloudConfigHeader + "\n" + string(out), nil
+}
+
+// regenerateNetworkData replaces the static addresses in the network config of a cloned VM with DHCP,
+// and drops the MAC address matches because the clone gets new MAC addresses.
+// Both version 1 and version 2 of the network config are supported.
+func regenerateNetworkData(networkData string) (string, error) {
+	if strings.TrimSpace(networkData) == "" {
+		return networkData, nil
+	}
+
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(networkData), &config); err != nil {
+		return "", err
+	}
+
+	network := config
+	if nested, ok := config["network"].(map[string]interface{}); ok {
+		network = nested
+	}
+
+	var changed bool
+	switch fmt.Sprint(network["version"]) {
+	case "1":
+		changed = regenerateNetworkConfigV1(network)
+	case "2":
+		changed = regenerateNetworkConfigV2(network)
+	}
+	if !changed {
+		return networkData, nil
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func regenerateNetworkConfigV1(network map[string]interface{}) bool {
+	changed := false
+	items, _ := network["config"].([]interface{})
+	for _, item := range items {
+		device, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := device["mac_address"]; ok {
+			delete(device, "mac_address")
+			changed = true
+		}
+		subnets, ok := device["subnets"].([]interface{})
+		if !ok {
+			continue
+		}
+		var newSubnets []interface{}
+		hasDHCP, hasDHCP6 := false, false
+		for _, s := range subnets {
+			subnet, ok := s.(map[string]interface{})
+			if !ok {
+				newSubnets = append(newSubnets, s)
+				continue
+			}
+			switch subnet["type"] {
+			case "static":
+				subnet = map[string]interface{}{"type": "dhcp"}
+				changed = true
+			case "static6":
+				subnet = map[string]interface{}{"type": "dhcp6"}
+				changed = true
+			}
+			// keep only one DHCP subnet of each address family
+			switch subnet["type"] {
+			case "dhcp", "dhcp4":
+				if hasDHCP {
+					continue
+				}
+				hasDHCP = true
+			case "dhcp6":
+				if hasDHCP6 {
+					continue
+				}
+				hasDHCP6 = true
+			}
+			newSubnets = append(newSubnets, subnet)
+		}
+		device["subnets"] = newSubnets
+	}
+	return changed
+}
+
+func regenerateNetworkConfigV2(network map[string]interface{}) bool {
+	changed := false
+	for _, deviceType := range networkDeviceTypes {
+		devices, _ := network[deviceType].(map[string]interface{})
+		for _, d := range devices {
+			device, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if addresses, ok := device["addresses"].([]interface{}); ok && len(addresses) > 0 {
+				for _, key := range []string{"addresses", "gateway4", "gateway6", "routes"} {
+					delete(device, key)
+				}
+				device["dhcp4"] = true
+				changed = true
+			}
+			if match, ok := device["match"].(map[string]interface{}); ok {
+				if _, ok := match["macaddress"]; ok {
+					delete(match, "macaddress")
+					changed = true
+				}
+				// set-name is only allowed along with match
+				if len(match) == 0 {
+					delete(device, "match")
+					delete(device, "set-name")
+				}
+			}
+		}
+	}
+	return changed
+}
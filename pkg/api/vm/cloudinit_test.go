@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRegenerateUserData(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		userData string
+		hostname string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "hostname, fqdn and ssh host keys",
+			userData: "#cloud-config\nhostname: golden\nfqdn: golden.example.com\nssh_keys:\n  rsa_private: key\npackages:\n- qemu-guest-agent\n",
+			hostname: "web-1",
+			expected: map[string]interface{}{
+				"hostname": "web-1",
+				"fqdn":     "web-1.example.com",
+				"packages": []interface{}{"qemu-guest-agent"},
+			},
+		},
+		{
+			name:     "no identity",
+			userData: "#cloud-config\npackages:\n- qemu-guest-agent\n",
+			hostname: "web-1",
+			expected: map[string]interface{}{
+				"packages": []interface{}{"qemu-guest-agent"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		userData, err := regenerateUserData(tc.userData, tc.hostname)
+		assert.Nil(t, err, tc.name)
+		assert.Contains(t, userData, cloudConfigHeader, tc.name)
+		config := map[string]interface{}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(userData), &config), tc.name)
+		assert.Equal(t, tc.expected, config, tc.name)
+	}
+
+	script := "#!/bin/sh\nhostname golden\n"
+	userData, err := regenerateUserData(script, "web-1")
+	assert.Nil(t, err)
+	assert.Equal(t, script, userData)
+}
+
+func TestRegenerateNetworkData(t *testing.T) {
+	var testCases = []struct {
+		name        string
+		networkData string
+		expected    map[string]interface{}
+	}{
+		{
+			name: "version 1",
+			networkData: `version: 1
+config:
+- type: physical
+  name: eth0
+  mac_address: "52:54:00:12:34:56"
+  subnets:
+  - type: static
+    address: 192.168.0.10/24
+    gateway: 192.168.0.1
+  - type: static
+    address: 192.168.1.10/24
+- type: nameserver
+  address: [8.8.8.8]
+`,
+			expected: map[string]interface{}{
+				"version": float64(1),
+				"config": []interface{}{
+					map[string]interface{}{
+						"type":    "physical",
+						"name":    "eth0",
+						"subnets": []interface{}{map[string]interface{}{"type": "dhcp"}},
+					},
+					map[string]interface{}{
+						"type":    "nameserver",
+						"address": []interface{}{"8.8.8.8"},
+					},
+				},
+			},
+		},
+		{
+			name: "version 2",
+			networkData: `network:
+  version: 2
+  ethernets:
+    eth0:
+      match:
+        macaddress: "52:54:00:12:34:56"
+      set-name: eth0
+      dhcp4: false
+      addresses: [192.168.0.10/24]
+      gateway4: 192.168.0.1
+      nameservers:
+        addresses: [8.8.8.8]
+`,
+			expected: map[string]interface{}{
+				"network": map[string]interface{}{
+					"version": float64(2),
+					"ethernets": map[string]interface{}{
+						"eth0": map[string]interface{}{
+							"dhcp4": true,
+							"nameservers": map[string]interface{}{
+								"addresses": []interface{}{"8.8.8.8"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		networkData, err := regenerateNetworkData(tc.networkData)
+		assert.Nil(t, err, tc.name)
+		config := map[string]interface{}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(networkData), &config), tc.name)
+		assert.Equal(t, tc.expected, config, tc.name)
+	}
+}
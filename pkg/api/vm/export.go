@@ -0,0 +1,549 @@
+package vm
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	lhmanager "github.com/longhorn/longhorn-manager/manager"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage/names"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllhv1beta1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	// exportFormatVMDK is the format of the disks in the OVA package, the volumes are exported to raw images by Longhorn
+	// and converted to streamOptimized VMDKs by the image controller, which are stored in backing images
+	exportFormatVMDK = "vmdk"
+
+	// ovfDiskFormatStreamOptimized is the OVF disk format URI of streamOptimized VMDKs
+	ovfDiskFormatStreamOptimized = "http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"
+	// ovfVirtualSystemType is the virtual hardware family of VMware hardware version 10, the first one with SATA controllers
+	ovfVirtualSystemType = "vmx-10"
+
+	// resource types of CIM_ResourceAllocationSettingData
+	ovfResourceTypeCPU            = 3
+	ovfResourceTypeMemory         = 4
+	ovfResourceTypeSCSIController = 6
+	ovfResourceTypeEthernet       = 10
+	ovfResourceTypeCDROM          = 15
+	ovfResourceTypeDisk           = 17
+	ovfResourceTypeSATAController = 20
+)
+
+// exportVM exports the PVC volumes of a VM to raw images. The images are converted to streamOptimized VMDKs once they're
+// imported, and the VMDKs are packaged with the OVF descriptor built from the VM spec as an OVA by the download link.
+func (h *vmActionHandler) exportVM(namespace, name string, input ExportVMInput) error {
+	format := input.Format
+	if format == "" {
+		format = exportFormatVMDK
+	}
+	if format != exportFormatVMDK {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Unsupported format %s, only %s is supported", format, exportFormatVMDK))
+	}
+
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	previousExport, err := getVMExport(vm)
+	if err != nil {
+		return err
+	}
+
+	export := VMExport{
+		Format: format,
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Images: map[string]string{},
+	}
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		image, err := h.vmImages.Create(&harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "image-",
+				Namespace:    namespace,
+				Labels: map[string]string{
+					util.LabelVMExport: vm.Name,
+				},
+				Annotations: map[string]string{
+					util.AnnotationImageExportType: lhmanager.DataSourceTypeExportFromVolumeParameterExportTypeRAW,
+				},
+				// the exported images are removed along with the VM
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: kubevirtv1.SchemeGroupVersion.String(),
+						Kind:       kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+						Name:       vm.Name,
+						UID:        vm.UID,
+					},
+				},
+			},
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				DisplayName:  names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-%s-export-", vm.Name, volume.Name)),
+				SourceType:   harvesterv1.VirtualMachineImageSourceTypeExportVolume,
+				PVCName:      volume.PersistentVolumeClaim.ClaimName,
+				PVCNamespace: namespace,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export volume %s of vm %s/%s, error: %w", volume.Name, namespace, name, err)
+		}
+		export.Images[volume.Name] = image.Name
+	}
+	if len(export.Images) == 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("VM %s/%s has no volumes to export", namespace, name))
+	}
+
+	exportJSON, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+	vmCopy := vm.DeepCopy()
+	if vmCopy.Annotations == nil {
+		vmCopy.Annotations = map[string]string{}
+	}
+	vmCopy.Annotations[util.AnnotationVMExport] = string(exportJSON)
+	if _, err := h.vms.Update(vmCopy); err != nil {
+		return err
+	}
+
+	// only the latest export is kept, the images which aren't exported from the VM are kept
+	if previousExport != nil {
+		for _, imageName := range previousExport.Images {
+			image, err := h.vmImageCache.Get(namespace, imageName)
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				logrus.Warnf("failed to get previously exported image %s/%s, error: %v", namespace, imageName, err)
+				continue
+			}
+			if !isVMExportImage(vm, image) {
+				logrus.Warnf("skip deleting image %s/%s which isn't exported from vm %s/%s", namespace, imageName, namespace, name)
+				continue
+			}
+			if err := h.vmImages.Delete(namespace, imageName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("failed to delete previously exported image %s/%s, error: %v", namespace, imageName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isVMExportImage returns true if the image is exported from the VM by the export action
+func isVMExportImage(vm *kubevirtv1.VirtualMachine, image *harvesterv1.VirtualMachineImage) bool {
+	return image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeExportVolume && image.Labels[util.LabelVMExport] == vm.Name
+}
+
+func getVMExport(vm *kubevirtv1.VirtualMachine) (*VMExport, error) {
+	value, ok := vm.Annotations[util.AnnotationVMExport]
+	if !ok {
+		return nil, nil
+	}
+	var export VMExport
+	if err := json.Unmarshal([]byte(value), &export); err != nil {
+		return nil, fmt.Errorf("failed to unserialize %s, error: %w", util.AnnotationVMExport, err)
+	}
+	return &export, nil
+}
+
+// exportHandler serves the OVA package of the last export of a VM
+type exportHandler struct {
+	httpClient        http.Client
+	vmCache           ctlkubevirtv1.VirtualMachineCache
+	imageCache        ctlharvesterv1.VirtualMachineImageCache
+	backingImageCache ctllhv1beta1.BackingImageCache
+}
+
+func (h exportHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if err := h.downloadOVA(rw, req); err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+}
+
+// ovaDisk is an exported disk in the OVA package
+type ovaDisk struct {
+	volumeName string
+	fileName   string
+	// backingImageName is the name of the backing image of the VMDK file
+	backingImageName string
+	capacity         int64
+	// size is the size of the VMDK file
+	size int64
+}
+
+func (h exportHandler) downloadOVA(rw http.ResponseWriter, req *http.Request) error {
+	vars := util.EncodeVars(mux.Vars(req))
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	export, err := getVMExport(vm)
+	if err != nil {
+		return err
+	}
+	if export == nil {
+		return apierror.NewAPIError(validation.NotFound, fmt.Sprintf("VM %s/%s is not exported", namespace, name))
+	}
+	if export.Format != exportFormatVMDK {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("VM %s/%s is exported in format %s, export it again", namespace, name, export.Format))
+	}
+
+	var disks []ovaDisk
+	for volumeName, imageName := range export.Images {
+		image, err := h.imageCache.Get(namespace, imageName)
+		if err != nil {
+			return err
+		}
+		if !isVMExportImage(vm, image) {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Image %s/%s isn't exported from vm %s/%s, export it again", namespace, imageName, namespace, name))
+		}
+		if !harvesterv1.ImageImported.IsTrue(image) {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Export of vm %s/%s is in progress", namespace, name))
+		}
+		// the tar headers and the OVF descriptor have the sizes of the VMDKs, which are known once they're converted
+		backingImageName := util.GetExportVMDKBackingImageName(image)
+		backingImage, err := h.backingImageCache.Get(util.LonghornSystemNamespaceName, backingImageName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err != nil || !isBackingImageReady(backingImage) {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Export of vm %s/%s is in progress", namespace, name))
+		}
+		disks = append(disks, ovaDisk{
+			volumeName:       volumeName,
+			fileName:         fmt.Sprintf("%s.%s", volumeName, exportFormatVMDK),
+			backingImageName: backingImageName,
+			// the raw image has the size of the volume
+			capacity: image.Status.Size,
+			size:     backingImage.Status.Size,
+		})
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].volumeName < disks[j].volumeName })
+
+	ovf, err := buildOVF(vm, disks)
+	if err != nil {
+		return err
+	}
+
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.ova", vm.Name))
+	rw.Header().Set("Content-Type", "application/x-tar")
+
+	// the OVF descriptor must be the first file of an OVA package
+	tw := tar.NewWriter(rw)
+	if err := tw.WriteHeader(&tar.Header{Name: vm.Name + ".ovf", Mode: 0644, Size: int64(len(ovf))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(ovf); err != nil {
+		return err
+	}
+	for _, disk := range disks {
+		if err := tw.WriteHeader(&tar.Header{Name: disk.fileName, Mode: 0644, Size: disk.size}); err != nil {
+			logrus.Errorf("failed to write disk %s of vm %s/%s to the OVA package, error: %v", disk.volumeName, namespace, name, err)
+			return nil
+		}
+		if err := h.writeDisk(req, tw, disk); err != nil {
+			// the response status has been sent, the broken package is detected by the client from the short read
+			logrus.Errorf("failed to write disk %s of vm %s/%s to the OVA package, error: %v", disk.volumeName, namespace, name, err)
+			return nil
+		}
+	}
+	if err := tw.Close(); err != nil {
+		logrus.Errorf("failed to close the OVA package of vm %s/%s, error: %v", namespace, name, err)
+	}
+	return nil
+}
+
+// isBackingImageReady returns true if the file of the backing image is ready to be downloaded
+func isBackingImageReady(backingImage *lhv1beta1.BackingImage) bool {
+	for _, status := range backingImage.Status.DiskFileStatusMap {
+		if status.State == lhv1beta1.BackingImageStateReady || status.State == lhv1beta1.BackingImageStateReadyForTransfer {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDisk downloads the VMDK of the disk converted at export time
+func (h exportHandler) writeDisk(req *http.Request, w io.Writer, disk ovaDisk) error {
+	bkimgName := disk.backingImageName
+	downloadURL := fmt.Sprintf("%s/backingimages/%s/download", util.LonghornDefaultManagerURL, bkimgName)
+	downloadReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the download request with backing Image(%s): %w", bkimgName, err)
+	}
+	downloadResp, err := h.httpClient.Do(downloadReq)
+	if err != nil {
+		return fmt.Errorf("failed to send the download request with backing Image(%s): %w", bkimgName, err)
+	}
+	defer downloadResp.Body.Close()
+	if downloadResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed with unexpected http Status code %d", downloadResp.StatusCode)
+	}
+
+	_, err = io.CopyN(w, downloadResp.Body, disk.size)
+	return err
+}
+
+type ovfEnvelope struct {
+	XMLName        xml.Name          `xml:"Envelope"`
+	XMLNS          string            `xml:"xmlns,attr"`
+	XMLNSOVF       string            `xml:"xmlns:ovf,attr"`
+	XMLNSRASD      string            `xml:"xmlns:rasd,attr"`
+	XMLNSVSSD      string            `xml:"xmlns:vssd,attr"`
+	References     []ovfFile         `xml:"References>File"`
+	DiskSection    ovfDiskSection    `xml:"DiskSection"`
+	NetworkSection ovfNetworkSection `xml:"NetworkSection"`
+	VirtualSystem  ovfVirtualSystem  `xml:"VirtualSystem"`
+}
+
+type ovfFile struct {
+	ID   string `xml:"ovf:id,attr"`
+	Href string `xml:"ovf:href,attr"`
+	Size int64  `xml:"ovf:size,attr"`
+}
+
+type ovfDiskSection struct {
+	Info  string    `xml:"Info"`
+	Disks []ovfDisk `xml:"Disk"`
+}
+
+type ovfDisk struct {
+	DiskID   string `xml:"ovf:diskId,attr"`
+	FileRef  string `xml:"ovf:fileRef,attr"`
+	Capacity int64  `xml:"ovf:capacity,attr"`
+	Format   string `xml:"ovf:format,attr"`
+}
+
+type ovfNetworkSection struct {
+	Info     string       `xml:"Info"`
+	Networks []ovfNetwork `xml:"Network"`
+}
+
+type ovfNetwork struct {
+	Name        string `xml:"ovf:name,attr"`
+	Description string `xml:"Description"`
+}
+
+type ovfVirtualSystem struct {
+	ID              string             `xml:"ovf:id,attr"`
+	Info            string             `xml:"Info"`
+	Name            string             `xml:"Name"`
+	HardwareSection ovfHardwareSection `xml:"VirtualHardwareSection"`
+}
+
+type ovfHardwareSection struct {
+	Info   string    `xml:"Info"`
+	System ovfSystem `xml:"System"`
+	Items  []ovfItem `xml:"Item"`
+}
+
+type ovfSystem struct {
+	ElementName             string `xml:"vssd:ElementName"`
+	InstanceID              int    `xml:"vssd:InstanceID"`
+	VirtualSystemIdentifier string `xml:"vssd:VirtualSystemIdentifier"`
+	VirtualSystemType       string `xml:"vssd:VirtualSystemType"`
+}
+
+type ovfItem struct {
+	Address         string `xml:"rasd:Address,omitempty"`
+	AddressOnParent string `xml:"rasd:AddressOnParent,omitempty"`
+	AllocationUnits string `xml:"rasd:AllocationUnits,omitempty"`
+	Connection      string `xml:"rasd:Connection,omitempty"`
+	ElementName     string `xml:"rasd:ElementName"`
+	HostResource    string `xml:"rasd:HostResource,omitempty"`
+	InstanceID      int    `xml:"rasd:InstanceID"`
+	Parent          int    `xml:"rasd:Parent,omitempty"`
+	ResourceSubType string `xml:"rasd:ResourceSubType,omitempty"`
+	ResourceType    int    `xml:"rasd:ResourceType"`
+	VirtualQuantity int64  `xml:"rasd:VirtualQuantity,omitempty"`
+}
+
+// buildOVF builds the OVF descriptor of a VM with the CPU, memory, exported disks and NICs of the VM spec
+func buildOVF(vm *kubevirtv1.VirtualMachine, disks []ovaDisk) ([]byte, error) {
+	out, err := xml.MarshalIndent(buildOVFEnvelope(vm, disks), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func buildOVFEnvelope(vm *kubevirtv1.VirtualMachine, disks []ovaDisk) ovfEnvelope {
+	domain := vm.Spec.Template.Spec.Domain
+
+	envelope := ovfEnvelope{
+		XMLNS:          "http://schemas.dmtf.org/ovf/envelope/1",
+		XMLNSOVF:       "http://schemas.dmtf.org/ovf/envelope/1",
+		XMLNSRASD:      "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData",
+		XMLNSVSSD:      "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData",
+		DiskSection:    ovfDiskSection{Info: "Virtual disk information"},
+		NetworkSection: ovfNetworkSection{Info: "The list of logical networks"},
+		VirtualSystem: ovfVirtualSystem{
+			ID:   vm.Name,
+			Info: "A virtual machine exported from Harvester",
+			Name: vm.Name,
+			HardwareSection: ovfHardwareSection{
+				Info: "Virtual hardware requirements",
+				System: ovfSystem{
+					ElementName:             "Virtual Hardware Family",
+					VirtualSystemIdentifier: vm.Name,
+					VirtualSystemType:       ovfVirtualSystemType,
+				},
+			},
+		},
+	}
+
+	instanceID := 0
+	nextInstanceID := func() int {
+		instanceID++
+		return instanceID
+	}
+	items := &envelope.VirtualSystem.HardwareSection.Items
+
+	vcpus := int64(getCPUSockets(domain.CPU))
+	if domain.CPU != nil && domain.CPU.Cores > 0 {
+		vcpus *= int64(domain.CPU.Cores)
+	}
+	if domain.CPU != nil && domain.CPU.Threads > 0 {
+		vcpus *= int64(domain.CPU.Threads)
+	}
+	*items = append(*items, ovfItem{
+		AllocationUnits: "hertz * 10^6",
+		ElementName:     fmt.Sprintf("%d virtual CPU(s)", vcpus),
+		InstanceID:      nextInstanceID(),
+		ResourceType:    ovfResourceTypeCPU,
+		VirtualQuantity: vcpus,
+	})
+
+	memory := domain.Resources.Limits.Memory()
+	if memory.IsZero() && domain.Memory != nil && domain.Memory.Guest != nil {
+		memory = domain.Memory.Guest
+	}
+	memoryMiB := memory.Value() / (1 << 20)
+	*items = append(*items, ovfItem{
+		AllocationUnits: "byte * 2^20",
+		ElementName:     fmt.Sprintf("%d MB of memory", memoryMiB),
+		InstanceID:      nextInstanceID(),
+		ResourceType:    ovfResourceTypeMemory,
+		VirtualQuantity: memoryMiB,
+	})
+
+	// disks are attached to a SATA or a SCSI controller by their buses, virtio disks are mapped to SCSI
+	exportedDisks := map[string]ovaDisk{}
+	for _, disk := range disks {
+		exportedDisks[disk.volumeName] = disk
+	}
+	controllers := map[string]int{}
+	controllerAddresses := map[string]int{}
+	for _, disk := range domain.Devices.Disks {
+		exportedDisk, ok := exportedDisks[disk.Name]
+		if !ok {
+			continue
+		}
+		var bus kubevirtv1.DiskBus
+		resourceType := ovfResourceTypeDisk
+		switch {
+		case disk.Disk != nil:
+			bus = disk.Disk.Bus
+		case disk.CDRom != nil:
+			bus, resourceType = disk.CDRom.Bus, ovfResourceTypeCDROM
+		case disk.LUN != nil:
+			bus = disk.LUN.Bus
+		}
+		controllerType := "scsi"
+		if bus == kubevirtv1.DiskBusSATA {
+			controllerType = "sata"
+		}
+		if _, ok := controllers[controllerType]; !ok {
+			controller := ovfItem{
+				ElementName:     fmt.Sprintf("%s controller", controllerType),
+				InstanceID:      nextInstanceID(),
+				ResourceSubType: "VirtualSCSI",
+				ResourceType:    ovfResourceTypeSCSIController,
+			}
+			if controllerType == "sata" {
+				controller.ResourceSubType = "AHCI"
+				controller.ResourceType = ovfResourceTypeSATAController
+			}
+			*items = append(*items, controller)
+			controllers[controllerType] = controller.InstanceID
+		}
+
+		index := len(envelope.References)
+		fileID, diskID := fmt.Sprintf("file%d", index+1), fmt.Sprintf("vmdisk%d", index+1)
+		envelope.References = append(envelope.References, ovfFile{ID: fileID, Href: exportedDisk.fileName, Size: exportedDisk.size})
+		envelope.DiskSection.Disks = append(envelope.DiskSection.Disks, ovfDisk{DiskID: diskID, FileRef: fileID, Capacity: exportedDisk.capacity, Format: ovfDiskFormatStreamOptimized})
+		*items = append(*items, ovfItem{
+			AddressOnParent: fmt.Sprint(controllerAddresses[controllerType]),
+			ElementName:     disk.Name,
+			HostResource:    "ovf:/disk/" + diskID,
+			InstanceID:      nextInstanceID(),
+			Parent:          controllers[controllerType],
+			ResourceType:    resourceType,
+		})
+		controllerAddresses[controllerType]++
+	}
+
+	networkNames := map[string]string{}
+	for _, network := range vm.Spec.Template.Spec.Networks {
+		switch {
+		case network.Pod != nil:
+			networkNames[network.Name] = "management"
+		case network.Multus != nil:
+			networkNames[network.Name] = network.Multus.NetworkName
+		}
+	}
+	addedNetworks := map[string]bool{}
+	for _, iface := range domain.Devices.Interfaces {
+		networkName := networkNames[iface.Name]
+		if networkName == "" {
+			continue
+		}
+		if !addedNetworks[networkName] {
+			envelope.NetworkSection.Networks = append(envelope.NetworkSection.Networks, ovfNetwork{Name: networkName, Description: fmt.Sprintf("The %s network", networkName)})
+			addedNetworks[networkName] = true
+		}
+		// virtio NICs are mapped to the paravirtualized NIC of VMware
+		subType := "E1000"
+		if iface.Model == "" || iface.Model == "virtio" {
+			subType = "VmxNet3"
+		}
+		*items = append(*items, ovfItem{
+			Address:         iface.MacAddress,
+			Connection:      networkName,
+			ElementName:     iface.Name,
+			InstanceID:      nextInstanceID(),
+			ResourceSubType: subType,
+			ResourceType:    ovfResourceTypeEthernet,
+		})
+	}
+
+	return envelope
+}
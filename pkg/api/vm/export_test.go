@@ -0,0 +1,154 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestBuildOVF(t *testing.T) {
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "vm",
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Domain: kubevirtv1.DomainSpec{
+						CPU: &kubevirtv1.CPU{Sockets: 2, Cores: 2, Threads: 1},
+						Resources: kubevirtv1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("4Gi"),
+							},
+						},
+						Devices: kubevirtv1.Devices{
+							Disks: []kubevirtv1.Disk{
+								{Name: "rootdisk", DiskDevice: kubevirtv1.DiskDevice{Disk: &kubevirtv1.DiskTarget{Bus: "virtio"}}},
+								{Name: "cdrom", DiskDevice: kubevirtv1.DiskDevice{CDRom: &kubevirtv1.CDRomTarget{Bus: "sata"}}},
+								{Name: "cloudinitdisk", DiskDevice: kubevirtv1.DiskDevice{Disk: &kubevirtv1.DiskTarget{Bus: "virtio"}}},
+							},
+							Interfaces: []kubevirtv1.Interface{
+								{Name: "default", Model: "virtio", MacAddress: "52:54:00:12:34:56"},
+								{Name: "nic-1", Model: "e1000"},
+							},
+						},
+					},
+					Networks: []kubevirtv1.Network{
+						{Name: "default", NetworkSource: kubevirtv1.NetworkSource{Pod: &kubevirtv1.PodNetwork{}}},
+						{Name: "nic-1", NetworkSource: kubevirtv1.NetworkSource{Multus: &kubevirtv1.MultusNetwork{NetworkName: "default/vlan1"}}},
+					},
+				},
+			},
+		},
+	}
+	disks := []ovaDisk{
+		{
+			volumeName: "cdrom",
+			fileName:   "cdrom.vmdk",
+			capacity:   1000,
+			size:       50,
+		},
+		{
+			volumeName: "rootdisk",
+			fileName:   "rootdisk.vmdk",
+			capacity:   2000,
+			size:       150,
+		},
+	}
+
+	envelope := buildOVFEnvelope(vm, disks)
+	assert.Equal(t, "vm", envelope.VirtualSystem.Name)
+	assert.Len(t, envelope.References, 2)
+	assert.Equal(t, "rootdisk.vmdk", envelope.References[0].Href)
+	assert.Equal(t, "cdrom.vmdk", envelope.References[1].Href)
+	assert.Len(t, envelope.DiskSection.Disks, 2)
+	assert.Equal(t, int64(2000), envelope.DiskSection.Disks[0].Capacity)
+	assert.Equal(t, ovfDiskFormatStreamOptimized, envelope.DiskSection.Disks[0].Format)
+	assert.Equal(t, ovfVirtualSystemType, envelope.VirtualSystem.HardwareSection.System.VirtualSystemType)
+	assert.Equal(t, []ovfNetwork{
+		{Name: "management", Description: "The management network"},
+		{Name: "default/vlan1", Description: "The default/vlan1 network"},
+	}, envelope.NetworkSection.Networks)
+
+	items := envelope.VirtualSystem.HardwareSection.Items
+	assert.Len(t, items, 8)
+	assert.Equal(t, ovfItem{AllocationUnits: "hertz * 10^6", ElementName: "4 virtual CPU(s)", InstanceID: 1, ResourceType: ovfResourceTypeCPU, VirtualQuantity: 4}, items[0])
+	assert.Equal(t, ovfItem{AllocationUnits: "byte * 2^20", ElementName: "4096 MB of memory", InstanceID: 2, ResourceType: ovfResourceTypeMemory, VirtualQuantity: 4096}, items[1])
+	assert.Equal(t, ovfResourceTypeSCSIController, items[2].ResourceType)
+	assert.Equal(t, ovfItem{AddressOnParent: "0", ElementName: "rootdisk", HostResource: "ovf:/disk/vmdisk1", InstanceID: 4, Parent: 3, ResourceType: ovfResourceTypeDisk}, items[3])
+	assert.Equal(t, ovfResourceTypeSATAController, items[4].ResourceType)
+	assert.Equal(t, ovfItem{AddressOnParent: "0", ElementName: "cdrom", HostResource: "ovf:/disk/vmdisk2", InstanceID: 6, Parent: 5, ResourceType: ovfResourceTypeCDROM}, items[5])
+	assert.Equal(t, ovfItem{Address: "52:54:00:12:34:56", Connection: "management", ElementName: "default", InstanceID: 7, ResourceSubType: "VmxNet3", ResourceType: ovfResourceTypeEthernet}, items[6])
+	assert.Equal(t, ovfItem{Connection: "default/vlan1", ElementName: "nic-1", InstanceID: 8, ResourceSubType: "E1000", ResourceType: ovfResourceTypeEthernet}, items[7])
+
+	out, err := buildOVF(vm, disks)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "<?xml"))
+	assert.Contains(t, string(out), `<File ovf:id="file1" ovf:href="rootdisk.vmdk" ovf:size="150"></File>`)
+	assert.Contains(t, string(out), "<rasd:ResourceType>17</rasd:ResourceType>")
+}
+
+func TestExportVMDeletesPreviousExport(t *testing.T) {
+	newImage := func(name, exportedVM string) *harvesterv1.VirtualMachineImage {
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       harvesterv1.VirtualMachineImageSpec{SourceType: harvesterv1.VirtualMachineImageSourceTypeExportVolume},
+		}
+		if exportedVM != "" {
+			image.Labels = map[string]string{util.LabelVMExport: exportedVM}
+		}
+		return image
+	}
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "vm",
+			Annotations: map[string]string{
+				util.AnnotationVMExport: `{"format":"vmdk","images":{"rootdisk":"previous","datadisk":"other-vm","cdrom":"not-exported"}}`,
+			},
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Volumes: []kubevirtv1.Volume{
+						{Name: "rootdisk", VolumeSource: kubevirtv1.VolumeSource{PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+							PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "rootdisk"},
+						}}},
+					},
+				},
+			},
+		},
+	}
+	clientSet := fake.NewSimpleClientset(vm, newImage("previous", "vm"), newImage("other-vm", "other"), newImage("not-exported", ""))
+	h := &vmActionHandler{
+		vms:          fakeclients.VirtualMachineClient(clientSet.KubevirtV1().VirtualMachines),
+		vmCache:      fakeclients.VirtualMachineCache(clientSet.KubevirtV1().VirtualMachines),
+		vmImages:     fakeclients.VirtualMachineImageClient(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		vmImageCache: fakeclients.VirtualMachineImageCache(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+	}
+	assert.Nil(t, h.exportVM("default", "vm", ExportVMInput{}))
+
+	// only the image exported from the VM is deleted
+	_, err := clientSet.HarvesterhciV1beta1().VirtualMachineImages("default").Get(context.TODO(), "previous", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	for _, name := range []string{"other-vm", "not-exported"} {
+		_, err := clientSet.HarvesterhciV1beta1().VirtualMachineImages("default").Get(context.TODO(), name, metav1.GetOptions{})
+		assert.Nil(t, err, name)
+	}
+	images, err := clientSet.HarvesterhciV1beta1().VirtualMachineImages("default").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, images.Items, 3)
+}
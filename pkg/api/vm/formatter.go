@@ -1,13 +1,19 @@
 package vm
 
 import (
+	"time"
+
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/wrangler/pkg/data/convert"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
 	"github.com/harvester/harvester/pkg/controller/master/migration"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
@@ -31,20 +37,42 @@ const (
 	addVolume      = "addVolume"
 	removeVolume   = "removeVolume"
 	cloneVM        = "clone"
+	migrateVolume  = "migrateVolume"
+	resizeVM       = "resize"
+	exportVM       = "export"
+	importVM       = "import"
+	guestExec      = "guestExec"
+	moveVM         = "move"
+	revertVM       = "revertToSnapshot"
+	// addLabel is only run by bulkAddLabel
+	addLabel = "addLabel"
+
+	bulkStart    = "bulkStart"
+	bulkStop     = "bulkStop"
+	bulkRestart  = "bulkRestart"
+	bulkMigrate  = "bulkMigrate"
+	bulkBackup   = "bulkBackup"
+	bulkAddLabel = "bulkAddLabel"
+
+	linkDownloadOVA    = "ova"
+	linkGuestOSInfo    = "guestosinfo"
+	linkFilesystemList = "filesystemlist"
+	linkUserList       = "userlist"
+
+	fieldPendingResize       = "pendingResize"
+	fieldNextPowerTransition = "nextPowerTransition"
 )
 
 type vmformatter struct {
-	vmiCache      ctlkubevirtv1.VirtualMachineInstanceCache
-	vmBackupCache ctlharvesterv1.VirtualMachineBackupCache
+	vmiCache           ctlkubevirtv1.VirtualMachineInstanceCache
+	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
+	powerScheduleCache ctlharvesterv1.VirtualMachinePowerScheduleCache
 }
 
 func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawResource) {
 	// reset resource actions, because action map already be set when add actions handler,
 	// but current framework can't support use formatter to remove key from action map
 	resource.Actions = make(map[string]string, 1)
-	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
-		return
-	}
 
 	vm := &kubevirtv1.VirtualMachine{}
 	err := convert.ToObj(resource.APIObject.Data(), vm)
@@ -52,15 +80,37 @@ func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawR
 		return
 	}
 
+	vmi := vf.getVMI(vm)
+	if pendingResize := getPendingResize(vm, vmi); pendingResize != nil {
+		resource.APIObject.Data().Set(fieldPendingResize, pendingResize)
+	}
+	if nextPowerTransition := vf.getNextPowerTransition(vm); nextPowerTransition != nil {
+		resource.APIObject.Data().Set(fieldNextPowerTransition, nextPowerTransition)
+	}
+
+	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
+		return
+	}
+
 	resource.AddAction(request, addVolume)
+	resource.AddAction(request, resizeVM)
 	resource.AddAction(request, removeVolume)
 	resource.AddAction(request, cloneVM)
+	resource.AddAction(request, exportVM)
+
+	// only the volumes of stopped VMs can be migrated
+	if _, ok := vm.Annotations[util.AnnotationVolumeMigration]; !ok && (vmi == nil || vmi.IsFinal()) {
+		resource.AddAction(request, migrateVolume)
+	}
+
+	if _, ok := vm.Annotations[util.AnnotationVMMove]; !ok {
+		resource.AddAction(request, moveVM)
+	}
 
 	if canEjectCdRom(vm) {
 		resource.AddAction(request, ejectCdRom)
 	}
 
-	vmi := vf.getVMI(vm)
 	if vf.canStart(vm, vmi) {
 		resource.AddAction(request, startVM)
 	}
@@ -101,9 +151,85 @@ func (vf *vmformatter) formatter(request *types.APIRequest, resource *types.RawR
 		resource.AddAction(request, restoreVM)
 	}
 
+	if vf.canRevertToSnapshot(vm, vmi) {
+		resource.AddAction(request, revertVM)
+	}
+
 	if vf.canCreateTemplate(vmi) {
 		resource.AddAction(request, createTemplate)
 	}
+
+	if canGuestExec(request, vmi) {
+		resource.AddAction(request, guestExec)
+	}
+}
+
+// getPendingResize returns the CPU sockets and memory which are changed by the resize action
+// but not applied to the running VMI yet, they take effect at the next restart of the VM.
+func getPendingResize(vm *kubevirtv1.VirtualMachine, vmi *kubevirtv1.VirtualMachineInstance) map[string]interface{} {
+	if vm.Spec.Template == nil || vmi == nil || vmi.IsFinal() {
+		return nil
+	}
+
+	pendingResize := map[string]interface{}{}
+	if sockets := getCPUSockets(vm.Spec.Template.Spec.Domain.CPU); sockets != getCPUSockets(vmi.Spec.Domain.CPU) {
+		pendingResize["sockets"] = sockets
+	}
+	if memory := vm.Spec.Template.Spec.Domain.Resources.Limits.Memory(); !memory.Equal(*vmi.Spec.Domain.Resources.Limits.Memory()) {
+		pendingResize["memory"] = memory.String()
+	}
+	if len(pendingResize) == 0 {
+		return nil
+	}
+	return pendingResize
+}
+
+// getNextPowerTransition returns the earliest scheduled start or stop of the VM among the power schedules selecting it
+func (vf *vmformatter) getNextPowerTransition(vm *kubevirtv1.VirtualMachine) map[string]interface{} {
+	schedules, err := vf.powerScheduleCache.List(vm.Namespace, labels.Everything())
+	if err != nil {
+		logrus.Warnf("failed to list power schedules in namespace %s: %v", vm.Namespace, err)
+		return nil
+	}
+	return getNextPowerTransition(vm, schedules)
+}
+
+func getNextPowerTransition(vm *kubevirtv1.VirtualMachine, schedules []*harvesterv1.VirtualMachinePowerSchedule) map[string]interface{} {
+	var (
+		next     *metav1.Time
+		action   string
+		schedule string
+	)
+	for _, s := range schedules {
+		if s.Spec.Suspend || s.DeletionTimestamp != nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&s.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(vm.Labels)) {
+			continue
+		}
+		if t := s.Status.NextStartTime; t != nil && (next == nil || t.Before(next)) {
+			next, action, schedule = t, startVM, s.Name
+		}
+		if t := s.Status.NextStopTime; t != nil && (next == nil || t.Before(next)) {
+			next, action, schedule = t, stopVM, s.Name
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"action":   action,
+		"time":     next.UTC().Format(time.RFC3339),
+		"schedule": schedule,
+	}
+}
+
+func getCPUSockets(cpu *kubevirtv1.CPU) uint32 {
+	if cpu == nil || cpu.Sockets == 0 {
+		return 1
+	}
+	return cpu.Sockets
 }
 
 func canEjectCdRom(vm *kubevirtv1.VirtualMachine) bool {
@@ -119,6 +245,11 @@ func canEjectCdRom(vm *kubevirtv1.VirtualMachine) bool {
 	return false
 }
 
+func collectionFormatter(request *types.APIRequest, collection *types.GenericCollection) {
+	importCollectionFormatter(request, collection)
+	bulkCollectionFormatter(request, collection)
+}
+
 func (vf *vmformatter) canPause(vmi *kubevirtv1.VirtualMachineInstance) bool {
 	if vmi == nil {
 		return false
@@ -257,6 +388,23 @@ func (vf *vmformatter) canDoRestore(vm *kubevirtv1.VirtualMachine, vmi *kubevirt
 	return len(vmBackups) != 0
 }
 
+func (vf *vmformatter) canRevertToSnapshot(vm *kubevirtv1.VirtualMachine, vmi *kubevirtv1.VirtualMachineInstance) bool {
+	if vm.Status.Ready || vm.Status.SnapshotInProgress != nil || vmi != nil {
+		return false
+	}
+	vmBackups, err := vf.vmBackupCache.GetByIndex(indexeres.VMBackupBySourceVMUIDIndex, string(vm.UID))
+	if err != nil {
+		logrus.Errorf("Can't list VM Backups by index %s, err: %+v", indexeres.VMBackupBySourceVMUIDIndex, err)
+		return false
+	}
+	for _, vmBackup := range vmBackups {
+		if vmBackup.Spec.Type == harvesterv1.Snapshot && backup.IsBackupReady(vmBackup) {
+			return true
+		}
+	}
+	return false
+}
+
 func (vf *vmformatter) isVMStarting(vm *kubevirtv1.VirtualMachine) bool {
 	for _, req := range vm.Status.StateChangeRequests {
 		if req.Action == kubevirtv1.StartRequest {
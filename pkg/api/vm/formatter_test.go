@@ -0,0 +1,63 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func TestGetPendingResize(t *testing.T) {
+	newDomain := func(sockets uint32, memory string) kubevirtv1.DomainSpec {
+		return kubevirtv1.DomainSpec{
+			CPU: &kubevirtv1.CPU{Cores: 2, Sockets: sockets, Threads: 1},
+			Resources: kubevirtv1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+			},
+		}
+	}
+	newVM := func(domain kubevirtv1.DomainSpec) *kubevirtv1.VirtualMachine {
+		return &kubevirtv1.VirtualMachine{
+			Spec: kubevirtv1.VirtualMachineSpec{
+				Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+					Spec: kubevirtv1.VirtualMachineInstanceSpec{Domain: domain},
+				},
+			},
+		}
+	}
+	newVMI := func(domain kubevirtv1.DomainSpec) *kubevirtv1.VirtualMachineInstance {
+		return &kubevirtv1.VirtualMachineInstance{
+			Spec:   kubevirtv1.VirtualMachineInstanceSpec{Domain: domain},
+			Status: kubevirtv1.VirtualMachineInstanceStatus{Phase: kubevirtv1.Running},
+		}
+	}
+
+	var testCases = []struct {
+		name     string
+		vm       *kubevirtv1.VirtualMachine
+		vmi      *kubevirtv1.VirtualMachineInstance
+		expected map[string]interface{}
+	}{
+		{
+			name: "stopped vm",
+			vm:   newVM(newDomain(2, "4Gi")),
+		},
+		{
+			name: "no change",
+			vm:   newVM(newDomain(1, "4Gi")),
+			vmi:  newVMI(newDomain(1, "4096Mi")),
+		},
+		{
+			name:     "sockets and memory changed",
+			vm:       newVM(newDomain(2, "8Gi")),
+			vmi:      newVMI(newDomain(1, "4Gi")),
+			expected: map[string]interface{}{"sockets": uint32(2), "memory": "8Gi"},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, getPendingResize(tc.vm, tc.vmi), tc.name)
+	}
+}
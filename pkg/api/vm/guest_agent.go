@@ -0,0 +1,145 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	// guestExecVerb is the RBAC verb on kubevirt.io virtualmachines required by the guestExec action,
+	// it isn't granted by the edit role, so operators have to be granted it explicitly.
+	guestExecVerb = "guestexec"
+
+	defaultGuestExecTimeout = 30 * time.Second
+	maxGuestExecTimeout     = 5 * time.Minute
+)
+
+// guestAgentHandler serves a guest agent link of the VM by proxying the KubeVirt guest agent subresource of the VMI
+type guestAgentHandler struct {
+	subresource   string
+	actionHandler *vmActionHandler
+}
+
+func (h guestAgentHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	vars := util.EncodeVars(mux.Vars(req))
+	body, err := h.actionHandler.subresourceOperate(req.Context(), vmiResource, vars["namespace"], vars["name"], h.subresource)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(apierrors.APIStatus); ok {
+			status = int(e.Status().Code)
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(body)
+}
+
+// guestExecHandler runs a command in the guest through the guest agent
+type guestExecHandler struct {
+	vmiCache   ctlkubevirtv1.VirtualMachineInstanceCache
+	podCache   ctlcorev1.PodCache
+	clientSet  kubernetes.Interface
+	restConfig *rest.Config
+}
+
+func (h guestExecHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	output, err := h.guestExec(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(output)
+}
+
+func (h guestExecHandler) guestExec(req *http.Request) (*GuestExecOutput, error) {
+	vars := util.EncodeVars(mux.Vars(req))
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	// the action is allowed for the users who can update the VM, the command also requires the guestexec verb
+	apiOp := types.GetAPIContext(req.Context())
+	if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, vmGroupResource, guestExecVerb, namespace, name) != nil {
+		return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("%s on virtual machine %s/%s is not allowed", guestExecVerb, namespace, name))
+	}
+
+	var input GuestExecInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+	}
+	if input.Command == "" {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `command` is required")
+	}
+	timeout := defaultGuestExecTimeout
+	if input.TimeoutSeconds > 0 {
+		timeout = time.Duration(input.TimeoutSeconds) * time.Second
+	}
+	if timeout > maxGuestExecTimeout {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Parameter `timeoutSeconds` can't be greater than %d", int(maxGuestExecTimeout.Seconds())))
+	}
+
+	vmi, err := h.vmiCache.Get(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if !vmi.IsRunning() || !util.IsGuestAgentConnected(vmi) {
+		return nil, apierror.NewAPIError(validation.Conflict, fmt.Sprintf("guest agent of virtual machine %s/%s is not connected", namespace, name))
+	}
+
+	pod, err := util.GetLauncherPod(h.podCache, vmi)
+	if err != nil {
+		return nil, err
+	}
+	status, err := util.GuestExec(req.Context(), h.restConfig, h.clientSet, pod, vmi, input.Command, input.Args, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return toGuestExecOutput(status)
+}
+
+func toGuestExecOutput(s *util.GuestExecStatus) (*GuestExecOutput, error) {
+	stdout, err := base64.StdEncoding.DecodeString(s.OutData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode stdout: %v", err)
+	}
+	stderr, err := base64.StdEncoding.DecodeString(s.ErrData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode stderr: %v", err)
+	}
+	return &GuestExecOutput{
+		ExitCode:  s.ExitCode,
+		Stdout:    string(stdout),
+		Stderr:    string(stderr),
+		Truncated: s.OutTruncated || s.ErrTruncated,
+	}, nil
+}
+
+func canGuestExec(request *types.APIRequest, vmi *kubevirtv1.VirtualMachineInstance) bool {
+	if vmi == nil || !vmi.IsRunning() || !util.IsGuestAgentConnected(vmi) {
+		return false
+	}
+	return request.AccessControl.CanDo(request, vmGroupResource, guestExecVerb, vmi.Namespace, vmi.Name) == nil
+}
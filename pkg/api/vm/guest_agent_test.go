@@ -0,0 +1,25 @@
+package vm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestGuestExecStatusToOutput(t *testing.T) {
+	var result util.GuestExecStatusResult
+	assert.Nil(t, json.Unmarshal([]byte(`{"return":{"exitcode":1,"out-data":"b3V0Cg==","err-data":"ZXJyCg==","exited":true,"err-truncated":true}}`), &result))
+	assert.True(t, result.Return.Exited)
+
+	output, err := toGuestExecOutput(&result.Return)
+	assert.Nil(t, err)
+	assert.Equal(t, &GuestExecOutput{
+		ExitCode:  1,
+		Stdout:    "out\n",
+		Stderr:    "err\n",
+		Truncated: true,
+	}, output)
+}
@@ -9,16 +9,22 @@ import (
 	"strings"
 
 	"github.com/gorilla/mux"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	longhorntypes "github.com/longhorn/longhorn-manager/types"
 	"github.com/pkg/errors"
 	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	ctlstoragev1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
 	wranglername "github.com/rancher/wrangler/pkg/name"
 	"github.com/rancher/wrangler/pkg/schemas/validation"
 	"github.com/rancher/wrangler/pkg/slice"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/rand"
@@ -33,6 +39,8 @@ import (
 	"github.com/harvester/harvester/pkg/builder"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
 	"github.com/harvester/harvester/pkg/settings"
 	"github.com/harvester/harvester/pkg/util"
 )
@@ -41,6 +49,10 @@ const (
 	vmResource    = "virtualmachines"
 	vmiResource   = "virtualmachineinstances"
 	sshAnnotation = "harvesterhci.io/sshNames"
+
+	// vmGroupResource is the group and resource of VMs to check the permissions of users with AccessControl.CanDo
+	vmGroupResource      = "kubevirt.io/virtualmachines"
+	vmImageGroupResource = "harvesterhci.io/virtualmachineimages"
 )
 
 type vmActionHandler struct {
@@ -66,6 +78,10 @@ type vmActionHandler struct {
 	vmImages                  ctlharvesterv1.VirtualMachineImageClient
 	vmImageCache              ctlharvesterv1.VirtualMachineImageCache
 	storageClassCache         ctlstoragev1.StorageClassCache
+	namespaceCache            ctlcorev1.NamespaceCache
+	snapshots                 ctlsnapshotv1.VolumeSnapshotClient
+	volumes                   ctllonghornv1.VolumeClient
+	volumeCache               ctllonghornv1.VolumeCache
 }
 
 func (h vmActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -108,11 +124,11 @@ func (h *vmActionHandler) doAction(rw http.ResponseWriter, r *http.Request) erro
 	case abortMigration:
 		return h.abortMigration(namespace, name)
 	case startVM, stopVM, restartVM:
-		if err := h.subresourceOperate(r.Context(), vmResource, namespace, name, action); err != nil {
+		if _, err := h.subresourceOperate(r.Context(), vmResource, namespace, name, action); err != nil {
 			return fmt.Errorf("%s virtual machine %s/%s failed, %v", action, namespace, name, err)
 		}
 	case pauseVM, unpauseVM, softReboot:
-		if err := h.subresourceOperate(r.Context(), vmiResource, namespace, name, action); err != nil {
+		if _, err := h.subresourceOperate(r.Context(), vmiResource, namespace, name, action); err != nil {
 			return fmt.Errorf("%s virtual machine %s/%s failed, %v", action, namespace, name, err)
 		}
 	case backupVM:
@@ -125,8 +141,11 @@ func (h *vmActionHandler) doAction(rw http.ResponseWriter, r *http.Request) erro
 			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter backup name is required")
 		}
 
-		if err := h.checkBackupTargetConfigured(); err != nil {
-			return err
+		// the named backup target is checked by the webhook
+		if input.BackupTargetName == "" || input.BackupTargetName == harvesterv1.DefaultBackupTargetName {
+			if err := h.checkBackupTargetConfigured(); err != nil {
+				return err
+			}
 		}
 
 		if err := h.createVMBackup(name, namespace, input); err != nil {
@@ -188,11 +207,52 @@ func (h *vmActionHandler) doAction(rw http.ResponseWriter, r *http.Request) erro
 		if input.TargetVM == "" {
 			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter targetVm are required")
 		}
+		if input.TargetNamespace != "" {
+			if _, err := h.namespaceCache.Get(input.TargetNamespace); err != nil {
+				return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to get target namespace %s: %v", input.TargetNamespace, err))
+			}
+		}
 
-		if err := h.cloneVM(name, namespace, input); err != nil {
+		if err := h.cloneVM(r.Context(), name, namespace, input); err != nil {
 			return err
 		}
 		return nil
+	case migrateVolume:
+		var input MigrateVolumeInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+		if input.DiskName == "" || input.StorageClassName == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `diskName` and `storageClassName` are required")
+		}
+		return h.migrateVolume(namespace, name, input)
+	case resizeVM:
+		var input ResizeInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+		if input.Sockets == 0 && input.Memory == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `sockets` or `memory` is required")
+		}
+		return h.resizeVM(namespace, name, input)
+	case exportVM:
+		var input ExportVMInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+		return h.exportVM(namespace, name, input)
+	case moveVM:
+		var input MoveInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+		return h.moveVM(r.Context(), namespace, name, input)
+	case revertVM:
+		var input RevertToSnapshotInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: %v "+err.Error())
+		}
+		return h.revertToSnapshot(namespace, name, input)
 	default:
 		return apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
 	}
@@ -214,7 +274,8 @@ func (h *vmActionHandler) ejectCdRom(ctx context.Context, name, namespace string
 		if _, err := h.vms.Update(vmCopy); err != nil {
 			return err
 		}
-		return h.subresourceOperate(ctx, vmResource, namespace, name, restartVM)
+		_, err := h.subresourceOperate(ctx, vmResource, namespace, name, restartVM)
+		return err
 	}
 
 	return nil
@@ -243,15 +304,19 @@ func (h *vmActionHandler) startPreCheck(namespace, name string) error {
 	return nil
 }
 
-func (h *vmActionHandler) subresourceOperate(ctx context.Context, resource, namespace, name, subresourece string) error {
+func (h *vmActionHandler) subresourceOperate(ctx context.Context, resource, namespace, name, subresourece string) ([]byte, error) {
+	req := h.virtSubresourceRestClient.Put()
 	switch subresourece {
 	case startVM:
 		if err := h.startPreCheck(namespace, name); err != nil {
-			return err
+			return nil, err
 		}
+	case linkGuestOSInfo, linkFilesystemList, linkUserList:
+		// the guest agent subresources are read only
+		req = h.virtSubresourceRestClient.Get()
 	}
 
-	return h.virtSubresourceRestClient.Put().Namespace(namespace).Resource(resource).SubResource(subresourece).Name(name).Do(ctx).Error()
+	return req.Namespace(namespace).Resource(resource).SubResource(subresourece).Name(name).Do(ctx).Raw()
 }
 
 func ejectCdRomFromVM(vm *kubevirtv1.VirtualMachine, diskNames []string) error {
@@ -410,7 +475,8 @@ func (h *vmActionHandler) createVMBackup(vmName, vmNamespace string, input Backu
 				Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
 				Name:     vmName,
 			},
-			Type: harvesterv1.Backup,
+			Type:             harvesterv1.Backup,
+			BackupTargetName: input.BackupTargetName,
 		},
 	}
 	if _, err := h.backups.Create(backup); err != nil {
@@ -438,6 +504,8 @@ func (h *vmActionHandler) restoreBackup(vmName, vmNamespace string, input Restor
 			VirtualMachineBackupNamespace: vmNamespace,
 			VirtualMachineBackupName:      input.BackupName,
 			NewVM:                         false,
+			Volumes:                       input.Volumes,
+			VolumeRestoreMode:             input.VolumeRestoreMode,
 		},
 	}
 	_, err := h.restores.Create(restore)
@@ -726,26 +794,7 @@ func (h *vmActionHandler) addVolume(ctx context.Context, namespace, name string,
 		return err
 	}
 
-	// Restrict the flexibility of disk options here but future extension may be possible.
-	body, err := json.Marshal(kubevirtv1.AddVolumeOptions{
-		Name: input.DiskName,
-		Disk: &kubevirtv1.Disk{
-			DiskDevice: kubevirtv1.DiskDevice{
-				Disk: &kubevirtv1.DiskTarget{
-					// KubeVirt only support SCSI for hotplug volume.
-					Bus: "scsi",
-				},
-			},
-		},
-		VolumeSource: &kubevirtv1.HotplugVolumeSource{
-			PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
-				PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
-					ClaimName: input.VolumeSourceName,
-				},
-				Hotpluggable: true,
-			},
-		},
-	})
+	body, err := json.Marshal(util.NewHotplugVolumeOptions(input.DiskName, input.VolumeSourceName))
 	if err != nil {
 		return fmt.Errorf("failed to serialize payload,: %v", err)
 	}
@@ -863,15 +912,163 @@ func (h *vmActionHandler) removeVolume(ctx context.Context, namespace, name stri
 	})
 }
 
+// migrateVolume moves the data of a VM volume to a new PVC of another StorageClass.
+// Only the volumes of stopped VMs can be migrated: the vendored KubeVirt has no volume migration, and a running VMI keeps
+// using the old PVC until it's recreated, so the request is rejected instead of stopping the VM behind the user's back.
+// The volume migration controller copies the data, switches the disk to the new PVC and removes the old PVC.
+func (h *vmActionHandler) migrateVolume(namespace, name string, input MigrateVolumeInput) error {
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	if _, ok := vm.Annotations[util.AnnotationVolumeMigration]; ok {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume migration of vm %s/%s is in progress", namespace, name))
+	}
+	if vmi, err := h.vmiCache.Get(namespace, name); err == nil && !vmi.IsFinal() {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Please stop vm %s/%s before migrating its volumes, volumes of running VMs can't be migrated", namespace, name))
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	var pvcName string
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.Name == input.DiskName && volume.PersistentVolumeClaim != nil {
+			pvcName = volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	if pvcName == "" {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Disk %s is not a PVC volume of vm %s/%s", input.DiskName, namespace, name))
+	}
+	pvc, err := h.pvcCache.Get(namespace, pvcName)
+	if err != nil {
+		return err
+	}
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == input.StorageClassName {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Disk %s is already in storage class %s", input.DiskName, input.StorageClassName))
+	}
+
+	storageClass, err := h.storageClassCache.Get(input.StorageClassName)
+	if err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to get storage class %s: %v", input.StorageClassName, err))
+	}
+	// the data is copied through a VolumeSnapshot, which can only be restored by the same CSI driver
+	provisioner := util.GetProvisionedPVCProvisioner(pvc)
+	if storageClass.Provisioner != provisioner {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Can't migrate disk %s from provisioner %s to %s", input.DiskName, provisioner, storageClass.Provisioner))
+	}
+	if _, err := settings.GetCSIDriverInfo(provisioner); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Can't migrate disk %s: %v", input.DiskName, err))
+	}
+	// a Longhorn volume created from an image can only be restored to a volume with the same backing image
+	if backingImage := h.getStorageClassBackingImage(pvc.Spec.StorageClassName); backingImage != storageClass.Parameters[util.LonghornOptionBackingImageName] {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Can't migrate disk %s: storage class %s doesn't have backing image %q", input.DiskName, input.StorageClassName, backingImage))
+	}
+
+	migration, err := json.Marshal(util.VolumeMigration{
+		DiskName:         input.DiskName,
+		SourcePVCName:    pvcName,
+		SourcePVCUID:     pvc.UID,
+		TargetPVCName:    names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-%s-", vm.Name, input.DiskName)),
+		StorageClassName: input.StorageClassName,
+	})
+	if err != nil {
+		return err
+	}
+
+	vmCopy := vm.DeepCopy()
+	if vmCopy.Annotations == nil {
+		vmCopy.Annotations = map[string]string{}
+	}
+	vmCopy.Annotations[util.AnnotationVolumeMigration] = string(migration)
+	_, err = h.vms.Update(vmCopy)
+	return err
+}
+
+// resizeVM changes the CPU sockets and memory of a VM. The vendored KubeVirt can't hotplug CPU or memory to a running VMI,
+// so the change is queued in the VM spec and takes effect at the next restart, the formatter shows it as pending.
+// The requests of the new resources are calculated by the overcommit logic of the VM mutator.
+func (h *vmActionHandler) resizeVM(namespace, name string, input ResizeInput) error {
+	var memory resource.Quantity
+	if input.Memory != "" {
+		var err error
+		if memory, err = resource.ParseQuantity(input.Memory); err != nil || memory.Sign() <= 0 {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid memory %s", input.Memory))
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vm, err := h.vms.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		vmCopy := vm.DeepCopy()
+		domain := &vmCopy.Spec.Template.Spec.Domain
+		if domain.Resources.Limits == nil {
+			domain.Resources.Limits = corev1.ResourceList{}
+		}
+		if input.Sockets > 0 {
+			if domain.CPU == nil {
+				domain.CPU = &kubevirtv1.CPU{Cores: 1, Threads: 1}
+			}
+			domain.CPU.Sockets = input.Sockets
+			vcpus := int64(domain.CPU.Sockets)
+			if domain.CPU.Cores > 0 {
+				vcpus *= int64(domain.CPU.Cores)
+			}
+			if domain.CPU.Threads > 0 {
+				vcpus *= int64(domain.CPU.Threads)
+			}
+			domain.Resources.Limits[corev1.ResourceCPU] = *resource.NewQuantity(vcpus, resource.DecimalSI)
+		}
+		if input.Memory != "" {
+			domain.Resources.Limits[corev1.ResourceMemory] = memory
+		}
+
+		if reflect.DeepEqual(vm, vmCopy) {
+			return nil
+		}
+		_, err = h.vms.Update(vmCopy)
+		return err
+	})
+}
+
+func (h *vmActionHandler) getStorageClassBackingImage(storageClassName *string) string {
+	if storageClassName == nil {
+		return ""
+	}
+	storageClass, err := h.storageClassCache.Get(*storageClassName)
+	if err != nil {
+		return ""
+	}
+	return storageClass.Parameters[util.LonghornOptionBackingImageName]
+}
+
 // cloneVM creates a VM which uses volume cloning from the source VM.
-func (h *vmActionHandler) cloneVM(name string, namespace string, input CloneInput) error {
+// The clone can be created in another namespace, and its cloud-init secrets are regenerated so that
+// it boots as a distinct machine.
+func (h *vmActionHandler) cloneVM(ctx context.Context, name string, namespace string, input CloneInput) error {
+	targetNamespace := input.TargetNamespace
+	if targetNamespace == "" {
+		targetNamespace = namespace
+	}
+	// the clone is created by the harvester service account, check that the user can create the VM in the target namespace
+	apiOp := types.GetAPIContext(ctx)
+	if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, vmGroupResource, "create", targetNamespace, "") != nil {
+		return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on virtual machines in namespace %s is not allowed", targetNamespace))
+	}
+
 	vm, err := h.vmCache.Get(namespace, name)
 	if err != nil {
 		return fmt.Errorf("cannot get vm %s/%s, err: %w", namespace, name, err)
 	}
-	newVM := getClonedVMYamlFromSourceVM(input.TargetVM, vm)
+	hostname := input.Hostname
+	if hostname == "" {
+		hostname = input.TargetVM
+	}
+	newVM := getClonedVMYamlFromSourceVM(input.TargetVM, targetNamespace, hostname, vm)
 
-	newPVCs, secretNameMap, err := h.cloneVolumes(newVM)
+	newPVCs, volumeSnapshots, secretNameMap, err := h.cloneVolumes(namespace, newVM)
 	if err != nil {
 		return fmt.Errorf("clone volumes error for new vm %s/%s, err %w", newVM.Namespace, newVM.Name, err)
 	}
@@ -885,15 +1082,27 @@ func (h *vmActionHandler) cloneVM(name string, namespace string, input CloneInpu
 		return fmt.Errorf("cannot create newVM %+v, err: %w", newVM, err)
 	}
 
+	// The PVCs of a clone in another namespace are restored from copies of these VolumeSnapshots,
+	// they stay pending until the copies are made by the clone controller.
+	for _, volumeSnapshot := range volumeSnapshots {
+		if err := h.createCloneVolumeSnapshot(volumeSnapshot); err != nil {
+			return err
+		}
+	}
+
 	for oldSecretName, newSecretName := range secretNameMap {
 		secret, err := h.secretCache.Get(namespace, oldSecretName)
 		if err != nil {
 			return fmt.Errorf("cannot get secret %s/%s, err: %w", namespace, oldSecretName, err)
 		}
+		data, err := regenerateCloudInitSecretData(secret.Data, hostname)
+		if err != nil {
+			return fmt.Errorf("cannot regenerate the cloud-init data of secret %s/%s, err: %w", namespace, oldSecretName, err)
+		}
 
 		newSecret := corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Namespace: namespace,
+				Namespace: newVM.Namespace,
 				Name:      newSecretName,
 				OwnerReferences: []metav1.OwnerReference{
 					{
@@ -904,7 +1113,7 @@ func (h *vmActionHandler) cloneVM(name string, namespace string, input CloneInpu
 					},
 				},
 			},
-			Data:       secret.Data,
+			Data:       data,
 			StringData: secret.StringData,
 			Type:       secret.Type,
 		}
@@ -915,19 +1124,21 @@ func (h *vmActionHandler) cloneVM(name string, namespace string, input CloneInpu
 	return nil
 }
 
-func (h *vmActionHandler) cloneVolumes(newVM *kubevirtv1.VirtualMachine) ([]corev1.PersistentVolumeClaim, map[string]string, error) {
+func (h *vmActionHandler) cloneVolumes(sourceNamespace string, newVM *kubevirtv1.VirtualMachine) ([]corev1.PersistentVolumeClaim, []*snapshotv1.VolumeSnapshot, map[string]string, error) {
 	var (
-		err           error
-		newPVCs       []corev1.PersistentVolumeClaim
-		secretNameMap = map[string]string{} // sourceVM secret name to newVM secret name
+		err             error
+		newPVCs         []corev1.PersistentVolumeClaim
+		volumeSnapshots []*snapshotv1.VolumeSnapshot
+		secretNameMap   = map[string]string{} // sourceVM secret name to newVM secret name
+		crossNamespace  = sourceNamespace != newVM.Namespace
 	)
 
 	for i, volume := range newVM.Spec.Template.Spec.Volumes {
 		if volume.PersistentVolumeClaim != nil {
 			var pvc *corev1.PersistentVolumeClaim
-			pvc, err = h.pvcCache.Get(newVM.Namespace, volume.PersistentVolumeClaim.ClaimName)
+			pvc, err = h.pvcCache.Get(sourceNamespace, volume.PersistentVolumeClaim.ClaimName)
 			if err != nil {
-				return nil, nil, fmt.Errorf("cannot get pvc %s, err: %w", volume.PersistentVolumeClaim.ClaimName, err)
+				return nil, nil, nil, fmt.Errorf("cannot get pvc %s, err: %w", volume.PersistentVolumeClaim.ClaimName, err)
 			}
 
 			annotations := map[string]string{}
@@ -951,6 +1162,19 @@ func (h *vmActionHandler) cloneVolumes(newVM *kubevirtv1.VirtualMachine) ([]core
 					VolumeMode:       pvc.Spec.VolumeMode,
 				},
 			}
+			if crossNamespace {
+				// a PVC can't be cloned from a PVC in another namespace, restore it from a VolumeSnapshot of the same name instead
+				var volumeSnapshot *snapshotv1.VolumeSnapshot
+				if volumeSnapshot, err = getCloneVolumeSnapshot(pvc, newPVC.Name, newVM.Namespace); err != nil {
+					return nil, nil, nil, err
+				}
+				volumeSnapshots = append(volumeSnapshots, volumeSnapshot)
+				newPVC.Spec.DataSource = &corev1.TypedLocalObjectReference{
+					APIGroup: pointer.StringPtr(snapshotv1.SchemeGroupVersion.Group),
+					Kind:     "VolumeSnapshot",
+					Name:     volumeSnapshot.Name,
+				}
+			}
 			newPVCs = append(newPVCs, newPVC)
 			volume.PersistentVolumeClaim.ClaimName = newPVC.Name
 		} else if volume.CloudInitNoCloud != nil {
@@ -966,14 +1190,103 @@ func (h *vmActionHandler) cloneVolumes(newVM *kubevirtv1.VirtualMachine) ([]core
 				}
 				volume.CloudInitNoCloud.NetworkDataSecretRef.Name = secretNameMap[volume.CloudInitNoCloud.NetworkDataSecretRef.Name]
 			}
+			if volume.CloudInitNoCloud.UserData != "" {
+				if volume.CloudInitNoCloud.UserData, err = regenerateUserData(volume.CloudInitNoCloud.UserData, newVM.Spec.Template.Spec.Hostname); err != nil {
+					return nil, nil, nil, fmt.Errorf("cannot regenerate the user data of volume %s, err: %w", volume.Name, err)
+				}
+			}
+			if volume.CloudInitNoCloud.NetworkData != "" {
+				if volume.CloudInitNoCloud.NetworkData, err = regenerateNetworkData(volume.CloudInitNoCloud.NetworkData); err != nil {
+					return nil, nil, nil, fmt.Errorf("cannot regenerate the network data of volume %s, err: %w", volume.Name, err)
+				}
+			}
 		} else if volume.ContainerDisk != nil {
 			continue
 		} else {
-			return nil, nil, fmt.Errorf("invalid volume %s, only support PersistentVolumeClaim, CloudInitNoCloud, and ContainerDisk", volume.Name)
+			return nil, nil, nil, fmt.Errorf("invalid volume %s, only support PersistentVolumeClaim, CloudInitNoCloud, and ContainerDisk", volume.Name)
 		}
 		newVM.Spec.Template.Spec.Volumes[i] = volume
 	}
-	return newPVCs, secretNameMap, nil
+
+	if crossNamespace {
+		// the secrets of the access credentials have to be copied to the target namespace as well
+		for i, credential := range newVM.Spec.Template.Spec.AccessCredentials {
+			if sshPublicKey := credential.SSHPublicKey; sshPublicKey != nil && sshPublicKey.Source.Secret != nil {
+				if _, ok := secretNameMap[sshPublicKey.Source.Secret.SecretName]; !ok {
+					secretNameMap[sshPublicKey.Source.Secret.SecretName] = names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-", newVM.Name))
+				}
+				newVM.Spec.Template.Spec.AccessCredentials[i].SSHPublicKey.Source.Secret.SecretName = secretNameMap[sshPublicKey.Source.Secret.SecretName]
+			}
+			if userPassword := credential.UserPassword; userPassword != nil && userPassword.Source.Secret != nil {
+				if _, ok := secretNameMap[userPassword.Source.Secret.SecretName]; !ok {
+					secretNameMap[userPassword.Source.Secret.SecretName] = names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-", newVM.Name))
+				}
+				newVM.Spec.Template.Spec.AccessCredentials[i].UserPassword.Source.Secret.SecretName = secretNameMap[userPassword.Source.Secret.SecretName]
+			}
+		}
+	}
+	return newPVCs, volumeSnapshots, secretNameMap, nil
+}
+
+// getCloneVolumeSnapshot returns the VolumeSnapshot of a source PVC to clone it to another namespace.
+func getCloneVolumeSnapshot(pvc *corev1.PersistentVolumeClaim, name, targetNamespace string) (*snapshotv1.VolumeSnapshot, error) {
+	provisioner := util.GetProvisionedPVCProvisioner(pvc)
+	csiDriverInfo, err := settings.GetCSIDriverInfo(provisioner)
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{
+		util.AnnotationCloneTargetNamespace: targetNamespace,
+		util.AnnotationStorageProvisioner:   provisioner,
+	}
+	if pvc.Spec.StorageClassName != nil {
+		annotations[util.AnnotationStorageClassName] = *pvc.Spec.StorageClassName
+	}
+	if imageID := pvc.Annotations[util.AnnotationImageID]; imageID != "" {
+		annotations[util.AnnotationImageID] = imageID
+	}
+	return &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   pvc.Namespace,
+			Annotations: annotations,
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: pointer.StringPtr(pvc.Name),
+			},
+			VolumeSnapshotClassName: pointer.StringPtr(csiDriverInfo.VolumeSnapshotClassName),
+		},
+	}, nil
+}
+
+func (h *vmActionHandler) createCloneVolumeSnapshot(volumeSnapshot *snapshotv1.VolumeSnapshot) error {
+	pvcName := *volumeSnapshot.Spec.Source.PersistentVolumeClaimName
+	pvc, err := h.pvcCache.Get(volumeSnapshot.Namespace, pvcName)
+	if err != nil {
+		return fmt.Errorf("cannot get pvc %s/%s, err: %w", volumeSnapshot.Namespace, pvcName, err)
+	}
+
+	// Longhorn can only take snapshots of attached volumes
+	if util.GetProvisionedPVCProvisioner(pvc) == longhorntypes.LonghornDriverName {
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+		if err != nil {
+			return fmt.Errorf("failed to get volume %s/%s, error: %s", util.LonghornSystemNamespaceName, pvc.Spec.VolumeName, err.Error())
+		}
+		if volume.Status.State == lhv1beta1.VolumeStateDetached || volume.Status.State == lhv1beta1.VolumeStateDetaching {
+			volCpy := volume.DeepCopy()
+			volCpy.Spec.NodeID = volume.Status.OwnerID
+			logrus.Infof("mount detached volume %s to the node %s", volCpy.Name, volCpy.Spec.NodeID)
+			if _, err = h.volumes.Update(volCpy); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = h.snapshots.Create(volumeSnapshot); err != nil {
+		return fmt.Errorf("cannot create volume snapshot %s/%s, err: %w", volumeSnapshot.Namespace, volumeSnapshot.Name, err)
+	}
+	return nil
 }
 
 func (h *vmActionHandler) sanitizeVirtualMachineForTemplateVersion(templateVersionName string, vm *kubevirtv1.VirtualMachine, withData bool) (harvesterv1.VirtualMachineSourceSpec, error) {
@@ -1113,20 +1426,26 @@ func getTemplateVersionUserPasswordSecretName(templateVersionName string, creden
 	return wranglername.SafeConcatName("templateversion", templateVersionName, fmt.Sprintf("credential-%d", credentialIndex), "userpassword")
 }
 
-func getClonedVMYamlFromSourceVM(newVMName string, sourceVM *kubevirtv1.VirtualMachine) *kubevirtv1.VirtualMachine {
+func getClonedVMYamlFromSourceVM(newVMName, newVMNamespace, hostname string, sourceVM *kubevirtv1.VirtualMachine) *kubevirtv1.VirtualMachine {
 	newVM := &kubevirtv1.VirtualMachine{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        newVMName,
-			Namespace:   sourceVM.Namespace,
+			Namespace:   newVMNamespace,
 			Annotations: map[string]string{},
 			Labels:      sourceVM.Labels,
 		},
 		Spec: *sourceVM.Spec.DeepCopy(),
 	}
-	newVM.Spec.Template.Spec.Hostname = newVM.Name
+	newVM.Spec.Template.Spec.Hostname = hostname
 	newVM.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName] = newVM.Name
 	for i := range newVM.Spec.Template.Spec.Domain.Devices.Interfaces {
 		newVM.Spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
 	}
+	// network names without a namespace refer to the namespace of the VM, keep them pointing to the source namespace
+	for i, network := range newVM.Spec.Template.Spec.Networks {
+		if newVM.Namespace != sourceVM.Namespace && network.Multus != nil && !strings.Contains(network.Multus.NetworkName, "/") {
+			newVM.Spec.Template.Spec.Networks[i].Multus.NetworkName = fmt.Sprintf("%s/%s", sourceVM.Namespace, network.Multus.NetworkName)
+		}
+	}
 	return newVM
 }
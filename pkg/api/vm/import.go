@@ -0,0 +1,584 @@
+package vm
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/builder"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/vmdk"
+)
+
+const (
+	importVMCreator = "harvester"
+
+	importFormatVMDK  = "vmdk"
+	importFormatQCOW2 = "qcow2"
+	importFormatRaw   = "raw"
+
+	// the OVF descriptor is small, it's read into memory with the limit
+	maxOVFDescriptorSize = 10 << 20
+
+	// resource type of IDE controllers, the other ones are shared with export
+	ovfResourceTypeIDEController = 5
+
+	qcow2Magic = "QFI\xfb"
+)
+
+// ovfDescriptor is the part of an OVF descriptor used to import a VM, the elements and attributes are matched by
+// their local names, so it works with both OVF 1.x and 2.x descriptors.
+type ovfDescriptor struct {
+	References []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+		Size int64  `xml:"size,attr"`
+	} `xml:"References>File"`
+	Disks []struct {
+		DiskID                  string `xml:"diskId,attr"`
+		FileRef                 string `xml:"fileRef,attr"`
+		Capacity                string `xml:"capacity,attr"`
+		CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+		Format                  string `xml:"format,attr"`
+	} `xml:"DiskSection>Disk"`
+	VirtualSystem struct {
+		ID    string              `xml:"id,attr"`
+		Name  string              `xml:"Name"`
+		Items []ovfItemDescriptor `xml:"VirtualHardwareSection>Item"`
+	} `xml:"VirtualSystem"`
+}
+
+type ovfItemDescriptor struct {
+	Address         string `xml:"Address"`
+	AddressOnParent string `xml:"AddressOnParent"`
+	AllocationUnits string `xml:"AllocationUnits"`
+	Connection      string `xml:"Connection"`
+	ElementName     string `xml:"ElementName"`
+	HostResource    string `xml:"HostResource"`
+	InstanceID      string `xml:"InstanceID"`
+	Parent          string `xml:"Parent"`
+	ResourceSubType string `xml:"ResourceSubType"`
+	ResourceType    int    `xml:"ResourceType"`
+	VirtualQuantity int64  `xml:"VirtualQuantity"`
+}
+
+// importPlan is the VM to create from an OVF descriptor
+type importPlan struct {
+	name   string
+	cpu    int64
+	memory int64
+	disks  []*importDisk
+	nics   []importNIC
+}
+
+type importDisk struct {
+	name      string
+	fileName  string
+	fileSize  int64
+	format    string
+	capacity  int64
+	cdrom     bool
+	bus       string
+	bootOrder uint
+	image     *harvesterv1.VirtualMachineImage
+}
+
+type importNIC struct {
+	name    string
+	model   string
+	mac     string
+	network string
+}
+
+// importHandler creates a VM and the images of its disks from an OVA package, or an OVF descriptor with its disk
+// files uploaded as a multipart form. The OVF descriptor must be the first file. VMDK disks are converted to raw.
+type importHandler struct {
+	httpClient              http.Client
+	vms                     ctlkubevirtv1.VirtualMachineClient
+	vmCache                 ctlkubevirtv1.VirtualMachineCache
+	images                  ctlharvesterv1.VirtualMachineImageClient
+	backingImageDataSources ctllonghornv1.BackingImageDataSourceClient
+	nadCache                ctlcniv1.NetworkAttachmentDefinitionCache
+}
+
+func (h importHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	vm, err := h.importVM(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(rw).Encode(vm)
+}
+
+func (h importHandler) importVM(req *http.Request) (*kubevirtv1.VirtualMachine, error) {
+	namespace := util.EncodeVars(mux.Vars(req))["namespace"]
+	if namespace == "" {
+		namespace = req.URL.Query().Get("namespace")
+	}
+	if namespace == "" {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `namespace` is required")
+	}
+	// the VM and the images are created by the harvester service account, check that the user can create them in the namespace
+	apiOp := types.GetAPIContext(req.Context())
+	for _, groupResource := range []string{vmGroupResource, vmImageGroupResource} {
+		if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, groupResource, "create", namespace, "") != nil {
+			return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on %s in namespace %s is not allowed", groupResource, namespace))
+		}
+	}
+
+	nextFile, err := newImportFileReader(req)
+	if err != nil {
+		return nil, err
+	}
+	ovfFile, err := nextFile()
+	if err != nil || !strings.HasSuffix(strings.ToLower(ovfFile.name), ".ovf") {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, "The OVF descriptor must be the first file")
+	}
+	var ovf ovfDescriptor
+	if err := xml.NewDecoder(io.LimitReader(ovfFile.reader, maxOVFDescriptorSize)).Decode(&ovf); err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to parse the OVF descriptor: %v", err))
+	}
+	plan, err := buildImportPlan(&ovf)
+	if err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if name := req.URL.Query().Get("name"); name != "" {
+		plan.name = name
+	}
+	if errs := k8svalidation.IsDNS1123Subdomain(plan.name); len(errs) > 0 {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid VM name %q, set it with the parameter `name`: %s", plan.name, strings.Join(errs, ", ")))
+	}
+	if _, err := h.vmCache.Get(namespace, plan.name); err == nil {
+		return nil, apierror.NewAPIError(validation.Conflict, fmt.Sprintf("VM %s/%s already exists", namespace, plan.name))
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// the images are removed if the import fails
+	succeeded := false
+	defer func() {
+		if succeeded {
+			return
+		}
+		for _, disk := range plan.disks {
+			if disk.image == nil {
+				continue
+			}
+			if err := h.images.Delete(disk.image.Namespace, disk.image.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("failed to delete image %s/%s of the failed import, error: %v", disk.image.Namespace, disk.image.Name, err)
+			}
+		}
+	}()
+
+	for {
+		file, err := nextFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to read the package: %v", err))
+		}
+		for _, disk := range plan.disks {
+			if disk.fileName != path.Base(file.name) || disk.image != nil {
+				continue
+			}
+			if err := h.importDisk(req, namespace, plan.name, disk, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, disk := range plan.disks {
+		if disk.image == nil {
+			return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Disk file %s is missing", disk.fileName))
+		}
+	}
+
+	vm, err := h.buildVM(namespace, plan)
+	if err != nil {
+		return nil, err
+	}
+	if vm, err = h.vms.Create(vm); err != nil {
+		return nil, err
+	}
+	succeeded = true
+	return vm, nil
+}
+
+type importFile struct {
+	name   string
+	size   int64
+	reader io.Reader
+}
+
+// newImportFileReader returns a function to iterate the files of an OVA package or a multipart form
+func newImportFileReader(req *http.Request) (func() (*importFile, error), error) {
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr := multipart.NewReader(req.Body, params["boundary"])
+		return func() (*importFile, error) {
+			for {
+				part, err := mr.NextPart()
+				if err != nil {
+					return nil, err
+				}
+				if part.FileName() == "" {
+					continue
+				}
+				return &importFile{name: part.FileName(), size: -1, reader: part}, nil
+			}
+		}, nil
+	}
+
+	tr := tar.NewReader(req.Body)
+	return func() (*importFile, error) {
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			return &importFile{name: header.Name, size: header.Size, reader: tr}, nil
+		}
+	}, nil
+}
+
+// importDisk creates an upload image for a disk and uploads the disk file to its backing image
+func (h importHandler) importDisk(req *http.Request, namespace, vmName string, disk *importDisk, file *importFile) error {
+	size := file.size
+	if size < 0 {
+		size = disk.fileSize
+	}
+	var reader io.Reader = file.reader
+	switch disk.format {
+	case importFormatVMDK:
+		vmdkReader, err := vmdk.NewStreamReader(file.reader)
+		if err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to read disk file %s: %v", disk.fileName, err))
+		}
+		reader, size = vmdkReader, vmdkReader.Size()
+		if disk.capacity < size {
+			disk.capacity = size
+		}
+	case importFormatQCOW2:
+		br := bufio.NewReader(file.reader)
+		if header, err := br.Peek(32); err == nil && string(header[:4]) == qcow2Magic {
+			if virtualSize := int64(binary.BigEndian.Uint64(header[24:32])); disk.capacity < virtualSize {
+				disk.capacity = virtualSize
+			}
+		}
+		reader = br
+	case importFormatRaw:
+		if disk.capacity < size {
+			disk.capacity = size
+		}
+	}
+	if size <= 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Size of disk file %s is unknown", disk.fileName))
+	}
+
+	image, err := h.images.Create(&harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "image-",
+			Namespace:    namespace,
+		},
+		Spec: harvesterv1.VirtualMachineImageSpec{
+			DisplayName: fmt.Sprintf("%s-%s", vmName, strings.TrimSuffix(disk.fileName, path.Ext(disk.fileName))),
+			SourceType:  harvesterv1.VirtualMachineImageSourceTypeUpload,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	disk.image = image
+
+	bkimgName := util.GetBackingImageName(image)
+	if err := h.waitForBackingImageDataSourceReady(bkimgName); err != nil {
+		return err
+	}
+
+	if err := util.UploadBackingImage(req.Context(), &h.httpClient, bkimgName, disk.fileName, reader, size); err != nil {
+		return err
+	}
+	logrus.Infof("disk file %s of vm %s/%s is uploaded to image %s", disk.fileName, namespace, vmName, image.Name)
+	return nil
+}
+
+func (h importHandler) waitForBackingImageDataSourceReady(name string) error {
+	retry := 30
+	for i := 0; i < retry; i++ {
+		ds, err := h.backingImageDataSources.Get(util.LonghornSystemNamespaceName, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed waiting for backing image data source to be ready: %w", err)
+		}
+		if err == nil {
+			if ds.Status.CurrentState == lhv1beta1.BackingImageStatePending {
+				return nil
+			}
+			if ds.Status.CurrentState == lhv1beta1.BackingImageStateFailed {
+				return errors.New(ds.Status.Message)
+			}
+		}
+		time.Sleep(2 * time.Second)
+	}
+	return errors.New("timeout waiting for backing image data source to be ready")
+}
+
+func (h importHandler) buildVM(namespace string, plan *importPlan) (*kubevirtv1.VirtualMachine, error) {
+	vmBuilder := builder.NewVMBuilder(importVMCreator).Namespace(namespace).Name(plan.name).Run(false)
+	if plan.cpu > 0 {
+		vmBuilder.CPU(int(plan.cpu))
+	}
+	if plan.memory > 0 {
+		vmBuilder.Memory(resource.NewQuantity(plan.memory, resource.BinarySI).String())
+	}
+
+	for _, disk := range plan.disks {
+		storageClassName := util.GetImageStorageClassName(disk.image.Name)
+		vmBuilder.PVCDisk(disk.name, disk.bus, disk.cdrom, false, disk.bootOrder,
+			resource.NewQuantity(disk.capacity, resource.BinarySI).String(), "", &builder.PersistentVolumeClaimOption{
+				ImageID:          ref.Construct(disk.image.Namespace, disk.image.Name),
+				VolumeMode:       corev1.PersistentVolumeBlock,
+				AccessMode:       corev1.ReadWriteMany,
+				StorageClassName: &storageClassName,
+			})
+	}
+
+	// only one interface can be attached to the management network
+	hasManagementNetwork := false
+	for _, nic := range plan.nics {
+		networkName := h.getNetworkName(namespace, nic.network)
+		if networkName == "" {
+			if hasManagementNetwork {
+				logrus.Warnf("skip interface %s of vm %s/%s, network %s is not found", nic.name, namespace, plan.name, nic.network)
+				continue
+			}
+			hasManagementNetwork = true
+			vmBuilder.NetworkInterface(nic.name, nic.model, nic.mac, builder.NetworkInterfaceTypeMasquerade, "")
+			continue
+		}
+		vmBuilder.NetworkInterface(nic.name, nic.model, nic.mac, builder.NetworkInterfaceTypeBridge, networkName)
+	}
+	return vmBuilder.VM()
+}
+
+// getNetworkName returns the NetworkAttachmentDefinition of an OVF network, it's empty for the management network
+func (h importHandler) getNetworkName(namespace, network string) string {
+	nadNamespace, nadName := ref.Parse(network)
+	if nadNamespace == "" {
+		nadNamespace = namespace
+	}
+	if _, err := h.nadCache.Get(nadNamespace, nadName); err != nil {
+		return ""
+	}
+	return ref.Construct(nadNamespace, nadName)
+}
+
+// buildImportPlan maps the virtual hardware of an OVF descriptor to a VM
+func buildImportPlan(ovf *ovfDescriptor) (*importPlan, error) {
+	plan := &importPlan{
+		name: strings.ToLower(strings.ReplaceAll(strings.TrimSpace(ovf.VirtualSystem.Name), " ", "-")),
+	}
+	if plan.name == "" {
+		plan.name = strings.ToLower(ovf.VirtualSystem.ID)
+	}
+
+	controllerBuses := map[string]string{}
+	for _, item := range ovf.VirtualSystem.Items {
+		switch item.ResourceType {
+		case ovfResourceTypeIDEController, ovfResourceTypeSATAController:
+			controllerBuses[item.InstanceID] = builder.DiskBusSata
+		case ovfResourceTypeSCSIController:
+			controllerBuses[item.InstanceID] = builder.DiskBusScsi
+		}
+	}
+
+	var disks, cdroms []*importDisk
+	for _, item := range ovf.VirtualSystem.Items {
+		switch item.ResourceType {
+		case ovfResourceTypeCPU:
+			plan.cpu = item.VirtualQuantity
+		case ovfResourceTypeMemory:
+			units, err := parseAllocationUnits(item.AllocationUnits, 1<<20)
+			if err != nil {
+				return nil, err
+			}
+			plan.memory = item.VirtualQuantity * units
+		case ovfResourceTypeDisk, ovfResourceTypeCDROM:
+			// empty CD-ROM drives have no host resources
+			if item.HostResource == "" {
+				continue
+			}
+			disk, err := getImportDisk(ovf, item.HostResource)
+			if err != nil {
+				return nil, err
+			}
+			if item.ResourceType == ovfResourceTypeCDROM {
+				disk.cdrom = true
+				disk.bus = builder.DiskBusSata
+				cdroms = append(cdroms, disk)
+				continue
+			}
+			disk.bus = builder.DiskBusVirtio
+			if bus, ok := controllerBuses[item.Parent]; ok {
+				disk.bus = bus
+			}
+			disks = append(disks, disk)
+		case ovfResourceTypeEthernet:
+			plan.nics = append(plan.nics, importNIC{
+				name:    fmt.Sprintf("nic-%d", len(plan.nics)),
+				model:   getImportNICModel(item.ResourceSubType),
+				mac:     item.Address,
+				network: item.Connection,
+			})
+		}
+	}
+
+	// boot from the hard disks first, then the CD-ROMs in the order of the descriptor
+	plan.disks = append(disks, cdroms...)
+	for i, disk := range plan.disks {
+		disk.name = fmt.Sprintf("disk-%d", i)
+		disk.bootOrder = uint(i + 1)
+	}
+	if len(plan.disks) == 0 {
+		return nil, errors.New("no disks are found in the OVF descriptor")
+	}
+	return plan, nil
+}
+
+func getImportDisk(ovf *ovfDescriptor, hostResource string) (*importDisk, error) {
+	// the host resource of a disk is in the form of ovf:/disk/<diskId>
+	diskID := path.Base(hostResource)
+	for _, d := range ovf.Disks {
+		if d.DiskID != diskID {
+			continue
+		}
+		disk := &importDisk{}
+		for _, file := range ovf.References {
+			if file.ID == d.FileRef {
+				disk.fileName = path.Base(file.Href)
+				disk.fileSize = file.Size
+			}
+		}
+		if disk.fileName == "" {
+			return nil, fmt.Errorf("file of disk %s is not found", diskID)
+		}
+		if capacity, err := strconv.ParseInt(d.Capacity, 10, 64); err == nil {
+			units, err := parseAllocationUnits(d.CapacityAllocationUnits, 1)
+			if err != nil {
+				return nil, err
+			}
+			disk.capacity = capacity * units
+		}
+		format, err := getImportDiskFormat(d.Format, disk.fileName)
+		if err != nil {
+			return nil, err
+		}
+		disk.format = format
+		return disk, nil
+	}
+	return nil, fmt.Errorf("disk %s is not found", diskID)
+}
+
+func getImportDiskFormat(format, fileName string) (string, error) {
+	format = strings.ToLower(format)
+	switch {
+	case strings.Contains(format, "vmdk"):
+		return importFormatVMDK, nil
+	case strings.Contains(format, "qcow"):
+		return importFormatQCOW2, nil
+	case strings.Contains(format, "raw"):
+		return importFormatRaw, nil
+	}
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".vmdk":
+		return importFormatVMDK, nil
+	case ".qcow2":
+		return importFormatQCOW2, nil
+	case ".raw", ".img":
+		return importFormatRaw, nil
+	}
+	return "", fmt.Errorf("format %s of disk file %s is not supported", format, fileName)
+}
+
+func getImportNICModel(subType string) string {
+	switch strings.ToLower(subType) {
+	case "e1000":
+		return "e1000"
+	case "e1000e":
+		return "e1000e"
+	case "pcnet32":
+		return "pcnet"
+	case "rtl8139":
+		return "rtl8139"
+	}
+	return "virtio"
+}
+
+// parseAllocationUnits parses the programmatic units of DSP0004, e.g. "byte * 2^20", and the common units used by
+// VMware, e.g. "MegaBytes"
+func parseAllocationUnits(units string, defaultUnits int64) (int64, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(units, " ", ""))
+	switch normalized {
+	case "":
+		return defaultUnits, nil
+	case "byte", "bytes":
+		return 1, nil
+	case "kilobytes", "kb":
+		return 1 << 10, nil
+	case "megabytes", "mb":
+		return 1 << 20, nil
+	case "gigabytes", "gb":
+		return 1 << 30, nil
+	}
+	var exponent uint
+	if _, err := fmt.Sscanf(normalized, "byte*2^%d", &exponent); err != nil || exponent > 40 {
+		return 0, fmt.Errorf("unsupported allocation units %q", units)
+	}
+	return 1 << exponent, nil
+}
+
+func importCollectionFormatter(request *types.APIRequest, collection *types.GenericCollection) {
+	if request.AccessControl.CanCreate(request, request.Schema) != nil {
+		return
+	}
+	collection.AddAction(request, importVM)
+}
@@ -0,0 +1,238 @@
+package vm
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester/pkg/builder"
+)
+
+const testVMwareOVF = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope vmw:buildId="build-123" xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vmw="http://www.vmware.com/schema/ovf">
+  <References>
+    <File ovf:href="Web Server-disk1.vmdk" ovf:id="file1" ovf:size="1073741824"/>
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+    <Disk ovf:capacity="40" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+    <Network ovf:name="VM Network"/>
+  </NetworkSection>
+  <VirtualSystem ovf:id="Web Server">
+    <Info>A virtual machine</Info>
+    <Name>Web Server</Name>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:ElementName>2 virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>2</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>4096MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>4096</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:ElementName>SCSI controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>VirtualSCSI</rasd:ResourceSubType>
+        <rasd:ResourceType>6</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:Address>1</rasd:Address>
+        <rasd:ElementName>IDE 1</rasd:ElementName>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:ResourceType>5</rasd:ResourceType>
+      </Item>
+      <Item ovf:required="false">
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:AutomaticAllocation>false</rasd:AutomaticAllocation>
+        <rasd:ElementName>CD/DVD drive 1</rasd:ElementName>
+        <rasd:InstanceID>5</rasd:InstanceID>
+        <rasd:Parent>4</rasd:Parent>
+        <rasd:ResourceType>15</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>0</rasd:AddressOnParent>
+        <rasd:ElementName>Hard disk 1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:InstanceID>6</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:Address>00:50:56:ab:cd:ef</rasd:Address>
+        <rasd:AddressOnParent>7</rasd:AddressOnParent>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Connection>VM Network</rasd:Connection>
+        <rasd:ElementName>Network adapter 1</rasd:ElementName>
+        <rasd:InstanceID>7</rasd:InstanceID>
+        <rasd:ResourceSubType>VmxNet3</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`
+
+func TestBuildImportPlan(t *testing.T) {
+	var ovf ovfDescriptor
+	assert.Nil(t, xml.Unmarshal([]byte(testVMwareOVF), &ovf))
+
+	plan, err := buildImportPlan(&ovf)
+	assert.Nil(t, err)
+	assert.Equal(t, &importPlan{
+		name:   "web-server",
+		cpu:    2,
+		memory: 4 << 30,
+		disks: []*importDisk{
+			{
+				name:      "disk-0",
+				fileName:  "Web Server-disk1.vmdk",
+				fileSize:  1 << 30,
+				format:    importFormatVMDK,
+				capacity:  40 << 30,
+				bus:       builder.DiskBusScsi,
+				bootOrder: 1,
+			},
+		},
+		nics: []importNIC{
+			{name: "nic-0", model: "virtio", mac: "00:50:56:ab:cd:ef", network: "VM Network"},
+		},
+	}, plan)
+}
+
+func TestBuildImportPlanFromExport(t *testing.T) {
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "vm",
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Domain: kubevirtv1.DomainSpec{
+						CPU: &kubevirtv1.CPU{Cores: 4},
+						Resources: kubevirtv1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("8Gi"),
+							},
+						},
+						Devices: kubevirtv1.Devices{
+							Disks: []kubevirtv1.Disk{
+								{Name: "cdrom", DiskDevice: kubevirtv1.DiskDevice{CDRom: &kubevirtv1.CDRomTarget{Bus: "sata"}}},
+								{Name: "rootdisk", DiskDevice: kubevirtv1.DiskDevice{Disk: &kubevirtv1.DiskTarget{Bus: "virtio"}}},
+							},
+							Interfaces: []kubevirtv1.Interface{
+								{Name: "default", Model: "virtio"},
+							},
+						},
+					},
+					Networks: []kubevirtv1.Network{
+						{Name: "default", NetworkSource: kubevirtv1.NetworkSource{Multus: &kubevirtv1.MultusNetwork{NetworkName: "default/vlan1"}}},
+					},
+				},
+			},
+		},
+	}
+	disks := []ovaDisk{
+		{
+			volumeName: "cdrom",
+			fileName:   "cdrom.vmdk",
+			capacity:   1 << 30,
+			size:       100,
+		},
+		{
+			volumeName: "rootdisk",
+			fileName:   "rootdisk.vmdk",
+			capacity:   10 << 30,
+			size:       200,
+		},
+	}
+	out, err := buildOVF(vm, disks)
+	assert.Nil(t, err)
+
+	var ovf ovfDescriptor
+	assert.Nil(t, xml.Unmarshal(out, &ovf))
+	plan, err := buildImportPlan(&ovf)
+	assert.Nil(t, err)
+	assert.Equal(t, &importPlan{
+		name:   "vm",
+		cpu:    4,
+		memory: 8 << 30,
+		disks: []*importDisk{
+			{
+				name:      "disk-0",
+				fileName:  "rootdisk.vmdk",
+				fileSize:  200,
+				format:    importFormatVMDK,
+				capacity:  10 << 30,
+				bus:       builder.DiskBusScsi,
+				bootOrder: 1,
+			},
+			{
+				name:      "disk-1",
+				fileName:  "cdrom.vmdk",
+				fileSize:  100,
+				format:    importFormatVMDK,
+				capacity:  1 << 30,
+				cdrom:     true,
+				bus:       builder.DiskBusSata,
+				bootOrder: 2,
+			},
+		},
+		nics: []importNIC{
+			{name: "nic-0", model: "virtio", network: "default/vlan1"},
+		},
+	}, plan)
+}
+
+func TestParseAllocationUnits(t *testing.T) {
+	var testCases = []struct {
+		units    string
+		expected int64
+		err      bool
+	}{
+		{units: "", expected: 1 << 20},
+		{units: "byte", expected: 1},
+		{units: "byte * 2^20", expected: 1 << 20},
+		{units: "byte*2^30", expected: 1 << 30},
+		{units: "MegaBytes", expected: 1 << 20},
+		{units: "GigaBytes", expected: 1 << 30},
+		{units: "hertz * 10^6", err: true},
+	}
+	for _, tc := range testCases {
+		units, err := parseAllocationUnits(tc.units, 1<<20)
+		if tc.err {
+			assert.NotNil(t, err, tc.units)
+			continue
+		}
+		assert.Nil(t, err, tc.units)
+		assert.Equal(t, tc.expected, units, tc.units)
+	}
+}
+
+func TestImportVMChecksPermissions(t *testing.T) {
+	// a request without the API context of an authorized user is rejected before the package is read
+	req := httptest.NewRequest(http.MethodPost, "/v1/harvester/kubevirt.io.virtualmachines?action=import&namespace=default", strings.NewReader(""))
+	rw := httptest.NewRecorder()
+	importHandler{}.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+}
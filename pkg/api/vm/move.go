@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// moveVM renames a VM or moves it to another namespace. The VM is stopped here, then the VM move controller
+// rebinds the PVs of its volumes to PVCs in the target namespace, recreates the VM under the new name or namespace
+// with its cloud-init secrets, deletes the original VM and starts the new one if the original was running.
+func (h *vmActionHandler) moveVM(ctx context.Context, namespace, name string, input MoveInput) error {
+	targetNamespace, targetName := input.TargetNamespace, input.TargetName
+	if targetNamespace == "" {
+		targetNamespace = namespace
+	}
+	if targetName == "" {
+		targetName = name
+	}
+	if targetNamespace == namespace && targetName == name {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `targetNamespace` or `targetName` must be different from the VM")
+	}
+	if errs := k8svalidation.IsDNS1123Subdomain(targetName); len(errs) > 0 {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Invalid target name %s: %s", targetName, strings.Join(errs, "; ")))
+	}
+
+	// the move is done by the harvester service account, check that the user can create the VM in the target namespace
+	apiOp := types.GetAPIContext(ctx)
+	if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, vmGroupResource, "create", targetNamespace, "") != nil {
+		return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on virtual machines in namespace %s is not allowed", targetNamespace))
+	}
+
+	// the controller checks the permission of the user again before acting on the move
+	if apiOp.Request == nil {
+		return apierror.NewAPIError(validation.PermissionDenied, "failed to get user from request")
+	}
+	user, ok := request.UserFrom(apiOp.Request.Context())
+	if !ok {
+		return apierror.NewAPIError(validation.PermissionDenied, "failed to get user from request")
+	}
+
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	if err := h.movePreCheck(vm, targetNamespace, targetName); err != nil {
+		return err
+	}
+
+	runStrategy, err := vm.RunStrategy()
+	if err != nil {
+		return err
+	}
+	move, err := json.Marshal(util.VMMove{
+		TargetNamespace: targetNamespace,
+		TargetName:      targetName,
+		RunStrategy:     runStrategy,
+		User:            user.GetName(),
+		UID:             user.GetUID(),
+		Groups:          user.GetGroups(),
+	})
+	if err != nil {
+		return err
+	}
+
+	vmCopy := vm.DeepCopy()
+	if vmCopy.Annotations == nil {
+		vmCopy.Annotations = map[string]string{}
+	}
+	vmCopy.Annotations[util.AnnotationVMMove] = string(move)
+	if vmCopy.Spec.RunStrategy != nil {
+		halted := kubevirtv1.RunStrategyHalted
+		vmCopy.Spec.RunStrategy = &halted
+	} else {
+		vmCopy.Spec.Running = pointer.BoolPtr(false)
+	}
+	_, err = h.vms.Update(vmCopy)
+	return err
+}
+
+func (h *vmActionHandler) movePreCheck(vm *kubevirtv1.VirtualMachine, targetNamespace, targetName string) error {
+	if _, ok := vm.Annotations[util.AnnotationVMMove]; ok {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Move of vm %s/%s is in progress", vm.Namespace, vm.Name))
+	}
+	if _, ok := vm.Annotations[util.AnnotationVolumeMigration]; ok {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume migration of vm %s/%s is in progress", vm.Namespace, vm.Name))
+	}
+	if vm.Spec.Template == nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("vm %s/%s has no template", vm.Namespace, vm.Name))
+	}
+	if _, err := h.namespaceCache.Get(targetNamespace); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Failed to get target namespace %s: %v", targetNamespace, err))
+	}
+	if _, err := h.vmCache.Get(targetNamespace, targetName); err == nil {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("vm %s/%s already exists", targetNamespace, targetName))
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// the snapshots of a VM are removed with it, so they must be removed by the user before the move
+	vmBackups, err := h.backupCache.GetByIndex(indexeres.VMBackupBySourceVMUIDIndex, string(vm.UID))
+	if err != nil {
+		return err
+	}
+	for _, vmBackup := range vmBackups {
+		if vmBackup.Spec.Type == harvesterv1.Snapshot {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("vm %s/%s has snapshot %s, please remove its snapshots before moving it", vm.Namespace, vm.Name, vmBackup.Name))
+		}
+	}
+
+	return h.moveVolumesPreCheck(vm, targetNamespace)
+}
+
+// moveVolumesPreCheck checks that the PVCs and the cloud-init secrets of the VM can be moved. The PVCs are kept by a rename,
+// they can't be owned by the VM through owner references which are garbage collected with the original VM. For a move to another
+// namespace, the PVs of the PVCs are rebound to PVCs of the same names in the target namespace, so the PVCs can't be shared with other VMs.
+func (h *vmActionHandler) moveVolumesPreCheck(vm *kubevirtv1.VirtualMachine, targetNamespace string) error {
+	crossNamespace := targetNamespace != vm.Namespace
+	vmID := ref.Construct(vm.Namespace, vm.Name)
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		pvc, err := h.pvcCache.Get(vm.Namespace, claimName)
+		if err != nil {
+			return err
+		}
+		if len(pvc.OwnerReferences) > 0 {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume %s/%s is owned by %s %s", pvc.Namespace, pvc.Name, pvc.OwnerReferences[0].Kind, pvc.OwnerReferences[0].Name))
+		}
+		if !crossNamespace {
+			continue
+		}
+
+		if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.VolumeName == "" {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume %s/%s is not bound", pvc.Namespace, pvc.Name))
+		}
+		owners, err := ref.GetSchemaOwnersFromAnnotation(pvc)
+		if err != nil {
+			return err
+		}
+		for _, owner := range owners.List(kubevirtv1.VirtualMachineGroupVersionKind.GroupKind()) {
+			if owner != vmID {
+				return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume %s/%s is also used by vm %s", pvc.Namespace, pvc.Name, owner))
+			}
+		}
+		if _, err := h.pvcCache.Get(targetNamespace, claimName); err == nil {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Volume %s/%s already exists", targetNamespace, claimName))
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if !crossNamespace {
+		return nil
+	}
+
+	for _, secretName := range util.GetCloudInitSecretNames(vm) {
+		if _, err := h.secretCache.Get(targetNamespace, secretName); err == nil {
+			return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("Secret %s/%s already exists", targetNamespace, secretName))
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
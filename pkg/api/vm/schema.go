@@ -36,6 +36,15 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 	server.BaseSchemas.MustImportAndCustomize(AddVolumeInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(RemoveVolumeInput{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(CloneInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(MigrateVolumeInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(ResizeInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(MoveInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(RevertToSnapshotInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(ExportVMInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(GuestExecInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(GuestExecOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(BulkActionInput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(BulkActionOutput{}, nil)
 
 	vms := scaled.VirtFactory.Kubevirt().V1().VirtualMachine()
 	vmis := scaled.VirtFactory.Kubevirt().V1().VirtualMachineInstance()
@@ -50,6 +59,10 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 	vmtv := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion()
 	vmImages := scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage()
 	storageClasses := scaled.StorageFactory.Storage().V1().StorageClass()
+	namespaces := scaled.CoreFactory.Core().V1().Namespace()
+	snapshots := scaled.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshot()
+	volumes := scaled.LonghornFactory.Longhorn().V1beta1().Volume()
+	backingImages := scaled.LonghornFactory.Longhorn().V1beta1().BackingImage()
 
 	copyConfig := rest.CopyConfig(server.RESTConfig)
 	copyConfig.GroupVersion = &kubevirtSubResouceGroupVersion
@@ -86,11 +99,47 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 		vmImages:                  vmImages,
 		vmImageCache:              vmImages.Cache(),
 		storageClassCache:         storageClasses.Cache(),
+		namespaceCache:            namespaces.Cache(),
+		snapshots:                 snapshots,
+		volumes:                   volumes,
+		volumeCache:               volumes.Cache(),
+	}
+
+	exportHandler := exportHandler{
+		httpClient:        http.Client{},
+		vmCache:           vms.Cache(),
+		imageCache:        vmImages.Cache(),
+		backingImageCache: backingImages.Cache(),
+	}
+
+	importHandler := importHandler{
+		httpClient:              http.Client{},
+		vms:                     vms,
+		vmCache:                 vms.Cache(),
+		images:                  vmImages,
+		backingImageDataSources: scaled.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource(),
+		nadCache:                scaled.CniFactory.K8s().V1().NetworkAttachmentDefinition().Cache(),
+	}
+
+	bulkHandler := bulkActionHandler{
+		actionHandler: &actionHandler,
+	}
+	bulkAction := schemas.Action{
+		Input:  "bulkActionInput",
+		Output: "bulkActionOutput",
+	}
+
+	guestExecHandler := guestExecHandler{
+		vmiCache:   vmis.Cache(),
+		podCache:   scaled.CoreFactory.Core().V1().Pod().Cache(),
+		clientSet:  scaled.Management.ClientSet,
+		restConfig: server.RESTConfig,
 	}
 
 	vmformatter := vmformatter{
-		vmiCache:      vmis.Cache(),
-		vmBackupCache: backups.Cache(),
+		vmiCache:           vmis.Cache(),
+		vmBackupCache:      backups.Cache(),
+		powerScheduleCache: scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachinePowerSchedule().Cache(),
 	}
 
 	vmStore := &vmStore{
@@ -120,6 +169,19 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 				addVolume:      &actionHandler,
 				removeVolume:   &actionHandler,
 				cloneVM:        &actionHandler,
+				migrateVolume:  &actionHandler,
+				resizeVM:       &actionHandler,
+				exportVM:       &actionHandler,
+				moveVM:         &actionHandler,
+				revertVM:       &actionHandler,
+				importVM:       importHandler,
+				guestExec:      guestExecHandler,
+				bulkStart:      bulkHandler,
+				bulkStop:       bulkHandler,
+				bulkRestart:    bulkHandler,
+				bulkMigrate:    bulkHandler,
+				bulkBackup:     bulkHandler,
+				bulkAddLabel:   bulkHandler,
 			}
 			apiSchema.ResourceActions = map[string]schemas.Action{
 				startVM:    {},
@@ -153,6 +215,41 @@ func RegisterSchema(scaled *config.Scaled, server *server.Server, options config
 				cloneVM: {
 					Input: "cloneInput",
 				},
+				migrateVolume: {
+					Input: "migrateVolumeInput",
+				},
+				resizeVM: {
+					Input: "resizeInput",
+				},
+				exportVM: {
+					Input: "exportVMInput",
+				},
+				moveVM: {
+					Input: "moveInput",
+				},
+				revertVM: {
+					Input: "revertToSnapshotInput",
+				},
+				guestExec: {
+					Input:  "guestExecInput",
+					Output: "guestExecOutput",
+				},
+			}
+			apiSchema.CollectionActions = map[string]schemas.Action{
+				importVM:     {},
+				bulkStart:    bulkAction,
+				bulkStop:     bulkAction,
+				bulkRestart:  bulkAction,
+				bulkMigrate:  bulkAction,
+				bulkBackup:   bulkAction,
+				bulkAddLabel: bulkAction,
+			}
+			apiSchema.CollectionFormatter = collectionFormatter
+			apiSchema.LinkHandlers = map[string]http.Handler{
+				linkDownloadOVA:    exportHandler,
+				linkGuestOSInfo:    guestAgentHandler{subresource: linkGuestOSInfo, actionHandler: &actionHandler},
+				linkFilesystemList: guestAgentHandler{subresource: linkFilesystemList, actionHandler: &actionHandler},
+				linkUserList:       guestAgentHandler{subresource: linkUserList, actionHandler: &actionHandler},
 			}
 		},
 		Formatter: vmformatter.formatter,
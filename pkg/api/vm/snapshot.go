@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage/names"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+)
+
+// revertToSnapshot restores all volumes of a stopped VM from one of its snapshots in place. It's done by a
+// VirtualMachineRestore replacing the VM, the restore controller keeps the MAC addresses of the VM and
+// marks the snapshot as the current snapshot of the VM. The replaced PVCs are deleted by the restore controller,
+// the ones storing other snapshots of the VM are deleted after those snapshots.
+func (h *vmActionHandler) revertToSnapshot(namespace, name string, input RevertToSnapshotInput) error {
+	if input.SnapshotName == "" {
+		return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter `snapshotName` is required")
+	}
+
+	vm, err := h.vmCache.Get(namespace, name)
+	if err != nil {
+		return err
+	}
+	snapshot, err := h.backupCache.Get(namespace, input.SnapshotName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("snapshot %s/%s is not found", namespace, input.SnapshotName))
+		}
+		return err
+	}
+	if snapshot.Spec.Type != harvesterv1.Snapshot || snapshot.Status == nil || snapshot.Status.SourceUID == nil || *snapshot.Status.SourceUID != vm.UID {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("%s is not a snapshot of virtual machine %s/%s", input.SnapshotName, namespace, name))
+	}
+	if !backup.IsBackupReady(snapshot) {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("snapshot %s/%s is not ready", namespace, input.SnapshotName))
+	}
+
+	vmi, err := h.vmiCache.Get(namespace, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if vm.Status.Ready || (err == nil && vmi != nil) {
+		return apierror.NewAPIError(validation.Conflict, "Please stop the VM before reverting it to a snapshot")
+	}
+
+	apiGroup := kubevirtv1.SchemeGroupVersion.Group
+	restore := &harvesterv1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      names.SimpleNameGenerator.GenerateName(vm.Name + "-revert-"),
+			Namespace: namespace,
+		},
+		Spec: harvesterv1.VirtualMachineRestoreSpec{
+			Target: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+				Name:     vm.Name,
+			},
+			VirtualMachineBackupNamespace: snapshot.Namespace,
+			VirtualMachineBackupName:      snapshot.Name,
+			NewVM:                         false,
+			DeletionPolicy:                harvesterv1.VirtualMachineRestoreDelete,
+		},
+	}
+	if _, err := h.restores.Create(restore); err != nil {
+		return fmt.Errorf("failed to create restore, error: %s", err.Error())
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package vm
 
-import "github.com/rancher/wrangler/pkg/condition"
+import (
+	"github.com/rancher/wrangler/pkg/condition"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
 
 var (
 	vmReady   condition.Cond = "Ready"
@@ -12,12 +16,15 @@ type EjectCdRomActionInput struct {
 }
 
 type BackupInput struct {
-	Name string `json:"name"`
+	Name             string `json:"name"`
+	BackupTargetName string `json:"backupTargetName,omitempty"`
 }
 
 type RestoreInput struct {
-	Name       string `json:"name"`
-	BackupName string `json:"backupName"`
+	Name              string                        `json:"name"`
+	BackupName        string                        `json:"backupName"`
+	Volumes           []string                      `json:"volumes,omitempty"`
+	VolumeRestoreMode harvesterv1.VolumeRestoreMode `json:"volumeRestoreMode,omitempty"`
 }
 
 type MigrateInput struct {
@@ -39,6 +46,86 @@ type RemoveVolumeInput struct {
 	DiskName string `json:"diskName"`
 }
 
+type MigrateVolumeInput struct {
+	DiskName         string `json:"diskName"`
+	StorageClassName string `json:"storageClassName"`
+}
+
+type ResizeInput struct {
+	Sockets uint32 `json:"sockets,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+}
+
+type MoveInput struct {
+	// TargetNamespace is the namespace to move the VM to, it's the namespace of the VM if it's empty
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// TargetName is the new name of the VM, it's the name of the VM if it's empty
+	TargetName string `json:"targetName,omitempty"`
+}
+
+type RevertToSnapshotInput struct {
+	// SnapshotName is the name of a VirtualMachineBackup of the snapshot type of the VM
+	SnapshotName string `json:"snapshotName"`
+}
+
 type CloneInput struct {
 	TargetVM string `json:"targetVm"`
+	// TargetNamespace is the namespace of the clone, it's the namespace of the source VM if it's empty
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+	// Hostname is the hostname of the clone, it's the name of the clone if it's empty
+	Hostname string `json:"hostname,omitempty"`
+}
+
+type ExportVMInput struct {
+	// Format is the file format of the disks in the OVA package, only vmdk (streamOptimized) is supported
+	Format string `json:"format,omitempty"`
+}
+
+// VMExport is the last export of a VM, it's recorded in the annotation of the VM
+type VMExport struct {
+	Format string `json:"format"`
+	Time   string `json:"time"`
+	// Images maps the volume names to the names of the images exported from them
+	Images map[string]string `json:"images"`
+}
+
+type GuestExecInput struct {
+	// Command is the path of the program to run in the guest
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// TimeoutSeconds is the time to wait for the command to exit, default to 30 seconds
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+type GuestExecOutput struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	// Truncated is true if the output is truncated by the guest agent
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// BulkActionInput is the input of the bulk collection actions, the VMs are selected by either Selector or Names
+type BulkActionInput struct {
+	// Selector is a label selector of the VMs, e.g. "app=web,tier!=db"
+	Selector string   `json:"selector,omitempty"`
+	Names    []string `json:"names,omitempty"`
+	// Concurrency is the number of VMs processed at the same time, default to 5
+	Concurrency int `json:"concurrency,omitempty"`
+	// NodeName is the target node of bulkMigrate, the VMs are scheduled by KubeVirt if it's empty
+	NodeName string `json:"nodeName,omitempty"`
+	// BackupTargetName is the backup target of bulkBackup, the backups are named <vm>-<time>
+	BackupTargetName string `json:"backupTargetName,omitempty"`
+	// Labels are added to the VMs by bulkAddLabel
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type BulkActionOutput struct {
+	Results []BulkActionResult `json:"results"`
+}
+
+type BulkActionResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
 }
@@ -0,0 +1,91 @@
+package vmbackup
+
+import (
+	"sort"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	"github.com/sirupsen/logrus"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/indexeres"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	actionVerify = "verify"
+
+	fieldProgress       = "progress"
+	fieldParentSnapshot = "parentSnapshot"
+	fieldChildSnapshots = "childSnapshots"
+	fieldCurrent        = "current"
+)
+
+type vmBackupFormatter struct {
+	vmBackupCache ctlharvesterv1.VirtualMachineBackupCache
+	vmCache       ctlkubevirtv1.VirtualMachineCache
+}
+
+func (f *vmBackupFormatter) formatter(request *types.APIRequest, resource *types.RawResource) {
+	resource.Actions = make(map[string]string, 1)
+
+	vmBackup := &harvesterv1.VirtualMachineBackup{}
+	if err := convert.ToObj(resource.APIObject.Data(), vmBackup); err != nil {
+		return
+	}
+
+	// expose the overall progress at the top level, so that clients can show it without walking the volume backups,
+	// the processed bytes are estimated from the percentage until the transfer is complete
+	if vmBackup.Status != nil && vmBackup.Status.Progress != nil {
+		resource.APIObject.Data().Set(fieldProgress, map[string]interface{}{
+			"percentage":     vmBackup.Status.Progress.Percentage,
+			"processedBytes": vmBackup.Status.Progress.ProcessedBytes,
+			"totalBytes":     vmBackup.Status.Progress.TotalBytes,
+			"estimated":      vmBackup.Status.Progress.Estimated,
+		})
+	}
+
+	if vmBackup.Spec.Type == harvesterv1.Snapshot {
+		f.formatSnapshotTree(resource, vmBackup)
+	}
+
+	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
+		return
+	}
+
+	if vmBackup.Spec.Type == harvesterv1.Backup && backup.IsBackupReady(vmBackup) {
+		resource.AddAction(request, actionVerify)
+	}
+}
+
+// formatSnapshotTree exposes the parent and children of a snapshot and whether it's the current snapshot
+// of the VM, so that clients can draw the snapshot tree of the VM.
+func (f *vmBackupFormatter) formatSnapshotTree(resource *types.RawResource, snapshot *harvesterv1.VirtualMachineBackup) {
+	data := resource.APIObject.Data()
+	data.Set(fieldParentSnapshot, snapshot.Annotations[util.AnnotationParentSnapshot])
+
+	children, err := f.vmBackupCache.GetByIndex(indexeres.VMBackupByParentSnapshotIndex, ref.Construct(snapshot.Namespace, snapshot.Name))
+	if err != nil {
+		logrus.Errorf("Can't get the child snapshots of %s/%s, err: %+v", snapshot.Namespace, snapshot.Name, err)
+		return
+	}
+	data.Set(fieldChildSnapshots, getChildSnapshotNames(children))
+
+	vm, err := f.vmCache.Get(snapshot.Namespace, snapshot.Spec.Source.Name)
+	data.Set(fieldCurrent, err == nil && snapshot.Status != nil && snapshot.Status.SourceUID != nil &&
+		*snapshot.Status.SourceUID == vm.UID && vm.Annotations[util.AnnotationCurrentSnapshot] == snapshot.Name)
+}
+
+// getChildSnapshotNames returns the sorted names of the child snapshots
+func getChildSnapshotNames(children []*harvesterv1.VirtualMachineBackup) []string {
+	names := make([]string, 0, len(children))
+	for _, child := range children {
+		names = append(names, child.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,95 @@
+package vmbackup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmGroupResource        = "kubevirt.io/virtualmachines"
+	vmRestoreGroupResource = "harvesterhci.io/virtualmachinerestores"
+)
+
+type ActionHandler struct {
+	vmBackups      ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache  ctlharvesterv1.VirtualMachineBackupCache
+	namespaceCache ctlcorev1.NamespaceCache
+}
+
+func (h ActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if err := h.do(rw, req); err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ActionHandler) do(rw http.ResponseWriter, r *http.Request) error {
+	vars := util.EncodeVars(mux.Vars(r))
+	action := vars["action"]
+	vmBackupName := vars["name"]
+	vmBackupNamespace := vars["namespace"]
+
+	switch action {
+	case actionVerify:
+		var input VerifyInput
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: "+err.Error())
+			}
+		}
+		return h.verify(types.GetAPIContext(r.Context()), vmBackupNamespace, vmBackupName, input.SandboxNamespace)
+	default:
+		return apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
+	}
+}
+
+// verify requests the backup controller to verify the VM backup, the result is recorded in the Verified condition
+func (h *ActionHandler) verify(apiOp *types.APIRequest, vmBackupNamespace, vmBackupName, sandboxNamespace string) error {
+	vmBackup, err := h.vmBackupCache.Get(vmBackupNamespace, vmBackupName)
+	if err != nil {
+		return err
+	}
+	if vmBackup.Spec.Type != harvesterv1.Backup || !backup.IsBackupReady(vmBackup) {
+		return apierror.NewAPIError(validation.InvalidAction, "Only ready backups stored in a backup target can be verified")
+	}
+	if _, ok := vmBackup.Annotations[util.AnnotationBackupVerifyRequest]; ok {
+		return apierror.NewAPIError(validation.Conflict, fmt.Sprintf("VM backup %s/%s is being verified", vmBackupNamespace, vmBackupName))
+	}
+	if sandboxNamespace != "" {
+		if _, err := h.namespaceCache.Get(sandboxNamespace); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("Can't get sandbox namespace %s: %v", sandboxNamespace, err))
+		}
+		// the test restore is created by the harvester service account, check that the user can restore the VM in the sandbox namespace
+		for _, resource := range []string{vmRestoreGroupResource, vmGroupResource} {
+			if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, resource, "create", sandboxNamespace, "") != nil {
+				return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on %s in namespace %s is not allowed", resource, sandboxNamespace))
+			}
+		}
+	}
+
+	vmBackupCpy := vmBackup.DeepCopy()
+	if vmBackupCpy.Annotations == nil {
+		vmBackupCpy.Annotations = map[string]string{}
+	}
+	vmBackupCpy.Annotations[util.AnnotationBackupVerifyRequest] = sandboxNamespace
+	_, err = h.vmBackups.Update(vmBackupCpy)
+	return err
+}
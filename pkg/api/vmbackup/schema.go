@@ -0,0 +1,45 @@
+package vmbackup
+
+import (
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/pkg/schemas"
+
+	"github.com/harvester/harvester/pkg/config"
+)
+
+const (
+	vmBackupSchemaID = "harvesterhci.io.virtualmachinebackup"
+)
+
+func RegisterSchema(scaled *config.Scaled, server *server.Server, options config.Options) error {
+	server.BaseSchemas.MustImportAndCustomize(VerifyInput{}, nil)
+	actionHandler := ActionHandler{
+		vmBackups:      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup(),
+		vmBackupCache:  scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+		namespaceCache: scaled.CoreFactory.Core().V1().Namespace().Cache(),
+	}
+	vmBackupFormatter := vmBackupFormatter{
+		vmBackupCache: scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+		vmCache:       scaled.VirtFactory.Kubevirt().V1().VirtualMachine().Cache(),
+	}
+	t := schema.Template{
+		ID: vmBackupSchemaID,
+		Customize: func(s *types.APISchema) {
+			s.ResourceActions = map[string]schemas.Action{
+				actionVerify: {
+					Input: "verifyInput",
+				},
+			}
+			s.ActionHandlers = map[string]http.Handler{
+				actionVerify: &actionHandler,
+			}
+		},
+		Formatter: vmBackupFormatter.formatter,
+	}
+	server.SchemaFactory.AddTemplate(t)
+	return nil
+}
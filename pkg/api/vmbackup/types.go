@@ -0,0 +1,6 @@
+package vmbackup
+
+type VerifyInput struct {
+	// SandboxNamespace is the namespace to run a test restore in, the test restore is skipped if it's empty
+	SandboxNamespace string `json:"sandboxNamespace"`
+}
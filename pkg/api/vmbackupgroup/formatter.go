@@ -0,0 +1,29 @@
+package vmbackupgroup
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/data/convert"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+)
+
+const (
+	actionRestore = "restore"
+)
+
+func Formatter(request *types.APIRequest, resource *types.RawResource) {
+	resource.Actions = make(map[string]string, 1)
+	if request.AccessControl.CanUpdate(request, resource.APIObject, resource.Schema) != nil {
+		return
+	}
+
+	group := &harvesterv1.VirtualMachineBackupGroup{}
+	if err := convert.ToObj(resource.APIObject.Data(), group); err != nil {
+		return
+	}
+
+	if backup.IsBackupGroupReady(group) {
+		resource.AddAction(request, actionRestore)
+	}
+}
@@ -0,0 +1,138 @@
+package vmbackupgroup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmGroupResource        = "kubevirt.io/virtualmachines"
+	vmRestoreGroupResource = "harvesterhci.io/virtualmachinerestores"
+)
+
+type ActionHandler struct {
+	vmBackupGroupCache ctlharvesterv1.VirtualMachineBackupGroupCache
+	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
+	restores           ctlharvesterv1.VirtualMachineRestoreClient
+}
+
+func (h ActionHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if err := h.do(rw, req); err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(*apierror.APIError); ok {
+			status = e.Code.Status
+		}
+		rw.WriteHeader(status)
+		_, _ = rw.Write([]byte(err.Error()))
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ActionHandler) do(rw http.ResponseWriter, r *http.Request) error {
+	vars := util.EncodeVars(mux.Vars(r))
+	action := vars["action"]
+	groupName := vars["name"]
+	groupNamespace := vars["namespace"]
+
+	switch action {
+	case actionRestore:
+		var input RestoreGroupInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Failed to decode request body: "+err.Error())
+		}
+		if input.Name == "" {
+			return apierror.NewAPIError(validation.InvalidBodyContent, "Parameter name is required")
+		}
+		return h.restore(types.GetAPIContext(r.Context()), groupNamespace, groupName, input)
+	default:
+		return apierror.NewAPIError(validation.InvalidAction, "Unsupported action")
+	}
+}
+
+// restore creates a VirtualMachineRestore for each member backup of the group,
+// the restores are labeled with the group name so they can be listed together.
+func (h *ActionHandler) restore(apiOp *types.APIRequest, groupNamespace, groupName string, input RestoreGroupInput) error {
+	group, err := h.vmBackupGroupCache.Get(groupNamespace, groupName)
+	if err != nil {
+		return err
+	}
+	if !backup.IsBackupGroupReady(group) {
+		return apierror.NewAPIError(validation.InvalidAction, fmt.Sprintf("Backup group %s/%s is not ready", groupNamespace, groupName))
+	}
+
+	// check all the member backups before creating any restore
+	for _, member := range group.Status.Members {
+		vmBackup, err := h.vmBackupCache.Get(groupNamespace, member.VMBackupName)
+		if err != nil {
+			return fmt.Errorf("failed to get backup of VM %s, error: %w", member.VMName, err)
+		}
+		if !backup.IsBackupReady(vmBackup) {
+			return apierror.NewAPIError(validation.InvalidAction, fmt.Sprintf("Backup %s/%s is not ready", groupNamespace, vmBackup.Name))
+		}
+	}
+
+	// the restores are created by the harvester service account, check that the user can restore the VMs
+	if apiOp == nil || apiOp.AccessControl.CanDo(apiOp, vmRestoreGroupResource, "create", groupNamespace, "") != nil {
+		return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on %s in namespace %s is not allowed", vmRestoreGroupResource, groupNamespace))
+	}
+	if input.NewVM {
+		if apiOp.AccessControl.CanDo(apiOp, vmGroupResource, "create", groupNamespace, "") != nil {
+			return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("create on %s in namespace %s is not allowed", vmGroupResource, groupNamespace))
+		}
+	} else {
+		for _, member := range group.Status.Members {
+			if apiOp.AccessControl.CanDo(apiOp, vmGroupResource, "update", groupNamespace, member.VMName) != nil {
+				return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("update on virtual machine %s/%s is not allowed", groupNamespace, member.VMName))
+			}
+		}
+	}
+
+	apiGroup := kubevirtv1.SchemeGroupVersion.Group
+	for _, member := range group.Status.Members {
+		targetName := member.VMName
+		if input.NewVM {
+			targetName = name.SafeConcatName(input.Name, member.VMName)
+		}
+		restore := &harvesterv1.VirtualMachineRestore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name.SafeConcatName(input.Name, member.VMName),
+				Namespace: groupNamespace,
+				Labels: map[string]string{
+					util.LabelVMBackupGroup: groupName,
+				},
+			},
+			Spec: harvesterv1.VirtualMachineRestoreSpec{
+				Target: corev1.TypedLocalObjectReference{
+					APIGroup: &apiGroup,
+					Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+					Name:     targetName,
+				},
+				VirtualMachineBackupNamespace: groupNamespace,
+				VirtualMachineBackupName:      member.VMBackupName,
+				NewVM:                         input.NewVM,
+				DeletionPolicy:                input.DeletionPolicy,
+			},
+		}
+		if _, err := h.restores.Create(restore); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to restore VM %s, error: %w", member.VMName, err)
+		}
+	}
+	return nil
+}
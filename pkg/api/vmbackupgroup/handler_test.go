@@ -0,0 +1,123 @@
+package vmbackupgroup
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+// fakeAccessControl allows the verbs on the resources in allowed, the keys are "<verb> <resource> <namespace>/<name>"
+type fakeAccessControl struct {
+	types.AccessControl
+	allowed map[string]bool
+}
+
+func (a fakeAccessControl) CanDo(apiOp *types.APIRequest, resource, verb, namespace, name string) error {
+	if a.allowed[fmt.Sprintf("%s %s %s/%s", verb, resource, namespace, name)] {
+		return nil
+	}
+	return fmt.Errorf("%s on %s is not allowed", verb, resource)
+}
+
+func TestRestore(t *testing.T) {
+	const namespace = "default"
+	group := &harvesterv1.VirtualMachineBackupGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "group"},
+		Status: &harvesterv1.VirtualMachineBackupGroupStatus{
+			ReadyToUse: pointer.BoolPtr(true),
+			Members: []harvesterv1.BackupGroupMember{
+				{VMName: "vm1", VMBackupName: "group-vm1"},
+				{VMName: "vm2", VMBackupName: "group-vm2"},
+			},
+		},
+	}
+	newBackup := func(name string) *harvesterv1.VirtualMachineBackup {
+		return &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Status:     &harvesterv1.VirtualMachineBackupStatus{ReadyToUse: pointer.BoolPtr(true)},
+		}
+	}
+	canRestore := "create " + vmRestoreGroupResource + " default/"
+
+	var testCases = []struct {
+		name        string
+		input       RestoreGroupInput
+		allowed     []string
+		expectError bool
+	}{
+		{
+			name:        "no permission to create restores",
+			input:       RestoreGroupInput{Name: "restore"},
+			allowed:     []string{"update " + vmGroupResource + " default/vm1", "update " + vmGroupResource + " default/vm2"},
+			expectError: true,
+		},
+		{
+			name:        "no permission to update one of the VMs",
+			input:       RestoreGroupInput{Name: "restore"},
+			allowed:     []string{canRestore, "update " + vmGroupResource + " default/vm1"},
+			expectError: true,
+		},
+		{
+			name:        "no permission to create VMs",
+			input:       RestoreGroupInput{Name: "restore", NewVM: true},
+			allowed:     []string{canRestore, "update " + vmGroupResource + " default/vm1", "update " + vmGroupResource + " default/vm2"},
+			expectError: true,
+		},
+		{
+			name:    "replace the VMs",
+			input:   RestoreGroupInput{Name: "restore"},
+			allowed: []string{canRestore, "update " + vmGroupResource + " default/vm1", "update " + vmGroupResource + " default/vm2"},
+		},
+		{
+			name:    "restore new VMs",
+			input:   RestoreGroupInput{Name: "restore", NewVM: true},
+			allowed: []string{canRestore, "create " + vmGroupResource + " default/"},
+		},
+	}
+
+	for _, tc := range testCases {
+		clientSet := fake.NewSimpleClientset(group, newBackup("group-vm1"), newBackup("group-vm2"))
+		h := &ActionHandler{
+			vmBackupGroupCache: fakeclients.VMBackupGroupCache(clientSet.HarvesterhciV1beta1().VirtualMachineBackupGroups),
+			vmBackupCache:      fakeclients.VMBackupCache(clientSet.HarvesterhciV1beta1().VirtualMachineBackups),
+			restores:           fakeclients.VMRestoreClient(clientSet.HarvesterhciV1beta1().VirtualMachineRestores),
+		}
+		allowed := map[string]bool{}
+		for _, key := range tc.allowed {
+			allowed[key] = true
+		}
+		apiOp := &types.APIRequest{AccessControl: fakeAccessControl{allowed: allowed}}
+
+		err := h.restore(apiOp, namespace, "group", tc.input)
+		restores, listErr := clientSet.HarvesterhciV1beta1().VirtualMachineRestores(namespace).List(context.TODO(), metav1.ListOptions{})
+		assert.Nil(t, listErr, tc.name)
+		if tc.expectError {
+			assert.NotNil(t, err, tc.name)
+			assert.Empty(t, restores.Items, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Len(t, restores.Items, 2, tc.name)
+		for _, restore := range restores.Items {
+			assert.Equal(t, tc.input.NewVM, restore.Spec.NewVM, tc.name)
+		}
+	}
+
+	// the permissions can't be checked without the API context
+	clientSet := fake.NewSimpleClientset(group, newBackup("group-vm1"), newBackup("group-vm2"))
+	h := &ActionHandler{
+		vmBackupGroupCache: fakeclients.VMBackupGroupCache(clientSet.HarvesterhciV1beta1().VirtualMachineBackupGroups),
+		vmBackupCache:      fakeclients.VMBackupCache(clientSet.HarvesterhciV1beta1().VirtualMachineBackups),
+		restores:           fakeclients.VMRestoreClient(clientSet.HarvesterhciV1beta1().VirtualMachineRestores),
+	}
+	assert.NotNil(t, h.restore(nil, namespace, "group", RestoreGroupInput{Name: "restore"}))
+}
@@ -0,0 +1,41 @@
+package vmbackupgroup
+
+import (
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/pkg/schemas"
+
+	"github.com/harvester/harvester/pkg/config"
+)
+
+const (
+	vmBackupGroupSchemaID = "harvesterhci.io.virtualmachinebackupgroup"
+)
+
+func RegisterSchema(scaled *config.Scaled, server *server.Server, options config.Options) error {
+	server.BaseSchemas.MustImportAndCustomize(RestoreGroupInput{}, nil)
+	actionHandler := ActionHandler{
+		vmBackupGroupCache: scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupGroup().Cache(),
+		vmBackupCache:      scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+		restores:           scaled.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore(),
+	}
+	t := schema.Template{
+		ID: vmBackupGroupSchemaID,
+		Customize: func(s *types.APISchema) {
+			s.ResourceActions = map[string]schemas.Action{
+				actionRestore: {
+					Input: "restoreGroupInput",
+				},
+			}
+			s.ActionHandlers = map[string]http.Handler{
+				actionRestore: &actionHandler,
+			}
+		},
+		Formatter: Formatter,
+	}
+	server.SchemaFactory.AddTemplate(t)
+	return nil
+}
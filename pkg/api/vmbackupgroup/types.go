@@ -0,0 +1,13 @@
+package vmbackupgroup
+
+import (
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+type RestoreGroupInput struct {
+	// Name prefixes the names of the VirtualMachineRestores, and of the new VMs if NewVM is true
+	Name string `json:"name"`
+	// NewVM restores the VMs as new VMs instead of replacing the existing ones
+	NewVM          bool                       `json:"newVM,omitempty"`
+	DeletionPolicy harvesterv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
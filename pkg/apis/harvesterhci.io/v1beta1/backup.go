@@ -17,6 +17,16 @@ const (
 
 	// ConditionProgressing is the "progressing" condition type
 	BackupConditionProgressing condition.Cond = "InProgress"
+
+	// BackupConditionGuestFrozen is true while the guest filesystems of the source VM are frozen
+	BackupConditionGuestFrozen condition.Cond = "GuestFrozen"
+
+	// BackupConditionVerified reports the result of the last verification of the backup
+	BackupConditionVerified condition.Cond = "Verified"
+
+	// BackupConditionLonghornBackupsCopied is true after the Longhorn backups of the volumes are copied between
+	// the default backup target and the named backup target of the backup
+	BackupConditionLonghornBackupsCopied condition.Cond = "LonghornBackupsCopied"
 )
 
 // DeletionPolicy defines that to do with resources when VirtualMachineRestore is deleted
@@ -38,6 +48,30 @@ const (
 	Snapshot BackupType = "snapshot"
 )
 
+// VolumeRestoreMode defines how the restored volumes are used
+type VolumeRestoreMode string
+
+const (
+	// VolumeRestoreModeVM is the default and restores the volumes with the VM, either as a new VM or replacing the existing one
+	VolumeRestoreModeVM VolumeRestoreMode = "vm"
+
+	// VolumeRestoreModePVC only restores the volumes as standalone PVCs next to the target VM
+	VolumeRestoreModePVC VolumeRestoreMode = "pvc"
+
+	// VolumeRestoreModeHotplug restores the volumes as PVCs and hot-plugs them into the target VM
+	VolumeRestoreModeHotplug VolumeRestoreMode = "hotplug"
+)
+
+type BackupConsistency string
+
+const (
+	// BackupConsistencyApplication means the guest filesystems were quiesced when the volume snapshots were taken
+	BackupConsistencyApplication BackupConsistency = "application"
+
+	// BackupConsistencyCrash means the volume snapshots were taken without quiescing the guest filesystems
+	BackupConsistencyCrash BackupConsistency = "crash"
+)
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=vmbackup;vmbackups,scope=Namespaced
@@ -45,6 +79,7 @@ const (
 // +kubebuilder:printcolumn:name="SOURCE_NAME",type=string,JSONPath=`.spec.source.name`
 // +kubebuilder:printcolumn:name="TYPE",type=string,JSONPath=`.spec.type`
 // +kubebuilder:printcolumn:name="READY_TO_USE",type=boolean,JSONPath=`.status.readyToUse`
+// +kubebuilder:printcolumn:name="CONSISTENCY",type=string,JSONPath=`.status.consistency`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
 // +kubebuilder:printcolumn:name="ERROR",type=date,JSONPath=`.status.error.message`
 
@@ -65,6 +100,12 @@ type VirtualMachineBackupSpec struct {
 	// +kubebuilder:validation:Enum=backup;snapshot
 	// +kubebuilder:validation:Optional
 	Type BackupType `json:"type,omitempty" default:"backup"`
+
+	// BackupTargetName is the name of the NamedBackupTarget the backup is stored in,
+	// the default backup target is used if it's empty.
+	// Longhorn backs up the volumes to the default backup target, then they are copied to the named backup target.
+	// +optional
+	BackupTargetName string `json:"backupTargetName,omitempty"`
 }
 
 // VirtualMachineBackupStatus is the status for a VirtualMachineBackup resource
@@ -75,6 +116,10 @@ type VirtualMachineBackupStatus struct {
 	// +optional
 	CreationTime *metav1.Time `json:"creationTime,omitempty"`
 
+	// SourceCluster is the UID of the kube-system namespace of the cluster where the backup was created
+	// +optional
+	SourceCluster string `json:"sourceCluster,omitempty"`
+
 	// +optional
 	BackupTarget *BackupTarget `json:"backupTarget,omitempty"`
 
@@ -94,6 +139,18 @@ type VirtualMachineBackupStatus struct {
 	// +optional
 	ReadyToUse *bool `json:"readyToUse,omitempty"`
 
+	// Consistency records whether the guest filesystems were quiesced when the volume snapshots were taken
+	// +optional
+	Consistency BackupConsistency `json:"consistency,omitempty"`
+
+	// ConsistencyMessage explains why the backup fell back to crash consistent
+	// +optional
+	ConsistencyMessage string `json:"consistencyMessage,omitempty"`
+
+	// Progress is the overall progress of the volume backups
+	// +optional
+	Progress *Progress `json:"progress,omitempty"`
+
 	// +optional
 	Error *Error `json:"error,omitempty"`
 
@@ -103,6 +160,9 @@ type VirtualMachineBackupStatus struct {
 
 // BackupTarget is where VM Backup stores
 type BackupTarget struct {
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	Endpoint     string `json:"endpoint,omitempty"`
 	BucketName   string `json:"bucketName,omitempty"`
 	BucketRegion string `json:"bucketRegion,omitempty"`
@@ -117,6 +177,24 @@ type Error struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// Progress is how far the data of a backup or restore is transferred
+type Progress struct {
+	// Percentage is the completed percentage, from 0 to 100
+	Percentage int `json:"percentage"`
+
+	// ProcessedBytes is the number of bytes transferred, it's estimated from the percentage and TotalBytes
+	// until the transfer is complete
+	ProcessedBytes int64 `json:"processedBytes"`
+
+	// TotalBytes is the size of the backup data reported by Longhorn, it's 0 until Longhorn reports it
+	TotalBytes int64 `json:"totalBytes"`
+
+	// Estimated is true if ProcessedBytes is estimated from the percentage and TotalBytes,
+	// Longhorn only reports the percentage while the data is transferred
+	// +optional
+	Estimated bool `json:"estimated,omitempty"`
+}
+
 // VolumeBackup contains the volume data need to restore a PVC
 type VolumeBackup struct {
 	// +optional
@@ -141,6 +219,9 @@ type VolumeBackup struct {
 	// +optional
 	ReadyToUse *bool `json:"readyToUse,omitempty"`
 
+	// +optional
+	Progress *Progress `json:"progress,omitempty"`
+
 	// +optional
 	Error *Error `json:"error,omitempty"`
 }
@@ -185,7 +266,8 @@ type VirtualMachineRestore struct {
 
 // VirtualMachineRestoreSpec is the spec for a VirtualMachineRestore resource
 type VirtualMachineRestoreSpec struct {
-	// initially only VirtualMachine type supported
+	// initially only VirtualMachine type supported,
+	// the target VM is restored in the namespace of the VirtualMachineRestore
 	Target corev1.TypedLocalObjectReference `json:"target"`
 
 	// +kubebuilder:validation:Required
@@ -199,6 +281,25 @@ type VirtualMachineRestoreSpec struct {
 
 	// +optional
 	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Volumes are the names of the backup volumes to restore, all the volumes are restored if it's empty.
+	// Only the pvc and hotplug volume restore modes support restoring part of the volumes.
+	// +optional
+	Volumes []string `json:"volumes,omitempty"`
+
+	// +kubebuilder:default:="vm"
+	// +kubebuilder:validation:Enum=vm;pvc;hotplug
+	// +kubebuilder:validation:Optional
+	VolumeRestoreMode VolumeRestoreMode `json:"volumeRestoreMode,omitempty" default:"vm"`
+
+	// StorageClassMappings maps the StorageClass names of the backup volumes to the ones of the restored PVCs
+	// +optional
+	StorageClassMappings map[string]string `json:"storageClassMappings,omitempty"`
+
+	// NetworkMappings maps the NetworkAttachmentDefinitions of the backup VM to the ones of the restored VM,
+	// the keys and values are in the "<namespace>/<name>" format used by the VM networks
+	// +optional
+	NetworkMappings map[string]string `json:"networkMappings,omitempty"`
 }
 
 // VirtualMachineRestoreStatus is the spec for a VirtualMachineRestore resource
@@ -215,6 +316,10 @@ type VirtualMachineRestoreStatus struct {
 	// +optional
 	Complete *bool `json:"complete,omitempty"`
 
+	// Progress is the overall progress of the volume restores
+	// +optional
+	Progress *Progress `json:"progress,omitempty"`
+
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
 
@@ -228,4 +333,7 @@ type VolumeRestore struct {
 	PersistentVolumeClaim PersistentVolumeClaimSourceSpec `json:"persistentVolumeClaimSpec,omitempty"`
 
 	VolumeBackupName string `json:"volumeBackupName,omitempty"`
+
+	// +optional
+	Progress *Progress `json:"progress,omitempty"`
 }
@@ -0,0 +1,76 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vmbackupgroup;vmbackupgroups,scope=Namespaced
+// +kubebuilder:printcolumn:name="TYPE",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="READY_TO_USE",type=boolean,JSONPath=`.status.readyToUse`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:printcolumn:name="ERROR",type=date,JSONPath=`.status.error.message`
+
+// VirtualMachineBackupGroup backs up a group of VMs at the same instant. The guest filesystems of all the VMs
+// are frozen before any volume snapshot is taken, and thawed after the volume snapshots of all the VMs are taken.
+// A VirtualMachineBackup owned by the group is created for each VM.
+type VirtualMachineBackupGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineBackupGroupSpec `json:"spec"`
+
+	// +optional
+	Status *VirtualMachineBackupGroupStatus `json:"status,omitempty"`
+}
+
+type VirtualMachineBackupGroupSpec struct {
+	// Selector selects the VMs in the same namespace to be backed up
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// VMNames are the names of the VMs in the same namespace to be backed up
+	// +optional
+	VMNames []string `json:"vmNames,omitempty"`
+
+	// +kubebuilder:default:="backup"
+	// +kubebuilder:validation:Enum=backup;snapshot
+	// +kubebuilder:validation:Optional
+	Type BackupType `json:"type,omitempty" default:"backup"`
+
+	// BackupTargetName is the name of the NamedBackupTarget the backups are stored in,
+	// the default backup target is used if it's empty.
+	// Longhorn backs up the volumes to the default backup target, then they are copied to the named backup target.
+	// +optional
+	BackupTargetName string `json:"backupTargetName,omitempty"`
+}
+
+type VirtualMachineBackupGroupStatus struct {
+	// Members are the VMs of the group and their backups, they are resolved once when the group is created
+	// +optional
+	Members []BackupGroupMember `json:"members,omitempty"`
+
+	// +optional
+	ReadyToUse *bool `json:"readyToUse,omitempty"`
+
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// Progress is the overall progress of the member backups
+	// +optional
+	Progress *Progress `json:"progress,omitempty"`
+
+	// +optional
+	Error *Error `json:"error,omitempty"`
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// BackupGroupMember links a VM of the group to its VirtualMachineBackup
+type BackupGroupMember struct {
+	VMName string `json:"vmName"`
+
+	VMBackupName string `json:"vmBackupName"`
+}
@@ -0,0 +1,71 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vmbackupschedule;vmbackupschedules,scope=Namespaced
+// +kubebuilder:printcolumn:name="CRON",type=string,JSONPath=`.spec.cron`
+// +kubebuilder:printcolumn:name="TYPE",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="RETAIN",type=integer,JSONPath=`.spec.retain`
+// +kubebuilder:printcolumn:name="SUSPEND",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="LAST_SCHEDULE",type=date,JSONPath=`.status.lastScheduleTime`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VirtualMachineBackupSchedule periodically creates VirtualMachineBackups for the VMs matching its selector
+// and prunes the backups exceeding the retention policy.
+type VirtualMachineBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineBackupScheduleSpec `json:"spec"`
+
+	// +optional
+	Status *VirtualMachineBackupScheduleStatus `json:"status,omitempty"`
+}
+
+type VirtualMachineBackupScheduleSpec struct {
+	// Cron is a standard five fields cron expression, e.g. "0 2 * * *"
+	// +kubebuilder:validation:Required
+	Cron string `json:"cron"`
+
+	// Selector selects the VMs in the same namespace to be backed up
+	// +kubebuilder:validation:Required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// +kubebuilder:default:="backup"
+	// +kubebuilder:validation:Enum=backup;snapshot
+	// +kubebuilder:validation:Optional
+	Type BackupType `json:"type,omitempty" default:"backup"`
+
+	// BackupTargetName is the name of the NamedBackupTarget the backups are stored in,
+	// the default backup target is used if it's empty.
+	// Longhorn backs up the volumes to the default backup target, then they are copied to the named backup target.
+	// +optional
+	BackupTargetName string `json:"backupTargetName,omitempty"`
+
+	// Retain is the number of backups kept for each VM, 0 means no limit
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Retain int `json:"retain,omitempty"`
+
+	// MaxAge is the maximum age of the kept backups, empty means no limit
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+type VirtualMachineBackupScheduleStatus struct {
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
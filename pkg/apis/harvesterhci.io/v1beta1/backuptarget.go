@@ -0,0 +1,80 @@
+package v1beta1
+
+import (
+	"github.com/rancher/wrangler/pkg/condition"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultBackupTargetName is the name reserved for the backup target of the backup-target setting,
+	// it's the backup target used by Longhorn to store the volume backups.
+	DefaultBackupTargetName = "default"
+)
+
+var (
+	BackupTargetAvailable condition.Cond = "Available"
+)
+
+type BackupTargetType string
+
+const (
+	BackupTargetTypeS3  BackupTargetType = "s3"
+	BackupTargetTypeNFS BackupTargetType = "nfs"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=nbt;nbts,scope=Cluster
+// +kubebuilder:printcolumn:name="TYPE",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="ENDPOINT",type=string,JSONPath=`.spec.endpoint`
+// +kubebuilder:printcolumn:name="BUCKET_NAME",type=string,JSONPath=`.spec.bucketName`
+// +kubebuilder:printcolumn:name="AVAILABLE",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamedBackupTarget is a named NFS or S3 server where VM backups are stored.
+// Longhorn only backs up volumes to the backup target of the backup-target setting, which is referred to as "default",
+// so the Longhorn backups are copied from the default backup target to the NamedBackupTargets.
+type NamedBackupTarget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NamedBackupTargetSpec `json:"spec"`
+
+	// +optional
+	Status NamedBackupTargetStatus `json:"status,omitempty"`
+}
+
+type NamedBackupTargetSpec struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=s3;nfs
+	Type BackupTargetType `json:"type"`
+
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// +optional
+	BucketName string `json:"bucketName,omitempty"`
+
+	// +optional
+	BucketRegion string `json:"bucketRegion,omitempty"`
+
+	// CredentialSecret refers to the secret containing the S3 credentials,
+	// the keys are the same as the Longhorn backup target credential secret, e.g. AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	// +optional
+	CredentialSecret *corev1.SecretReference `json:"credentialSecret,omitempty"`
+
+	// +optional
+	VirtualHostedStyle bool `json:"virtualHostedStyle,omitempty"`
+
+	// EncryptionSecret refers to the secret containing the CRYPTO_KEY_VALUE key,
+	// the VM backup metadata and secrets are encrypted with it before being written to the backup target
+	// +optional
+	EncryptionSecret *corev1.SecretReference `json:"encryptionSecret,omitempty"`
+}
+
+type NamedBackupTargetStatus struct {
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
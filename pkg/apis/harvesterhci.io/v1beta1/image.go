@@ -10,12 +10,31 @@ var (
 	ImageInitialized        condition.Cond = "Initialized"
 	ImageImported           condition.Cond = "Imported"
 	ImageRetryLimitExceeded condition.Cond = "RetryLimitExceeded"
+	ImageVerified           condition.Cond = "Verified"
+	ImagePublished          condition.Cond = "Published"
 )
 
 const (
 	VirtualMachineImageSourceTypeDownload     = "download"
 	VirtualMachineImageSourceTypeUpload       = "upload"
 	VirtualMachineImageSourceTypeExportVolume = "export-from-volume"
+	VirtualMachineImageSourceTypeRegistry     = "registry"
+	VirtualMachineImageSourceTypeBackupTarget = "backup-target"
+
+	VirtualMachineImageChecksumAlgorithmSHA256 = "sha256"
+	VirtualMachineImageChecksumAlgorithmSHA512 = "sha512"
+
+	VirtualMachineImageSignatureTypeGPG    = "gpg"
+	VirtualMachineImageSignatureTypeCosign = "cosign"
+
+	VirtualMachineImageFormatRaw   = "raw"
+	VirtualMachineImageFormatISO   = "iso"
+	VirtualMachineImageFormatQCOW2 = "qcow2"
+	VirtualMachineImageFormatVMDK  = "vmdk"
+	VirtualMachineImageFormatVHDX  = "vhdx"
+	VirtualMachineImageFormatVHD   = "vhd"
+	// VirtualMachineImageFormatUnknown is the format of the images which format can't be detected before the import
+	VirtualMachineImageFormatUnknown = "unknown"
 )
 
 // +genclient
@@ -41,7 +60,7 @@ type VirtualMachineImageSpec struct {
 	DisplayName string `json:"displayName"`
 
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=download;upload;export-from-volume
+	// +kubebuilder:validation:Enum=download;upload;export-from-volume;registry;backup-target
 	SourceType string `json:"sourceType"`
 
 	// +optional
@@ -50,15 +69,45 @@ type VirtualMachineImageSpec struct {
 	// +optional
 	PVCNamespace string `json:"pvcNamespace"`
 
+	// URL is the URL to download the image from for the download source type,
+	// or the reference of a KubeVirt containerdisk image for the registry source type.
 	// +optional
 	URL string `json:"url"`
 
+	// RegistrySecretName is the name of a kubernetes.io/dockerconfigjson secret in the namespace of the image
+	// with the credentials to pull the image for the registry source type, the image is pulled anonymously if it's empty.
+	// +optional
+	RegistrySecretName string `json:"registrySecretName,omitempty"`
+
 	// +optional
 	Checksum string `json:"checksum"`
 
+	// ChecksumAlgorithm is the algorithm of the checksum, it's sha512 if it's empty.
+	// SHA-512 checksums of the images imported as-is are verified by Longhorn while importing them except for
+	// the upload images, the other checksums are verified by harvester and reported by the Verified condition.
+	// +optional
+	// +kubebuilder:validation:Enum=sha256;sha512
+	ChecksumAlgorithm string `json:"checksumAlgorithm,omitempty"`
+
+	// Signature is a detached signature of the image, it's verified after the image is imported
+	// and reported by the Verified condition.
+	// +optional
+	Signature *VirtualMachineImageSignature `json:"signature,omitempty"`
+
 	// +optional
 	StorageClassParameters map[string]string `json:"storageClassParameters"`
 
+	// BackupTargetName is the backup target the image is replicated from for the backup-target source type,
+	// it's "default" for the backup target of the backup-target setting.
+	// +optional
+	BackupTargetName string `json:"backupTargetName,omitempty"`
+
+	// PublishTo is the backup target the image is published to once it's imported, the images published to
+	// the backup targets in the image-subscription setting of other clusters are replicated to them.
+	// It's "default" for the backup target of the backup-target setting.
+	// +optional
+	PublishTo string `json:"publishTo,omitempty"`
+
 	// +optional
 	// +kubebuilder:default:=3
 	// +kubebuilder:validation:Minimum:=0
@@ -67,6 +116,29 @@ type VirtualMachineImageSpec struct {
 	Retry int `json:"retry" default:"3"`
 }
 
+type VirtualMachineImageSignature struct {
+	// Type is gpg for OpenPGP detached signatures, or cosign for signatures created by `cosign sign-blob`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=gpg;cosign
+	Type string `json:"type"`
+
+	// URL to download the signature from, the signature is an armored or binary OpenPGP signature for gpg,
+	// or a base64 encoded signature for cosign.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Signature is used if URL is empty, it's an armored or base64 encoded OpenPGP signature for gpg,
+	// or a base64 encoded signature for cosign.
+	// +optional
+	Signature string `json:"signature,omitempty"`
+
+	// PublicKeySecretName is the name of a secret in the namespace of the image,
+	// every value of the secret is a public key the signature can be made by.
+	// The keys are armored OpenPGP public keys for gpg, or PEM encoded ECDSA or RSA public keys for cosign.
+	// +kubebuilder:validation:Required
+	PublicKeySecretName string `json:"publicKeySecretName"`
+}
+
 type VirtualMachineImageStatus struct {
 	// +optional
 	AppliedURL string `json:"appliedUrl,omitempty"`
@@ -91,6 +163,36 @@ type VirtualMachineImageStatus struct {
 
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
+
+	// UploadSession is the resumable upload session of an upload image
+	// +optional
+	UploadSession *VirtualMachineImageUploadSession `json:"uploadSession,omitempty"`
+
+	// Format is the detected format of the image file. The images of the vmdk, vhdx and vhd formats
+	// are converted to raw on import, the other formats, including unknown, are imported as-is.
+	// +optional
+	// +kubebuilder:validation:Enum=raw;iso;qcow2;vmdk;vhdx;vhd;unknown
+	Format string `json:"format,omitempty"`
+
+	// PublishedTo is the backup target the image is published to, the published image is removed from it
+	// when spec.publishTo is changed.
+	// +optional
+	PublishedTo string `json:"publishedTo,omitempty"`
+}
+
+type VirtualMachineImageUploadSession struct {
+	// ID of the upload session
+	ID string `json:"id"`
+
+	// Size of the file to upload in bytes
+	Size int64 `json:"size"`
+
+	// Offset is the number of bytes received, the upload is resumed from it
+	Offset int64 `json:"offset"`
+
+	// Server is the address of the harvester server streaming the upload to the backing image,
+	// the chunks of the session are only accepted by this server.
+	Server string `json:"server"`
 }
 
 type Condition struct {
@@ -0,0 +1,66 @@
+package v1beta1
+
+import (
+	"github.com/rancher/wrangler/pkg/condition"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	PowerScheduleReady condition.Cond = "Ready"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vmpowerschedule;vmpowerschedules,scope=Namespaced
+// +kubebuilder:printcolumn:name="START",type=string,JSONPath=`.spec.startCron`
+// +kubebuilder:printcolumn:name="STOP",type=string,JSONPath=`.spec.stopCron`
+// +kubebuilder:printcolumn:name="TIMEZONE",type=string,JSONPath=`.spec.timeZone`
+// +kubebuilder:printcolumn:name="SUSPEND",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VirtualMachinePowerSchedule starts and stops the VMs matching its selector periodically.
+type VirtualMachinePowerSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VirtualMachinePowerScheduleSpec   `json:"spec"`
+	Status VirtualMachinePowerScheduleStatus `json:"status,omitempty"`
+}
+
+type VirtualMachinePowerScheduleSpec struct {
+	// StartCron is a standard five fields cron expression to start the VMs, e.g. "0 8 * * 1-5"
+	// +optional
+	StartCron string `json:"startCron,omitempty"`
+
+	// StopCron is a standard five fields cron expression to stop the VMs, e.g. "0 20 * * 1-5"
+	// +optional
+	StopCron string `json:"stopCron,omitempty"`
+
+	// TimeZone is the IANA time zone of the cron expressions, e.g. "Europe/Berlin", default to UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Selector selects the VMs in the same namespace to be started and stopped
+	// +kubebuilder:validation:Required
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+type VirtualMachinePowerScheduleStatus struct {
+	// +optional
+	LastStartTime *metav1.Time `json:"lastStartTime,omitempty"`
+
+	// +optional
+	LastStopTime *metav1.Time `json:"lastStopTime,omitempty"`
+
+	// +optional
+	NextStartTime *metav1.Time `json:"nextStartTime,omitempty"`
+
+	// +optional
+	NextStopTime *metav1.Time `json:"nextStopTime,omitempty"`
+
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
@@ -162,6 +162,74 @@ func NewVirtualMachineRestore(namespace, name string, obj VirtualMachineRestore)
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// VirtualMachineBackupScheduleList is a list of VirtualMachineBackupSchedule resources
+type VirtualMachineBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualMachineBackupSchedule `json:"items"`
+}
+
+func NewVirtualMachineBackupSchedule(namespace, name string, obj VirtualMachineBackupSchedule) *VirtualMachineBackupSchedule {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualMachineBackupSchedule").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VirtualMachineBackupGroupList is a list of VirtualMachineBackupGroup resources
+type VirtualMachineBackupGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualMachineBackupGroup `json:"items"`
+}
+
+func NewVirtualMachineBackupGroup(namespace, name string, obj VirtualMachineBackupGroup) *VirtualMachineBackupGroup {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualMachineBackupGroup").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VirtualMachinePowerScheduleList is a list of VirtualMachinePowerSchedule resources
+type VirtualMachinePowerScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualMachinePowerSchedule `json:"items"`
+}
+
+func NewVirtualMachinePowerSchedule(namespace, name string, obj VirtualMachinePowerSchedule) *VirtualMachinePowerSchedule {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualMachinePowerSchedule").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamedBackupTargetList is a list of NamedBackupTarget resources
+type NamedBackupTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NamedBackupTarget `json:"items"`
+}
+
+func NewNamedBackupTarget(namespace, name string, obj NamedBackupTarget) *NamedBackupTarget {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NamedBackupTarget").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // VirtualMachineImageList is a list of VirtualMachineImage resources
 type VirtualMachineImageList struct {
 	metav1.TypeMeta `json:",inline"`
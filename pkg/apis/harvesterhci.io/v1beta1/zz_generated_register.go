@@ -30,6 +30,7 @@ import (
 var (
 	AddonResourceName                         = "addons"
 	KeyPairResourceName                       = "keypairs"
+	NamedBackupTargetResourceName             = "namedbackuptargets"
 	PreferenceResourceName                    = "preferences"
 	SettingResourceName                       = "settings"
 	SupportBundleResourceName                 = "supportbundles"
@@ -37,7 +38,10 @@ var (
 	UpgradeLogResourceName                    = "upgradelogs"
 	VersionResourceName                       = "versions"
 	VirtualMachineBackupResourceName          = "virtualmachinebackups"
+	VirtualMachineBackupGroupResourceName     = "virtualmachinebackupgroups"
+	VirtualMachineBackupScheduleResourceName  = "virtualmachinebackupschedules"
 	VirtualMachineImageResourceName           = "virtualmachineimages"
+	VirtualMachinePowerScheduleResourceName   = "virtualmachinepowerschedules"
 	VirtualMachineRestoreResourceName         = "virtualmachinerestores"
 	VirtualMachineTemplateResourceName        = "virtualmachinetemplates"
 	VirtualMachineTemplateVersionResourceName = "virtualmachinetemplateversions"
@@ -68,6 +72,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&AddonList{},
 		&KeyPair{},
 		&KeyPairList{},
+		&NamedBackupTarget{},
+		&NamedBackupTargetList{},
 		&Preference{},
 		&PreferenceList{},
 		&Setting{},
@@ -82,8 +88,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&VersionList{},
 		&VirtualMachineBackup{},
 		&VirtualMachineBackupList{},
+		&VirtualMachineBackupGroup{},
+		&VirtualMachineBackupGroupList{},
+		&VirtualMachineBackupSchedule{},
+		&VirtualMachineBackupScheduleList{},
 		&VirtualMachineImage{},
 		&VirtualMachineImageList{},
+		&VirtualMachinePowerSchedule{},
+		&VirtualMachinePowerScheduleList{},
 		&VirtualMachineRestore{},
 		&VirtualMachineRestoreList{},
 		&VirtualMachineTemplate{},
@@ -41,6 +41,10 @@ func main() {
 					harvesterv1.Version{},
 					harvesterv1.VirtualMachineBackup{},
 					harvesterv1.VirtualMachineRestore{},
+					harvesterv1.VirtualMachineBackupSchedule{},
+					harvesterv1.VirtualMachineBackupGroup{},
+					harvesterv1.VirtualMachinePowerSchedule{},
+					harvesterv1.NamedBackupTarget{},
 					harvesterv1.VirtualMachineImage{},
 					harvesterv1.VirtualMachineTemplate{},
 					harvesterv1.VirtualMachineTemplateVersion{},
@@ -99,6 +103,7 @@ func main() {
 					longhornv1.Setting{},
 					longhornv1.Backup{},
 					longhornv1.Replica{},
+					longhornv1.Engine{},
 				},
 				GenerateClients: true,
 			},
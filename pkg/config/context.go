@@ -51,6 +51,9 @@ type Options struct {
 	RancherEmbedded bool
 	RancherURL      string
 	HCIMode         bool
+
+	ImageConversionDir       string
+	ImageConversionSizeLimit string
 }
 
 type Scaled struct {
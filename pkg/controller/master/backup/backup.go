@@ -10,7 +10,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"reflect"
 	"time"
@@ -29,12 +28,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/scheme"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
@@ -72,8 +75,24 @@ func RegisterBackup(ctx context.Context, management *config.Management, opts con
 	snapshots := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshot()
 	snapshotContents := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotContent()
 	snapshotClass := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotClass()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget()
+	vmis := management.VirtFactory.Kubevirt().V1().VirtualMachineInstance()
+	pods := management.CoreFactory.Core().V1().Pod()
+	vmRestores := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore()
+	namespaces := management.CoreFactory.Core().V1().Namespace()
+	vmBackupGroups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupGroup()
+
+	copyConfig := rest.CopyConfig(management.RestConfig)
+	copyConfig.GroupVersion = &k8sschema.GroupVersion{Group: kubevirtv1.SubresourceGroupName, Version: kubevirtv1.ApiLatestVersion}
+	copyConfig.APIPath = "/apis"
+	copyConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	restClient, err := rest.RESTClientFor(copyConfig)
+	if err != nil {
+		return err
+	}
 
 	vmBackupController := &Handler{
+		ctx:                  ctx,
 		vmBackups:            vmBackups,
 		vmBackupController:   vmBackups,
 		vmBackupCache:        vmBackups.Cache(),
@@ -91,7 +110,17 @@ func RegisterBackup(ctx context.Context, management *config.Management, opts con
 		snapshotContents:     snapshotContents,
 		snapshotContentCache: snapshotContents.Cache(),
 		snapshotClassCache:   snapshotClass.Cache(),
+		backupTargetCache:    backupTargets.Cache(),
+		vmiCache:             vmis.Cache(),
+		podCache:             pods.Cache(),
+		vmRestores:           vmRestores,
+		vmRestoreCache:       vmRestores.Cache(),
+		namespaceCache:       namespaces.Cache(),
+		vmBackupGroupCache:   vmBackupGroups.Cache(),
 		recorder:             management.NewRecorder(backupControllerName, "", ""),
+		clientSet:            management.ClientSet,
+		restConfig:           management.RestConfig,
+		restClient:           restClient,
 	}
 
 	vmBackups.OnChange(ctx, backupControllerName, vmBackupController.OnBackupChange)
@@ -102,6 +131,7 @@ func RegisterBackup(ctx context.Context, management *config.Management, opts con
 }
 
 type Handler struct {
+	ctx                  context.Context
 	vmBackups            ctlharvesterv1.VirtualMachineBackupClient
 	vmBackupCache        ctlharvesterv1.VirtualMachineBackupCache
 	vmBackupController   ctlharvesterv1.VirtualMachineBackupController
@@ -119,7 +149,20 @@ type Handler struct {
 	snapshotContents     ctlsnapshotv1.VolumeSnapshotContentClient
 	snapshotContentCache ctlsnapshotv1.VolumeSnapshotContentCache
 	snapshotClassCache   ctlsnapshotv1.VolumeSnapshotClassCache
+	backupTargetCache    ctlharvesterv1.NamedBackupTargetCache
+	vmiCache             ctlkubevirtv1.VirtualMachineInstanceCache
+	podCache             ctlcorev1.PodCache
+	vmRestores           ctlharvesterv1.VirtualMachineRestoreClient
+	vmRestoreCache       ctlharvesterv1.VirtualMachineRestoreCache
+	namespaceCache       ctlcorev1.NamespaceCache
+	vmBackupGroupCache   ctlharvesterv1.VirtualMachineBackupGroupCache
 	recorder             record.EventRecorder
+	// copying tracks the Longhorn backups copied in the background by the vm backups
+	copying util.BackgroundTasks
+
+	clientSet  kubernetes.Interface
+	restConfig *rest.Config
+	restClient *rest.RESTClient
 }
 
 // OnBackupChange handles vm backup object on change and reconcile vm backup status
@@ -129,7 +172,14 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 	}
 
 	if IsBackupReady(vmBackup) {
-		return nil, h.handleBackupReady(vmBackup)
+		h.copying.Forget(string(vmBackup.UID))
+		if err := h.handleBackupReady(vmBackup); err != nil {
+			return nil, err
+		}
+		if isBackupVerifyRequested(vmBackup) {
+			return nil, h.reconcileBackupVerify(vmBackup)
+		}
+		return nil, nil
 	}
 
 	logrus.Debugf("OnBackupChange: vmBackup name:%s", vmBackup.Name)
@@ -158,6 +208,43 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 
 	// TODO, make sure status is initialized, and "Lock" the source VM by adding a finalizer and setting snapshotInProgress in status
 
+	// quiesce the source VM before creating volume snapshots, VM backups synced from the backup target have no source UID
+	if vmBackup.Status.SourceUID != nil && vmBackup.Status.Consistency == "" {
+		if isBackupGroupMember(vmBackup) {
+			return nil, h.quiesceBackupGroup(vmBackup)
+		}
+		return nil, h.quiesceVM(vmBackup)
+	}
+
+	if isGuestFrozen(vmBackup) {
+		if thawed, err := h.reconcileGuestThaw(vmBackup); err != nil || thawed {
+			return nil, err
+		}
+	}
+
+	// the volume snapshots of a backup group are taken together, after all the VMs of the group are quiesced
+	if vmBackup.Status.SourceUID != nil && isBackupGroupMember(vmBackup) {
+		quiesced, err := h.isBackupGroupQuiesced(vmBackup)
+		if err != nil {
+			return nil, err
+		}
+		if !quiesced {
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, backupGroupPollInterval)
+			return nil, nil
+		}
+	}
+
+	// the Longhorn backups of a vm backup synced from a named backup target are copied to the default backup target,
+	// the volume snapshots are created after Longhorn syncs them from it
+	if vmBackup.Status.SourceUID == nil && needsLonghornBackupCopy(vmBackup) {
+		if !isLonghornBackupCopied(vmBackup) {
+			return nil, h.reconcileLonghornBackupCopy(vmBackup)
+		}
+		if synced, err := h.areLonghornBackupsSynced(vmBackup); err != nil || !synced {
+			return nil, err
+		}
+	}
+
 	_, csiDriverVolumeSnapshotClassMap, err := h.getCSIDriverMap(vmBackup)
 	if err != nil {
 		return nil, h.setStatusError(vmBackup, err)
@@ -173,18 +260,50 @@ func (h *Handler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachin
 		return nil, err
 	}
 
+	// the Longhorn backups are copied to the named backup target after Longhorn backs up the volumes to the default one
+	if vmBackup.Status.SourceUID != nil {
+		return nil, h.reconcileLonghornBackupCopy(vmBackup)
+	}
 	return nil, nil
 }
 
 // OnBackupRemove remove remote vm backup metadata
 func (h *Handler) OnBackupRemove(key string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	if vmBackup != nil && isGuestFrozen(vmBackup) {
+		h.thawGuest(vmBackup)
+	}
+
+	if vmBackup != nil && vmBackup.Spec.Type == harvesterv1.Snapshot {
+		if err := h.removeSnapshotFromTree(vmBackup); err != nil {
+			return nil, err
+		}
+	}
+
+	if vmBackup != nil {
+		if err := h.stopCopyingLonghornBackups(vmBackup); err != nil {
+			return nil, err
+		}
+	}
+
+	if vmBackup != nil && vmBackup.Annotations[util.AnnotationBackupVerifyRequest] != "" {
+		if err := h.cleanupVerifyRestore(vmBackup, vmBackup.Annotations[util.AnnotationBackupVerifyRequest]); err != nil {
+			return nil, err
+		}
+	}
+
 	if vmBackup == nil || vmBackup.Status == nil || vmBackup.Status.BackupTarget == nil {
 		return nil, nil
 	}
 
-	target, err := settings.DecodeBackupTarget(settings.BackupTargetSet.Get())
+	backupTargetName := getBackupTargetName(vmBackup)
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backupTargetName)
 	if err != nil {
-		return nil, err
+		// the named backup target may be removed before its vm backups
+		if !apierrors.IsNotFound(err) || isDefaultBackupTargetName(backupTargetName) {
+			return nil, err
+		}
+		logrus.Infof("backup target %s of vmBackup %s/%s is not found, skip deleting metadata", backupTargetName, vmBackup.Namespace, vmBackup.Name)
+		return nil, nil
 	}
 
 	if !target.IsDefaultBackupTarget() {
@@ -193,6 +312,12 @@ func (h *Handler) OnBackupRemove(key string, vmBackup *harvesterv1.VirtualMachin
 		}
 	}
 
+	// Longhorn deletes the LH Backups in the default backup target with the VolumeSnapshotContents,
+	// the ones copied to the named backup target are deleted here.
+	if !isDefaultBackupTargetName(backupTargetName) {
+		return nil, h.deleteLonghornBackupCopies(vmBackup, target)
+	}
+
 	// Since VolumeSnapshot and VolumeSnapshotContent has finalizers,
 	// when we delete VM Backup and its backup target is not same as current backup target,
 	// VolumeSnapshot and VolumeSnapshotContent may not be deleted immediately.
@@ -389,6 +514,13 @@ func (h *Handler) initBackup(backup *harvesterv1.VirtualMachineBackup, vm *kubev
 		},
 	}
 
+	// the UID of kube-system namespace identifies the cluster in the backup catalog
+	kubeSystem, err := h.namespaceCache.Get(metav1.NamespaceSystem)
+	if err != nil {
+		return err
+	}
+	backupCpy.Status.SourceCluster = string(kubeSystem.UID)
+
 	if backupCpy.Status.VolumeBackups, err = h.getVolumeBackups(backup, vm); err != nil {
 		return err
 	}
@@ -402,21 +534,26 @@ func (h *Handler) initBackup(backup *harvesterv1.VirtualMachineBackup, vm *kubev
 	}
 
 	if backup.Spec.Type == harvesterv1.Backup {
-		target, err := settings.DecodeBackupTarget(settings.BackupTargetSet.Get())
+		target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backup.Spec.BackupTargetName)
 		if err != nil {
 			return err
 		}
 
 		backupCpy.Status.BackupTarget = &harvesterv1.BackupTarget{
+			Name:         getBackupTargetName(backup),
 			Endpoint:     target.Endpoint,
 			BucketName:   target.BucketName,
 			BucketRegion: target.BucketRegion,
 		}
 	}
 
+	setSnapshotParent(backupCpy, vm)
 	if _, err := h.vmBackups.Update(backupCpy); err != nil {
 		return err
 	}
+	if backup.Spec.Type == harvesterv1.Snapshot {
+		return setCurrentSnapshot(h.vms, vm.Namespace, vm.Name, backup.Name)
+	}
 	return nil
 }
 
@@ -637,13 +774,6 @@ func (h *Handler) setStatusError(vmBackup *harvesterv1.VirtualMachineBackup, err
 }
 
 func (h *Handler) deleteVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup, target *settings.BackupTarget) error {
-	var err error
-	if target == nil {
-		if target, err = settings.DecodeBackupTarget(settings.BackupTargetSet.Get()); err != nil {
-			return err
-		}
-	}
-
 	// when backup target has been reset to default, skip following
 	if target.IsDefaultBackupTarget() {
 		logrus.Debugf("vmBackup delete:%s, backup target is default, skip", vmBackup.Name)
@@ -654,29 +784,14 @@ func (h *Handler) deleteVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBac
 		return nil
 	}
 
-	if target.Type == settings.S3BackupType {
-		secret, err := h.secretCache.Get(util.LonghornSystemNamespaceName, util.BackupTargetSecretName)
-		if err != nil {
-			return err
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		destURL := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(vmBackup.Namespace, vmBackup.Name))
+		if exist := bsDriver.FileExists(destURL); exist {
+			logrus.Debugf("delete vm backup metadata %s/%s in backup target %s", vmBackup.Namespace, vmBackup.Name, target.Type)
+			return bsDriver.Remove(destURL)
 		}
-		os.Setenv(AWSAccessKey, string(secret.Data[AWSAccessKey]))
-		os.Setenv(AWSSecretKey, string(secret.Data[AWSSecretKey]))
-		os.Setenv(AWSEndpoints, string(secret.Data[AWSEndpoints]))
-		os.Setenv(AWSCERT, string(secret.Data[AWSCERT]))
-	}
-
-	bsDriver, err := backupstore.GetBackupStoreDriver(ConstructEndpoint(target))
-	if err != nil {
-		return err
-	}
-
-	destURL := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(vmBackup.Namespace, vmBackup.Name))
-	if exist := bsDriver.FileExists(destURL); exist {
-		logrus.Debugf("delete vm backup metadata %s/%s in backup target %s", vmBackup.Namespace, vmBackup.Name, target.Type)
-		return bsDriver.Remove(destURL)
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func (h *Handler) uploadVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup) error {
@@ -685,7 +800,7 @@ func (h *Handler) uploadVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBac
 		return fmt.Errorf("no backup target in vmbackup.status")
 	}
 
-	target, err := settings.DecodeBackupTarget(settings.BackupTargetSet.Get())
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, vmBackup.Spec.BackupTargetName)
 	if err != nil {
 		return err
 	}
@@ -700,22 +815,46 @@ func (h *Handler) uploadVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBac
 		return nil
 	}
 
-	if target.Type == settings.S3BackupType {
-		secret, err := h.secretCache.Get(util.LonghornSystemNamespaceName, util.BackupTargetSecretName)
-		if err != nil {
-			return err
-		}
-		os.Setenv(AWSAccessKey, string(secret.Data[AWSAccessKey]))
-		os.Setenv(AWSSecretKey, string(secret.Data[AWSSecretKey]))
-		os.Setenv(AWSEndpoints, string(secret.Data[AWSEndpoints]))
-		os.Setenv(AWSCERT, string(secret.Data[AWSCERT]))
-	}
-
-	bsDriver, err := backupstore.GetBackupStoreDriver(ConstructEndpoint(target))
+	vmBackupMetadata := newVMBackupMetadata(vmBackup)
+	j, err := json.Marshal(vmBackupMetadata)
 	if err != nil {
 		return err
 	}
+	// the metadata contains the VM spec and the secret backups, keep them from being read from the backup target
+	if j, err = encryptBackupMetadata(j, target.EncryptionKey); err != nil {
+		return err
+	}
+
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		shouldUpload := true
+		destURL := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(vmBackup.Namespace, vmBackup.Name))
+		if bsDriver.FileExists(destURL) {
+			content, err := readBackupMetadataFile(destURL, bsDriver)
+			if err != nil {
+				return err
+			}
+			remoteVMBackupMetadata, err := decodeBackupMetadata(destURL, content, target.EncryptionKey)
+			if err != nil {
+				return err
+			}
+			// upload again to encrypt the metadata written before the encryption key is set
+			if reflect.DeepEqual(vmBackupMetadata, remoteVMBackupMetadata) &&
+				(target.EncryptionKey == "" || isBackupMetadataEncrypted(content)) {
+				shouldUpload = false
+			}
+		}
+
+		if shouldUpload {
+			logrus.Debugf("upload vm backup metadata %s/%s to backup target %s", vmBackup.Namespace, vmBackup.Name, target.Type)
+			if err := bsDriver.Write(destURL, bytes.NewReader(j)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
 
+func newVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBackup) *VirtualMachineBackupMetadata {
 	vmBackupMetadata := &VirtualMachineBackupMetadata{
 		Name:          vmBackup.Name,
 		Namespace:     vmBackup.Namespace,
@@ -723,34 +862,13 @@ func (h *Handler) uploadVMBackupMetadata(vmBackup *harvesterv1.VirtualMachineBac
 		VMSourceSpec:  vmBackup.Status.SourceSpec,
 		VolumeBackups: sanitizeVolumeBackups(vmBackup.Status.VolumeBackups),
 		SecretBackups: vmBackup.Status.SecretBackups,
+		CreationTime:  vmBackup.Status.CreationTime,
+		SourceCluster: vmBackup.Status.SourceCluster,
 	}
 	if vmBackup.Namespace == "" {
 		vmBackupMetadata.Namespace = metav1.NamespaceDefault
 	}
-
-	j, err := json.Marshal(vmBackupMetadata)
-	if err != nil {
-		return err
-	}
-
-	shouldUpload := true
-	destURL := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(vmBackup.Namespace, vmBackup.Name))
-	if bsDriver.FileExists(destURL) {
-		if remoteVMBackupMetadata, err := loadBackupMetadataInBackupTarget(destURL, bsDriver); err != nil {
-			return err
-		} else if reflect.DeepEqual(vmBackupMetadata, remoteVMBackupMetadata) {
-			shouldUpload = false
-		}
-	}
-
-	if shouldUpload {
-		logrus.Debugf("upload vm backup metadata %s/%s to backup target %s", vmBackup.Namespace, vmBackup.Name, target.Type)
-		if err := bsDriver.Write(destURL, bytes.NewReader(j)); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return vmBackupMetadata
 }
 
 func sanitizeVolumeBackups(volumeBackups []harvesterv1.VolumeBackup) []harvesterv1.VolumeBackup {
@@ -758,6 +876,7 @@ func sanitizeVolumeBackups(volumeBackups []harvesterv1.VolumeBackup) []harvester
 		volumeBackups[i].ReadyToUse = nil
 		volumeBackups[i].CreationTime = nil
 		volumeBackups[i].Error = nil
+		volumeBackups[i].Progress = nil
 	}
 	return volumeBackups
 }
@@ -0,0 +1,502 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/longhorn/backupstore"
+	bsutil "github.com/longhorn/backupstore/util"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// Longhorn only backs up volumes to the default backup target. The Longhorn backups of a VM backup to a named backup
+// target are copied from the default backup target to the named one after Longhorn completes them, and the ones of a
+// VM backup synced from a named backup target are copied back to the default backup target, where Longhorn restores
+// the volumes from. The files are copied in the layout of backupstore, so that the copies can be restored by Longhorn.
+
+const (
+	// longhornBackupCopyTimeout bounds the copying of the Longhorn backups of a VM backup, which reads all their blocks
+	longhornBackupCopyTimeout = 6 * time.Hour
+	// longhornBackupCopyRetryInterval is the interval to retry the copying after it fails
+	longhornBackupCopyRetryInterval = time.Minute
+	// longhornBackupCopyBatchSize is the number of blocks copied with the backupstore mutex held,
+	// the blocks are up to 2 MiB and a batch is kept in memory between reading and writing it
+	longhornBackupCopyBatchSize = 16
+	// longhornBackupSyncInterval is the interval to check if Longhorn has synced the Longhorn backups copied to the
+	// default backup target, Longhorn polls the backup target with the backupstore-poll-interval setting.
+	longhornBackupSyncInterval = 30 * time.Second
+
+	longhornBackupsCopiedReasonCopying = "Copying"
+	longhornBackupsCopiedReasonCopied  = "Copied"
+)
+
+func isLonghornVolumeBackup(volumeBackup harvesterv1.VolumeBackup) bool {
+	return volumeBackup.CSIDriverName == longhorntypes.LonghornDriverName
+}
+
+// needsLonghornBackupCopy returns true if the vm backup is stored in a named backup target and has Longhorn volumes
+func needsLonghornBackupCopy(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	if vmBackup.Spec.Type != harvesterv1.Backup || isDefaultBackupTargetName(vmBackup.Spec.BackupTargetName) || vmBackup.Status == nil {
+		return false
+	}
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if isLonghornVolumeBackup(volumeBackup) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLonghornBackupCopied(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	c := getBackupCondition(vmBackup, harvesterv1.BackupConditionLonghornBackupsCopied)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// isLonghornBackupCopyPending returns true if the Longhorn backups of the vm backup are to be copied to its named
+// backup target, the vm backup isn't ready until they are copied.
+func isLonghornBackupCopyPending(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	return vmBackup.Status.SourceUID != nil && needsLonghornBackupCopy(vmBackup) && !isLonghornBackupCopied(vmBackup)
+}
+
+// reconcileLonghornBackupCopy copies the Longhorn backups of the vm backup in the background, the LonghornBackupsCopied
+// condition is set after they are copied. The Copying reason is persisted before the copying starts, so that it's
+// started again if it's interrupted. The failures are retried after a while, the files copied already are skipped.
+func (h *Handler) reconcileLonghornBackupCopy(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	key := string(vmBackup.UID)
+	if !needsLonghornBackupCopy(vmBackup) || isLonghornBackupCopied(vmBackup) {
+		h.copying.Forget(key)
+		return nil
+	}
+
+	// the Longhorn backups of a vm backup created in this cluster are copied after Longhorn completes them
+	if vmBackup.Status.SourceUID != nil {
+		for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+			if volumeBackup.ReadyToUse == nil || !*volumeBackup.ReadyToUse {
+				return nil
+			}
+		}
+	}
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if !isLonghornVolumeBackup(volumeBackup) || volumeBackup.LonghornBackupName != nil {
+			continue
+		}
+		// the vm backup is enqueued when the Longhorn backup name is set by OnLHBackupChanged
+		if vmBackup.Status.SourceUID != nil {
+			return nil
+		}
+		return fmt.Errorf("volume %s of vmBackup %s/%s has no Longhorn backup", volumeBackup.VolumeName, vmBackup.Namespace, vmBackup.Name)
+	}
+
+	c := getBackupCondition(vmBackup, harvesterv1.BackupConditionLonghornBackupsCopied)
+	if c == nil || c.Reason != longhornBackupsCopiedReasonCopying {
+		vmBackupCpy := vmBackup.DeepCopy()
+		updateBackupCondition(vmBackupCpy, newLonghornBackupsCopiedCondition(corev1.ConditionUnknown, longhornBackupsCopiedReasonCopying, ""))
+		_, err := h.vmBackups.Update(vmBackupCpy)
+		return err
+	}
+
+	h.copying.Start(key, key, longhornBackupCopyTimeout, func(ctx context.Context) error {
+		err := h.copyLonghornBackups(ctx, vmBackup)
+		// the copying is cancelled when the vm backup is removed
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		if err != nil {
+			logrus.Errorf("failed to copy Longhorn backups of vmBackup %s/%s, err: %v", vmBackup.Namespace, vmBackup.Name, err)
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, longhornBackupCopyRetryInterval)
+			return err
+		}
+		if err := h.setLonghornBackupsCopied(vmBackup); err != nil {
+			logrus.Errorf("failed to update Longhorn backups copied condition of vmBackup %s/%s, err: %v", vmBackup.Namespace, vmBackup.Name, err)
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, longhornBackupCopyRetryInterval)
+			return err
+		}
+		return nil
+	})
+	return nil
+}
+
+// stopCopyingLonghornBackups cancels the copying of the Longhorn backups in progress, it returns an error to be retried
+// until the copying stops, so that the files written by it are deleted with the vm backup.
+func (h *Handler) stopCopyingLonghornBackups(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	key := string(vmBackup.UID)
+	if h.copying.IsRunning(key) {
+		h.copying.Cancel(key)
+		return fmt.Errorf("Longhorn backups of vmBackup %s/%s are being copied", vmBackup.Namespace, vmBackup.Name)
+	}
+	h.copying.Forget(key)
+	return nil
+}
+
+func (h *Handler) copyLonghornBackups(ctx context.Context, vmBackup *harvesterv1.VirtualMachineBackup) error {
+	namedTarget, err := getBackupTarget(h.backupTargetCache, h.secretCache, vmBackup.Spec.BackupTargetName)
+	if err != nil {
+		return err
+	}
+	defaultTarget, err := getBackupTarget(h.backupTargetCache, h.secretCache, harvesterv1.DefaultBackupTargetName)
+	if err != nil {
+		return err
+	}
+	if defaultTarget.IsDefaultBackupTarget() {
+		return fmt.Errorf("backup target %s is not set, Longhorn restores the volumes from it", harvesterv1.DefaultBackupTargetName)
+	}
+
+	src, dst := defaultTarget, namedTarget
+	// vm backups synced from the backup target have no source UID
+	if vmBackup.Status.SourceUID == nil {
+		src, dst = namedTarget, defaultTarget
+	}
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if !isLonghornVolumeBackup(volumeBackup) {
+			continue
+		}
+		if err := copyLonghornBackup(ctx, src, dst, volumeBackup.PersistentVolumeClaim.Spec.VolumeName, *volumeBackup.LonghornBackupName); err != nil {
+			return fmt.Errorf("failed to copy Longhorn backup %s of volume %s: %w", *volumeBackup.LonghornBackupName, volumeBackup.VolumeName, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) setLonghornBackupsCopied(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.vmBackups.Get(vmBackup.Namespace, vmBackup.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// the vm backup is recreated or deleted
+		if current.UID != vmBackup.UID || current.DeletionTimestamp != nil {
+			return nil
+		}
+		vmBackupCpy := current.DeepCopy()
+		updateBackupCondition(vmBackupCpy, newLonghornBackupsCopiedCondition(corev1.ConditionTrue, longhornBackupsCopiedReasonCopied, ""))
+		_, err = h.vmBackups.Update(vmBackupCpy)
+		return err
+	})
+}
+
+// areLonghornBackupsSynced returns true if Longhorn has synced the Longhorn backups copied to the default backup target,
+// the vm backup is checked again after a while if they aren't synced yet.
+func (h *Handler) areLonghornBackupsSynced(vmBackup *harvesterv1.VirtualMachineBackup) (bool, error) {
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if !isLonghornVolumeBackup(volumeBackup) {
+			continue
+		}
+		if _, err := h.lhbackupCache.Get(util.LonghornSystemNamespaceName, *volumeBackup.LonghornBackupName); apierrors.IsNotFound(err) {
+			logrus.Debugf("waiting for Longhorn to sync backup %s of vmBackup %s/%s", *volumeBackup.LonghornBackupName, vmBackup.Namespace, vmBackup.Name)
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, longhornBackupSyncInterval)
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// deleteLonghornBackupCopies deletes the Longhorn backups copied to the named backup target of the vm backup
+func (h *Handler) deleteLonghornBackupCopies(vmBackup *harvesterv1.VirtualMachineBackup, target *settings.BackupTarget) error {
+	if !needsLonghornBackupCopy(vmBackup) || !IsBackupTargetSame(vmBackup.Status.BackupTarget, target) {
+		return nil
+	}
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if !isLonghornVolumeBackup(volumeBackup) || volumeBackup.LonghornBackupName == nil {
+			continue
+		}
+		logrus.Debugf("delete Longhorn backup %s of vmBackup %s/%s in backup target %s", *volumeBackup.LonghornBackupName, vmBackup.Namespace, vmBackup.Name, vmBackup.Spec.BackupTargetName)
+		if err := deleteLonghornBackup(target, volumeBackup.PersistentVolumeClaim.Spec.VolumeName, *volumeBackup.LonghornBackupName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLonghornVolumePath returns the folder of the Longhorn volume in the backup target, it's the same as backupstore's
+func getLonghornVolumePath(volumeName string) string {
+	checksum := bsutil.GetChecksum([]byte(volumeName))
+	return filepath.Join(backupstore.GetBackupstoreBase(), backupstore.VOLUME_DIRECTORY,
+		checksum[:backupstore.VOLUME_SEPARATE_LAYER1], checksum[backupstore.VOLUME_SEPARATE_LAYER1:backupstore.VOLUME_SEPARATE_LAYER2], volumeName)
+}
+
+func getLonghornVolumeConfigPath(volumeName string) string {
+	return filepath.Join(getLonghornVolumePath(volumeName), backupstore.VOLUME_CONFIG_FILE)
+}
+
+func getLonghornBackupConfigPath(volumeName, backupName string) string {
+	return filepath.Join(getLonghornVolumePath(volumeName), backupstore.BACKUP_DIRECTORY, backupstore.BACKUP_CONFIG_PREFIX+backupName+backupstore.CFG_SUFFIX)
+}
+
+func getLonghornBlockPath(volumeName, checksum string) string {
+	return filepath.Join(getLonghornVolumePath(volumeName), backupstore.BLOCKS_DIRECTORY,
+		checksum[:backupstore.BLOCK_SEPARATE_LAYER1], checksum[backupstore.BLOCK_SEPARATE_LAYER1:backupstore.BLOCK_SEPARATE_LAYER2], checksum+backupstore.BLK_SUFFIX)
+}
+
+// loadLonghornConfig loads the volume or backup config in the backup target into v,
+// it returns false if the config doesn't exist.
+func loadLonghornConfig(bsDriver backupstore.BackupStoreDriver, filePath string, v interface{}) (bool, error) {
+	if !bsDriver.FileExists(filePath) {
+		return false, nil
+	}
+	content, err := readBackupMetadataFile(filePath, bsDriver)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(content, v)
+}
+
+func saveLonghornConfig(bsDriver backupstore.BackupStoreDriver, filePath string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bsDriver.Write(filePath, bytes.NewReader(content))
+}
+
+// copyLonghornBackup copies the Longhorn backup of the volume from the src backup target to the dst one. The backup
+// config is written without its creation time first, so that backupstore treats the backup as in progress and
+// doesn't delete its blocks while they are copied. The blocks are named by their checksums, the ones which exist
+// in the dst backup target already are skipped. The drivers of the backup targets are opened once, and the
+// backupstore mutex is held for a batch of blocks instead of the whole copying, so that the other backupstore
+// operations aren't blocked.
+func copyLonghornBackup(ctx context.Context, srcTarget, dstTarget *settings.BackupTarget, volumeName, backupName string) (err error) {
+	src, err := openBackupStore(srcTarget)
+	if err != nil {
+		return err
+	}
+	dst, err := openBackupStore(dstTarget)
+	if err != nil {
+		return err
+	}
+
+	backupPath := getLonghornBackupConfigPath(volumeName, backupName)
+	backup := &backupstore.Backup{}
+	if err := src.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		found, err := loadLonghornConfig(bsDriver, backupPath, backup)
+		if err == nil && !found {
+			err = fmt.Errorf("cannot find %v in backupstore", backupPath)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+	if backup.CreatedTime == "" {
+		return fmt.Errorf("backup %s of volume %s is in progress", backupName, volumeName)
+	}
+
+	copied := &backupstore.Backup{}
+	if err := dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		_, err := loadLonghornConfig(bsDriver, backupPath, copied)
+		return err
+	}); err != nil {
+		return err
+	}
+	if copied.CreatedTime != "" {
+		return nil
+	}
+
+	inProgress := *backup
+	inProgress.CreatedTime = ""
+	if err := dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		return saveLonghornConfig(bsDriver, backupPath, &inProgress)
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if removeErr := dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+			return bsDriver.Remove(backupPath)
+		}); removeErr != nil {
+			logrus.Errorf("failed to remove backup %s of volume %s in progress, err: %v", backupName, volumeName, removeErr)
+		}
+	}()
+
+	if err := copyLonghornVolumeConfig(src, dst, volumeName); err != nil {
+		return err
+	}
+	var blockPaths []string
+	checksums := map[string]bool{}
+	for _, block := range backup.Blocks {
+		if checksums[block.BlockChecksum] {
+			continue
+		}
+		checksums[block.BlockChecksum] = true
+		blockPaths = append(blockPaths, getLonghornBlockPath(volumeName, block.BlockChecksum))
+	}
+	for len(blockPaths) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch := blockPaths
+		if len(batch) > longhornBackupCopyBatchSize {
+			batch = batch[:longhornBackupCopyBatchSize]
+		}
+		if err := copyBackupStoreFiles(src, dst, batch); err != nil {
+			return err
+		}
+		blockPaths = blockPaths[len(batch):]
+	}
+
+	return dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		return saveLonghornConfig(bsDriver, backupPath, backup)
+	})
+}
+
+// copyLonghornVolumeConfig writes the volume config to the dst backup target if it's missing there, or updates its
+// size if the volume is expanded, since Longhorn restores the volume with the size in it.
+func copyLonghornVolumeConfig(src, dst *backupStore, volumeName string) error {
+	volumePath := getLonghornVolumeConfigPath(volumeName)
+	volume := &backupstore.Volume{}
+	if err := src.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		found, err := loadLonghornConfig(bsDriver, volumePath, volume)
+		if err == nil && !found {
+			err = fmt.Errorf("cannot find %v in backupstore", volumePath)
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		existing := &backupstore.Volume{}
+		found, err := loadLonghornConfig(bsDriver, volumePath, existing)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return saveLonghornConfig(bsDriver, volumePath, volume)
+		}
+		if existing.Size >= volume.Size {
+			return nil
+		}
+		existing.Size = volume.Size
+		return saveLonghornConfig(bsDriver, volumePath, existing)
+	})
+}
+
+// copyBackupStoreFiles copies the files from the src backup target to the dst one unless they exist there,
+// the backupstore mutex is taken once for each step of the batch.
+func copyBackupStoreFiles(src, dst *backupStore, filePaths []string) error {
+	var missing []string
+	if err := dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		for _, filePath := range filePaths {
+			if !bsDriver.FileExists(filePath) {
+				missing = append(missing, filePath)
+			}
+		}
+		return nil
+	}); err != nil || len(missing) == 0 {
+		return err
+	}
+
+	contents := make([][]byte, len(missing))
+	if err := src.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		for i, filePath := range missing {
+			content, err := readBackupMetadataFile(filePath, bsDriver)
+			if err != nil {
+				return err
+			}
+			contents[i] = content
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return dst.use(func(bsDriver backupstore.BackupStoreDriver) error {
+		for i, filePath := range missing {
+			if err := bsDriver.Write(filePath, bytes.NewReader(contents[i])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteLonghornBackup deletes the Longhorn backup copied to the named backup target and the blocks which aren't
+// referenced by the other backups of the volume. Like backupstore, the blocks are kept if another backup of the volume
+// is in progress, i.e. being copied. The volume folder is removed with the last backup of the volume.
+func deleteLonghornBackup(target *settings.BackupTarget, volumeName, backupName string) error {
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		if err := bsDriver.Remove(getLonghornBackupConfigPath(volumeName, backupName)); err != nil {
+			return err
+		}
+
+		volumePath := getLonghornVolumePath(volumeName)
+		fileNames, err := bsDriver.List(filepath.Join(volumePath, backupstore.BACKUP_DIRECTORY))
+		if err != nil {
+			return err
+		}
+		backupNames := bsutil.ExtractNames(fileNames, backupstore.BACKUP_CONFIG_PREFIX, backupstore.CFG_SUFFIX)
+		if len(backupNames) == 0 {
+			return bsDriver.Remove(volumePath)
+		}
+
+		referenced := map[string]bool{}
+		for _, name := range backupNames {
+			backup := &backupstore.Backup{}
+			if _, err := loadLonghornConfig(bsDriver, getLonghornBackupConfigPath(volumeName, name), backup); err != nil {
+				return err
+			}
+			if backup.CreatedTime == "" {
+				logrus.Infof("backup %s of volume %s is in progress, skip deleting blocks", name, volumeName)
+				return nil
+			}
+			for _, block := range backup.Blocks {
+				referenced[block.BlockChecksum] = true
+			}
+		}
+
+		checksums, err := listLonghornBlocks(bsDriver, volumeName)
+		if err != nil {
+			return err
+		}
+		for _, checksum := range checksums {
+			if referenced[checksum] {
+				continue
+			}
+			if err := bsDriver.Remove(getLonghornBlockPath(volumeName, checksum)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listLonghornBlocks returns the checksums of the blocks of the volume in the backup target
+func listLonghornBlocks(bsDriver backupstore.BackupStoreDriver, volumeName string) ([]string, error) {
+	blocksPath := filepath.Join(getLonghornVolumePath(volumeName), backupstore.BLOCKS_DIRECTORY)
+	lv1Dirs, err := bsDriver.List(blocksPath)
+	if err != nil {
+		return nil, err
+	}
+	var fileNames []string
+	for _, lv1 := range lv1Dirs {
+		lv2Dirs, err := bsDriver.List(filepath.Join(blocksPath, lv1))
+		if err != nil {
+			return nil, err
+		}
+		for _, lv2 := range lv2Dirs {
+			names, err := bsDriver.List(filepath.Join(blocksPath, lv1, lv2))
+			if err != nil {
+				return nil, err
+			}
+			fileNames = append(fileNames, names...)
+		}
+	}
+	return bsutil.ExtractNames(fileNames, "", backupstore.BLK_SUFFIX), nil
+}
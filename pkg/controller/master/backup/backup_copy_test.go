@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/longhorn/backupstore"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+)
+
+func TestIsLonghornBackupCopyPending(t *testing.T) {
+	longhornVolume := harvesterv1.VolumeBackup{CSIDriverName: longhorntypes.LonghornDriverName}
+	otherVolume := harvesterv1.VolumeBackup{CSIDriverName: "other.csi.driver"}
+	sourceUID := types.UID("uid")
+	copied := harvesterv1.Condition{Type: harvesterv1.BackupConditionLonghornBackupsCopied, Status: corev1.ConditionTrue}
+	copying := harvesterv1.Condition{Type: harvesterv1.BackupConditionLonghornBackupsCopied, Status: corev1.ConditionUnknown}
+
+	var testCases = []struct {
+		name             string
+		backupTargetName string
+		status           harvesterv1.VirtualMachineBackupStatus
+		expected         bool
+	}{
+		{
+			name:   "default backup target",
+			status: harvesterv1.VirtualMachineBackupStatus{SourceUID: &sourceUID, VolumeBackups: []harvesterv1.VolumeBackup{longhornVolume}},
+		},
+		{
+			name:             "no Longhorn volumes",
+			backupTargetName: "offsite",
+			status:           harvesterv1.VirtualMachineBackupStatus{SourceUID: &sourceUID, VolumeBackups: []harvesterv1.VolumeBackup{otherVolume}},
+		},
+		{
+			name:             "synced from the backup target",
+			backupTargetName: "offsite",
+			status:           harvesterv1.VirtualMachineBackupStatus{VolumeBackups: []harvesterv1.VolumeBackup{longhornVolume}},
+		},
+		{
+			name:             "being copied",
+			backupTargetName: "offsite",
+			status: harvesterv1.VirtualMachineBackupStatus{SourceUID: &sourceUID, VolumeBackups: []harvesterv1.VolumeBackup{otherVolume, longhornVolume},
+				Conditions: []harvesterv1.Condition{copying}},
+			expected: true,
+		},
+		{
+			name:             "copied",
+			backupTargetName: "offsite",
+			status: harvesterv1.VirtualMachineBackupStatus{SourceUID: &sourceUID, VolumeBackups: []harvesterv1.VolumeBackup{longhornVolume},
+				Conditions: []harvesterv1.Condition{copied}},
+		},
+	}
+
+	for _, tc := range testCases {
+		status := tc.status
+		vmBackup := &harvesterv1.VirtualMachineBackup{
+			Spec:   harvesterv1.VirtualMachineBackupSpec{Type: harvesterv1.Backup, BackupTargetName: tc.backupTargetName},
+			Status: &status,
+		}
+		assert.Equal(t, tc.expected, isLonghornBackupCopyPending(vmBackup), tc.name)
+	}
+}
+
+func writeTestLonghornBackup(t *testing.T, target *settings.BackupTarget, volumeName string, size int64, backup *backupstore.Backup, blocks map[string]string) {
+	assert.Nil(t, withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		for checksum, content := range blocks {
+			assert.Nil(t, bsDriver.Write(getLonghornBlockPath(volumeName, checksum), strings.NewReader(content)))
+		}
+		assert.Nil(t, saveLonghornConfig(bsDriver, getLonghornVolumeConfigPath(volumeName), &backupstore.Volume{Name: volumeName, Size: size}))
+		return saveLonghornConfig(bsDriver, getLonghornBackupConfigPath(volumeName, backup.Name), backup)
+	}))
+}
+
+func getTestBackupStoreFile(store *fakeBackupStore, filePath string, v interface{}) bool {
+	content, ok := store.files[filePath]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(content, v) == nil
+}
+
+func TestCopyAndDeleteLonghornBackup(t *testing.T) {
+	src := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://default"}
+	dst := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://copy"}
+	srcStore := &fakeBackupStore{files: map[string][]byte{}}
+	dstStore := &fakeBackupStore{files: map[string][]byte{}}
+	testBackupStores[src.Endpoint], testBackupStores[dst.Endpoint] = srcStore, dstStore
+	defer func() {
+		delete(testBackupStores, src.Endpoint)
+		delete(testBackupStores, dst.Endpoint)
+	}()
+
+	const volumeName = "pvc-1"
+	aaa, bbb, ccc := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)
+	backup1 := &backupstore.Backup{Name: "backup-1", VolumeName: volumeName, CreatedTime: "2026-10-18T00:00:00Z",
+		Blocks: []backupstore.BlockMapping{{Offset: 0, BlockChecksum: aaa}, {Offset: 2097152, BlockChecksum: bbb}, {Offset: 4194304, BlockChecksum: aaa}}}
+	backup2 := &backupstore.Backup{Name: "backup-2", VolumeName: volumeName, CreatedTime: "2026-10-18T01:00:00Z",
+		Blocks: []backupstore.BlockMapping{{Offset: 0, BlockChecksum: aaa}, {Offset: 2097152, BlockChecksum: ccc}}}
+	writeTestLonghornBackup(t, src, volumeName, 10, backup1, map[string]string{aaa: "aaa", bbb: "bbb"})
+
+	// the blocks and the volume config are copied with the completed backup config
+	assert.Nil(t, copyLonghornBackup(context.TODO(), src, dst, volumeName, backup1.Name))
+	copied := &backupstore.Backup{}
+	assert.True(t, getTestBackupStoreFile(dstStore, getLonghornBackupConfigPath(volumeName, backup1.Name), copied))
+	assert.Equal(t, backup1, copied)
+	assert.Equal(t, []byte("aaa"), dstStore.files[getLonghornBlockPath(volumeName, aaa)])
+	assert.Equal(t, []byte("bbb"), dstStore.files[getLonghornBlockPath(volumeName, bbb)])
+	volume := &backupstore.Volume{}
+	assert.True(t, getTestBackupStoreFile(dstStore, getLonghornVolumeConfigPath(volumeName), volume))
+	assert.Equal(t, int64(10), volume.Size)
+
+	// the blocks in the dst backup target already aren't copied again, the volume is expanded
+	writeTestLonghornBackup(t, src, volumeName, 20, backup2, map[string]string{ccc: "ccc"})
+	dstStore.files[getLonghornBlockPath(volumeName, aaa)] = []byte("kept")
+	assert.Nil(t, copyLonghornBackup(context.TODO(), src, dst, volumeName, backup2.Name))
+	assert.Equal(t, []byte("kept"), dstStore.files[getLonghornBlockPath(volumeName, aaa)])
+	assert.Equal(t, []byte("ccc"), dstStore.files[getLonghornBlockPath(volumeName, ccc)])
+	assert.True(t, getTestBackupStoreFile(dstStore, getLonghornVolumeConfigPath(volumeName), volume))
+	assert.Equal(t, int64(20), volume.Size)
+
+	// the backup config isn't left in the dst backup target if the copying is cancelled
+	backup3 := &backupstore.Backup{Name: "backup-3", VolumeName: volumeName, CreatedTime: "2026-10-18T02:00:00Z",
+		Blocks: []backupstore.BlockMapping{{Offset: 0, BlockChecksum: strings.Repeat("d", 64)}}}
+	writeTestLonghornBackup(t, src, volumeName, 20, backup3, map[string]string{strings.Repeat("d", 64): "ddd"})
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.NotNil(t, copyLonghornBackup(ctx, src, dst, volumeName, backup3.Name))
+	assert.NotContains(t, dstStore.files, getLonghornBackupConfigPath(volumeName, backup3.Name))
+
+	// a backup in progress isn't copied
+	backup3.CreatedTime = ""
+	writeTestLonghornBackup(t, src, volumeName, 20, backup3, nil)
+	assert.NotNil(t, copyLonghornBackup(context.TODO(), src, dst, volumeName, backup3.Name))
+
+	// the blocks of a backup are kept while another backup of the volume is in progress
+	dstStore.files[getLonghornBackupConfigPath(volumeName, backup3.Name)] = srcStore.files[getLonghornBackupConfigPath(volumeName, backup3.Name)]
+	assert.Nil(t, deleteLonghornBackup(dst, volumeName, backup1.Name))
+	assert.NotContains(t, dstStore.files, getLonghornBackupConfigPath(volumeName, backup1.Name))
+	assert.Contains(t, dstStore.files, getLonghornBlockPath(volumeName, bbb))
+	delete(dstStore.files, getLonghornBackupConfigPath(volumeName, backup3.Name))
+
+	// the blocks which aren't referenced by the other backups are deleted
+	assert.Nil(t, deleteLonghornBackup(dst, volumeName, backup1.Name))
+	assert.NotContains(t, dstStore.files, getLonghornBlockPath(volumeName, bbb))
+	assert.Contains(t, dstStore.files, getLonghornBlockPath(volumeName, aaa))
+	assert.Contains(t, dstStore.files, getLonghornBlockPath(volumeName, ccc))
+
+	// the volume folder is deleted with the last backup
+	assert.Nil(t, deleteLonghornBackup(dst, volumeName, backup2.Name))
+	assert.Empty(t, dstStore.files)
+	assert.Contains(t, srcStore.files, getLonghornBackupConfigPath(volumeName, backup1.Name))
+}
+
+func TestCopyLonghornBackupInBatches(t *testing.T) {
+	src := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://default"}
+	dst := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://copy"}
+	srcStore := &fakeBackupStore{files: map[string][]byte{}}
+	dstStore := &fakeBackupStore{files: map[string][]byte{}}
+	testBackupStores[src.Endpoint], testBackupStores[dst.Endpoint] = srcStore, dstStore
+	defer func() {
+		delete(testBackupStores, src.Endpoint)
+		delete(testBackupStores, dst.Endpoint)
+	}()
+
+	// the blocks of a backup more than a batch are all copied
+	const volumeName = "pvc-1"
+	backup := &backupstore.Backup{Name: "backup-1", VolumeName: volumeName, CreatedTime: "2026-10-18T00:00:00Z"}
+	blocks := map[string]string{}
+	for i := 0; i < longhornBackupCopyBatchSize*2+1; i++ {
+		checksum := fmt.Sprintf("%064d", i)
+		blocks[checksum] = checksum
+		backup.Blocks = append(backup.Blocks, backupstore.BlockMapping{Offset: int64(i) * 2097152, BlockChecksum: checksum})
+	}
+	writeTestLonghornBackup(t, src, volumeName, 10, backup, blocks)
+
+	assert.Nil(t, copyLonghornBackup(context.TODO(), src, dst, volumeName, backup.Name))
+	for checksum, content := range blocks {
+		assert.Equal(t, []byte(content), dstStore.files[getLonghornBlockPath(volumeName, checksum)])
+	}
+}
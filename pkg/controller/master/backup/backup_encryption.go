@@ -0,0 +1,163 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	metadataEncryptionAES256GCM = "aes-256-gcm"
+	metadataKDFScrypt           = "scrypt"
+
+	// the scrypt parameters recommended for interactive logins, the derived keys are cached by metadataKeys
+	scryptN          = 1 << 15
+	scryptR          = 8
+	scryptP          = 1
+	metadataKeyLen   = 32
+	metadataSaltSize = 16
+)
+
+var (
+	errMetadataDecryptionKeyMissing  = errors.New("decryption key missing, the metadata is encrypted but the backup target has no encryption key")
+	errMetadataDecryptionKeyMismatch = errors.New("decryption key mismatch, the metadata isn't encrypted with the encryption key of the backup target")
+
+	metadataKeys = &metadataKeyCache{
+		keys:  map[metadataKeyID][]byte{},
+		salts: map[string][]byte{},
+	}
+)
+
+// encryptedBackupMetadata is the content of an encrypted vm backup metadata file,
+// the key is derived from the passphrase of the backup target with KDF and Salt,
+// Data is the nonce followed by the encrypted VirtualMachineBackupMetadata.
+type encryptedBackupMetadata struct {
+	Encryption string `json:"encryption"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Data       []byte `json:"data"`
+}
+
+type metadataKeyID struct {
+	passphrase string
+	salt       string
+}
+
+// metadataKeyCache caches the keys derived by scrypt, which is slow on purpose. A salt is generated once
+// for every passphrase, so that the metadata files written by a cluster don't need their own derived keys.
+type metadataKeyCache struct {
+	mutex sync.Mutex
+	keys  map[metadataKeyID][]byte
+	salts map[string][]byte
+}
+
+func (c *metadataKeyCache) getSalt(passphrase string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if salt, ok := c.salts[passphrase]; ok {
+		return salt, nil
+	}
+	salt := make([]byte, metadataSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	c.salts[passphrase] = salt
+	return salt, nil
+}
+
+func (c *metadataKeyCache) getKey(passphrase string, salt []byte) ([]byte, error) {
+	id := metadataKeyID{passphrase: passphrase, salt: string(salt)}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if key, ok := c.keys[id]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, metadataKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	c.keys[id] = key
+	return key, nil
+}
+
+func newMetadataCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := metadataKeys.getKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptBackupMetadata returns the encrypted metadata file content, the content is returned as is if the key is empty
+func encryptBackupMetadata(content []byte, key string) ([]byte, error) {
+	if key == "" {
+		return content, nil
+	}
+
+	salt, err := metadataKeys.getSalt(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newMetadataCipher(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&encryptedBackupMetadata{
+		Encryption: metadataEncryptionAES256GCM,
+		KDF:        metadataKDFScrypt,
+		Salt:       salt,
+		Data:       gcm.Seal(nonce, nonce, content, nil),
+	})
+}
+
+func isBackupMetadataEncrypted(content []byte) bool {
+	encrypted := &encryptedBackupMetadata{}
+	return json.Unmarshal(content, encrypted) == nil && encrypted.Encryption != ""
+}
+
+// decryptBackupMetadata returns the plain metadata file content. The metadata written without encryption
+// is returned as is, so that the backups created before the encryption key is set can still be restored.
+func decryptBackupMetadata(content []byte, key string) ([]byte, error) {
+	encrypted := &encryptedBackupMetadata{}
+	if err := json.Unmarshal(content, encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.Encryption == "" {
+		return content, nil
+	}
+	if encrypted.Encryption != metadataEncryptionAES256GCM || encrypted.KDF != metadataKDFScrypt {
+		return nil, fmt.Errorf("unsupported encryption %s with key derivation %s", encrypted.Encryption, encrypted.KDF)
+	}
+	if key == "" {
+		return nil, errMetadataDecryptionKeyMissing
+	}
+
+	gcm, err := newMetadataCipher(key, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Data) < gcm.NonceSize() {
+		return nil, errMetadataDecryptionKeyMismatch
+	}
+	nonce, data := encrypted.Data[:gcm.NonceSize()], encrypted.Data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, errMetadataDecryptionKeyMismatch
+	}
+	return plain, nil
+}
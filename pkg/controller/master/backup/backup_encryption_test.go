@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupMetadataEncryption(t *testing.T) {
+	content := []byte(`{"name":"vm-backup","namespace":"default"}`)
+
+	encrypted, err := encryptBackupMetadata(content, "passphrase")
+	assert.Nil(t, err)
+	assert.True(t, isBackupMetadataEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "vm-backup")
+
+	header := &encryptedBackupMetadata{}
+	assert.Nil(t, json.Unmarshal(encrypted, header))
+	assert.Equal(t, metadataKDFScrypt, header.KDF)
+	assert.Len(t, header.Salt, metadataSaltSize)
+
+	plain, err := decryptBackupMetadata(encrypted, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, content, plain)
+
+	// the metadata written by another cluster has another salt
+	metadataKeys = &metadataKeyCache{keys: map[metadataKeyID][]byte{}, salts: map[string][]byte{}}
+	plain, err = decryptBackupMetadata(encrypted, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, content, plain)
+	reencrypted, err := encryptBackupMetadata(content, "passphrase")
+	assert.Nil(t, err)
+	reencryptedHeader := &encryptedBackupMetadata{}
+	assert.Nil(t, json.Unmarshal(reencrypted, reencryptedHeader))
+	assert.NotEqual(t, header.Salt, reencryptedHeader.Salt)
+
+	_, err = decryptBackupMetadata(encrypted, "wrong")
+	assert.ErrorIs(t, err, errMetadataDecryptionKeyMismatch)
+
+	_, err = decryptBackupMetadata(encrypted, "")
+	assert.ErrorIs(t, err, errMetadataDecryptionKeyMissing)
+
+	// the metadata written without encryption can be read with or without the key
+	assert.False(t, isBackupMetadataEncrypted(content))
+	plain, err = decryptBackupMetadata(content, "passphrase")
+	assert.Nil(t, err)
+	assert.Equal(t, content, plain)
+}
@@ -0,0 +1,352 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	wranglername "github.com/rancher/wrangler/pkg/name"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	backupGroupControllerName       = "harvester-vm-backup-group-controller"
+	backupGroupBackupControllerName = "harvester-vm-backup-group-backup-controller"
+	vmBackupGroupKindName           = "VirtualMachineBackupGroup"
+
+	// backupGroupPollInterval is how often a member backup checks the other members of its group
+	// while it waits for them to be quiesced or to take their volume snapshots
+	backupGroupPollInterval = 2 * time.Second
+)
+
+var vmBackupGroupKind = harvesterv1.SchemeGroupVersion.WithKind(vmBackupGroupKindName)
+
+// RegisterBackupGroup register the vmBackupGroup controller, which creates a vm backup for each VM of the group
+// and aggregates their status. The member backups are coordinated by the vmBackup controller, the guests of all the
+// VMs are frozen together before the volume snapshots of any of them are taken.
+func RegisterBackupGroup(ctx context.Context, management *config.Management, opts config.Options) error {
+	groups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupGroup()
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+
+	groupController := &GroupHandler{
+		groups:          groups,
+		groupController: groups,
+		vmBackups:       vmBackups,
+		vmBackupCache:   vmBackups.Cache(),
+		vmCache:         vms.Cache(),
+	}
+
+	groups.OnChange(ctx, backupGroupControllerName, groupController.OnBackupGroupChange)
+	vmBackups.OnChange(ctx, backupGroupBackupControllerName, groupController.OnBackupChange)
+	return nil
+}
+
+type GroupHandler struct {
+	groups          ctlharvesterv1.VirtualMachineBackupGroupClient
+	groupController ctlharvesterv1.VirtualMachineBackupGroupController
+	vmBackups       ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache   ctlharvesterv1.VirtualMachineBackupCache
+	vmCache         ctlkubevirtv1.VirtualMachineCache
+}
+
+// OnBackupGroupChange resolves the VMs of the group, creates the member vm backups and aggregates their status
+func (h *GroupHandler) OnBackupGroupChange(key string, group *harvesterv1.VirtualMachineBackupGroup) (*harvesterv1.VirtualMachineBackupGroup, error) {
+	if group == nil || group.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	// the group is initialized again until its VMs are resolved, the error is returned so that it's requeued
+	if group.Status == nil || len(group.Status.Members) == 0 {
+		return h.initBackupGroup(group)
+	}
+
+	if IsBackupGroupReady(group) {
+		return nil, nil
+	}
+
+	if err := h.createMemberBackups(group); err != nil {
+		return h.setGroupError(group, err)
+	}
+	return h.updateGroupStatus(group)
+}
+
+// OnBackupChange enqueues the group of a member vm backup, so the status of the group follows its members
+func (h *GroupHandler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	if vmBackup == nil || vmBackup.DeletionTimestamp != nil {
+		return vmBackup, nil
+	}
+
+	if groupName := vmBackup.Labels[util.LabelVMBackupGroup]; groupName != "" {
+		h.groupController.Enqueue(vmBackup.Namespace, groupName)
+	}
+	return vmBackup, nil
+}
+
+// initBackupGroup resolves the VMs of the group once, so VMs labeled later don't join a group in progress
+func (h *GroupHandler) initBackupGroup(group *harvesterv1.VirtualMachineBackupGroup) (*harvesterv1.VirtualMachineBackupGroup, error) {
+	vmNames, err := h.getGroupVMNames(group)
+	if err != nil {
+		return h.setGroupError(group, err)
+	}
+
+	groupCpy := group.DeepCopy()
+	groupCpy.Status = &harvesterv1.VirtualMachineBackupGroupStatus{
+		ReadyToUse: pointer.BoolPtr(false),
+		Conditions: []harvesterv1.Condition{
+			newProgressingCondition(corev1.ConditionTrue, "", "Initializing VirtualMachineBackupGroup"),
+			newReadyCondition(corev1.ConditionFalse, "", "Initializing VirtualMachineBackupGroup"),
+		},
+	}
+	for _, vmName := range vmNames {
+		groupCpy.Status.Members = append(groupCpy.Status.Members, harvesterv1.BackupGroupMember{
+			VMName:       vmName,
+			VMBackupName: getGroupMemberBackupName(group.Name, vmName),
+		})
+	}
+	return h.groups.Update(groupCpy)
+}
+
+// getGroupVMNames returns the sorted names of the listed VMs and the VMs matching the selector
+func (h *GroupHandler) getGroupVMNames(group *harvesterv1.VirtualMachineBackupGroup) ([]string, error) {
+	names := map[string]bool{}
+	for _, vmName := range group.Spec.VMNames {
+		vm, err := h.vmCache.Get(group.Namespace, vmName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get vm %s/%s, error: %w", group.Namespace, vmName, err)
+		}
+		if vm.DeletionTimestamp != nil {
+			return nil, fmt.Errorf("vm %s/%s is being deleted", group.Namespace, vmName)
+		}
+		names[vmName] = true
+	}
+
+	if group.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(group.Spec.Selector)
+		if err != nil {
+			return nil, err
+		}
+		vms, err := h.vmCache.List(group.Namespace, selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			if vm.DeletionTimestamp == nil {
+				names[vm.Name] = true
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no vm is selected by the backup group")
+	}
+
+	vmNames := make([]string, 0, len(names))
+	for vmName := range names {
+		vmNames = append(vmNames, vmName)
+	}
+	sort.Strings(vmNames)
+	return vmNames, nil
+}
+
+func (h *GroupHandler) createMemberBackups(group *harvesterv1.VirtualMachineBackupGroup) error {
+	apiGroup := kubevirtv1.SchemeGroupVersion.Group
+	for _, member := range group.Status.Members {
+		if _, err := h.vmBackupCache.Get(group.Namespace, member.VMBackupName); err == nil {
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		// the member backups are owned by the group, they are removed together with the group
+		backup := &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      member.VMBackupName,
+				Namespace: group.Namespace,
+				Labels: map[string]string{
+					util.LabelVMBackupGroup: group.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(group, vmBackupGroupKind),
+				},
+			},
+			Spec: harvesterv1.VirtualMachineBackupSpec{
+				Source: corev1.TypedLocalObjectReference{
+					APIGroup: &apiGroup,
+					Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+					Name:     member.VMName,
+				},
+				Type:             group.Spec.Type,
+				BackupTargetName: group.Spec.BackupTargetName,
+			},
+		}
+		logrus.Infof("creating vm backup %s/%s of backup group %s", backup.Namespace, backup.Name, group.Name)
+		if _, err := h.vmBackups.Create(backup); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create backup for VM %s/%s, error: %w", group.Namespace, member.VMName, err)
+		}
+	}
+	return nil
+}
+
+// updateGroupStatus aggregates the readiness, errors and progress of the member backups
+func (h *GroupHandler) updateGroupStatus(group *harvesterv1.VirtualMachineBackupGroup) (*harvesterv1.VirtualMachineBackupGroup, error) {
+	groupCpy := group.DeepCopy()
+
+	ready := true
+	errorMessage := ""
+	progresses := make([]*harvesterv1.Progress, 0, len(group.Status.Members))
+	for _, member := range group.Status.Members {
+		vmBackup, err := h.vmBackupCache.Get(group.Namespace, member.VMBackupName)
+		if apierrors.IsNotFound(err) {
+			ready = false
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !IsBackupReady(vmBackup) {
+			ready = false
+		}
+		if e := GetVMBackupError(vmBackup); e != nil && e.Message != nil && errorMessage == "" {
+			errorMessage = fmt.Sprintf("VirtualMachineBackup %s in error state: %s", vmBackup.Name, *e.Message)
+		}
+		if vmBackup.Status != nil && vmBackup.Status.Progress != nil {
+			progresses = append(progresses, vmBackup.Status.Progress)
+		}
+	}
+	groupCpy.Status.Progress = sumProgress(progresses)
+
+	if errorMessage != "" {
+		if groupCpy.Status.Error == nil || groupCpy.Status.Error.Message == nil || *groupCpy.Status.Error.Message != errorMessage {
+			groupCpy.Status.Error = &harvesterv1.Error{
+				Time:    currentTime(),
+				Message: pointer.StringPtr(errorMessage),
+			}
+		}
+		updateBackupGroupCondition(groupCpy, newProgressingCondition(corev1.ConditionFalse, "Error", errorMessage))
+		updateBackupGroupCondition(groupCpy, newReadyCondition(corev1.ConditionFalse, "", "Not Ready"))
+	} else if ready {
+		groupCpy.Status.Error = nil
+		groupCpy.Status.ReadyToUse = pointer.BoolPtr(true)
+		groupCpy.Status.CreationTime = currentTime()
+		updateBackupGroupCondition(groupCpy, newProgressingCondition(corev1.ConditionFalse, "", "Operation complete"))
+		updateBackupGroupCondition(groupCpy, newReadyCondition(corev1.ConditionTrue, "", "Operation complete"))
+	} else {
+		updateBackupGroupCondition(groupCpy, newProgressingCondition(corev1.ConditionTrue, "", "Operation in progress"))
+		updateBackupGroupCondition(groupCpy, newReadyCondition(corev1.ConditionFalse, "", "Not ready"))
+	}
+
+	if reflect.DeepEqual(group.Status, groupCpy.Status) {
+		return group, nil
+	}
+	return h.groups.Update(groupCpy)
+}
+
+func (h *GroupHandler) setGroupError(group *harvesterv1.VirtualMachineBackupGroup, err error) (*harvesterv1.VirtualMachineBackupGroup, error) {
+	groupCpy := group.DeepCopy()
+	if groupCpy.Status == nil {
+		groupCpy.Status = &harvesterv1.VirtualMachineBackupGroupStatus{
+			ReadyToUse: pointer.BoolPtr(false),
+		}
+	}
+	groupCpy.Status.Error = &harvesterv1.Error{
+		Time:    currentTime(),
+		Message: pointer.StringPtr(err.Error()),
+	}
+	updateBackupGroupCondition(groupCpy, newProgressingCondition(corev1.ConditionFalse, "Error", err.Error()))
+	updateBackupGroupCondition(groupCpy, newReadyCondition(corev1.ConditionFalse, "", "Not Ready"))
+	if _, updateErr := h.groups.Update(groupCpy); updateErr != nil {
+		return group, updateErr
+	}
+	return group, err
+}
+
+func IsBackupGroupReady(group *harvesterv1.VirtualMachineBackupGroup) bool {
+	return group.Status != nil && group.Status.ReadyToUse != nil && *group.Status.ReadyToUse
+}
+
+func updateBackupGroupCondition(group *harvesterv1.VirtualMachineBackupGroup, c harvesterv1.Condition) {
+	group.Status.Conditions = updateCondition(group.Status.Conditions, c)
+}
+
+func getGroupMemberBackupName(groupName, vmName string) string {
+	return wranglername.SafeConcatName(groupName, vmName)
+}
+
+func isBackupGroupMember(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	return vmBackup.Labels[util.LabelVMBackupGroup] != ""
+}
+
+// getBackupGroupMembers returns all the vm backups of the group of the vm backup,
+// it returns nil if some of them are not created yet.
+func (h *Handler) getBackupGroupMembers(vmBackup *harvesterv1.VirtualMachineBackup) ([]*harvesterv1.VirtualMachineBackup, error) {
+	group, err := h.vmBackupGroupCache.Get(vmBackup.Namespace, vmBackup.Labels[util.LabelVMBackupGroup])
+	if apierrors.IsNotFound(err) {
+		// the label is set by hand, the vm backup has no one to wait for
+		return []*harvesterv1.VirtualMachineBackup{vmBackup}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if group.Status == nil {
+		return nil, nil
+	}
+
+	members := make([]*harvesterv1.VirtualMachineBackup, 0, len(group.Status.Members))
+	for _, member := range group.Status.Members {
+		memberBackup, err := h.vmBackupCache.Get(vmBackup.Namespace, member.VMBackupName)
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		members = append(members, memberBackup)
+	}
+	return members, nil
+}
+
+// isBackupGroupQuiesced returns true if all the VMs of the group of the vm backup are quiesced,
+// or have fallen back to crash consistent.
+func (h *Handler) isBackupGroupQuiesced(vmBackup *harvesterv1.VirtualMachineBackup) (bool, error) {
+	members, err := h.getBackupGroupMembers(vmBackup)
+	if err != nil || members == nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.Status == nil || member.Status.Consistency == "" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// areBackupGroupSnapshotsTaken returns true if the volume snapshots of the vm backup are taken,
+// and so are those of the other vm backups of its group.
+func (h *Handler) areBackupGroupSnapshotsTaken(vmBackup *harvesterv1.VirtualMachineBackup) (bool, error) {
+	if !isBackupGroupMember(vmBackup) {
+		return areVolumeSnapshotsTaken(vmBackup), nil
+	}
+
+	members, err := h.getBackupGroupMembers(vmBackup)
+	if err != nil || members == nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.Status == nil || !areVolumeSnapshotsTaken(member) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
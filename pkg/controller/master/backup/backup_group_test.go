@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestGetGroupVMNames(t *testing.T) {
+	newVM := func(name string, labels map[string]string) *kubevirtv1.VirtualMachine {
+		return &kubevirtv1.VirtualMachine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		newVM("db", map[string]string{"app": "shop"}),
+		newVM("web", map[string]string{"app": "shop"}),
+		newVM("cache", nil),
+	)
+	h := &GroupHandler{
+		vmCache: fakeclients.VirtualMachineCache(client.KubevirtV1().VirtualMachines),
+	}
+
+	var testCases = []struct {
+		name        string
+		spec        harvesterv1.VirtualMachineBackupGroupSpec
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			name:     "selector",
+			spec:     harvesterv1.VirtualMachineBackupGroupSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}}},
+			expected: []string{"db", "web"},
+		},
+		{
+			name: "selector and names are merged",
+			spec: harvesterv1.VirtualMachineBackupGroupSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}},
+				VMNames:  []string{"web", "cache"},
+			},
+			expected: []string{"cache", "db", "web"},
+		},
+		{
+			name:        "missing vm",
+			spec:        harvesterv1.VirtualMachineBackupGroupSpec{VMNames: []string{"db", "mail"}},
+			expectedErr: true,
+		},
+		{
+			name:        "no vm selected",
+			spec:        harvesterv1.VirtualMachineBackupGroupSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "blog"}}},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		group := &harvesterv1.VirtualMachineBackupGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+			Spec:       tc.spec,
+		}
+		vmNames, err := h.getGroupVMNames(group)
+		if tc.expectedErr {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, vmNames, tc.name)
+	}
+}
+
+func TestGetGuestFrozenTime(t *testing.T) {
+	newMember := func(name string, frozen corev1.ConditionStatus, at string) *harvesterv1.VirtualMachineBackup {
+		return &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{util.LabelVMBackupGroup: "shop"}},
+			Status: &harvesterv1.VirtualMachineBackupStatus{
+				Conditions: []harvesterv1.Condition{
+					{Type: harvesterv1.BackupConditionGuestFrozen, Status: frozen, LastTransitionTime: at},
+				},
+			},
+		}
+	}
+	db := newMember("shop-db", corev1.ConditionTrue, "2022-01-01T00:00:10Z")
+	web := newMember("shop-web", corev1.ConditionTrue, "2022-01-01T00:00:05Z")
+	cache := newMember("shop-cache", corev1.ConditionFalse, "2022-01-01T00:00:01Z")
+	group := &harvesterv1.VirtualMachineBackupGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+		Status: &harvesterv1.VirtualMachineBackupGroupStatus{
+			Members: []harvesterv1.BackupGroupMember{
+				{VMName: "cache", VMBackupName: cache.Name},
+				{VMName: "db", VMBackupName: db.Name},
+				{VMName: "web", VMBackupName: web.Name},
+			},
+		},
+	}
+	client := fake.NewSimpleClientset(db, web, cache, group)
+	h := &Handler{
+		vmBackupCache:      fakeclients.VMBackupCache(client.HarvesterhciV1beta1().VirtualMachineBackups),
+		vmBackupGroupCache: fakeclients.VMBackupGroupCache(client.HarvesterhciV1beta1().VirtualMachineBackupGroups),
+	}
+
+	// the members share the deadline of the first frozen guest, the thawed members are ignored
+	frozenAt, err := h.getGuestFrozenTime(db)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-01-01T00:00:05Z", frozenAt.Format(time.RFC3339))
+
+	// a vm backup out of any group has its own deadline
+	single := newMember("single", corev1.ConditionTrue, "2022-01-01T00:00:10Z")
+	single.Labels = nil
+	frozenAt, err = h.getGuestFrozenTime(single)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-01-01T00:00:10Z", frozenAt.Format(time.RFC3339))
+}
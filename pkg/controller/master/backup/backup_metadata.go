@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"time"
 
@@ -19,6 +19,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/utils/pointer"
 
 	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
@@ -27,7 +28,6 @@ import (
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
 	"github.com/harvester/harvester/pkg/settings"
-	"github.com/harvester/harvester/pkg/util"
 )
 
 const (
@@ -42,6 +42,8 @@ type VirtualMachineBackupMetadata struct {
 	VMSourceSpec  *harvesterv1.VirtualMachineSourceSpec `json:"vmSourceSpec,omitempty"`
 	VolumeBackups []harvesterv1.VolumeBackup            `json:"volumeBackups,omitempty"`
 	SecretBackups []harvesterv1.SecretBackup            `json:"secretBackups,omitempty"`
+	CreationTime  *metav1.Time                          `json:"creationTime,omitempty"`
+	SourceCluster string                                `json:"sourceCluster,omitempty"`
 }
 
 type MetadataHandler struct {
@@ -54,6 +56,8 @@ type MetadataHandler struct {
 	settings             ctlharvesterv1.SettingController
 	vmBackups            ctlharvesterv1.VirtualMachineBackupClient
 	vmBackupCache        ctlharvesterv1.VirtualMachineBackupCache
+	backupTargets        ctlharvesterv1.NamedBackupTargetController
+	backupTargetCache    ctlharvesterv1.NamedBackupTargetCache
 }
 
 // RegisterBackupMetadata register the setting controller and resync vm backup metadata when backup target change
@@ -64,6 +68,7 @@ func RegisterBackupMetadata(ctx context.Context, management *config.Management,
 	secrets := management.CoreFactory.Core().V1().Secret()
 	longhornSettings := management.LonghornFactory.Longhorn().V1beta1().Setting()
 	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget()
 
 	backupMetadataController := &MetadataHandler{
 		ctx:                  ctx,
@@ -75,9 +80,12 @@ func RegisterBackupMetadata(ctx context.Context, management *config.Management,
 		settings:             settings,
 		vmBackups:            vmBackups,
 		vmBackupCache:        vmBackups.Cache(),
+		backupTargets:        backupTargets,
+		backupTargetCache:    backupTargets.Cache(),
 	}
 
 	settings.OnChange(ctx, backupMetadataControllerName, backupMetadataController.OnBackupTargetChange)
+	backupTargets.OnChange(ctx, backupMetadataControllerName, backupMetadataController.OnNamedBackupTargetChange)
 	return nil
 }
 
@@ -100,7 +108,7 @@ func (h *MetadataHandler) OnBackupTargetChange(key string, setting *harvesterv1.
 		return nil, nil
 	}
 
-	if err = h.syncVMBackup(target); err != nil {
+	if err = h.syncVMBackup(harvesterv1.DefaultBackupTargetName); err != nil {
 		logrus.Errorf("can't sync vm backup metadata, target:%s:%s, err: %v", target.Type, target.Endpoint, err)
 		h.settings.EnqueueAfter(setting.Name, 5*time.Second)
 		return nil, nil
@@ -109,45 +117,52 @@ func (h *MetadataHandler) OnBackupTargetChange(key string, setting *harvesterv1.
 	return nil, nil
 }
 
-func (h *MetadataHandler) syncVMBackup(target *settings.BackupTarget) error {
-	if target.Type == settings.S3BackupType {
-		secret, err := h.secretCache.Get(util.LonghornSystemNamespaceName, util.BackupTargetSecretName)
-		if err != nil {
-			return err
-		}
-		os.Setenv(AWSAccessKey, string(secret.Data[AWSAccessKey]))
-		os.Setenv(AWSSecretKey, string(secret.Data[AWSSecretKey]))
-		os.Setenv(AWSEndpoints, string(secret.Data[AWSEndpoints]))
-		os.Setenv(AWSCERT, string(secret.Data[AWSCERT]))
+// OnNamedBackupTargetChange resync vm metadata files of the NamedBackupTarget, and reports whether it's available
+func (h *MetadataHandler) OnNamedBackupTargetChange(key string, backupTarget *harvesterv1.NamedBackupTarget) (*harvesterv1.NamedBackupTarget, error) {
+	if backupTarget == nil || backupTarget.DeletionTimestamp != nil {
+		return nil, nil
 	}
 
-	endpoint := ConstructEndpoint(target)
-	bsDriver, err := backupstore.GetBackupStoreDriver(endpoint)
+	logrus.Debugf("backup target %s change, sync vm backup:%s:%s", backupTarget.Name, backupTarget.Spec.Type, backupTarget.Spec.Endpoint)
+
+	err := h.syncVMBackup(backupTarget.Name)
+	if err != nil {
+		logrus.Errorf("can't sync vm backup metadata, target:%s, err: %v", backupTarget.Name, err)
+		h.backupTargets.EnqueueAfter(backupTarget.Name, 5*time.Second)
+	}
+
+	if harvesterv1.BackupTargetAvailable.MatchesError(backupTarget, "", err) {
+		return backupTarget, nil
+	}
+	backupTargetCpy := backupTarget.DeepCopy()
+	harvesterv1.BackupTargetAvailable.SetError(backupTargetCpy, "", err)
+	return h.backupTargets.Update(backupTargetCpy)
+}
+
+func (h *MetadataHandler) syncVMBackup(backupTargetName string) error {
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backupTargetName)
 	if err != nil {
 		return err
 	}
 
-	fileNames, err := bsDriver.List(filepath.Join(metadataFolderPath))
+	backupMetadatas, loadErrs, err := listBackupMetadataInBackupTarget(target)
 	if err != nil {
 		return err
 	}
 
-	for _, fileName := range fileNames {
-		backupMetadata, err := loadBackupMetadataInBackupTarget(filepath.Join(metadataFolderPath, fileName), bsDriver)
-		if err != nil {
-			return err
-		}
+	for _, backupMetadata := range backupMetadatas {
 		if backupMetadata.Namespace == "" {
 			backupMetadata.Namespace = metav1.NamespaceDefault
 		}
-		if err := h.createVMBackupIfNotExist(*backupMetadata, target); err != nil {
+		if err := h.createVMBackupIfNotExist(*backupMetadata, backupTargetName, target); err != nil {
 			return err
 		}
 	}
-	return nil
+	// the other vm backups are synced, report the metadata files which can't be loaded
+	return utilerrors.NewAggregate(loadErrs)
 }
 
-func (h *MetadataHandler) createVMBackupIfNotExist(backupMetadata VirtualMachineBackupMetadata, target *settings.BackupTarget) error {
+func (h *MetadataHandler) createVMBackupIfNotExist(backupMetadata VirtualMachineBackupMetadata, backupTargetName string, target *settings.BackupTarget) error {
 	if _, err := h.vmBackupCache.Get(backupMetadata.Namespace, backupMetadata.Name); err != nil && !apierrors.IsNotFound(err) {
 		return err
 	} else if err == nil {
@@ -157,6 +172,12 @@ func (h *MetadataHandler) createVMBackupIfNotExist(backupMetadata VirtualMachine
 	if err := h.createNamespaceIfNotExist(backupMetadata.Namespace); err != nil {
 		return err
 	}
+
+	// the backup target name in the metadata may be different from the one in this cluster
+	backupMetadata.BackupSpec.BackupTargetName = ""
+	if !isDefaultBackupTargetName(backupTargetName) {
+		backupMetadata.BackupSpec.BackupTargetName = backupTargetName
+	}
 	if _, err := h.vmBackups.Create(&harvesterv1.VirtualMachineBackup{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backupMetadata.Name,
@@ -166,6 +187,7 @@ func (h *MetadataHandler) createVMBackupIfNotExist(backupMetadata VirtualMachine
 		Status: &harvesterv1.VirtualMachineBackupStatus{
 			ReadyToUse: pointer.BoolPtr(false),
 			BackupTarget: &harvesterv1.BackupTarget{
+				Name:         backupTargetName,
 				Endpoint:     target.Endpoint,
 				BucketName:   target.BucketName,
 				BucketRegion: target.BucketRegion,
@@ -173,6 +195,8 @@ func (h *MetadataHandler) createVMBackupIfNotExist(backupMetadata VirtualMachine
 			SourceSpec:    backupMetadata.VMSourceSpec,
 			VolumeBackups: backupMetadata.VolumeBackups,
 			SecretBackups: backupMetadata.SecretBackups,
+			CreationTime:  backupMetadata.CreationTime,
+			SourceCluster: backupMetadata.SourceCluster,
 		},
 	}); err != nil {
 		return err
@@ -195,7 +219,53 @@ func (h *MetadataHandler) createNamespaceIfNotExist(namespace string) error {
 	return err
 }
 
-func loadBackupMetadataInBackupTarget(filePath string, bsDriver backupstore.BackupStoreDriver) (*VirtualMachineBackupMetadata, error) {
+// ListBackupMetadata returns the vm backup metadata stored in the backup target,
+// the backup-target setting is used if the name is empty or default.
+// The metadata files which can't be loaded are skipped with a logged error.
+func ListBackupMetadata(backupTargetCache ctlharvesterv1.NamedBackupTargetCache, secretCache ctlcorev1.SecretCache, backupTargetName string) ([]*VirtualMachineBackupMetadata, error) {
+	target, err := getBackupTarget(backupTargetCache, secretCache, backupTargetName)
+	if err != nil {
+		return nil, err
+	}
+	if target.IsDefaultBackupTarget() {
+		return nil, fmt.Errorf("backup target %s is not set", backupTargetName)
+	}
+	backupMetadatas, _, err := listBackupMetadataInBackupTarget(target)
+	return backupMetadatas, err
+}
+
+// listBackupMetadataInBackupTarget returns the vm backup metadata stored in the backup target. A metadata file which
+// can't be loaded, e.g. it's corrupted or encrypted with another key, is skipped and its error is returned in loadErrs.
+func listBackupMetadataInBackupTarget(target *settings.BackupTarget) (backupMetadatas []*VirtualMachineBackupMetadata, loadErrs []error, err error) {
+	err = withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		fileNames, err := bsDriver.List(filepath.Join(metadataFolderPath))
+		if err != nil {
+			return err
+		}
+
+		for _, fileName := range fileNames {
+			backupMetadata, err := loadBackupMetadataInBackupTarget(filepath.Join(metadataFolderPath, fileName), bsDriver, target.EncryptionKey)
+			if err != nil {
+				logrus.Errorf("skip vm backup metadata %s in backup target %s, err: %v", fileName, target.Endpoint, err)
+				loadErrs = append(loadErrs, err)
+				continue
+			}
+			backupMetadatas = append(backupMetadatas, backupMetadata)
+		}
+		return nil
+	})
+	return backupMetadatas, loadErrs, err
+}
+
+func loadBackupMetadataInBackupTarget(filePath string, bsDriver backupstore.BackupStoreDriver, encryptionKey string) (*VirtualMachineBackupMetadata, error) {
+	content, err := readBackupMetadataFile(filePath, bsDriver)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBackupMetadata(filePath, content, encryptionKey)
+}
+
+func readBackupMetadataFile(filePath string, bsDriver backupstore.BackupStoreDriver) ([]byte, error) {
 	if !bsDriver.FileExists(filePath) {
 		return nil, fmt.Errorf("cannot find %v in backupstore", filePath)
 	}
@@ -206,8 +276,17 @@ func loadBackupMetadataInBackupTarget(filePath string, bsDriver backupstore.Back
 	}
 	defer rc.Close()
 
+	return io.ReadAll(rc)
+}
+
+func decodeBackupMetadata(filePath string, content []byte, encryptionKey string) (*VirtualMachineBackupMetadata, error) {
+	content, err := decryptBackupMetadata(content, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vm backup metadata %s: %w", filePath, err)
+	}
+
 	backupMetadata := &VirtualMachineBackupMetadata{}
-	if err := json.NewDecoder(rc).Decode(backupMetadata); err != nil {
+	if err := json.Unmarshal(content, backupMetadata); err != nil {
 		return nil, err
 	}
 	return backupMetadata, nil
@@ -0,0 +1,221 @@
+package backup
+
+import (
+	"strconv"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// newProgress returns the progress of a volume from the percentage and the data size reported by Longhorn.
+// Longhorn only reports the percentage while the data is transferred, so the processed bytes are estimated from the
+// percentage and the data size until it's complete, e.g. the size of the backup restored is known during a restore.
+func newProgress(percentage int, dataSize int64) *harvesterv1.Progress {
+	if percentage < 0 {
+		percentage = 0
+	} else if percentage > 100 {
+		percentage = 100
+	}
+	progress := &harvesterv1.Progress{
+		Percentage: percentage,
+		TotalBytes: dataSize,
+	}
+	progress.ProcessedBytes = dataSize * int64(percentage) / 100
+	progress.Estimated = percentage < 100 && progress.ProcessedBytes > 0
+	return progress
+}
+
+// sumProgress aggregates the progress of the volumes,
+// the percentage is weighted by the data size if the sizes of all the volumes are known.
+func sumProgress(progresses []*harvesterv1.Progress) *harvesterv1.Progress {
+	if len(progresses) == 0 {
+		return nil
+	}
+
+	total := &harvesterv1.Progress{}
+	percentages := 0
+	var weightedPercentages int64
+	sizesKnown := true
+	for _, progress := range progresses {
+		total.ProcessedBytes += progress.ProcessedBytes
+		total.Estimated = total.Estimated || progress.Estimated
+		total.TotalBytes += progress.TotalBytes
+		percentages += progress.Percentage
+		weightedPercentages += int64(progress.Percentage) * progress.TotalBytes
+		if progress.TotalBytes == 0 {
+			sizesKnown = false
+		}
+	}
+	if sizesKnown {
+		total.Percentage = int(weightedPercentages / total.TotalBytes)
+	} else {
+		total.Percentage = percentages / len(progresses)
+	}
+	return total
+}
+
+func getLHBackupProgress(lhBackup *lhv1beta1.Backup) int {
+	if lhBackup.Status.State == lhv1beta1.BackupStateCompleted {
+		return 100
+	}
+	return lhBackup.Status.Progress
+}
+
+// getLHBackupSize returns the size of the data in the Longhorn backup, it's 0 until Longhorn reports it
+func getLHBackupSize(lhBackup *lhv1beta1.Backup) int64 {
+	size, err := strconv.ParseInt(lhBackup.Status.Size, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// updateBackupProgress sets the overall progress of the vm backup, the volume backups ready to use are complete
+func updateBackupProgress(vmBackup *harvesterv1.VirtualMachineBackup) {
+	progresses := make([]*harvesterv1.Progress, 0, len(vmBackup.Status.VolumeBackups))
+	for i := range vmBackup.Status.VolumeBackups {
+		volumeBackup := &vmBackup.Status.VolumeBackups[i]
+		var size int64
+		if volumeBackup.Progress != nil {
+			size = volumeBackup.Progress.TotalBytes
+		}
+		if volumeBackup.ReadyToUse != nil && *volumeBackup.ReadyToUse {
+			volumeBackup.Progress = newProgress(100, size)
+		} else if volumeBackup.Progress == nil {
+			volumeBackup.Progress = newProgress(0, size)
+		}
+		progresses = append(progresses, volumeBackup.Progress)
+	}
+	vmBackup.Status.Progress = sumProgress(progresses)
+}
+
+// updateRestoreProgress sets the progress of the volume restores from the Longhorn volumes of the restored PVCs,
+// and the data sizes from the Longhorn backups they're restored from.
+func (h *RestoreHandler) updateRestoreProgress(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) error {
+	progresses := make([]*harvesterv1.Progress, 0, len(vmRestore.Status.VolumeRestores))
+	for i := range vmRestore.Status.VolumeRestores {
+		volumeRestore := &vmRestore.Status.VolumeRestores[i]
+		percentage, err := h.getVolumeRestoreProgress(vmRestore.Namespace, volumeRestore.PersistentVolumeClaim.ObjectMeta.Name)
+		if err != nil {
+			return err
+		}
+		size, err := h.getVolumeRestoreSize(backup, volumeRestore.VolumeBackupName)
+		if err != nil {
+			return err
+		}
+		volumeRestore.Progress = newProgress(percentage, size)
+		progresses = append(progresses, volumeRestore.Progress)
+	}
+	vmRestore.Status.Progress = sumProgress(progresses)
+	return nil
+}
+
+func completeRestoreProgress(vmRestore *harvesterv1.VirtualMachineRestore) {
+	progresses := make([]*harvesterv1.Progress, 0, len(vmRestore.Status.VolumeRestores))
+	for i := range vmRestore.Status.VolumeRestores {
+		volumeRestore := &vmRestore.Status.VolumeRestores[i]
+		var size int64
+		if volumeRestore.Progress != nil {
+			size = volumeRestore.Progress.TotalBytes
+		}
+		volumeRestore.Progress = newProgress(100, size)
+		progresses = append(progresses, volumeRestore.Progress)
+	}
+	vmRestore.Status.Progress = sumProgress(progresses)
+}
+
+// getVolumeRestoreSize returns the data size of the Longhorn backup of the volume backup
+func (h *RestoreHandler) getVolumeRestoreSize(backup *harvesterv1.VirtualMachineBackup, volumeBackupName string) (int64, error) {
+	for _, volumeBackup := range backup.Status.VolumeBackups {
+		if volumeBackup.Name == nil || *volumeBackup.Name != volumeBackupName || volumeBackup.LonghornBackupName == nil {
+			continue
+		}
+		lhBackup, err := h.lhbackupCache.Get(util.LonghornSystemNamespaceName, *volumeBackup.LonghornBackupName)
+		if apierrors.IsNotFound(err) {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		return getLHBackupSize(lhBackup), nil
+	}
+	return 0, nil
+}
+
+// getVolumeRestoreProgress returns the average restore progress of the replicas of the PVC volume
+func (h *RestoreHandler) getVolumeRestoreProgress(namespace, pvcName string) (int, error) {
+	pvc, err := h.pvcCache.Get(namespace, pvcName)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if pvc.Spec.VolumeName == "" {
+		return 0, nil
+	}
+
+	volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if volume.Spec.FromBackup != "" && !volume.Status.RestoreInitiated {
+		return 0, nil
+	}
+	if !volume.Status.RestoreRequired {
+		return 100, nil
+	}
+
+	engines, err := h.engineCache.List(util.LonghornSystemNamespaceName, labels.SelectorFromSet(longhorntypes.GetVolumeLabels(volume.Name)))
+	if err != nil {
+		return 0, err
+	}
+	progress, replicas := 0, 0
+	for _, engine := range engines {
+		for _, restoreStatus := range engine.Status.RestoreStatus {
+			if restoreStatus == nil {
+				continue
+			}
+			progress += restoreStatus.Progress
+			replicas++
+		}
+	}
+	if replicas == 0 {
+		return 0, nil
+	}
+	return progress / replicas, nil
+}
+
+// EngineOnChange enqueues the vmRestore of the restoring Longhorn engine, so that the restore progress is updated
+func (h *RestoreHandler) EngineOnChange(key string, engine *lhv1beta1.Engine) (*lhv1beta1.Engine, error) {
+	if engine == nil || engine.DeletionTimestamp != nil || len(engine.Status.RestoreStatus) == 0 {
+		return nil, nil
+	}
+
+	volume, err := h.volumeCache.Get(engine.Namespace, engine.Spec.VolumeName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if volume.Status.KubernetesStatus.PVCName == "" {
+		return nil, nil
+	}
+
+	pvc, err := h.pvcCache.Get(volume.Status.KubernetesStatus.Namespace, volume.Status.KubernetesStatus.PVCName)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if restoreName, ok := pvc.Annotations[restoreNameAnnotation]; ok {
+		h.restoreController.EnqueueAfter(pvc.Namespace, restoreName, 5*time.Second)
+	}
+	return nil, nil
+}
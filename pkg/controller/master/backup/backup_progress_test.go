@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"testing"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/stretchr/testify/assert"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+func TestSumProgress(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		progresses []*harvesterv1.Progress
+		expected   *harvesterv1.Progress
+	}{
+		{
+			name:     "no volumes",
+			expected: nil,
+		},
+		{
+			name:       "weighted by the volume size",
+			progresses: []*harvesterv1.Progress{newProgress(100, 1000), newProgress(10, 9000)},
+			expected:   &harvesterv1.Progress{Percentage: 19, ProcessedBytes: 1900, TotalBytes: 10000, Estimated: true},
+		},
+		{
+			name:       "volumes without size",
+			progresses: []*harvesterv1.Progress{newProgress(100, 0), newProgress(50, 0)},
+			expected:   &harvesterv1.Progress{Percentage: 75},
+		},
+		{
+			name:       "some volume sizes unknown",
+			progresses: []*harvesterv1.Progress{newProgress(100, 1000), newProgress(20, 0)},
+			expected:   &harvesterv1.Progress{Percentage: 60, ProcessedBytes: 1000, TotalBytes: 1000},
+		},
+		{
+			name:       "out of range percentages",
+			progresses: []*harvesterv1.Progress{newProgress(120, 100), newProgress(-1, 100)},
+			expected:   &harvesterv1.Progress{Percentage: 50, ProcessedBytes: 100, TotalBytes: 200},
+		},
+		{
+			name:       "completed volumes aren't estimated",
+			progresses: []*harvesterv1.Progress{newProgress(100, 1000), newProgress(100, 3000)},
+			expected:   &harvesterv1.Progress{Percentage: 100, ProcessedBytes: 4000, TotalBytes: 4000},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, sumProgress(tc.progresses), tc.name)
+	}
+}
+
+func TestGetLHBackupSize(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		size     string
+		expected int64
+	}{
+		{name: "not reported", size: "", expected: 0},
+		{name: "reported", size: "2147483648", expected: 2147483648},
+		{name: "invalid", size: "2Gi", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		lhBackup := &lhv1beta1.Backup{Status: lhv1beta1.BackupStatus{Size: tc.size}}
+		assert.Equal(t, tc.expected, getLHBackupSize(lhBackup), tc.name)
+	}
+}
@@ -0,0 +1,280 @@
+package backup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	// guestFreezeTimeout is the longest time the guest filesystems are kept frozen,
+	// KubeVirt thaws the guest by itself if it isn't unfrozen in time.
+	guestFreezeTimeout = 60 * time.Second
+	backupHookTimeout  = 30 * time.Second
+	// guestFSFrozen is the FSFreezeStatus of a VMI whose guest filesystems are frozen
+	guestFSFrozen = "frozen"
+
+	guestFreezeFailedEvent = "GuestFreezeFailed"
+	guestThawTimeoutEvent  = "GuestThawTimeout"
+)
+
+// quiesceVM runs the pre hook and freezes the guest filesystems before the volume snapshots are created.
+// The backup falls back to crash consistent if the guest can't be frozen.
+// The freeze is recorded before it's attempted, so that the pre hook and the freeze aren't run again
+// if the result of the freeze fails to be recorded.
+func (h *Handler) quiesceVM(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	vmi, err := h.vmiCache.Get(vmBackup.Namespace, vmBackup.Spec.Source.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// nothing writes to the volumes of a stopped VM
+	if err != nil || !vmi.IsRunning() {
+		return h.setBackupConsistency(vmBackup, "", nil)
+	}
+
+	if getBackupCondition(vmBackup, harvesterv1.BackupConditionGuestFrozen) != nil {
+		return h.setBackupConsistency(vmBackup, h.getInterruptedFreezeResult(vmBackup, vmi), vmi)
+	}
+
+	vmBackupCpy := vmBackup.DeepCopy()
+	updateBackupCondition(vmBackupCpy, newGuestFrozenCondition(corev1.ConditionUnknown, "Freezing guest filesystems"))
+	if vmBackup, err = h.vmBackups.Update(vmBackupCpy); err != nil {
+		return err
+	}
+	return h.setBackupConsistency(vmBackup, h.freezeGuest(vmBackup, vmi), vmi)
+}
+
+// getInterruptedFreezeResult returns the reason if the guest filesystems aren't frozen by the freeze which was
+// attempted before. The guest is thawed and the post hook is run if it isn't frozen, since the pre hook may have run.
+func (h *Handler) getInterruptedFreezeResult(vmBackup *harvesterv1.VirtualMachineBackup, vmi *kubevirtv1.VirtualMachineInstance) string {
+	if vmi.Status.FSFreezeStatus == guestFSFrozen {
+		return ""
+	}
+	h.thawGuest(vmBackup)
+	return "guest filesystems are not frozen after the freeze was interrupted"
+}
+
+// setBackupConsistency records the consistency of the vm backup, it's crash consistent if the message of the
+// freeze failure isn't empty. vmi is nil if the VM isn't running.
+func (h *Handler) setBackupConsistency(vmBackup *harvesterv1.VirtualMachineBackup, message string, vmi *kubevirtv1.VirtualMachineInstance) error {
+	if message != "" {
+		logrus.Infof("vmBackup %s/%s falls back to crash consistent: %s", vmBackup.Namespace, vmBackup.Name, message)
+		h.recorder.Event(vmBackup, corev1.EventTypeWarning, guestFreezeFailedEvent, message)
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.vmBackups.Get(vmBackup.Namespace, vmBackup.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current.UID != vmBackup.UID || current.Status == nil {
+			return nil
+		}
+		vmBackupCpy := current.DeepCopy()
+		vmBackupCpy.Status.Consistency = harvesterv1.BackupConsistencyApplication
+		if message != "" {
+			vmBackupCpy.Status.Consistency = harvesterv1.BackupConsistencyCrash
+			vmBackupCpy.Status.ConsistencyMessage = message
+			updateBackupCondition(vmBackupCpy, newGuestFrozenCondition(corev1.ConditionFalse, message))
+		} else if vmi != nil {
+			updateBackupCondition(vmBackupCpy, newGuestFrozenCondition(corev1.ConditionTrue, "Guest filesystems are frozen"))
+		}
+		_, err = h.vmBackups.Update(vmBackupCpy)
+		return err
+	})
+}
+
+// quiesceBackupGroup quiesces the VMs of a backup group in one pass once all the member backups are initialized,
+// so the guests are frozen together and share the same deadline to be thawed.
+// Only the first member of the group does it, the other members wait for it.
+func (h *Handler) quiesceBackupGroup(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	members, err := h.getBackupGroupMembers(vmBackup)
+	if err != nil {
+		return err
+	}
+	if members == nil || members[0].Name != vmBackup.Name {
+		h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, backupGroupPollInterval)
+		return nil
+	}
+	for _, member := range members {
+		if isBackupMissingStatus(member) {
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, backupGroupPollInterval)
+			return nil
+		}
+	}
+
+	for _, member := range members {
+		if member.Status.SourceUID == nil || member.Status.Consistency != "" {
+			continue
+		}
+		if err := h.quiesceVM(member); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freezeGuest returns the reason if the guest filesystems are not frozen
+func (h *Handler) freezeGuest(vmBackup *harvesterv1.VirtualMachineBackup, vmi *kubevirtv1.VirtualMachineInstance) string {
+	// the hooks are run by the guest agent as well
+	if !util.IsGuestAgentConnected(vmi) {
+		return "guest agent is not connected"
+	}
+
+	annotations := vmBackup.Status.SourceSpec.ObjectMeta.Annotations
+	if err := h.runBackupHook(vmi, annotations[util.AnnotationBackupPreHook]); err != nil {
+		return fmt.Sprintf("failed to run pre hook: %v", err)
+	}
+
+	err := h.freezeVMI(vmi)
+	if err == nil {
+		return ""
+	}
+
+	// the pre hook has run, so run the post hook right away
+	if err := h.runBackupHook(vmi, annotations[util.AnnotationBackupPostHook]); err != nil {
+		logrus.Warnf("failed to run post hook of vmBackup %s/%s: %v", vmBackup.Namespace, vmBackup.Name, err)
+	}
+	return fmt.Sprintf("failed to freeze guest filesystems: %v", err)
+}
+
+func (h *Handler) freezeVMI(vmi *kubevirtv1.VirtualMachineInstance) error {
+	body, err := json.Marshal(&kubevirtv1.FreezeUnfreezeTimeout{
+		UnfreezeTimeout: &metav1.Duration{Duration: guestFreezeTimeout},
+	})
+	if err != nil {
+		return err
+	}
+	return h.restClient.Put().
+		Namespace(vmi.Namespace).Resource("virtualmachineinstances").SubResource("freeze").Name(vmi.Name).
+		Body(body).Do(h.ctx).Error()
+}
+
+// reconcileGuestThaw thaws the guest filesystems once all the volume snapshots are taken,
+// or when they can't be taken within guestFreezeTimeout. It returns true if the guest is thawed.
+// The guests of a backup group are thawed after the volume snapshots of all the VMs of the group are taken.
+func (h *Handler) reconcileGuestThaw(vmBackup *harvesterv1.VirtualMachineBackup) (bool, error) {
+	vmBackupCpy := vmBackup.DeepCopy()
+	taken, err := h.areBackupGroupSnapshotsTaken(vmBackup)
+	if err != nil {
+		return false, err
+	}
+	if !taken {
+		frozenAt, err := h.getGuestFrozenTime(vmBackup)
+		if err != nil {
+			return false, err
+		}
+		if remaining := time.Until(frozenAt.Add(guestFreezeTimeout)); remaining > 0 {
+			if isBackupGroupMember(vmBackup) && remaining > backupGroupPollInterval {
+				remaining = backupGroupPollInterval
+			}
+			h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, remaining)
+			return false, nil
+		}
+
+		message := "timed out waiting for the volume snapshots while the guest filesystems were frozen"
+		h.recorder.Event(vmBackup, corev1.EventTypeWarning, guestThawTimeoutEvent, message)
+		vmBackupCpy.Status.Consistency = harvesterv1.BackupConsistencyCrash
+		vmBackupCpy.Status.ConsistencyMessage = message
+	}
+
+	h.thawGuest(vmBackup)
+	updateBackupCondition(vmBackupCpy, newGuestFrozenCondition(corev1.ConditionFalse, "Guest filesystems are thawed"))
+	if _, err := h.vmBackups.Update(vmBackupCpy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getGuestFrozenTime returns when the guest of the vm backup is frozen. For a backup group it's when the first guest
+// of the group is frozen, so all the members time out together and none of them is thawed by KubeVirt
+// while the volume snapshots of the others are taken.
+func (h *Handler) getGuestFrozenTime(vmBackup *harvesterv1.VirtualMachineBackup) (time.Time, error) {
+	frozenAt, err := time.Parse(time.RFC3339, getBackupCondition(vmBackup, harvesterv1.BackupConditionGuestFrozen).LastTransitionTime)
+	if err != nil || !isBackupGroupMember(vmBackup) {
+		return frozenAt, err
+	}
+
+	members, err := h.getBackupGroupMembers(vmBackup)
+	if err != nil {
+		return frozenAt, err
+	}
+	for _, member := range members {
+		if member.Status == nil || !isGuestFrozen(member) {
+			continue
+		}
+		memberFrozenAt, err := time.Parse(time.RFC3339, getBackupCondition(member, harvesterv1.BackupConditionGuestFrozen).LastTransitionTime)
+		if err != nil {
+			return frozenAt, err
+		}
+		if memberFrozenAt.Before(frozenAt) {
+			frozenAt = memberFrozenAt
+		}
+	}
+	return frozenAt, nil
+}
+
+// thawGuest unfreezes the guest filesystems and runs the post hook.
+// Errors are only logged since KubeVirt thaws the guest by itself after guestFreezeTimeout.
+func (h *Handler) thawGuest(vmBackup *harvesterv1.VirtualMachineBackup) {
+	vmi, err := h.vmiCache.Get(vmBackup.Namespace, vmBackup.Spec.Source.Name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Warnf("failed to get vmi of vmBackup %s/%s: %v", vmBackup.Namespace, vmBackup.Name, err)
+		}
+		return
+	}
+
+	if err := h.restClient.Put().
+		Namespace(vmi.Namespace).Resource("virtualmachineinstances").SubResource("unfreeze").Name(vmi.Name).
+		Do(h.ctx).Error(); err != nil {
+		logrus.Warnf("failed to unfreeze vmi %s/%s: %v", vmi.Namespace, vmi.Name, err)
+	}
+
+	if vmBackup.Status.SourceSpec == nil {
+		return
+	}
+	if err := h.runBackupHook(vmi, vmBackup.Status.SourceSpec.ObjectMeta.Annotations[util.AnnotationBackupPostHook]); err != nil {
+		logrus.Warnf("failed to run post hook of vmBackup %s/%s: %v", vmBackup.Namespace, vmBackup.Name, err)
+	}
+}
+
+// runBackupHook runs the hook command in the guest through the guest agent
+func (h *Handler) runBackupHook(vmi *kubevirtv1.VirtualMachineInstance, hook string) error {
+	if hook == "" {
+		return nil
+	}
+
+	var command []string
+	if err := json.Unmarshal([]byte(hook), &command); err != nil || len(command) == 0 {
+		return fmt.Errorf("hook %q is not a JSON array of the command and its arguments", hook)
+	}
+
+	pod, err := util.GetLauncherPod(h.podCache, vmi)
+	if err != nil {
+		return err
+	}
+
+	status, err := util.GuestExec(h.ctx, h.restConfig, h.clientSet, pod, vmi, command[0], command[1:], backupHookTimeout)
+	if err != nil {
+		return err
+	}
+	if status.ExitCode != 0 {
+		stderr, _ := base64.StdEncoding.DecodeString(status.ErrData)
+		return fmt.Errorf("command %v exited with %d, stderr: %s", command, status.ExitCode, strings.TrimSpace(string(stderr)))
+	}
+	logrus.Debugf("backup hook %v of vmi %s/%s exited with 0", command, vmi.Namespace, vmi.Name)
+	return nil
+}
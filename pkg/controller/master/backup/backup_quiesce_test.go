@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/scheme"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func newQuiesceTestBackup(name string, annotations map[string]string) *harvesterv1.VirtualMachineBackup {
+	sourceUID := types.UID("vm-uid")
+	return &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)},
+		Spec:       harvesterv1.VirtualMachineBackupSpec{Source: corev1.TypedLocalObjectReference{Name: "vm"}},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			SourceUID:  &sourceUID,
+			SourceSpec: &harvesterv1.VirtualMachineSourceSpec{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}},
+		},
+	}
+}
+
+func TestQuiesceVM(t *testing.T) {
+	var freezes, unfreezes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/unfreeze"):
+			unfreezes++
+		case strings.HasSuffix(r.URL.Path, "/freeze"):
+			freezes++
+		}
+	}))
+	defer server.Close()
+	restClient, err := rest.RESTClientFor(&rest.Config{
+		Host:    server.URL,
+		APIPath: "/apis",
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &k8sschema.GroupVersion{Group: kubevirtv1.SubresourceGroupName, Version: kubevirtv1.ApiLatestVersion},
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+	})
+	assert.Nil(t, err)
+
+	vmi := &kubevirtv1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm", UID: "vmi-uid"},
+		Status: kubevirtv1.VirtualMachineInstanceStatus{
+			Phase: kubevirtv1.Running,
+			Conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+				{Type: kubevirtv1.VirtualMachineInstanceAgentConnected, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	hookBackup := newQuiesceTestBackup("hook", map[string]string{util.AnnotationBackupPreHook: `["fsfreeze-prepare"]`})
+	clientSet := fake.NewSimpleClientset(vmi, newQuiesceTestBackup("frozen", nil), newQuiesceTestBackup("interrupted-frozen", nil),
+		newQuiesceTestBackup("interrupted-thawed", nil), hookBackup)
+	// the results of the freezes fail to be recorded while failRecording is true
+	failRecording := false
+	clientSet.PrependReactor("update", "virtualmachinebackups", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		vmBackup := action.(k8stesting.UpdateAction).GetObject().(*harvesterv1.VirtualMachineBackup)
+		if failRecording && vmBackup.Status.Consistency != "" {
+			return true, nil, errors.New("etcdserver: request timed out")
+		}
+		return false, nil, nil
+	})
+	h := &Handler{
+		ctx:        context.TODO(),
+		vmBackups:  fakeclients.VMBackupClient(clientSet.HarvesterhciV1beta1().VirtualMachineBackups),
+		vmiCache:   fakeclients.VirtualMachineInstanceCache(clientSet.KubevirtV1().VirtualMachineInstances),
+		podCache:   fakeclients.PodCache(k8sfake.NewSimpleClientset().CoreV1().Pods),
+		recorder:   record.NewFakeRecorder(10),
+		restClient: restClient,
+	}
+	getBackup := func(name string) *harvesterv1.VirtualMachineBackup {
+		vmBackup, err := clientSet.HarvesterhciV1beta1().VirtualMachineBackups("default").Get(context.TODO(), name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		return vmBackup
+	}
+	setFreezeStatus := func(status string) {
+		vmi.Status.FSFreezeStatus = status
+		_, err := clientSet.KubevirtV1().VirtualMachineInstances("default").Update(context.TODO(), vmi, metav1.UpdateOptions{})
+		assert.Nil(t, err)
+	}
+
+	// the guest is frozen and the backup is application consistent
+	assert.Nil(t, h.quiesceVM(getBackup("frozen")))
+	assert.Equal(t, 1, freezes)
+	vmBackup := getBackup("frozen")
+	assert.Equal(t, harvesterv1.BackupConsistencyApplication, vmBackup.Status.Consistency)
+	assert.True(t, isGuestFrozen(vmBackup))
+
+	// the guest isn't frozen again if the result of the freeze isn't recorded
+	failRecording = true
+	assert.NotNil(t, h.quiesceVM(getBackup("interrupted-frozen")))
+	failRecording = false
+	assert.Equal(t, 2, freezes)
+	vmBackup = getBackup("interrupted-frozen")
+	assert.Equal(t, harvesterv1.BackupConsistency(""), vmBackup.Status.Consistency)
+	setFreezeStatus(guestFSFrozen)
+	assert.Nil(t, h.quiesceVM(vmBackup))
+	assert.Equal(t, 2, freezes)
+	vmBackup = getBackup("interrupted-frozen")
+	assert.Equal(t, harvesterv1.BackupConsistencyApplication, vmBackup.Status.Consistency)
+	assert.True(t, isGuestFrozen(vmBackup))
+
+	// the guest is thawed and the backup falls back to crash consistent if the guest isn't frozen by the interrupted freeze
+	failRecording = true
+	assert.NotNil(t, h.quiesceVM(getBackup("interrupted-thawed")))
+	failRecording = false
+	assert.Equal(t, 3, freezes)
+	setFreezeStatus("")
+	assert.Nil(t, h.quiesceVM(getBackup("interrupted-thawed")))
+	assert.Equal(t, 3, freezes)
+	assert.Equal(t, 1, unfreezes)
+	vmBackup = getBackup("interrupted-thawed")
+	assert.Equal(t, harvesterv1.BackupConsistencyCrash, vmBackup.Status.Consistency)
+	assert.False(t, isGuestFrozen(vmBackup))
+
+	// the guest isn't frozen if the pre hook fails
+	assert.Nil(t, h.quiesceVM(getBackup("hook")))
+	assert.Equal(t, 3, freezes)
+	vmBackup = getBackup("hook")
+	assert.Equal(t, harvesterv1.BackupConsistencyCrash, vmBackup.Status.Consistency)
+	assert.Contains(t, vmBackup.Status.ConsistencyMessage, "failed to run pre hook")
+	assert.False(t, isGuestFrozen(vmBackup))
+}
@@ -0,0 +1,287 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	wranglername "github.com/rancher/wrangler/pkg/name"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	backupScheduleControllerName             = "harvester-vm-backup-schedule-controller"
+	backupScheduleBackupTargetControllerName = "harvester-vm-backup-schedule-backup-target-controller"
+	backupScheduleBackupControllerName       = "harvester-vm-backup-schedule-backup-controller"
+	backupScheduleTimeFormat                 = "20060102150405"
+	backupScheduleInvalidCronReason          = "InvalidCron"
+	backupScheduleSuspendedReason            = "Suspended"
+	backupScheduleBackupTargetNotSetReason   = "BackupTargetNotConfigured"
+	backupScheduleCreateBackupFailedReason   = "CreateBackupFailed"
+)
+
+// RegisterBackupSchedule register the vmBackupSchedule controller, which creates vm backups periodically
+// and prunes the backups exceeding the retention policy
+func RegisterBackupSchedule(ctx context.Context, management *config.Management, opts config.Options) error {
+	schedules := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackupSchedule()
+	vmBackups := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup()
+	settings := management.HarvesterFactory.Harvesterhci().V1beta1().Setting()
+	vms := management.VirtFactory.Kubevirt().V1().VirtualMachine()
+
+	scheduleController := &ScheduleHandler{
+		schedules:          schedules,
+		scheduleController: schedules,
+		scheduleCache:      schedules.Cache(),
+		vmBackups:          vmBackups,
+		vmBackupCache:      vmBackups.Cache(),
+		vmCache:            vms.Cache(),
+		settingCache:       settings.Cache(),
+	}
+
+	schedules.OnChange(ctx, backupScheduleControllerName, scheduleController.OnScheduleChange)
+	settings.OnChange(ctx, backupScheduleBackupTargetControllerName, scheduleController.OnBackupTargetChange)
+	vmBackups.OnChange(ctx, backupScheduleBackupControllerName, scheduleController.OnBackupChange)
+	return nil
+}
+
+type ScheduleHandler struct {
+	schedules          ctlharvesterv1.VirtualMachineBackupScheduleClient
+	scheduleController ctlharvesterv1.VirtualMachineBackupScheduleController
+	scheduleCache      ctlharvesterv1.VirtualMachineBackupScheduleCache
+	vmBackups          ctlharvesterv1.VirtualMachineBackupClient
+	vmBackupCache      ctlharvesterv1.VirtualMachineBackupCache
+	vmCache            ctlkubevirtv1.VirtualMachineCache
+	settingCache       ctlharvesterv1.SettingCache
+}
+
+// OnScheduleChange creates the due vm backups and prunes the expired ones, then requeue the schedule until the next run
+func (h *ScheduleHandler) OnScheduleChange(key string, schedule *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	if schedule == nil || schedule.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	scheduleCpy := schedule.DeepCopy()
+	if scheduleCpy.Status == nil {
+		scheduleCpy.Status = &harvesterv1.VirtualMachineBackupScheduleStatus{}
+	}
+
+	sched, err := cron.ParseStandard(schedule.Spec.Cron)
+	if err != nil {
+		scheduleCpy.Status.NextScheduleTime = nil
+		updateScheduleCondition(scheduleCpy, newReadyCondition(corev1.ConditionFalse, backupScheduleInvalidCronReason, err.Error()))
+		return h.updateStatus(schedule, scheduleCpy)
+	}
+
+	if err := h.pruneBackups(schedule); err != nil {
+		return schedule, err
+	}
+
+	if schedule.Spec.Suspend {
+		scheduleCpy.Status.NextScheduleTime = nil
+		updateScheduleCondition(scheduleCpy, newReadyCondition(corev1.ConditionFalse, backupScheduleSuspendedReason, "schedule is suspended"))
+		return h.updateStatus(schedule, scheduleCpy)
+	}
+
+	now := currentTime().Time
+	lastScheduleTime := schedule.CreationTimestamp.Time
+	if schedule.Status != nil && schedule.Status.LastScheduleTime != nil {
+		lastScheduleTime = schedule.Status.LastScheduleTime.Time
+	}
+
+	if due := util.GetMostRecentScheduleTime(sched, lastScheduleTime, now); !due.IsZero() {
+		if schedule.Spec.Type != harvesterv1.Snapshot && isDefaultBackupTargetName(schedule.Spec.BackupTargetName) {
+			if err := h.checkBackupTargetConfigured(); err != nil {
+				// the schedule is enqueued again when the backup target setting changes
+				scheduleCpy.Status.NextScheduleTime = nil
+				updateScheduleCondition(scheduleCpy, newReadyCondition(corev1.ConditionFalse, backupScheduleBackupTargetNotSetReason, err.Error()))
+				return h.updateStatus(schedule, scheduleCpy)
+			}
+		}
+
+		if err := h.createScheduledBackups(schedule, due); err != nil {
+			updateScheduleCondition(scheduleCpy, newReadyCondition(corev1.ConditionFalse, backupScheduleCreateBackupFailedReason, err.Error()))
+			if _, updateErr := h.updateStatus(schedule, scheduleCpy); updateErr != nil {
+				return schedule, updateErr
+			}
+			return schedule, err
+		}
+		scheduleCpy.Status.LastScheduleTime = &metav1.Time{Time: due}
+	}
+
+	next := sched.Next(now)
+	if next.IsZero() {
+		scheduleCpy.Status.NextScheduleTime = nil
+	} else {
+		scheduleCpy.Status.NextScheduleTime = &metav1.Time{Time: next}
+		h.scheduleController.EnqueueAfter(schedule.Namespace, schedule.Name, next.Sub(now))
+	}
+	updateScheduleCondition(scheduleCpy, newReadyCondition(corev1.ConditionTrue, "", ""))
+	return h.updateStatus(schedule, scheduleCpy)
+}
+
+// OnBackupTargetChange enqueues all the schedules when the backup target setting changes,
+// so the schedules blocked by an unset backup target can continue
+func (h *ScheduleHandler) OnBackupTargetChange(key string, setting *harvesterv1.Setting) (*harvesterv1.Setting, error) {
+	if setting == nil || setting.DeletionTimestamp != nil || setting.Name != settings.BackupTargetSettingName {
+		return setting, nil
+	}
+
+	schedules, err := h.scheduleCache.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return setting, err
+	}
+	for _, schedule := range schedules {
+		h.scheduleController.Enqueue(schedule.Namespace, schedule.Name)
+	}
+	return setting, nil
+}
+
+// OnBackupChange enqueues the schedule of a scheduled vm backup, so the retention policy is applied once the backup is ready
+func (h *ScheduleHandler) OnBackupChange(key string, vmBackup *harvesterv1.VirtualMachineBackup) (*harvesterv1.VirtualMachineBackup, error) {
+	if vmBackup == nil || vmBackup.DeletionTimestamp != nil {
+		return vmBackup, nil
+	}
+
+	if scheduleName := vmBackup.Labels[util.LabelVMBackupSchedule]; scheduleName != "" && IsBackupReady(vmBackup) {
+		h.scheduleController.Enqueue(vmBackup.Namespace, scheduleName)
+	}
+	return vmBackup, nil
+}
+
+func (h *ScheduleHandler) createScheduledBackups(schedule *harvesterv1.VirtualMachineBackupSchedule, scheduleTime time.Time) error {
+	selector, err := metav1.LabelSelectorAsSelector(&schedule.Spec.Selector)
+	if err != nil {
+		return err
+	}
+
+	vms, err := h.vmCache.List(schedule.Namespace, selector)
+	if err != nil {
+		return err
+	}
+
+	apiGroup := kubevirtv1.SchemeGroupVersion.Group
+	for _, vm := range vms {
+		if vm.DeletionTimestamp != nil {
+			continue
+		}
+		// The backups are not owned by the schedule, so they are kept when the schedule is removed.
+		backup := &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getScheduledBackupName(schedule.Name, vm.Name, scheduleTime),
+				Namespace: schedule.Namespace,
+				Labels: map[string]string{
+					util.LabelVMBackupSchedule: schedule.Name,
+				},
+			},
+			Spec: harvesterv1.VirtualMachineBackupSpec{
+				Source: corev1.TypedLocalObjectReference{
+					APIGroup: &apiGroup,
+					Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+					Name:     vm.Name,
+				},
+				Type:             schedule.Spec.Type,
+				BackupTargetName: schedule.Spec.BackupTargetName,
+			},
+		}
+		if _, err := h.vmBackups.Create(backup); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create scheduled backup for VM %s/%s, error: %w", vm.Namespace, vm.Name, err)
+		}
+	}
+	return nil
+}
+
+// pruneBackups deletes the ready backups of the schedule exceeding the retained count or the max age of each VM
+func (h *ScheduleHandler) pruneBackups(schedule *harvesterv1.VirtualMachineBackupSchedule) error {
+	if schedule.Spec.Retain == 0 && schedule.Spec.MaxAge == nil {
+		return nil
+	}
+
+	vmBackups, err := h.vmBackupCache.List(schedule.Namespace, labels.SelectorFromSet(map[string]string{
+		util.LabelVMBackupSchedule: schedule.Name,
+	}))
+	if err != nil {
+		return err
+	}
+
+	for _, vmBackup := range getExpiredScheduledBackups(schedule, vmBackups, currentTime().Time) {
+		logrus.Infof("deleting scheduled vm backup %s/%s of schedule %s", vmBackup.Namespace, vmBackup.Name, schedule.Name)
+		if err := h.vmBackups.Delete(vmBackup.Namespace, vmBackup.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			logrus.Errorf("failed to delete scheduled vm backup %s/%s, error: %v", vmBackup.Namespace, vmBackup.Name, err)
+		}
+	}
+	return nil
+}
+
+func (h *ScheduleHandler) checkBackupTargetConfigured() error {
+	targetSetting, err := h.settingCache.Get(settings.BackupTargetSettingName)
+	if err != nil {
+		return err
+	}
+	target, err := settings.DecodeBackupTarget(targetSetting.Value)
+	if err != nil {
+		return err
+	}
+	if target.IsDefaultBackupTarget() {
+		return fmt.Errorf("backup target is not set")
+	}
+	return nil
+}
+
+func (h *ScheduleHandler) updateStatus(schedule, scheduleCpy *harvesterv1.VirtualMachineBackupSchedule) (*harvesterv1.VirtualMachineBackupSchedule, error) {
+	if reflect.DeepEqual(schedule.Status, scheduleCpy.Status) {
+		return schedule, nil
+	}
+	return h.schedules.Update(scheduleCpy)
+}
+
+func updateScheduleCondition(schedule *harvesterv1.VirtualMachineBackupSchedule, c harvesterv1.Condition) {
+	schedule.Status.Conditions = updateCondition(schedule.Status.Conditions, c)
+}
+
+func getScheduledBackupName(scheduleName, vmName string, scheduleTime time.Time) string {
+	return wranglername.SafeConcatName(scheduleName, vmName, scheduleTime.UTC().Format(backupScheduleTimeFormat))
+}
+
+// getExpiredScheduledBackups groups the ready backups by the source VM, and returns the backups
+// beyond the retained count or older than the max age. Backups in progress or failed are left untouched.
+func getExpiredScheduledBackups(schedule *harvesterv1.VirtualMachineBackupSchedule, vmBackups []*harvesterv1.VirtualMachineBackup, now time.Time) []*harvesterv1.VirtualMachineBackup {
+	backupsByVM := map[string][]*harvesterv1.VirtualMachineBackup{}
+	for _, vmBackup := range vmBackups {
+		if vmBackup.DeletionTimestamp != nil || !IsBackupReady(vmBackup) {
+			continue
+		}
+		backupsByVM[vmBackup.Spec.Source.Name] = append(backupsByVM[vmBackup.Spec.Source.Name], vmBackup)
+	}
+
+	var expired []*harvesterv1.VirtualMachineBackup
+	for _, backups := range backupsByVM {
+		sort.Slice(backups, func(i, j int) bool {
+			return backups[j].CreationTimestamp.Before(&backups[i].CreationTimestamp)
+		})
+		for i, vmBackup := range backups {
+			if schedule.Spec.Retain > 0 && i >= schedule.Spec.Retain {
+				expired = append(expired, vmBackup)
+				continue
+			}
+			if schedule.Spec.MaxAge != nil && now.Sub(vmBackup.CreationTimestamp.Time) > schedule.Spec.MaxAge.Duration {
+				expired = append(expired, vmBackup)
+			}
+		}
+	}
+	return expired
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+func TestGetExpiredScheduledBackups(t *testing.T) {
+	now := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	newBackup := func(name, vm string, age time.Duration, ready bool) *harvesterv1.VirtualMachineBackup {
+		return &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: harvesterv1.VirtualMachineBackupSpec{
+				Source: corev1.TypedLocalObjectReference{Name: vm},
+			},
+			Status: &harvesterv1.VirtualMachineBackupStatus{
+				ReadyToUse: pointer.BoolPtr(ready),
+			},
+		}
+	}
+	backups := []*harvesterv1.VirtualMachineBackup{
+		newBackup("vm1-1", "vm1", 1*24*time.Hour, true),
+		newBackup("vm1-2", "vm1", 2*24*time.Hour, true),
+		newBackup("vm1-3", "vm1", 3*24*time.Hour, true),
+		newBackup("vm1-4", "vm1", 4*24*time.Hour, false),
+		newBackup("vm2-1", "vm2", 5*24*time.Hour, true),
+	}
+
+	var testCases = []struct {
+		name     string
+		spec     harvesterv1.VirtualMachineBackupScheduleSpec
+		expected []string
+	}{
+		{
+			name:     "no retention policy",
+			spec:     harvesterv1.VirtualMachineBackupScheduleSpec{},
+			expected: nil,
+		},
+		{
+			name:     "retain per vm, skip not ready backups",
+			spec:     harvesterv1.VirtualMachineBackupScheduleSpec{Retain: 2},
+			expected: []string{"vm1-3"},
+		},
+		{
+			name:     "max age",
+			spec:     harvesterv1.VirtualMachineBackupScheduleSpec{MaxAge: &metav1.Duration{Duration: 60 * time.Hour}},
+			expected: []string{"vm1-3", "vm2-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		schedule := &harvesterv1.VirtualMachineBackupSchedule{Spec: tc.spec}
+		var names []string
+		for _, b := range getExpiredScheduledBackups(schedule, backups, now) {
+			names = append(names, b.Name)
+		}
+		assert.ElementsMatch(t, tc.expected, names, tc.name)
+	}
+}
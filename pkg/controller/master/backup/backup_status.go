@@ -39,8 +39,16 @@ func (h *Handler) updateConditions(vmBackup *harvesterv1.VirtualMachineBackup) e
 		}
 	}
 
+	// the vm backup to a named backup target is ready after its Longhorn backups are copied to it
+	if ready && isLonghornBackupCopyPending(vmBackup) {
+		ready = false
+	}
+
 	if ready && (vmBackupCpy.Status.ReadyToUse == nil || !*vmBackupCpy.Status.ReadyToUse) {
-		vmBackupCpy.Status.CreationTime = currentTime()
+		// the vm backups synced from the backup target keep their creation time
+		if vmBackupCpy.Status.CreationTime == nil {
+			vmBackupCpy.Status.CreationTime = currentTime()
+		}
 		vmBackupCpy.Status.Error = nil
 		updateBackupCondition(vmBackupCpy, newProgressingCondition(corev1.ConditionFalse, "", "Operation complete"))
 		updateBackupCondition(vmBackupCpy, newReadyCondition(corev1.ConditionTrue, "", "Operation complete"))
@@ -57,6 +65,7 @@ func (h *Handler) updateConditions(vmBackup *harvesterv1.VirtualMachineBackup) e
 	}
 
 	vmBackupCpy.Status.ReadyToUse = pointer.BoolPtr(ready)
+	updateBackupProgress(vmBackupCpy)
 
 	if !reflect.DeepEqual(vmBackup.Status, vmBackupCpy.Status) {
 		if _, err := h.vmBackups.Update(vmBackupCpy); err != nil {
@@ -180,8 +189,10 @@ func (h *Handler) OnLHBackupChanged(key string, lhBackup *lhv1beta1.Backup) (*lh
 		for i, volumeBackup := range vmBackupCpy.Status.VolumeBackups {
 			if *volumeBackup.Name == snapshot.Name {
 				vmBackupCpy.Status.VolumeBackups[i].LonghornBackupName = pointer.StringPtr(lhBackup.Name)
+				vmBackupCpy.Status.VolumeBackups[i].Progress = newProgress(getLHBackupProgress(lhBackup), getLHBackupSize(lhBackup))
 			}
 		}
+		updateBackupProgress(vmBackupCpy)
 
 		if !reflect.DeepEqual(vmBackup.Status, vmBackupCpy.Status) {
 			if _, err := h.vmBackups.Update(vmBackupCpy); err != nil {
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/longhorn/backupstore"
 	longhornv1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
@@ -35,8 +38,15 @@ const (
 	AWSEndpoints       = "AWS_ENDPOINTS"
 	AWSCERT            = "AWS_CERT"
 	VirtualHostedStyle = "VIRTUAL_HOSTED_STYLE"
+
+	// EncryptionKey is the key of the backup target encryption secret, it's the same as the Longhorn volume encryption secret
+	EncryptionKey = "CRYPTO_KEY_VALUE"
 )
 
+// backupStoreMutex serializes the backupstore operations, the S3 driver reads the credentials from
+// the environment variables on every request, which are different for each backup target.
+var backupStoreMutex sync.Mutex
+
 // RegisterBackupTarget register the setting controller and reconsile longhorn setting when backup target changed
 func RegisterBackupTarget(ctx context.Context, management *config.Management, opts config.Options) error {
 	settings := management.HarvesterFactory.Harvesterhci().V1beta1().Setting()
@@ -306,3 +316,136 @@ func ConstructEndpoint(target *settings.BackupTarget) string {
 		return target.Endpoint
 	}
 }
+
+// getBackupTarget returns the backup target with its S3 credentials by name,
+// the backup-target setting is used if the name is empty or default.
+func getBackupTarget(backupTargetCache ctlharvesterv1.NamedBackupTargetCache, secretCache ctlcorev1.SecretCache, name string) (*settings.BackupTarget, error) {
+	if name == "" || name == harvesterv1.DefaultBackupTargetName {
+		target, err := settings.DecodeBackupTarget(settings.BackupTargetSet.Get())
+		if err != nil {
+			return nil, err
+		}
+		if target.Type == settings.S3BackupType {
+			secret, err := secretCache.Get(util.LonghornSystemNamespaceName, util.BackupTargetSecretName)
+			if err != nil {
+				return nil, err
+			}
+			setS3Credentials(target, secret)
+		}
+		if err := setEncryptionKey(target, secretCache); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	backupTarget, err := backupTargetCache.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &settings.BackupTarget{
+		Type:               settings.TargetType(backupTarget.Spec.Type),
+		Endpoint:           backupTarget.Spec.Endpoint,
+		BucketName:         backupTarget.Spec.BucketName,
+		BucketRegion:       backupTarget.Spec.BucketRegion,
+		VirtualHostedStyle: backupTarget.Spec.VirtualHostedStyle,
+		EncryptionSecret:   backupTarget.Spec.EncryptionSecret,
+	}
+	if target.Type == settings.S3BackupType && backupTarget.Spec.CredentialSecret != nil {
+		secret, err := secretCache.Get(backupTarget.Spec.CredentialSecret.Namespace, backupTarget.Spec.CredentialSecret.Name)
+		if err != nil {
+			return nil, err
+		}
+		setS3Credentials(target, secret)
+	}
+	if err := setEncryptionKey(target, secretCache); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func setS3Credentials(target *settings.BackupTarget, secret *corev1.Secret) {
+	target.AccessKeyID = string(secret.Data[AWSAccessKey])
+	target.SecretAccessKey = string(secret.Data[AWSSecretKey])
+	target.Cert = string(secret.Data[AWSCERT])
+}
+
+func setEncryptionKey(target *settings.BackupTarget, secretCache ctlcorev1.SecretCache) error {
+	if target.EncryptionSecret == nil {
+		return nil
+	}
+	secret, err := secretCache.Get(target.EncryptionSecret.Namespace, target.EncryptionSecret.Name)
+	if err != nil {
+		return fmt.Errorf("can't get encryption secret %s/%s: %w", target.EncryptionSecret.Namespace, target.EncryptionSecret.Name, err)
+	}
+	if len(secret.Data[EncryptionKey]) == 0 {
+		return fmt.Errorf("encryption secret %s/%s has no %s", secret.Namespace, secret.Name, EncryptionKey)
+	}
+	target.EncryptionKey = string(secret.Data[EncryptionKey])
+	return nil
+}
+
+// withBackupStoreDriver runs fn with the backupstore driver of the target
+func withBackupStoreDriver(target *settings.BackupTarget, fn func(bsDriver backupstore.BackupStoreDriver) error) error {
+	backupStoreMutex.Lock()
+	defer backupStoreMutex.Unlock()
+
+	setBackupStoreEnv(target)
+	bsDriver, err := backupstore.GetBackupStoreDriver(ConstructEndpoint(target))
+	if err != nil {
+		return err
+	}
+	return fn(bsDriver)
+}
+
+// CheckBackupStore connects to the backup target with the backupstore mutex held, it's for the callers out of
+// the backup controllers, so that they don't change the S3 credentials while the controllers access other targets.
+func CheckBackupStore(target *settings.BackupTarget) error {
+	return withBackupStoreDriver(target, func(_ backupstore.BackupStoreDriver) error {
+		return nil
+	})
+}
+
+// setBackupStoreEnv sets the S3 credentials of the target, it must be called with the backupstore mutex held.
+func setBackupStoreEnv(target *settings.BackupTarget) {
+	if target.Type != settings.S3BackupType {
+		return
+	}
+	os.Setenv(AWSAccessKey, target.AccessKeyID)
+	os.Setenv(AWSSecretKey, target.SecretAccessKey)
+	os.Setenv(AWSEndpoints, target.Endpoint)
+	os.Setenv(AWSCERT, target.Cert)
+	if target.VirtualHostedStyle {
+		os.Setenv(VirtualHostedStyle, strconv.FormatBool(target.VirtualHostedStyle))
+	} else {
+		os.Unsetenv(VirtualHostedStyle)
+	}
+}
+
+// backupStore is the backupstore driver of a target opened once, for the long operations like copying backups
+// which would otherwise reopen the driver for every file.
+type backupStore struct {
+	target   *settings.BackupTarget
+	bsDriver backupstore.BackupStoreDriver
+}
+
+func openBackupStore(target *settings.BackupTarget) (*backupStore, error) {
+	store := &backupStore{target: target}
+	if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		store.bsDriver = bsDriver
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// use runs fn with the opened driver, the driver still reads the S3 credentials from the environment variables,
+// so they're set again with the backupstore mutex held.
+func (s *backupStore) use(fn func(bsDriver backupstore.BackupStoreDriver) error) error {
+	backupStoreMutex.Lock()
+	defer backupStoreMutex.Unlock()
+
+	setBackupStoreEnv(s.target)
+	return fn(s.bsDriver)
+}
@@ -0,0 +1,310 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/longhorn/backupstore"
+	lhutil "github.com/longhorn/longhorn-manager/util"
+	"github.com/rancher/wrangler/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	// verifyRestoreTimeout is the longest time to wait for the test restore to boot and connect the guest agent
+	verifyRestoreTimeout = 30 * time.Minute
+	verifyPollInterval   = 10 * time.Second
+
+	verifyReasonRestoring = "TestRestoring"
+	verifyReasonSucceeded = "Succeeded"
+	verifyReasonFailed    = "Failed"
+
+	backupVerifiedEvent     = "BackupVerified"
+	backupVerifyFailedEvent = "BackupVerifyFailed"
+)
+
+func isBackupVerifyRequested(vmBackup *harvesterv1.VirtualMachineBackup) bool {
+	_, ok := vmBackup.Annotations[util.AnnotationBackupVerifyRequest]
+	return ok
+}
+
+// reconcileBackupVerify checks the VM backup metadata and the Longhorn volume backups in the backup target,
+// and the volume snapshots of the volumes of the other CSI drivers,
+// then restores the VM in the sandbox namespace if requested and waits for its guest agent.
+func (h *Handler) reconcileBackupVerify(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	sandboxNamespace := vmBackup.Annotations[util.AnnotationBackupVerifyRequest]
+	if sandboxNamespace == "" {
+		return h.completeBackupVerify(vmBackup, "", h.verifyBackupInTarget(vmBackup))
+	}
+
+	restoreName := getVerifyRestoreName(vmBackup)
+	restore, err := h.vmRestoreCache.Get(sandboxNamespace, restoreName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if apierrors.IsNotFound(err) {
+		if err := h.verifyBackupInTarget(vmBackup); err != nil {
+			return h.completeBackupVerify(vmBackup, "", err)
+		}
+		if err := h.createVerifyRestore(vmBackup, sandboxNamespace, restoreName); err != nil {
+			return h.completeBackupVerify(vmBackup, "", fmt.Errorf("failed to create test restore: %w", err))
+		}
+		h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, verifyPollInterval)
+		return h.updateVerifyProgress(vmBackup, verifyReasonRestoring, fmt.Sprintf("Restoring test VM %s/%s", sandboxNamespace, restoreName))
+	}
+
+	if time.Since(restore.CreationTimestamp.Time) > verifyRestoreTimeout {
+		return h.completeBackupVerify(vmBackup, sandboxNamespace,
+			fmt.Errorf("test VM %s/%s didn't boot with a connected guest agent within %v", sandboxNamespace, restoreName, verifyRestoreTimeout))
+	}
+
+	vmi, err := h.vmiCache.Get(sandboxNamespace, restoreName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && vmi.IsRunning() && util.IsGuestAgentConnected(vmi) {
+		return h.completeBackupVerify(vmBackup, sandboxNamespace, nil)
+	}
+
+	h.vmBackupController.EnqueueAfter(vmBackup.Namespace, vmBackup.Name, verifyPollInterval)
+	return nil
+}
+
+// verifyBackupInTarget returns an error if the VM backup can't be restored from the backup target
+func (h *Handler) verifyBackupInTarget(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	if vmBackup.Spec.Type != harvesterv1.Backup || vmBackup.Status.BackupTarget == nil {
+		return fmt.Errorf("only backups stored in a backup target can be verified")
+	}
+
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, vmBackup.Spec.BackupTargetName)
+	if err != nil {
+		return err
+	}
+	if target.IsDefaultBackupTarget() {
+		return fmt.Errorf("backup target is not set")
+	}
+	if !IsBackupTargetSame(vmBackup.Status.BackupTarget, target) {
+		return fmt.Errorf("backup target has been changed since the backup was created")
+	}
+
+	expectedChecksum, err := getBackupMetadataChecksum(newVMBackupMetadata(vmBackup.DeepCopy()))
+	if err != nil {
+		return err
+	}
+
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		metadataPath := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(vmBackup.Namespace, vmBackup.Name))
+		remoteVMBackupMetadata, err := loadBackupMetadataInBackupTarget(metadataPath, bsDriver, target.EncryptionKey)
+		if err != nil {
+			return err
+		}
+		checksum, err := getBackupMetadataChecksum(remoteVMBackupMetadata)
+		if err != nil {
+			return err
+		}
+		if checksum != expectedChecksum {
+			return fmt.Errorf("checksum %s of vm backup metadata %s doesn't match the expected %s", checksum, metadataPath, expectedChecksum)
+		}
+
+		for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+			if !isLonghornVolumeBackup(volumeBackup) {
+				if err := h.verifyVolumeSnapshot(vmBackup, volumeBackup); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := h.verifyVolumeBackup(target, volumeBackup); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// verifyVolumeSnapshot checks the volume snapshot of a volume which isn't provisioned by Longhorn. Its data is stored
+// by the CSI driver with the backup VolumeSnapshotClass instead of the backup target, so the volume snapshot and its
+// content must be ready and refer to a snapshot of the CSI driver.
+func (h *Handler) verifyVolumeSnapshot(vmBackup *harvesterv1.VirtualMachineBackup, volumeBackup harvesterv1.VolumeBackup) error {
+	if volumeBackup.Name == nil {
+		return fmt.Errorf("volume backup %s has no volume snapshot", volumeBackup.VolumeName)
+	}
+	snapshot, err := h.snapshotCache.Get(vmBackup.Namespace, *volumeBackup.Name)
+	if err != nil {
+		return fmt.Errorf("can't get volume snapshot %s/%s of volume %s: %w", vmBackup.Namespace, *volumeBackup.Name, volumeBackup.VolumeName, err)
+	}
+	if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse || snapshot.Status.BoundVolumeSnapshotContentName == nil {
+		return fmt.Errorf("volume snapshot %s/%s of volume %s is not ready", vmBackup.Namespace, *volumeBackup.Name, volumeBackup.VolumeName)
+	}
+	content, err := h.snapshotContentCache.Get(*snapshot.Status.BoundVolumeSnapshotContentName)
+	if err != nil {
+		return fmt.Errorf("can't get volume snapshot content %s of volume %s: %w", *snapshot.Status.BoundVolumeSnapshotContentName, volumeBackup.VolumeName, err)
+	}
+	if content.Spec.Driver != volumeBackup.CSIDriverName {
+		return fmt.Errorf("volume snapshot content %s of volume %s is of CSI driver %s instead of %s",
+			content.Name, volumeBackup.VolumeName, content.Spec.Driver, volumeBackup.CSIDriverName)
+	}
+	if content.Status == nil || content.Status.ReadyToUse == nil || !*content.Status.ReadyToUse ||
+		content.Status.SnapshotHandle == nil || *content.Status.SnapshotHandle == "" {
+		return fmt.Errorf("volume snapshot content %s of volume %s has no ready snapshot in CSI driver %s",
+			content.Name, volumeBackup.VolumeName, volumeBackup.CSIDriverName)
+	}
+	return nil
+}
+
+// getVerifiedMessage returns the message of the Verified condition of a verified vm backup
+func getVerifiedMessage(vmBackup *harvesterv1.VirtualMachineBackup, sandboxNamespace string) string {
+	message := "Volume backups and metadata are verified in the backup target"
+	var csiVolumes []string
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if !isLonghornVolumeBackup(volumeBackup) {
+			csiVolumes = append(csiVolumes, volumeBackup.VolumeName)
+		}
+	}
+	if len(csiVolumes) > 0 {
+		message = fmt.Sprintf("%s, volumes %s aren't stored in the backup target and their volume snapshots are verified in the cluster",
+			message, strings.Join(csiVolumes, ", "))
+	}
+	if sandboxNamespace != "" {
+		message = fmt.Sprintf("%s, the test VM restored in namespace %s booted with a connected guest agent", message, sandboxNamespace)
+	}
+	return message
+}
+
+// verifyVolumeBackup checks the Longhorn backup of the volume in the backup target,
+// it must be called in withBackupStoreDriver.
+func (h *Handler) verifyVolumeBackup(target *settings.BackupTarget, volumeBackup harvesterv1.VolumeBackup) error {
+	if volumeBackup.LonghornBackupName == nil {
+		return fmt.Errorf("volume backup %s has no Longhorn backup", volumeBackup.VolumeName)
+	}
+
+	lhBackupName := *volumeBackup.LonghornBackupName
+	backupURL := backupstore.EncodeBackupURL(lhBackupName, volumeBackup.PersistentVolumeClaim.Spec.VolumeName, ConstructEndpoint(target))
+	backupInfo, err := backupstore.InspectBackup(backupURL)
+	if err != nil {
+		return fmt.Errorf("can't inspect Longhorn backup %s of volume %s: %w", lhBackupName, volumeBackup.VolumeName, err)
+	}
+
+	// the volume in the backup target only grows, since it's updated by the latest backup
+	claimSize := volumeBackup.PersistentVolumeClaim.Spec.Resources.Requests.Storage().Value()
+	if backupInfo.VolumeSize < lhutil.RoundUpSize(claimSize) {
+		return fmt.Errorf("size %d of Longhorn backup volume %s is less than the size %d of volume %s",
+			backupInfo.VolumeSize, backupInfo.VolumeName, claimSize, volumeBackup.VolumeName)
+	}
+
+	// the Longhorn backups synced from the backup target may not exist yet
+	lhBackup, err := h.lhbackupCache.Get(util.LonghornSystemNamespaceName, lhBackupName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if lhBackup.Status.Size != "" && lhBackup.Status.Size != strconv.FormatInt(backupInfo.Size, 10) {
+		return fmt.Errorf("size %d of Longhorn backup %s in the backup target doesn't match the recorded size %s",
+			backupInfo.Size, lhBackupName, lhBackup.Status.Size)
+	}
+	return nil
+}
+
+func getBackupMetadataChecksum(vmBackupMetadata *VirtualMachineBackupMetadata) (string, error) {
+	j, err := json.Marshal(vmBackupMetadata)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(j)), nil
+}
+
+func getVerifyRestoreName(vmBackup *harvesterv1.VirtualMachineBackup) string {
+	return name.SafeConcatName(vmBackup.Name, "verify")
+}
+
+func (h *Handler) createVerifyRestore(vmBackup *harvesterv1.VirtualMachineBackup, sandboxNamespace, restoreName string) error {
+	apiGroup := kubevirtv1.SchemeGroupVersion.Group
+	_, err := h.vmRestores.Create(&harvesterv1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreName,
+			Namespace: sandboxNamespace,
+			Annotations: map[string]string{
+				verifyBackupAnnotation: fmt.Sprintf("%s/%s", vmBackup.Namespace, vmBackup.Name),
+			},
+		},
+		Spec: harvesterv1.VirtualMachineRestoreSpec{
+			Target: corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+				Name:     restoreName,
+			},
+			VirtualMachineBackupNamespace: vmBackup.Namespace,
+			VirtualMachineBackupName:      vmBackup.Name,
+			NewVM:                         true,
+			DeletionPolicy:                harvesterv1.VirtualMachineRestoreDelete,
+		},
+	})
+	return err
+}
+
+// isolateVerifyVM detaches the networks of the test VM, so it doesn't conflict with the source VM,
+// and starts it regardless of the run strategy of the source VM. The guest agent doesn't need any network.
+func isolateVerifyVM(vm *kubevirtv1.VirtualMachine) {
+	runStrategy := kubevirtv1.RunStrategyAlways
+	vm.Spec.RunStrategy = &runStrategy
+	vm.Spec.Template.Spec.Networks = nil
+	vm.Spec.Template.Spec.Domain.Devices.Interfaces = nil
+	autoattachPodInterface := false
+	vm.Spec.Template.Spec.Domain.Devices.AutoattachPodInterface = &autoattachPodInterface
+}
+
+// cleanupVerifyRestore deletes the test VM, its restore and restored volumes are garbage collected with it
+func (h *Handler) cleanupVerifyRestore(vmBackup *harvesterv1.VirtualMachineBackup, sandboxNamespace string) error {
+	restoreName := getVerifyRestoreName(vmBackup)
+	propagation := metav1.DeletePropagationBackground
+	if err := h.vms.Delete(sandboxNamespace, restoreName, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	// the restore isn't owned by the VM if it failed before creating the VM
+	if err := h.vmRestores.Delete(sandboxNamespace, restoreName, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) updateVerifyProgress(vmBackup *harvesterv1.VirtualMachineBackup, reason, message string) error {
+	vmBackupCpy := vmBackup.DeepCopy()
+	updateBackupCondition(vmBackupCpy, newVerifiedCondition(corev1.ConditionUnknown, reason, message))
+	_, err := h.vmBackups.Update(vmBackupCpy)
+	return err
+}
+
+// completeBackupVerify records the verification result in the Verified condition and removes the verify request
+func (h *Handler) completeBackupVerify(vmBackup *harvesterv1.VirtualMachineBackup, sandboxNamespace string, verifyErr error) error {
+	if sandboxNamespace != "" {
+		if err := h.cleanupVerifyRestore(vmBackup, sandboxNamespace); err != nil {
+			return err
+		}
+	}
+
+	vmBackupCpy := vmBackup.DeepCopy()
+	delete(vmBackupCpy.Annotations, util.AnnotationBackupVerifyRequest)
+	if verifyErr != nil {
+		h.recorder.Event(vmBackup, corev1.EventTypeWarning, backupVerifyFailedEvent, verifyErr.Error())
+		updateBackupCondition(vmBackupCpy, newVerifiedCondition(corev1.ConditionFalse, verifyReasonFailed, verifyErr.Error()))
+	} else {
+		message := getVerifiedMessage(vmBackup, sandboxNamespace)
+		h.recorder.Event(vmBackup, corev1.EventTypeNormal, backupVerifiedEvent, message)
+		updateBackupCondition(vmBackupCpy, newVerifiedCondition(corev1.ConditionTrue, verifyReasonSucceeded, message))
+	}
+	_, err := h.vmBackups.Update(vmBackupCpy)
+	return err
+}
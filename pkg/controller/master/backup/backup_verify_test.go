@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/longhorn/backupstore"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+const testCSIDriverName = "csi.example.com"
+
+func newVerifyTestBackup() *harvesterv1.VirtualMachineBackup {
+	requests := corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Mi")}
+	return &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "backup",
+			Annotations: map[string]string{util.AnnotationBackupVerifyRequest: ""},
+		},
+		Spec: harvesterv1.VirtualMachineBackupSpec{Type: harvesterv1.Backup, BackupTargetName: "verify"},
+		Status: &harvesterv1.VirtualMachineBackupStatus{
+			ReadyToUse:   pointer.BoolPtr(true),
+			BackupTarget: &harvesterv1.BackupTarget{Endpoint: "fake://verify"},
+			VolumeBackups: []harvesterv1.VolumeBackup{
+				{
+					Name:               pointer.StringPtr("backup-disk-1"),
+					VolumeName:         "disk-1",
+					CSIDriverName:      longhorntypes.LonghornDriverName,
+					LonghornBackupName: pointer.StringPtr("backup-1"),
+					PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+						Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pvc-1", Resources: corev1.ResourceRequirements{Requests: requests}},
+					},
+				},
+				{
+					Name:          pointer.StringPtr("backup-disk-2"),
+					VolumeName:    "disk-2",
+					CSIDriverName: testCSIDriverName,
+					PersistentVolumeClaim: harvesterv1.PersistentVolumeClaimSourceSpec{
+						Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pvc-2", Resources: corev1.ResourceRequirements{Requests: requests}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestReconcileBackupVerify(t *testing.T) {
+	target := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://verify"}
+	backupTarget := &harvesterv1.NamedBackupTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "verify"},
+		Spec:       harvesterv1.NamedBackupTargetSpec{Type: fakeBackupStoreKind, Endpoint: target.Endpoint},
+	}
+	newSnapshot := func(ready bool) *snapshotv1.VolumeSnapshot {
+		return &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "backup-disk-2"},
+			Status: &snapshotv1.VolumeSnapshotStatus{
+				ReadyToUse:                     pointer.BoolPtr(ready),
+				BoundVolumeSnapshotContentName: pointer.StringPtr("snapcontent-2"),
+			},
+		}
+	}
+	snapshotContent := &snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{Name: "snapcontent-2"},
+		Spec:       snapshotv1.VolumeSnapshotContentSpec{Driver: testCSIDriverName},
+		Status:     &snapshotv1.VolumeSnapshotContentStatus{ReadyToUse: pointer.BoolPtr(true), SnapshotHandle: pointer.StringPtr("snap-2")},
+	}
+
+	var testCases = []struct {
+		name             string
+		snapshot         *snapshotv1.VolumeSnapshot
+		noLonghornBackup bool
+		expectedStatus   corev1.ConditionStatus
+		expectedMessage  string
+	}{
+		{
+			name:            "the volume of another CSI driver is verified by its volume snapshot",
+			snapshot:        newSnapshot(true),
+			expectedStatus:  corev1.ConditionTrue,
+			expectedMessage: "volumes disk-2 aren't stored in the backup target",
+		},
+		{
+			name:            "the volume snapshot of the volume of another CSI driver isn't ready",
+			snapshot:        newSnapshot(false),
+			expectedStatus:  corev1.ConditionFalse,
+			expectedMessage: "volume snapshot default/backup-disk-2 of volume disk-2 is not ready",
+		},
+		{
+			name:            "the volume snapshot of the volume of another CSI driver is missing",
+			expectedStatus:  corev1.ConditionFalse,
+			expectedMessage: "can't get volume snapshot default/backup-disk-2",
+		},
+		{
+			name:             "the Longhorn backup is missing in the backup target",
+			snapshot:         newSnapshot(true),
+			noLonghornBackup: true,
+			expectedStatus:   corev1.ConditionFalse,
+			expectedMessage:  "can't inspect Longhorn backup backup-1 of volume disk-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		testBackupStores[target.Endpoint] = &fakeBackupStore{files: map[string][]byte{}}
+		vmBackup := newVerifyTestBackup()
+		clientSet := fake.NewSimpleClientset(backupTarget, vmBackup, snapshotContent)
+		if tc.snapshot != nil {
+			assert.Nil(t, clientSet.Tracker().Add(tc.snapshot), tc.name)
+		}
+		h := &Handler{
+			vmBackups:            fakeclients.VMBackupClient(clientSet.HarvesterhciV1beta1().VirtualMachineBackups),
+			backupTargetCache:    fakeclients.NamedBackupTargetCache(clientSet.HarvesterhciV1beta1().NamedBackupTargets),
+			lhbackupCache:        fakeclients.LonghornBackupCache(clientSet.LonghornV1beta1().Backups),
+			snapshotCache:        fakeclients.VolumeSnapshotCache(clientSet.SnapshotV1beta1().VolumeSnapshots),
+			snapshotContentCache: fakeclients.VolumeSnapshotContentCache(clientSet.SnapshotV1beta1().VolumeSnapshotContents),
+			recorder:             record.NewFakeRecorder(10),
+		}
+
+		assert.Nil(t, h.uploadVMBackupMetadata(vmBackup.DeepCopy()), tc.name)
+		if !tc.noLonghornBackup {
+			writeTestLonghornBackup(t, target, "pvc-1", 10*1024*1024, &backupstore.Backup{
+				Name: "backup-1", VolumeName: "pvc-1", CreatedTime: "2026-10-18T00:00:00Z",
+			}, nil)
+		}
+
+		assert.Nil(t, h.reconcileBackupVerify(vmBackup), tc.name)
+		vmBackup, err := clientSet.HarvesterhciV1beta1().VirtualMachineBackups("default").Get(context.TODO(), "backup", metav1.GetOptions{})
+		assert.Nil(t, err, tc.name)
+		assert.NotContains(t, vmBackup.Annotations, util.AnnotationBackupVerifyRequest, tc.name)
+		c := getBackupCondition(vmBackup, harvesterv1.BackupConditionVerified)
+		if assert.NotNil(t, c, tc.name) {
+			assert.Equal(t, tc.expectedStatus, c.Status, tc.name)
+			assert.Contains(t, c.Message, tc.expectedMessage, tc.name)
+		}
+	}
+	delete(testBackupStores, target.Endpoint)
+}
@@ -0,0 +1,608 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/longhorn/backupstore"
+	"github.com/rancher/wrangler/pkg/condition"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/config"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	imagePublishControllerName      = "harvester-image-publish-controller"
+	imageSubscriptionControllerName = "harvester-image-subscription-controller"
+
+	imageFolderPath       = "harvester/vmimages/"
+	imageMetadataFileName = "metadata.cfg"
+	imageDataFolderName   = "data"
+	// imageDataChunkSize is the size of the data files of a published image,
+	// each file is written to the backup target in a single request.
+	imageDataChunkSize = 64 << 20
+	// imageSubscriptionSyncInterval is the interval to replicate the images published to the subscribed backup targets
+	imageSubscriptionSyncInterval = 5 * time.Minute
+	// imagePublishTimeout bounds the publishing of an image, which reads the whole image data
+	imagePublishTimeout = 6 * time.Hour
+
+	publishedReasonPublishing = "Publishing"
+	publishedReasonPublished  = "Published"
+	publishedReasonFailed     = "PublishFailed"
+)
+
+// VirtualMachineImageMetadata is the metadata of an image published to a backup target,
+// the image data is split into the files in the data folder next to it.
+type VirtualMachineImageMetadata struct {
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description,omitempty"`
+	// Checksum is the SHA-512 checksum of the image data
+	Checksum string `json:"checksum"`
+	// SourceChecksum and SourceChecksumAlgorithm are the spec.checksum of the published image and its algorithm,
+	// they are empty if the image has no checksum or it's converted, then the checksum doesn't match the data.
+	SourceChecksum          string       `json:"sourceChecksum,omitempty"`
+	SourceChecksumAlgorithm string       `json:"sourceChecksumAlgorithm,omitempty"`
+	Size                    int64        `json:"size"`
+	Chunks                  int          `json:"chunks"`
+	CreationTime            *metav1.Time `json:"creationTime,omitempty"`
+	SourceCluster           string       `json:"sourceCluster,omitempty"`
+}
+
+// imagePublishHandler publishes the images with spec.publishTo to the backup targets
+type imagePublishHandler struct {
+	vmImages          ctlharvesterv1.VirtualMachineImageClient
+	backupTargetCache ctlharvesterv1.NamedBackupTargetCache
+	secretCache       ctlcorev1.SecretCache
+	namespaceCache    ctlcorev1.NamespaceCache
+	// dataHTTPClient reads the image data from Longhorn, it has no timeout,
+	// the requests are bounded by the contexts of the background tasks
+	dataHTTPClient http.Client
+	// publishing tracks the images published in the background by the backup targets they're published to
+	publishing util.BackgroundTasks
+}
+
+// imageSubscriptionHandler replicates the images published to the backup targets in the image-subscription setting
+type imageSubscriptionHandler struct {
+	settings          ctlharvesterv1.SettingController
+	vmImages          ctlharvesterv1.VirtualMachineImageClient
+	vmImageCache      ctlharvesterv1.VirtualMachineImageCache
+	namespaceCache    ctlcorev1.NamespaceCache
+	backupTargetCache ctlharvesterv1.NamedBackupTargetCache
+	secretCache       ctlcorev1.SecretCache
+}
+
+// RegisterImageReplication registers the controllers publishing images to backup targets and replicating them from backup targets
+func RegisterImageReplication(ctx context.Context, management *config.Management, opts config.Options) error {
+	vmImages := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage()
+	settings := management.HarvesterFactory.Harvesterhci().V1beta1().Setting()
+	namespaces := management.CoreFactory.Core().V1().Namespace()
+	secrets := management.CoreFactory.Core().V1().Secret()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget()
+
+	publishHandler := &imagePublishHandler{
+		vmImages:          vmImages,
+		backupTargetCache: backupTargets.Cache(),
+		secretCache:       secrets.Cache(),
+		namespaceCache:    namespaces.Cache(),
+	}
+	subscriptionHandler := &imageSubscriptionHandler{
+		settings:          settings,
+		vmImages:          vmImages,
+		vmImageCache:      vmImages.Cache(),
+		namespaceCache:    namespaces.Cache(),
+		backupTargetCache: backupTargets.Cache(),
+		secretCache:       secrets.Cache(),
+	}
+
+	vmImages.OnChange(ctx, imagePublishControllerName, publishHandler.OnImageChanged)
+	vmImages.OnRemove(ctx, imagePublishControllerName, publishHandler.OnImageRemoved)
+	settings.OnChange(ctx, imageSubscriptionControllerName, subscriptionHandler.OnImageSubscriptionChanged)
+	return nil
+}
+
+// OnImageChanged publishes the imported image to the backup target of spec.publishTo in the background,
+// and removes it from the backup target it's published to before when spec.publishTo is changed.
+func (h *imagePublishHandler) OnImageChanged(_ string, image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	if image == nil || image.DeletionTimestamp != nil {
+		return image, nil
+	}
+
+	if image.Status.PublishedTo != "" && image.Status.PublishedTo != image.Spec.PublishTo {
+		if err := h.stopPublishing(image); err != nil {
+			return image, err
+		}
+		if err := h.unpublish(image, image.Status.PublishedTo); err != nil {
+			return image, err
+		}
+		toUpdate := image.DeepCopy()
+		toUpdate.Status.PublishedTo = ""
+		toUpdate.Status.Conditions = removeImageCondition(toUpdate.Status.Conditions, harvesterv1.ImagePublished)
+		return h.vmImages.Update(toUpdate)
+	}
+
+	if image.Spec.PublishTo == "" || !harvesterv1.ImageImported.IsTrue(image) {
+		return image, nil
+	}
+	if harvesterv1.ImagePublished.IsTrue(image) || harvesterv1.ImagePublished.IsFalse(image) {
+		h.publishing.Forget(string(image.UID))
+		return image, nil
+	}
+
+	if image.Status.PublishedTo == "" || harvesterv1.ImagePublished.GetReason(image) != publishedReasonPublishing {
+		toUpdate := image.DeepCopy()
+		toUpdate.Status.PublishedTo = image.Spec.PublishTo
+		harvesterv1.ImagePublished.Unknown(toUpdate)
+		harvesterv1.ImagePublished.Reason(toUpdate, publishedReasonPublishing)
+		harvesterv1.ImagePublished.Message(toUpdate, "")
+		harvesterv1.ImagePublished.LastUpdated(toUpdate, time.Now().Format(time.RFC3339))
+		return h.vmImages.Update(toUpdate)
+	}
+
+	// the image is published again if harvester is restarted during publishing
+	h.publishing.Start(string(image.UID), image.Spec.PublishTo, imagePublishTimeout, func(ctx context.Context) error {
+		err := h.publish(ctx, image)
+		// the publishing is cancelled when the image is removed or published to another backup target
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		if err != nil {
+			logrus.Errorf("failed to publish image %s/%s to backup target %s, err: %v", image.Namespace, image.Name, image.Spec.PublishTo, err)
+		}
+		if updateErr := h.updatePublishedCondition(image, err); updateErr != nil {
+			logrus.Errorf("failed to update published condition of image %s/%s, err: %v", image.Namespace, image.Name, updateErr)
+			return updateErr
+		}
+		return nil
+	})
+	return image, nil
+}
+
+// OnImageRemoved removes the image from the backup target it's published to, so that the other clusters stop replicating it
+func (h *imagePublishHandler) OnImageRemoved(_ string, image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	if image == nil {
+		return image, nil
+	}
+	if err := h.stopPublishing(image); err != nil {
+		return image, err
+	}
+	if image.Status.PublishedTo == "" {
+		return image, nil
+	}
+	return image, h.unpublish(image, image.Status.PublishedTo)
+}
+
+// stopPublishing cancels the publishing of the image in progress, it returns an error to be retried until the
+// publishing stops, so that the data written by it isn't left behind in the backup target.
+func (h *imagePublishHandler) stopPublishing(image *harvesterv1.VirtualMachineImage) error {
+	key := string(image.UID)
+	if h.publishing.IsRunning(key) {
+		h.publishing.Cancel(key)
+		return fmt.Errorf("image %s/%s is being published to backup target %s", image.Namespace, image.Name, image.Status.PublishedTo)
+	}
+	h.publishing.Forget(key)
+	return nil
+}
+
+// publish writes the image data and then the metadata to the backup target, so that the other clusters
+// only replicate the images which are completely published.
+func (h *imagePublishHandler) publish(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, image.Spec.PublishTo)
+	if err != nil {
+		return err
+	}
+	if target.IsDefaultBackupTarget() {
+		return fmt.Errorf("backup target %s is not set", image.Spec.PublishTo)
+	}
+	sourceCluster, err := getSourceCluster(h.namespaceCache)
+	if err != nil {
+		return err
+	}
+
+	folder := getImageFolderPath(image.Namespace, image.Name)
+	existing, err := loadImageMetadata(target, folder)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.SourceCluster != sourceCluster {
+		return fmt.Errorf("image %s/%s is already published to the backup target by another cluster", image.Namespace, image.Name)
+	}
+	if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		return bsDriver.Remove(folder)
+	}); err != nil {
+		return err
+	}
+
+	downloadURL := fmt.Sprintf("%s/backingimages/%s/download", util.LonghornDefaultManagerURL, util.GetBackingImageName(image))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.dataHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to read image data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to read image data: got %d status code from %s", resp.StatusCode, downloadURL)
+	}
+
+	metadata := &VirtualMachineImageMetadata{
+		Name:          image.Name,
+		Namespace:     image.Namespace,
+		DisplayName:   image.Spec.DisplayName,
+		Description:   image.Spec.Description,
+		CreationTime:  &metav1.Time{Time: time.Now()},
+		SourceCluster: sourceCluster,
+	}
+	// the checksums of converted images are of the files before they are converted
+	if image.Spec.Checksum != "" && !util.NeedImageConversion(image.Status.Format) {
+		metadata.SourceChecksum = image.Spec.Checksum
+		metadata.SourceChecksumAlgorithm = image.Spec.ChecksumAlgorithm
+	}
+	return writePublishedImage(target, folder, metadata, resp.Body)
+}
+
+// writePublishedImage writes the image data in chunks and then the metadata to the image folder in the backup target
+func writePublishedImage(target *settings.BackupTarget, folder string, metadata *VirtualMachineImageMetadata, reader io.Reader) error {
+	digest := sha512.New()
+	data := io.TeeReader(reader, digest)
+	buf := make([]byte, imageDataChunkSize)
+	for {
+		n, readErr := io.ReadFull(data, buf)
+		if n > 0 {
+			if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+				return bsDriver.Write(getImageChunkPath(folder, metadata.Chunks), bytes.NewReader(buf[:n]))
+			}); err != nil {
+				return err
+			}
+			metadata.Chunks++
+			metadata.Size += int64(n)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		} else if readErr != nil {
+			return fmt.Errorf("failed to read image data: %w", readErr)
+		}
+	}
+	metadata.Checksum = hex.EncodeToString(digest.Sum(nil))
+
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if content, err = encryptBackupMetadata(content, target.EncryptionKey); err != nil {
+		return err
+	}
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		return bsDriver.Write(filepath.Join(folder, imageMetadataFileName), bytes.NewReader(content))
+	})
+}
+
+// unpublish removes the image published by this cluster from the backup target
+func (h *imagePublishHandler) unpublish(image *harvesterv1.VirtualMachineImage, backupTargetName string) error {
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backupTargetName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if target.IsDefaultBackupTarget() {
+		return nil
+	}
+	sourceCluster, err := getSourceCluster(h.namespaceCache)
+	if err != nil {
+		return err
+	}
+
+	folder := getImageFolderPath(image.Namespace, image.Name)
+	existing, err := loadImageMetadata(target, folder)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.SourceCluster != sourceCluster {
+		return nil
+	}
+	return withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		return bsDriver.Remove(folder)
+	})
+}
+
+func (h *imagePublishHandler) updatePublishedCondition(image *harvesterv1.VirtualMachineImage, publishErr error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.vmImages.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// the image is recreated, deleted or published to another backup target
+		if current.UID != image.UID || current.DeletionTimestamp != nil || current.Status.PublishedTo != image.Spec.PublishTo {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		if publishErr != nil {
+			harvesterv1.ImagePublished.False(toUpdate)
+			harvesterv1.ImagePublished.Reason(toUpdate, publishedReasonFailed)
+			harvesterv1.ImagePublished.Message(toUpdate, publishErr.Error())
+		} else {
+			harvesterv1.ImagePublished.True(toUpdate)
+			harvesterv1.ImagePublished.Reason(toUpdate, publishedReasonPublished)
+			harvesterv1.ImagePublished.Message(toUpdate, "")
+		}
+		harvesterv1.ImagePublished.LastUpdated(toUpdate, time.Now().Format(time.RFC3339))
+		_, err = h.vmImages.Update(toUpdate)
+		return err
+	})
+}
+
+// OnImageSubscriptionChanged replicates the images published by other clusters to the subscribed backup targets,
+// they are synced periodically to replicate the images published later.
+func (h *imageSubscriptionHandler) OnImageSubscriptionChanged(_ string, setting *harvesterv1.Setting) (*harvesterv1.Setting, error) {
+	if setting == nil || setting.DeletionTimestamp != nil || setting.Name != settings.ImageSubscriptionSettingName {
+		return nil, nil
+	}
+
+	subscription, err := settings.DecodeImageSubscription(setting.Value)
+	if err != nil {
+		logrus.Errorf("can't decode setting %s, err: %v", setting.Name, err)
+		return nil, nil
+	}
+	if len(subscription.BackupTargets) == 0 {
+		return nil, nil
+	}
+	for _, backupTargetName := range subscription.BackupTargets {
+		if err := h.syncImages(backupTargetName, subscription.Namespace); err != nil {
+			logrus.Errorf("can't replicate images from backup target %s, err: %v", backupTargetName, err)
+		}
+	}
+	h.settings.EnqueueAfter(setting.Name, imageSubscriptionSyncInterval)
+	return nil, nil
+}
+
+// syncImages replicates the images published to the backup target to the namespace
+func (h *imageSubscriptionHandler) syncImages(backupTargetName, namespace string) error {
+	// the namespace isn't created for the images, so that the subscription can't create namespaces
+	if _, err := h.namespaceCache.Get(namespace); err != nil {
+		return fmt.Errorf("can't get namespace %s to replicate images to, err: %w", namespace, err)
+	}
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backupTargetName)
+	if err != nil {
+		return err
+	}
+	if target.IsDefaultBackupTarget() {
+		return fmt.Errorf("backup target %s is not set", backupTargetName)
+	}
+	sourceCluster, err := getSourceCluster(h.namespaceCache)
+	if err != nil {
+		return err
+	}
+
+	imageMetadatas, err := listImageMetadataInBackupTarget(target)
+	if err != nil {
+		return err
+	}
+	for _, imageMetadata := range imageMetadatas {
+		if imageMetadata.SourceCluster == sourceCluster {
+			continue
+		}
+		if err := h.createImageIfNotExist(imageMetadata, backupTargetName, namespace); err != nil {
+			logrus.Errorf("can't replicate image %s/%s from backup target %s, err: %v", imageMetadata.Namespace, imageMetadata.Name, backupTargetName, err)
+		}
+	}
+	return nil
+}
+
+// createImageIfNotExist creates the image replicated from the backup target in the namespace, unless there is
+// already an image with the same display name and checksum in it. The checksum of the published image is carried
+// over to verify the replicated data, or the SHA-512 checksum of the data is used if it has none.
+func (h *imageSubscriptionHandler) createImageIfNotExist(imageMetadata *VirtualMachineImageMetadata, backupTargetName, namespace string) error {
+	checksum, checksumAlgorithm := imageMetadata.SourceChecksum, imageMetadata.SourceChecksumAlgorithm
+	if checksum == "" {
+		checksum, checksumAlgorithm = imageMetadata.Checksum, harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512
+	}
+
+	// the display names of the images in a namespace are unique
+	images, err := h.vmImageCache.List(namespace, labels.SelectorFromSet(map[string]string{
+		util.LabelImageDisplayName: imageMetadata.DisplayName,
+	}))
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if image.Spec.DisplayName != imageMetadata.DisplayName {
+			continue
+		}
+		if image.Spec.Checksum == checksum {
+			return nil
+		}
+		return fmt.Errorf("image %s/%s with the display name %s has a different checksum", image.Namespace, image.Name, imageMetadata.DisplayName)
+	}
+
+	_, err = h.vmImages.Create(&harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getReplicatedImageName(imageMetadata),
+			Namespace: namespace,
+			Annotations: map[string]string{
+				util.AnnotationPublishedImage: ref.Construct(imageMetadata.Namespace, imageMetadata.Name),
+			},
+		},
+		Spec: harvesterv1.VirtualMachineImageSpec{
+			DisplayName:       imageMetadata.DisplayName,
+			Description:       imageMetadata.Description,
+			SourceType:        harvesterv1.VirtualMachineImageSourceTypeBackupTarget,
+			BackupTargetName:  backupTargetName,
+			Checksum:          checksum,
+			ChecksumAlgorithm: checksumAlgorithm,
+		},
+	})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// getReplicatedImageName returns the name of the image replicated from the published image,
+// it's the same for the image published by a cluster, so that it's not replicated twice.
+func getReplicatedImageName(imageMetadata *VirtualMachineImageMetadata) string {
+	sum := sha256.Sum256([]byte(imageMetadata.SourceCluster + "/" + ref.Construct(imageMetadata.Namespace, imageMetadata.Name)))
+	return "image-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// OpenPublishedImage returns the metadata of the image published to the backup target and a reader of its data
+func OpenPublishedImage(backupTargetCache ctlharvesterv1.NamedBackupTargetCache, secretCache ctlcorev1.SecretCache,
+	backupTargetName, namespace, name string) (*VirtualMachineImageMetadata, io.ReadCloser, error) {
+	target, err := getBackupTarget(backupTargetCache, secretCache, backupTargetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target.IsDefaultBackupTarget() {
+		return nil, nil, fmt.Errorf("backup target %s is not set", backupTargetName)
+	}
+
+	folder := getImageFolderPath(namespace, name)
+	metadata, err := loadImageMetadata(target, folder)
+	if err != nil {
+		return nil, nil, err
+	}
+	if metadata == nil {
+		return nil, nil, fmt.Errorf("image %s/%s is not published to backup target %s", namespace, name, backupTargetName)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		for i := 0; i < metadata.Chunks; i++ {
+			var rc io.ReadCloser
+			if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+				var readErr error
+				rc, readErr = bsDriver.Read(getImageChunkPath(folder, i))
+				return readErr
+			}); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			_, err := io.Copy(pw, rc)
+			rc.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+	return metadata, pr, nil
+}
+
+func listImageMetadataInBackupTarget(target *settings.BackupTarget) ([]*VirtualMachineImageMetadata, error) {
+	// the images are in the folders of their namespaces
+	var folders []string
+	if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		namespaces, err := bsDriver.List(imageFolderPath)
+		if err != nil {
+			return err
+		}
+		for _, namespace := range namespaces {
+			names, err := bsDriver.List(filepath.Join(imageFolderPath, namespace))
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				folders = append(folders, getImageFolderPath(namespace, name))
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	imageMetadatas := make([]*VirtualMachineImageMetadata, 0, len(folders))
+	for _, folder := range folders {
+		imageMetadata, err := loadImageMetadata(target, folder)
+		if err != nil {
+			logrus.Errorf("can't load image metadata in %s, err: %v", folder, err)
+			continue
+		}
+		// the image is being published
+		if imageMetadata == nil {
+			continue
+		}
+		imageMetadatas = append(imageMetadatas, imageMetadata)
+	}
+	return imageMetadatas, nil
+}
+
+// loadImageMetadata returns the metadata in the image folder, it returns nil if the image isn't published
+func loadImageMetadata(target *settings.BackupTarget, folder string) (*VirtualMachineImageMetadata, error) {
+	var content []byte
+	filePath := filepath.Join(folder, imageMetadataFileName)
+	if err := withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		if !bsDriver.FileExists(filePath) {
+			return nil
+		}
+		var err error
+		content, err = readBackupMetadataFile(filePath, bsDriver)
+		return err
+	}); err != nil || content == nil {
+		return nil, err
+	}
+
+	content, err := decryptBackupMetadata(content, target.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image metadata %s: %w", filePath, err)
+	}
+	imageMetadata := &VirtualMachineImageMetadata{}
+	if err := json.Unmarshal(content, imageMetadata); err != nil {
+		return nil, err
+	}
+	return imageMetadata, nil
+}
+
+func getSourceCluster(namespaceCache ctlcorev1.NamespaceCache) (string, error) {
+	// the UID of kube-system namespace identifies the cluster
+	kubeSystem, err := namespaceCache.Get(metav1.NamespaceSystem)
+	if err != nil {
+		return "", err
+	}
+	return string(kubeSystem.UID), nil
+}
+
+// getImageFolderPath returns the folder of the published image, the names can't contain "/",
+// so the folders of different images never collide.
+func getImageFolderPath(namespace, name string) string {
+	return filepath.Join(imageFolderPath, namespace, name)
+}
+
+func getImageChunkPath(folder string, index int) string {
+	return filepath.Join(folder, imageDataFolderName, fmt.Sprintf("%08d", index))
+}
+
+func removeImageCondition(conditions []harvesterv1.Condition, cond condition.Cond) []harvesterv1.Condition {
+	result := make([]harvesterv1.Condition, 0, len(conditions))
+	for _, c := range conditions {
+		if c.Type != cond {
+			result = append(result, c)
+		}
+	}
+	return result
+}
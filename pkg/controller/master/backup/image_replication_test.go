@@ -0,0 +1,270 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/longhorn/backupstore"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+const fakeBackupStoreKind = "fake"
+
+// fakeBackupStore is an in-memory backupstore driver, the paths of the files are the keys of files
+type fakeBackupStore struct {
+	files map[string][]byte
+}
+
+var (
+	testBackupStore = &fakeBackupStore{files: map[string][]byte{}}
+	// testBackupStores are the backupstores of the URLs, the backupstore of fake://offsite is testBackupStore
+	testBackupStores = map[string]*fakeBackupStore{"fake://offsite": testBackupStore}
+)
+
+func init() {
+	if err := backupstore.RegisterDriver(fakeBackupStoreKind, func(destURL string) (backupstore.BackupStoreDriver, error) {
+		// the backup URLs have the backup and volume names in the query
+		destURL = strings.SplitN(destURL, "?", 2)[0]
+		store, ok := testBackupStores[destURL]
+		if !ok {
+			store = &fakeBackupStore{files: map[string][]byte{}}
+			testBackupStores[destURL] = store
+		}
+		return store, nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func (s *fakeBackupStore) Kind() string {
+	return fakeBackupStoreKind
+}
+func (s *fakeBackupStore) GetURL() string {
+	return fakeBackupStoreKind + "://"
+}
+func (s *fakeBackupStore) FileExists(filePath string) bool {
+	_, ok := s.files[filePath]
+	return ok
+}
+func (s *fakeBackupStore) FileSize(filePath string) int64 {
+	return int64(len(s.files[filePath]))
+}
+func (s *fakeBackupStore) FileTime(filePath string) time.Time {
+	return time.Time{}
+}
+func (s *fakeBackupStore) Remove(path string) error {
+	for filePath := range s.files {
+		if filePath == path || strings.HasPrefix(filePath, path+"/") {
+			delete(s.files, filePath)
+		}
+	}
+	return nil
+}
+func (s *fakeBackupStore) Read(src string) (io.ReadCloser, error) {
+	content, ok := s.files[src]
+	if !ok {
+		return nil, fmt.Errorf("%s is not found", src)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+func (s *fakeBackupStore) Write(dst string, rs io.ReadSeeker) error {
+	content, err := io.ReadAll(rs)
+	if err != nil {
+		return err
+	}
+	s.files[dst] = content
+	return nil
+}
+func (s *fakeBackupStore) List(path string) ([]string, error) {
+	path = strings.TrimSuffix(path, "/")
+	names := map[string]struct{}{}
+	for filePath := range s.files {
+		if strings.HasPrefix(filePath, path+"/") {
+			names[strings.SplitN(strings.TrimPrefix(filePath, path+"/"), "/", 2)[0]] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+func (s *fakeBackupStore) Upload(src, dst string) error {
+	return fmt.Errorf("upload is not supported")
+}
+func (s *fakeBackupStore) Download(src, dst string) error {
+	return fmt.Errorf("download is not supported")
+}
+
+func newTestCluster(uid string, objects ...runtime.Object) *k8sfake.Clientset {
+	kubeSystem := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem, UID: types.UID(uid)}}
+	return k8sfake.NewSimpleClientset(append(objects, kubeSystem)...)
+}
+
+func TestPublishAndSubscribeImages(t *testing.T) {
+	testBackupStore.files = map[string][]byte{}
+	backupTarget := &harvesterv1.NamedBackupTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "offsite"},
+		Spec:       harvesterv1.NamedBackupTargetSpec{Type: fakeBackupStoreKind, Endpoint: "fake://offsite"},
+	}
+	target := &settings.BackupTarget{Type: fakeBackupStoreKind, Endpoint: "fake://offsite"}
+
+	// the images of the namespaces and names joined by "-" don't collide
+	ubuntuData := []byte("ubuntu image data")
+	ubuntuChecksum := strings.Repeat("a", 128)
+	assert.Nil(t, writePublishedImage(target, getImageFolderPath("a-b", "c"), &VirtualMachineImageMetadata{
+		Namespace: "a-b", Name: "c", DisplayName: "ubuntu", SourceCluster: "cluster-a",
+		SourceChecksum: ubuntuChecksum, SourceChecksumAlgorithm: harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512,
+	}, bytes.NewReader(ubuntuData)))
+	fedoraData := []byte("fedora image data")
+	assert.Nil(t, writePublishedImage(target, getImageFolderPath("a", "b-c"), &VirtualMachineImageMetadata{
+		Namespace: "a", Name: "b-c", DisplayName: "fedora", SourceCluster: "cluster-a",
+	}, bytes.NewReader(fedoraData)))
+	imageMetadatas, err := listImageMetadataInBackupTarget(target)
+	assert.Nil(t, err)
+	assert.Len(t, imageMetadatas, 2)
+
+	harvesterClientSet := fake.NewSimpleClientset(backupTarget)
+	backupTargetCache := fakeclients.NamedBackupTargetCache(harvesterClientSet.HarvesterhciV1beta1().NamedBackupTargets)
+	metadata, data, err := OpenPublishedImage(backupTargetCache, nil, "offsite", "a", "b-c")
+	assert.Nil(t, err)
+	content, err := io.ReadAll(data)
+	assert.Nil(t, err)
+	assert.Equal(t, fedoraData, content)
+	fedoraSum := sha512.Sum512(fedoraData)
+	assert.Equal(t, hex.EncodeToString(fedoraSum[:]), metadata.Checksum)
+
+	// the images are replicated to the namespace of the subscription
+	subscriberClientSet := newTestCluster("cluster-b", &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "images"}})
+	subscriptionHandler := &imageSubscriptionHandler{
+		vmImages:          fakeclients.VirtualMachineImageClient(harvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		vmImageCache:      fakeclients.VirtualMachineImageCache(harvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		namespaceCache:    fakeclients.NamespaceCache(subscriberClientSet.CoreV1().Namespaces),
+		backupTargetCache: backupTargetCache,
+	}
+	assert.Nil(t, subscriptionHandler.syncImages("offsite", "images"))
+	images, err := harvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages("images").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, images.Items, 2)
+	checksums := map[string]string{}
+	for _, image := range images.Items {
+		assert.Equal(t, harvesterv1.VirtualMachineImageSourceTypeBackupTarget, image.Spec.SourceType)
+		assert.Equal(t, harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512, image.Spec.ChecksumAlgorithm)
+		checksums[image.Annotations[util.AnnotationPublishedImage]] = image.Spec.Checksum
+	}
+	assert.Equal(t, map[string]string{
+		"a-b/c": ubuntuChecksum,
+		"a/b-c": hex.EncodeToString(fedoraSum[:]),
+	}, checksums)
+
+	// the images aren't replicated again
+	assert.Nil(t, subscriptionHandler.syncImages("offsite", "images"))
+	images, err = harvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages("images").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, images.Items, 2)
+
+	// the namespace isn't created
+	assert.NotNil(t, subscriptionHandler.syncImages("offsite", "missing"))
+	_, err = subscriberClientSet.CoreV1().Namespaces().Get(context.TODO(), "missing", metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// the images published by the cluster aren't replicated to itself
+	publisherClientSet := newTestCluster("cluster-a", &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "images"}})
+	publisherHarvesterClientSet := fake.NewSimpleClientset(backupTarget)
+	publisherSubscriptionHandler := &imageSubscriptionHandler{
+		vmImages:          fakeclients.VirtualMachineImageClient(publisherHarvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		vmImageCache:      fakeclients.VirtualMachineImageCache(publisherHarvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		namespaceCache:    fakeclients.NamespaceCache(publisherClientSet.CoreV1().Namespaces),
+		backupTargetCache: backupTargetCache,
+	}
+	assert.Nil(t, publisherSubscriptionHandler.syncImages("offsite", "images"))
+	images, err = publisherHarvesterClientSet.HarvesterhciV1beta1().VirtualMachineImages("images").List(context.TODO(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, images.Items, 0)
+
+	// the image is removed from the backup target when it's removed
+	publishHandler := &imagePublishHandler{
+		backupTargetCache: backupTargetCache,
+		namespaceCache:    fakeclients.NamespaceCache(publisherClientSet.CoreV1().Namespaces),
+	}
+	_, err = publishHandler.OnImageRemoved("", &harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "a", Name: "b-c"},
+		Status:     harvesterv1.VirtualMachineImageStatus{PublishedTo: "offsite"},
+	})
+	assert.Nil(t, err)
+	imageMetadatas, err = listImageMetadataInBackupTarget(target)
+	assert.Nil(t, err)
+	assert.Len(t, imageMetadatas, 1)
+	assert.Equal(t, "a-b", imageMetadatas[0].Namespace)
+}
+
+func TestCreateImageIfNotExist(t *testing.T) {
+	existing := &harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "images",
+			Name:      "ubuntu",
+			Labels:    map[string]string{util.LabelImageDisplayName: "ubuntu"},
+		},
+		Spec: harvesterv1.VirtualMachineImageSpec{DisplayName: "ubuntu", Checksum: strings.Repeat("a", 128)},
+	}
+	clientSet := fake.NewSimpleClientset(existing)
+	h := &imageSubscriptionHandler{
+		vmImages:     fakeclients.VirtualMachineImageClient(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		vmImageCache: fakeclients.VirtualMachineImageCache(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+	}
+
+	var testCases = []struct {
+		name      string
+		metadata  *VirtualMachineImageMetadata
+		expectErr bool
+	}{
+		{
+			name:     "image with the same display name and checksum",
+			metadata: &VirtualMachineImageMetadata{Namespace: "default", Name: "ubuntu", DisplayName: "ubuntu", SourceChecksum: strings.Repeat("a", 128)},
+		},
+		{
+			name:      "image with the same display name and another checksum",
+			metadata:  &VirtualMachineImageMetadata{Namespace: "default", Name: "ubuntu", DisplayName: "ubuntu", Checksum: strings.Repeat("b", 128)},
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		err := h.createImageIfNotExist(tc.metadata, "offsite", "images")
+		assert.Equal(t, tc.expectErr, err != nil, tc.name)
+		images, err := clientSet.HarvesterhciV1beta1().VirtualMachineImages("images").List(context.TODO(), metav1.ListOptions{})
+		assert.Nil(t, err, tc.name)
+		assert.Len(t, images.Items, 1, tc.name)
+	}
+}
+
+func TestRemoveImageCondition(t *testing.T) {
+	conditions := []harvesterv1.Condition{
+		{Type: harvesterv1.ImageImported, Status: "True"},
+		{Type: harvesterv1.ImagePublished, Status: "False"},
+		{Type: harvesterv1.ImageInitialized, Status: "True"},
+	}
+	assert.Equal(t, []harvesterv1.Condition{
+		{Type: harvesterv1.ImageImported, Status: "True"},
+		{Type: harvesterv1.ImageInitialized, Status: "True"},
+	}, removeImageCondition(conditions, harvesterv1.ImagePublished))
+}
@@ -7,12 +7,16 @@ package backup
 // 2. restore a backup to a new VM or replacing it with the existing VM is supported.
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
 
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/longhorn/backupstore"
 	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
 	longhorntypes "github.com/longhorn/longhorn-manager/types"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
@@ -34,6 +38,7 @@ import (
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
 	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
+	"github.com/harvester/harvester/pkg/indexeres"
 	"github.com/harvester/harvester/pkg/ref"
 	"github.com/harvester/harvester/pkg/settings"
 	"github.com/harvester/harvester/pkg/util"
@@ -52,8 +57,9 @@ const (
 	volumeSnapshotKindName = "VolumeSnapshot"
 	vmRestoreKindName      = "VirtualMachineRestore"
 
-	restoreNameAnnotation = "restore.harvesterhci.io/name"
-	lastRestoreAnnotation = "restore.harvesterhci.io/last-restore-uid"
+	restoreNameAnnotation  = "restore.harvesterhci.io/name"
+	lastRestoreAnnotation  = "restore.harvesterhci.io/last-restore-uid"
+	verifyBackupAnnotation = "restore.harvesterhci.io/verify-backup"
 
 	vmCreatorLabel = "harvesterhci.io/creator"
 	vmNameLabel    = "harvesterhci.io/vmName"
@@ -73,6 +79,7 @@ type RestoreHandler struct {
 	vmis                 ctlkubevirtv1.VirtualMachineInstanceClient
 	vmiCache             ctlkubevirtv1.VirtualMachineInstanceCache
 	pvcClient            ctlcorev1.PersistentVolumeClaimClient
+	pvcController        ctlcorev1.PersistentVolumeClaimController
 	pvcCache             ctlcorev1.PersistentVolumeClaimCache
 	secretClient         ctlcorev1.SecretClient
 	secretCache          ctlcorev1.SecretCache
@@ -83,6 +90,8 @@ type RestoreHandler struct {
 	lhbackupCache        ctllonghornv1.BackupCache
 	volumeCache          ctllonghornv1.VolumeCache
 	volumes              ctllonghornv1.VolumeClient
+	engineCache          ctllonghornv1.EngineCache
+	backupTargetCache    ctlharvesterv1.NamedBackupTargetCache
 
 	recorder   record.EventRecorder
 	restClient *rest.RESTClient
@@ -99,6 +108,8 @@ func RegisterRestore(ctx context.Context, management *config.Management, opts co
 	snapshotContents := management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotContent()
 	lhbackups := management.LonghornFactory.Longhorn().V1beta1().Backup()
 	volumes := management.LonghornFactory.Longhorn().V1beta1().Volume()
+	engines := management.LonghornFactory.Longhorn().V1beta1().Engine()
+	backupTargets := management.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget()
 
 	copyConfig := rest.CopyConfig(management.RestConfig)
 	copyConfig.GroupVersion = &k8sschema.GroupVersion{Group: kubevirtv1.SubresourceGroupName, Version: kubevirtv1.ApiLatestVersion}
@@ -119,6 +130,7 @@ func RegisterRestore(ctx context.Context, management *config.Management, opts co
 		vmis:                 vmis,
 		vmiCache:             vmis.Cache(),
 		pvcClient:            pvcs,
+		pvcController:        pvcs,
 		pvcCache:             pvcs.Cache(),
 		secretClient:         secrets,
 		secretCache:          secrets.Cache(),
@@ -129,6 +141,8 @@ func RegisterRestore(ctx context.Context, management *config.Management, opts co
 		lhbackupCache:        lhbackups.Cache(),
 		volumes:              volumes,
 		volumeCache:          volumes.Cache(),
+		engineCache:          engines.Cache(),
+		backupTargetCache:    backupTargets.Cache(),
 		recorder:             management.NewRecorder(restoreControllerName, "", ""),
 		restClient:           restClient,
 	}
@@ -137,6 +151,8 @@ func RegisterRestore(ctx context.Context, management *config.Management, opts co
 	restores.OnRemove(ctx, restoreControllerName, handler.RestoreOnRemove)
 	pvcs.OnChange(ctx, restoreControllerName, handler.PersistentVolumeClaimOnChange)
 	vms.OnChange(ctx, restoreControllerName, handler.VMOnChange)
+	snapshots.OnChange(ctx, restoreControllerName, handler.VolumeSnapshotOnChange)
+	engines.OnChange(ctx, restoreControllerName, handler.EngineOnChange)
 	return nil
 }
 
@@ -161,9 +177,16 @@ func (h *RestoreHandler) RestoreOnChanged(key string, restore *harvesterv1.Virtu
 	}
 
 	if isVMRestoreMissingVolumes(restore) {
+		if err := h.checkBackupEncryptionKey(backup); err != nil {
+			return nil, h.updateStatusError(restore, err, true)
+		}
 		return nil, h.initVolumesStatus(restore, backup)
 	}
 
+	if IsVolumeOnlyRestore(restore) {
+		return nil, h.reconcileVolumeOnlyRestore(restore, backup)
+	}
+
 	vm, isVolumesReady, err := h.reconcileResources(restore, backup)
 	if err != nil {
 		return nil, h.updateStatusError(restore, err, true)
@@ -215,6 +238,10 @@ func (h *RestoreHandler) PersistentVolumeClaimOnChange(key string, pvc *corev1.P
 		return nil, nil
 	}
 
+	if _, ok := pvc.Annotations[util.AnnotationDeleteAfterSnapshots]; ok {
+		return nil, h.deletePVCAfterSnapshots(pvc)
+	}
+
 	restoreName, ok := pvc.Annotations[restoreNameAnnotation]
 	if !ok {
 		return nil, nil
@@ -225,6 +252,17 @@ func (h *RestoreHandler) PersistentVolumeClaimOnChange(key string, pvc *corev1.P
 	return nil, nil
 }
 
+// VolumeSnapshotOnChange enqueues the source PVC of a VolumeSnapshot being deleted,
+// so that the PVC replaced by reverting its VM to a snapshot is deleted after its last VolumeSnapshot
+func (h *RestoreHandler) VolumeSnapshotOnChange(key string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	if snapshot == nil || snapshot.DeletionTimestamp == nil || snapshot.Spec.Source.PersistentVolumeClaimName == nil {
+		return nil, nil
+	}
+
+	h.pvcController.EnqueueAfter(snapshot.Namespace, *snapshot.Spec.Source.PersistentVolumeClaimName, 5*time.Second)
+	return nil, nil
+}
+
 // VMOnChange watching the VM on change and enqueue the vmRestore if it has the restore annotation
 func (h *RestoreHandler) VMOnChange(key string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error) {
 	if vm == nil || vm.DeletionTimestamp != nil {
@@ -269,7 +307,7 @@ func (h *RestoreHandler) initVolumesStatus(vmRestore *harvesterv1.VirtualMachine
 		restoreCpy.Status.VolumeRestores = volumeRestores
 	}
 
-	if !isNewVMOrHasRetainPolicy(vmRestore) && vmRestore.Status.DeletedVolumes == nil {
+	if !isOldVolumesRetained(vmRestore) && vmRestore.Status.DeletedVolumes == nil {
 		var deletedVolumes []string
 		for _, vol := range backup.Status.VolumeBackups {
 			deletedVolumes = append(deletedVolumes, vol.PersistentVolumeClaim.ObjectMeta.Name)
@@ -283,6 +321,39 @@ func (h *RestoreHandler) initVolumesStatus(vmRestore *harvesterv1.VirtualMachine
 	return nil
 }
 
+// checkBackupEncryptionKey returns an error if the metadata of the backup in the backup target can't be decrypted
+// with the encryption key of the backup target, the backup target has been changed or the key is missing or wrong.
+// Other errors of reading the metadata are only logged, since the backup is restored from the VMBackup.
+func (h *RestoreHandler) checkBackupEncryptionKey(backup *harvesterv1.VirtualMachineBackup) error {
+	if backup.Spec.Type != harvesterv1.Backup || backup.Status.BackupTarget == nil {
+		return nil
+	}
+
+	target, err := getBackupTarget(h.backupTargetCache, h.secretCache, backup.Spec.BackupTargetName)
+	if err != nil {
+		return fmt.Errorf("failed to get backup target of VMBackup %s/%s: %w", backup.Namespace, backup.Name, err)
+	}
+	if target.IsDefaultBackupTarget() || !IsBackupTargetSame(backup.Status.BackupTarget, target) {
+		return nil
+	}
+
+	err = withBackupStoreDriver(target, func(bsDriver backupstore.BackupStoreDriver) error {
+		metadataPath := filepath.Join(metadataFolderPath, getVMBackupMetadataFileName(backup.Namespace, backup.Name))
+		if !bsDriver.FileExists(metadataPath) {
+			return nil
+		}
+		_, err := loadBackupMetadataInBackupTarget(metadataPath, bsDriver, target.EncryptionKey)
+		return err
+	})
+	if errors.Is(err, errMetadataDecryptionKeyMissing) || errors.Is(err, errMetadataDecryptionKeyMismatch) {
+		return err
+	}
+	if err != nil {
+		logrus.Warnf("failed to check the metadata of VMBackup %s/%s in the backup target: %v", backup.Namespace, backup.Name, err)
+	}
+	return nil
+}
+
 // getVM returns restore target VM
 func (h *RestoreHandler) getVM(vmRestore *harvesterv1.VirtualMachineRestore) (*kubevirtv1.VirtualMachine, error) {
 	switch vmRestore.Spec.Target.Kind {
@@ -305,6 +376,10 @@ func (h *RestoreHandler) getVM(vmRestore *harvesterv1.VirtualMachineRestore) (*k
 func getVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) ([]harvesterv1.VolumeRestore, error) {
 	restores := make([]harvesterv1.VolumeRestore, 0, len(backup.Status.VolumeBackups))
 	for _, vb := range backup.Status.VolumeBackups {
+		if !isVolumeSelected(vmRestore, vb.VolumeName) {
+			continue
+		}
+
 		found := false
 		for _, vr := range vmRestore.Status.VolumeRestores {
 			if vb.VolumeName == vr.VolumeName {
@@ -326,7 +401,7 @@ func getVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, backup *har
 						Name:      getRestorePVCName(vmRestore, vb.VolumeName),
 						Namespace: vmRestore.Namespace,
 					},
-					Spec: vb.PersistentVolumeClaim.Spec,
+					Spec: getRestorePVCSpec(vmRestore, vb.PersistentVolumeClaim.Spec),
 				},
 				VolumeBackupName: *vb.Name,
 			}
@@ -365,16 +440,81 @@ func (h *RestoreHandler) reconcileResources(
 	return vm, isVolumesReady, nil
 }
 
+// reconcileVolumeOnlyRestore restores the selected volumes as PVCs next to the target VM,
+// and hot-plugs them into the VM with the hotplug volume restore mode.
+func (h *RestoreHandler) reconcileVolumeOnlyRestore(
+	vmRestore *harvesterv1.VirtualMachineRestore,
+	backup *harvesterv1.VirtualMachineBackup,
+) error {
+	vm, err := h.getVM(vmRestore)
+	if err != nil {
+		return h.updateStatusError(vmRestore, err, true)
+	} else if vm == nil {
+		return h.updateStatusError(vmRestore, fmt.Errorf("target vm %s/%s is not found", vmRestore.Namespace, vmRestore.Spec.Target.Name), true)
+	}
+
+	isVolumesReady, err := h.reconcileVolumeRestores(vmRestore, backup)
+	if err != nil {
+		return h.updateStatusError(vmRestore, err, true)
+	}
+
+	// mount volumes after creating PVCs, so detaching volumes controller doesn't detach the volumes
+	if err := h.mountLonghornVolumes(backup); err != nil {
+		return h.updateStatusError(vmRestore, err, true)
+	}
+
+	// set vmRestore owner reference to the target VM
+	if len(vmRestore.OwnerReferences) == 0 {
+		return h.updateOwnerRefAndTargetUID(vmRestore, vm)
+	}
+
+	if !isVolumesReady {
+		return h.updateVolumesNotReadyStatus(vmRestore, backup)
+	}
+
+	if vmRestore.Spec.VolumeRestoreMode == harvesterv1.VolumeRestoreModeHotplug {
+		if err := h.hotplugVolumeRestores(vmRestore, vm); err != nil {
+			return h.updateStatusError(vmRestore, fmt.Errorf("failed to hot-plug volumes, err:%s", err.Error()), true)
+		}
+	}
+
+	return h.completeRestore(vmRestore)
+}
+
+// hotplugVolumeRestores adds the restored PVCs to the target VM through the KubeVirt addvolume subresource
+func (h *RestoreHandler) hotplugVolumeRestores(vmRestore *harvesterv1.VirtualMachineRestore, vm *kubevirtv1.VirtualMachine) error {
+	for _, volumeRestore := range vmRestore.Status.VolumeRestores {
+		claimName := volumeRestore.PersistentVolumeClaim.ObjectMeta.Name
+		if isVolumeClaimInVM(vm, claimName) {
+			continue
+		}
+
+		body, err := json.Marshal(util.NewHotplugVolumeOptions(name.SafeConcatName("restore", vmRestore.Name, volumeRestore.VolumeName), claimName))
+		if err != nil {
+			return err
+		}
+
+		logrus.Infof("hot-plug restored PVC %s/%s into vm %s", vm.Namespace, claimName, vm.Name)
+		if err := h.restClient.Put().Namespace(vm.Namespace).Resource("virtualmachines").SubResource("addvolume").Name(vm.Name).Body(body).Do(h.context).Error(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *RestoreHandler) reconcileVolumeRestores(
 	vmRestore *harvesterv1.VirtualMachineRestore,
 	backup *harvesterv1.VirtualMachineBackup,
 ) (bool, error) {
 	isVolumesReady := true
-	for i, volumeRestore := range vmRestore.Status.VolumeRestores {
+	for _, volumeRestore := range vmRestore.Status.VolumeRestores {
 		pvc, err := h.pvcCache.Get(vmRestore.Namespace, volumeRestore.PersistentVolumeClaim.ObjectMeta.Name)
 		if apierrors.IsNotFound(err) {
-			volumeBackup := backup.Status.VolumeBackups[i]
-			if err = h.createRestoredPVC(vmRestore, volumeBackup, volumeRestore); err != nil {
+			volumeBackup := getVolumeBackup(backup, volumeRestore.VolumeName)
+			if volumeBackup == nil {
+				return false, fmt.Errorf("volume %s is not found in VMBackup %s/%s", volumeRestore.VolumeName, backup.Namespace, backup.Name)
+			}
+			if err = h.createRestoredPVC(vmRestore, *volumeBackup, volumeRestore); err != nil {
 				return false, err
 			}
 			isVolumesReady = false
@@ -422,11 +562,17 @@ func (h *RestoreHandler) reconcileVM(
 	}
 
 	vmCpy := vm.DeepCopy()
-	vmCpy.Spec = backup.Status.SourceSpec.Spec
+	vmCpy.Spec = *backup.Status.SourceSpec.Spec.DeepCopy()
 	vmCpy.Spec.Template.Spec.Volumes = newVolumes
+	vmCpy.Spec.Template.Spec.Networks = getRestoreNetworks(vmRestore, backup, vmCpy.Spec.Template.Spec.Networks)
 	if vmCpy.Annotations == nil {
 		vmCpy.Annotations = make(map[string]string)
 	}
+	// reverting an existing VM to a snapshot keeps its identity, and the snapshot becomes its current snapshot
+	if !vmRestore.Spec.NewVM && backup.Spec.Type == harvesterv1.Snapshot {
+		keepMacAddresses(vm, &vmCpy.Spec)
+		vmCpy.Annotations[util.AnnotationCurrentSnapshot] = backup.Name
+	}
 	vmCpy.Annotations[lastRestoreAnnotation] = restoreID
 	vmCpy.Annotations[restoreNameAnnotation] = vmRestore.Name
 	delete(vmCpy.Annotations, util.AnnotationVolumeClaimTemplates)
@@ -566,12 +712,17 @@ func (h *RestoreHandler) createNewVM(restore *harvesterv1.VirtualMachineRestore,
 		return nil, err
 	}
 	vm.Spec.Template.Spec.Volumes = newVolumes
+	vm.Spec.Template.Spec.Networks = getRestoreNetworks(restore, backup, vm.Spec.Template.Spec.Networks)
 
 	for i := range vm.Spec.Template.Spec.Domain.Devices.Interfaces {
 		// remove the copied mac address of the new VM
 		vm.Spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = ""
 	}
 
+	if restore.Annotations[verifyBackupAnnotation] != "" {
+		isolateVerifyVM(vm)
+	}
+
 	newVM, err := h.vms.Create(vm)
 	if err != nil {
 		return nil, err
@@ -656,7 +807,7 @@ func (h *RestoreHandler) createRestoredPVC(
 				Name:     dataSourceName,
 			},
 			Resources:        volumeBackup.PersistentVolumeClaim.Spec.Resources,
-			StorageClassName: volumeBackup.PersistentVolumeClaim.Spec.StorageClassName,
+			StorageClassName: volumeRestore.PersistentVolumeClaim.Spec.StorageClassName,
 			VolumeMode:       volumeBackup.PersistentVolumeClaim.Spec.VolumeMode,
 		},
 	})
@@ -756,7 +907,7 @@ func (h *RestoreHandler) getOrCreateVolumeSnapshot(
 }
 
 func (h *RestoreHandler) deleteOldPVC(vmRestore *harvesterv1.VirtualMachineRestore, vm *kubevirtv1.VirtualMachine) error {
-	if isNewVMOrHasRetainPolicy(vmRestore) {
+	if isOldVolumesRetained(vmRestore) {
 		logrus.Infof("skip deleting old PVC of vm %s/%s", vm.Name, vm.Namespace)
 		return nil
 	}
@@ -768,17 +919,60 @@ func (h *RestoreHandler) deleteOldPVC(vmRestore *harvesterv1.VirtualMachineResto
 			return err
 		}
 
-		if vol != nil {
-			err = h.pvcClient.Delete(vol.Namespace, vol.Name, &metav1.DeleteOptions{})
-			if err != nil {
+		if vol == nil {
+			continue
+		}
+
+		// the snapshots of the VM are stored in the replaced PVCs, those PVCs are deleted after the snapshots
+		volumeSnapshots, err := h.snapshotCache.GetByIndex(indexeres.VolumeSnapshotBySourcePVCIndex, fmt.Sprintf("%s/%s", vol.Namespace, vol.Name))
+		if err != nil {
+			return err
+		}
+		if len(volumeSnapshots) > 0 {
+			if _, ok := vol.Annotations[util.AnnotationDeleteAfterSnapshots]; ok {
+				continue
+			}
+			volCpy := vol.DeepCopy()
+			if volCpy.Annotations == nil {
+				volCpy.Annotations = map[string]string{}
+			}
+			volCpy.Annotations[util.AnnotationDeleteAfterSnapshots] = "true"
+			if _, err := h.pvcClient.Update(volCpy); err != nil {
 				return err
 			}
+			continue
+		}
+
+		if err := h.pvcClient.Delete(vol.Namespace, vol.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// deletePVCAfterSnapshots deletes a PVC replaced by reverting its VM to a snapshot after the VolumeSnapshots taken from it are deleted
+func (h *RestoreHandler) deletePVCAfterSnapshots(pvc *corev1.PersistentVolumeClaim) error {
+	volumeSnapshots, err := h.snapshotCache.GetByIndex(indexeres.VolumeSnapshotBySourcePVCIndex, fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name))
+	if err != nil {
+		return err
+	}
+	if len(volumeSnapshots) > 0 {
+		// the PVC is enqueued again when the remaining VolumeSnapshots are deleted
+		if isAllVolumeSnapshotsDeleting(volumeSnapshots) {
+			h.pvcController.EnqueueAfter(pvc.Namespace, pvc.Name, 5*time.Second)
+		}
+		return nil
+	}
+
+	logrus.Infof("delete pvc %s/%s replaced by a revert after its snapshots are deleted", pvc.Namespace, pvc.Name)
+	err = h.pvcClient.Delete(pvc.Namespace, pvc.Name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &pvc.UID}})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
 func (h *RestoreHandler) startVM(vm *kubevirtv1.VirtualMachine) error {
 	runStrategy, err := vm.RunStrategy()
 	if err != nil {
@@ -811,16 +1005,8 @@ func (h *RestoreHandler) updateStatus(
 	vm *kubevirtv1.VirtualMachine,
 	isVolumesReady bool,
 ) error {
-	restoreCpy := vmRestore.DeepCopy()
 	if !isVolumesReady {
-		updateRestoreCondition(restoreCpy, newProgressingCondition(corev1.ConditionTrue, "", "Creating new PVCs"))
-		updateRestoreCondition(restoreCpy, newReadyCondition(corev1.ConditionFalse, "", "Waiting for new PVCs"))
-		if !reflect.DeepEqual(vmRestore, restoreCpy) {
-			if _, err := h.restores.Update(restoreCpy); err != nil {
-				return err
-			}
-		}
-		return nil
+		return h.updateVolumesNotReadyStatus(vmRestore, backup)
 	}
 
 	// start VM before checking status
@@ -828,8 +1014,12 @@ func (h *RestoreHandler) updateStatus(
 		return h.updateStatusError(vmRestore, fmt.Errorf("failed to start vm, err:%s", err.Error()), false)
 	}
 
+	restoreCpy := vmRestore.DeepCopy()
 	if !vm.Status.Ready {
 		message := "Waiting for target vm to be ready"
+		if err := h.updateRestoreProgress(restoreCpy, backup); err != nil {
+			return err
+		}
 		updateRestoreCondition(restoreCpy, newProgressingCondition(corev1.ConditionFalse, "", message))
 		updateRestoreCondition(restoreCpy, newReadyCondition(corev1.ConditionFalse, "", message))
 		if !reflect.DeepEqual(vmRestore, restoreCpy) {
@@ -844,6 +1034,26 @@ func (h *RestoreHandler) updateStatus(
 		return h.updateStatusError(vmRestore, fmt.Errorf("error cleaning up, err:%s", err.Error()), false)
 	}
 
+	return h.completeRestore(vmRestore)
+}
+
+func (h *RestoreHandler) updateVolumesNotReadyStatus(vmRestore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup) error {
+	restoreCpy := vmRestore.DeepCopy()
+	if err := h.updateRestoreProgress(restoreCpy, backup); err != nil {
+		return err
+	}
+	updateRestoreCondition(restoreCpy, newProgressingCondition(corev1.ConditionTrue, "", "Creating new PVCs"))
+	updateRestoreCondition(restoreCpy, newReadyCondition(corev1.ConditionFalse, "", "Waiting for new PVCs"))
+	if !reflect.DeepEqual(vmRestore, restoreCpy) {
+		if _, err := h.restores.Update(restoreCpy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *RestoreHandler) completeRestore(vmRestore *harvesterv1.VirtualMachineRestore) error {
+	restoreCpy := vmRestore.DeepCopy()
 	h.recorder.Eventf(
 		restoreCpy,
 		corev1.EventTypeNormal,
@@ -854,6 +1064,7 @@ func (h *RestoreHandler) updateStatus(
 
 	restoreCpy.Status.RestoreTime = currentTime()
 	restoreCpy.Status.Complete = pointer.BoolPtr(true)
+	completeRestoreProgress(restoreCpy)
 	updateRestoreCondition(restoreCpy, newProgressingCondition(corev1.ConditionFalse, "", "Operation complete"))
 	updateRestoreCondition(restoreCpy, newReadyCondition(corev1.ConditionTrue, "", "Operation complete"))
 	if _, err := h.restores.Update(restoreCpy); err != nil {
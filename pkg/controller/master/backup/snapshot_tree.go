@@ -0,0 +1,106 @@
+package backup
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/indexeres"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// The snapshots of a VM form a tree, the parent of a snapshot is the current snapshot of the VM when it's taken.
+// The current snapshot of a VM is the last snapshot taken of it, or the snapshot it's reverted to.
+
+// setSnapshotParent records the current snapshot of the VM as the parent of a new snapshot
+func setSnapshotParent(backup *harvesterv1.VirtualMachineBackup, vm *kubevirtv1.VirtualMachine) {
+	if backup.Spec.Type != harvesterv1.Snapshot {
+		return
+	}
+	parent := vm.Annotations[util.AnnotationCurrentSnapshot]
+	if parent == "" || parent == backup.Name {
+		return
+	}
+	if backup.Annotations == nil {
+		backup.Annotations = map[string]string{}
+	}
+	backup.Annotations[util.AnnotationParentSnapshot] = parent
+}
+
+// setCurrentSnapshot records the snapshot as the current snapshot of the VM, it's removed if the snapshot name is empty
+func setCurrentSnapshot(vms ctlkubevirtv1.VirtualMachineClient, namespace, name, snapshotName string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vm, err := vms.Get(namespace, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if vm.DeletionTimestamp != nil || vm.Annotations[util.AnnotationCurrentSnapshot] == snapshotName {
+			return nil
+		}
+		vmCpy := vm.DeepCopy()
+		if snapshotName == "" {
+			delete(vmCpy.Annotations, util.AnnotationCurrentSnapshot)
+		} else {
+			if vmCpy.Annotations == nil {
+				vmCpy.Annotations = map[string]string{}
+			}
+			vmCpy.Annotations[util.AnnotationCurrentSnapshot] = snapshotName
+		}
+		_, err = vms.Update(vmCpy)
+		return err
+	})
+}
+
+// removeSnapshotFromTree re-parents the children of a removed snapshot to its parent,
+// and moves the current snapshot of the VM to the parent if it's the removed one.
+func (h *Handler) removeSnapshotFromTree(vmBackup *harvesterv1.VirtualMachineBackup) error {
+	parent := vmBackup.Annotations[util.AnnotationParentSnapshot]
+	children, err := h.vmBackupCache.GetByIndex(indexeres.VMBackupByParentSnapshotIndex, ref.Construct(vmBackup.Namespace, vmBackup.Name))
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		childCpy := child.DeepCopy()
+		if parent == "" {
+			delete(childCpy.Annotations, util.AnnotationParentSnapshot)
+		} else {
+			childCpy.Annotations[util.AnnotationParentSnapshot] = parent
+		}
+		if _, err := h.vmBackups.Update(childCpy); err != nil {
+			return err
+		}
+	}
+
+	vm, err := h.vmsCache.Get(vmBackup.Namespace, vmBackup.Spec.Source.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if vm.Annotations[util.AnnotationCurrentSnapshot] != vmBackup.Name {
+		return nil
+	}
+	return setCurrentSnapshot(h.vms, vm.Namespace, vm.Name, parent)
+}
+
+// keepMacAddresses keeps the MAC addresses of the interfaces of the VM in the spec restored from a snapshot
+func keepMacAddresses(vm *kubevirtv1.VirtualMachine, spec *kubevirtv1.VirtualMachineSpec) {
+	if vm.Spec.Template == nil || spec.Template == nil {
+		return
+	}
+	macAddresses := map[string]string{}
+	for _, iface := range vm.Spec.Template.Spec.Domain.Devices.Interfaces {
+		macAddresses[iface.Name] = iface.MacAddress
+	}
+	for i, iface := range spec.Template.Spec.Domain.Devices.Interfaces {
+		if macAddress := macAddresses[iface.Name]; macAddress != "" {
+			spec.Template.Spec.Domain.Devices.Interfaces[i].MacAddress = macAddress
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package backup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestRemoveSnapshotFromTree(t *testing.T) {
+	newSnapshot := func(name, parent string) *harvesterv1.VirtualMachineBackup {
+		snapshot := &harvesterv1.VirtualMachineBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: map[string]string{}},
+			Spec: harvesterv1.VirtualMachineBackupSpec{
+				Type:   harvesterv1.Snapshot,
+				Source: corev1.TypedLocalObjectReference{Name: "vm"},
+			},
+		}
+		if parent != "" {
+			snapshot.Annotations[util.AnnotationParentSnapshot] = parent
+		}
+		return snapshot
+	}
+	// root <- middle <- (leaf-a, leaf-b), middle is the current snapshot of the VM
+	root, middle := newSnapshot("root", ""), newSnapshot("middle", "root")
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "vm",
+			Namespace:   "default",
+			Annotations: map[string]string{util.AnnotationCurrentSnapshot: "middle"},
+		},
+	}
+	client := fake.NewSimpleClientset(root, middle, newSnapshot("leaf-a", "middle"), newSnapshot("leaf-b", "middle"), vm)
+	h := &Handler{
+		vmBackups:     fakeclients.VMBackupClient(client.HarvesterhciV1beta1().VirtualMachineBackups),
+		vmBackupCache: fakeclients.VMBackupCache(client.HarvesterhciV1beta1().VirtualMachineBackups),
+		vms:           fakeclients.VirtualMachineClient(client.KubevirtV1().VirtualMachines),
+		vmsCache:      fakeclients.VirtualMachineCache(client.KubevirtV1().VirtualMachines),
+	}
+
+	assert.Nil(t, h.removeSnapshotFromTree(middle))
+	for _, name := range []string{"leaf-a", "leaf-b"} {
+		leaf, err := client.HarvesterhciV1beta1().VirtualMachineBackups("default").Get(context.TODO(), name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "root", leaf.Annotations[util.AnnotationParentSnapshot])
+	}
+	vm, err := client.KubevirtV1().VirtualMachines("default").Get(context.TODO(), "vm", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "root", vm.Annotations[util.AnnotationCurrentSnapshot])
+
+	// the children of a root snapshot become roots
+	assert.Nil(t, h.removeSnapshotFromTree(root))
+	leaf, err := client.HarvesterhciV1beta1().VirtualMachineBackups("default").Get(context.TODO(), "leaf-a", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotContains(t, leaf.Annotations, util.AnnotationParentSnapshot)
+	vm, err = client.KubevirtV1().VirtualMachines("default").Get(context.TODO(), "vm", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotContains(t, vm.Annotations, util.AnnotationCurrentSnapshot)
+}
+
+func TestKeepMacAddresses(t *testing.T) {
+	newSpec := func(interfaces ...kubevirtv1.Interface) *kubevirtv1.VirtualMachineSpec {
+		return &kubevirtv1.VirtualMachineSpec{
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Domain: kubevirtv1.DomainSpec{Devices: kubevirtv1.Devices{Interfaces: interfaces}},
+				},
+			},
+		}
+	}
+	vm := &kubevirtv1.VirtualMachine{
+		Spec: *newSpec(
+			kubevirtv1.Interface{Name: "nic-1", MacAddress: "52:54:00:00:00:01"},
+			kubevirtv1.Interface{Name: "nic-3", MacAddress: "52:54:00:00:00:03"},
+		),
+	}
+	spec := newSpec(
+		kubevirtv1.Interface{Name: "nic-1", MacAddress: "52:54:00:00:00:0a"},
+		kubevirtv1.Interface{Name: "nic-2", MacAddress: "52:54:00:00:00:0b"},
+	)
+
+	keepMacAddresses(vm, spec)
+	interfaces := spec.Template.Spec.Domain.Devices.Interfaces
+	assert.Equal(t, "52:54:00:00:00:01", interfaces[0].MacAddress)
+	// the interfaces not in the VM keep the MAC addresses in the snapshot
+	assert.Equal(t, "52:54:00:00:00:0b", interfaces[1].MacAddress)
+}
@@ -3,9 +3,11 @@ package backup
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/rancher/wrangler/pkg/condition"
 	wranglername "github.com/rancher/wrangler/pkg/name"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +41,18 @@ func IsBackupTargetSame(vmBackupTarget *harvesterv1.BackupTarget, target *settin
 	return vmBackupTarget.Endpoint == target.Endpoint && vmBackupTarget.BucketName == target.BucketName && vmBackupTarget.BucketRegion == target.BucketRegion
 }
 
+// getBackupTargetName returns the name of the backup target where the vm backup is stored
+func getBackupTargetName(backup *harvesterv1.VirtualMachineBackup) string {
+	if backup.Spec.BackupTargetName == "" {
+		return harvesterv1.DefaultBackupTargetName
+	}
+	return backup.Spec.BackupTargetName
+}
+
+func isDefaultBackupTargetName(name string) bool {
+	return name == "" || name == harvesterv1.DefaultBackupTargetName
+}
+
 func isBackupTargetOnAnnotation(backup *harvesterv1.VirtualMachineBackup) bool {
 	return backup.Annotations != nil &&
 		(backup.Annotations[backupTargetAnnotation] != "" ||
@@ -52,11 +66,67 @@ func isVMRestoreProgressing(vmRestore *harvesterv1.VirtualMachineRestore) bool {
 
 func isVMRestoreMissingVolumes(vmRestore *harvesterv1.VirtualMachineRestore) bool {
 	return len(vmRestore.Status.VolumeRestores) == 0 ||
-		(!isNewVMOrHasRetainPolicy(vmRestore) && len(vmRestore.Status.DeletedVolumes) == 0)
+		(!isOldVolumesRetained(vmRestore) && len(vmRestore.Status.DeletedVolumes) == 0)
+}
+
+// isOldVolumesRetained returns true if the volumes of the target VM are not deleted after restoring
+func isOldVolumesRetained(vmRestore *harvesterv1.VirtualMachineRestore) bool {
+	return vmRestore.Spec.NewVM || vmRestore.Spec.DeletionPolicy == harvesterv1.VirtualMachineRestoreRetain ||
+		IsVolumeOnlyRestore(vmRestore)
+}
+
+// isAllVolumeSnapshotsDeleting returns true if all the VolumeSnapshots are being deleted
+func isAllVolumeSnapshotsDeleting(volumeSnapshots []*snapshotv1.VolumeSnapshot) bool {
+	for _, volumeSnapshot := range volumeSnapshots {
+		if volumeSnapshot.DeletionTimestamp == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// IsVolumeOnlyRestore returns true if the volumes are restored without restoring the VM
+func IsVolumeOnlyRestore(vmRestore *harvesterv1.VirtualMachineRestore) bool {
+	return vmRestore.Spec.VolumeRestoreMode == harvesterv1.VolumeRestoreModePVC ||
+		vmRestore.Spec.VolumeRestoreMode == harvesterv1.VolumeRestoreModeHotplug
 }
 
-func isNewVMOrHasRetainPolicy(vmRestore *harvesterv1.VirtualMachineRestore) bool {
-	return vmRestore.Spec.NewVM || vmRestore.Spec.DeletionPolicy == harvesterv1.VirtualMachineRestoreRetain
+func isVolumeSelected(vmRestore *harvesterv1.VirtualMachineRestore, volumeName string) bool {
+	if len(vmRestore.Spec.Volumes) == 0 {
+		return true
+	}
+	for _, name := range vmRestore.Spec.Volumes {
+		if name == volumeName {
+			return true
+		}
+	}
+	return false
+}
+
+// isVolumeClaimInVM returns true if the PVC is a volume of the VM or is being hot-plugged into the VM
+func isVolumeClaimInVM(vm *kubevirtv1.VirtualMachine, claimName string) bool {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == claimName {
+			return true
+		}
+	}
+	for _, request := range vm.Status.VolumeRequests {
+		if request.AddVolumeOptions != nil && request.AddVolumeOptions.VolumeSource != nil &&
+			request.AddVolumeOptions.VolumeSource.PersistentVolumeClaim != nil &&
+			request.AddVolumeOptions.VolumeSource.PersistentVolumeClaim.ClaimName == claimName {
+			return true
+		}
+	}
+	return false
+}
+
+func getVolumeBackup(backup *harvesterv1.VirtualMachineBackup, volumeName string) *harvesterv1.VolumeBackup {
+	for i := range backup.Status.VolumeBackups {
+		if backup.Status.VolumeBackups[i].VolumeName == volumeName {
+			return &backup.Status.VolumeBackups[i]
+		}
+	}
+	return nil
 }
 
 func GetVMBackupError(vmBackup *harvesterv1.VirtualMachineBackup) *harvesterv1.Error {
@@ -88,6 +158,63 @@ func newProgressingCondition(status corev1.ConditionStatus, reason string, messa
 	}
 }
 
+func newGuestFrozenCondition(status corev1.ConditionStatus, message string) harvesterv1.Condition {
+	return harvesterv1.Condition{
+		Type:               harvesterv1.BackupConditionGuestFrozen,
+		Status:             status,
+		Message:            message,
+		LastTransitionTime: currentTime().Format(time.RFC3339),
+	}
+}
+
+func newVerifiedCondition(status corev1.ConditionStatus, reason string, message string) harvesterv1.Condition {
+	return harvesterv1.Condition{
+		Type:               harvesterv1.BackupConditionVerified,
+		Status:             status,
+		Message:            message,
+		Reason:             reason,
+		LastTransitionTime: currentTime().Format(time.RFC3339),
+	}
+}
+
+func newLonghornBackupsCopiedCondition(status corev1.ConditionStatus, reason string, message string) harvesterv1.Condition {
+	return harvesterv1.Condition{
+		Type:               harvesterv1.BackupConditionLonghornBackupsCopied,
+		Status:             status,
+		Message:            message,
+		Reason:             reason,
+		LastTransitionTime: currentTime().Format(time.RFC3339),
+	}
+}
+
+func getBackupCondition(backup *harvesterv1.VirtualMachineBackup, conditionType condition.Cond) *harvesterv1.Condition {
+	if backup.Status == nil {
+		return nil
+	}
+	for i := range backup.Status.Conditions {
+		if backup.Status.Conditions[i].Type == conditionType {
+			return &backup.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func isGuestFrozen(backup *harvesterv1.VirtualMachineBackup) bool {
+	c := getBackupCondition(backup, harvesterv1.BackupConditionGuestFrozen)
+	return c != nil && c.Status == corev1.ConditionTrue
+}
+
+// areVolumeSnapshotsTaken returns true if the CSI drivers have cut all the volume snapshots,
+// the data may still be uploading to the backup target.
+func areVolumeSnapshotsTaken(backup *harvesterv1.VirtualMachineBackup) bool {
+	for _, vb := range backup.Status.VolumeBackups {
+		if vb.CreationTime == nil {
+			return false
+		}
+	}
+	return true
+}
+
 func updateBackupCondition(ss *harvesterv1.VirtualMachineBackup, c harvesterv1.Condition) {
 	ss.Status.Conditions = updateCondition(ss.Status.Conditions, c)
 }
@@ -220,6 +347,48 @@ func sanitizeVirtualMachineForRestore(restore *harvesterv1.VirtualMachineRestore
 	return spec
 }
 
+// getRestorePVCSpec replaces the StorageClass of the backup volume according to the StorageClass mappings
+func getRestorePVCSpec(restore *harvesterv1.VirtualMachineRestore, spec corev1.PersistentVolumeClaimSpec) corev1.PersistentVolumeClaimSpec {
+	specCpy := spec.DeepCopy()
+	if specCpy.StorageClassName == nil {
+		return *specCpy
+	}
+	if storageClassName, ok := restore.Spec.StorageClassMappings[*specCpy.StorageClassName]; ok {
+		specCpy.StorageClassName = pointer.StringPtr(storageClassName)
+	}
+	return *specCpy
+}
+
+// getRestoreNetworks replaces the multus networks of the backup VM according to the network mappings.
+// The unmapped network names without namespace are qualified with the backup namespace
+// if the VM is restored to another namespace, so they still refer to the same NetworkAttachmentDefinitions.
+func getRestoreNetworks(restore *harvesterv1.VirtualMachineRestore, backup *harvesterv1.VirtualMachineBackup, networks []kubevirtv1.Network) []kubevirtv1.Network {
+	newNetworks := make([]kubevirtv1.Network, 0, len(networks))
+	for _, network := range networks {
+		networkCpy := network.DeepCopy()
+		if multus := networkCpy.Multus; multus != nil {
+			multus.NetworkName = getRestoreNetworkName(restore, backup.Namespace, multus.NetworkName)
+		}
+		newNetworks = append(newNetworks, *networkCpy)
+	}
+	return newNetworks
+}
+
+func getRestoreNetworkName(restore *harvesterv1.VirtualMachineRestore, backupNamespace, networkName string) string {
+	qualifiedName := networkName
+	if !strings.Contains(networkName, "/") {
+		qualifiedName = backupNamespace + "/" + networkName
+	}
+
+	if newName, ok := restore.Spec.NetworkMappings[qualifiedName]; ok {
+		return newName
+	}
+	if restore.Namespace != backupNamespace {
+		return qualifiedName
+	}
+	return networkName
+}
+
 func getSecretRefName(vmName string, secretName string) string {
 	// Use secret Hex to avoid the length of secret name exceeding the K8s limit caused by repeated backup and restore
 	return fmt.Sprintf("vm-%s-%s-ref", vmName, wranglername.Hex(secretName, 8))
@@ -0,0 +1,67 @@
+package backup
+
+import (
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+)
+
+func TestGetRestoreNetworks(t *testing.T) {
+	backup := &harvesterv1.VirtualMachineBackup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod"},
+	}
+	networks := []kubevirtv1.Network{
+		{Name: "default", NetworkSource: kubevirtv1.NetworkSource{Pod: &kubevirtv1.PodNetwork{}}},
+		{Name: "nic-1", NetworkSource: kubevirtv1.NetworkSource{Multus: &kubevirtv1.MultusNetwork{NetworkName: "prod/vlan1"}}},
+		{Name: "nic-2", NetworkSource: kubevirtv1.NetworkSource{Multus: &kubevirtv1.MultusNetwork{NetworkName: "vlan2"}}},
+	}
+
+	var testCases = []struct {
+		name      string
+		namespace string
+		mappings  map[string]string
+		expected  []string
+	}{
+		{
+			name:      "same namespace without mappings",
+			namespace: "prod",
+			expected:  []string{"prod/vlan1", "vlan2"},
+		},
+		{
+			name:      "other namespace keeps referring to the same networks",
+			namespace: "test",
+			expected:  []string{"prod/vlan1", "prod/vlan2"},
+		},
+		{
+			name:      "other namespace with mappings",
+			namespace: "test",
+			mappings:  map[string]string{"prod/vlan1": "test/vlan100", "prod/vlan2": "test/vlan200"},
+			expected:  []string{"test/vlan100", "test/vlan200"},
+		},
+	}
+
+	for _, tc := range testCases {
+		restore := &harvesterv1.VirtualMachineRestore{
+			ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace},
+			Spec:       harvesterv1.VirtualMachineRestoreSpec{NetworkMappings: tc.mappings},
+		}
+		newNetworks := getRestoreNetworks(restore, backup, networks)
+		assert.NotNil(t, newNetworks[0].Pod, tc.name)
+		assert.Equal(t, tc.expected, []string{newNetworks[1].Multus.NetworkName, newNetworks[2].Multus.NetworkName}, tc.name)
+	}
+	assert.Equal(t, "vlan2", networks[2].Multus.NetworkName, "the backup networks are not modified")
+}
+
+func TestIsAllVolumeSnapshotsDeleting(t *testing.T) {
+	now := metav1.Now()
+	deleting := &snapshotv1.VolumeSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "deleting", DeletionTimestamp: &now}}
+	kept := &snapshotv1.VolumeSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "kept"}}
+
+	assert.True(t, isAllVolumeSnapshotsDeleting([]*snapshotv1.VolumeSnapshot{deleting}))
+	assert.False(t, isAllVolumeSnapshotsDeleting([]*snapshotv1.VolumeSnapshot{deleting, kept}))
+}
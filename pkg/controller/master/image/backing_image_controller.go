@@ -18,6 +18,7 @@ import (
 // backingImageHandler syncs upload progress from backing image to vm image status
 type backingImageHandler struct {
 	vmImages          ctlharvesterv1beta1.VirtualMachineImageClient
+	vmImageController ctlharvesterv1beta1.VirtualMachineImageController
 	vmImageCache      ctlharvesterv1beta1.VirtualMachineImageCache
 	backingImages     ctllhv1beta1.BackingImageClient
 	backingImageCache ctllhv1beta1.BackingImageCache
@@ -27,6 +28,11 @@ func (h *backingImageHandler) OnChanged(_ string, backingImage *lhv1beta1.Backin
 	if backingImage == nil || backingImage.DeletionTimestamp != nil {
 		return nil, nil
 	}
+	// the exported images are synced when their VMDKs are uploaded
+	if exportImageID := backingImage.Annotations[util.AnnotationExportImageID]; exportImageID != "" {
+		h.vmImageController.Enqueue(ref.Parse(exportImageID))
+		return nil, nil
+	}
 	if backingImage.Annotations[util.AnnotationImageID] == "" || len(backingImage.Status.DiskFileStatusMap) != 1 {
 		return nil, nil
 	}
@@ -11,26 +11,38 @@ import (
 const (
 	vmImageControllerName      = "vm-image-controller"
 	backingImageControllerName = "backing-image-controller"
+
+	// imageTaskTimeout bounds the background tasks which read or write the whole image data
+	imageTaskTimeout = 6 * time.Hour
+	// imageTaskRetryInterval is how long the failed background tasks wait to be retried
+	imageTaskRetryInterval = time.Minute
 )
 
 func Register(ctx context.Context, management *config.Management, options config.Options) error {
 	backingImages := management.LonghornFactory.Longhorn().V1beta1().BackingImage()
+	backingImageDataSources := management.LonghornFactory.Longhorn().V1beta1().BackingImageDataSource()
 	images := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage()
 	storageClasses := management.StorageFactory.Storage().V1().StorageClass()
 	pvcs := management.CoreFactory.Core().V1().PersistentVolumeClaim()
+	secrets := management.CoreFactory.Core().V1().Secret()
 	vmImageHandler := &vmImageHandler{
-		backingImages:     backingImages,
-		backingImageCache: backingImages.Cache(),
-		storageClasses:    storageClasses,
-		images:            images,
-		imageController:   images,
+		backingImages:               backingImages,
+		backingImageCache:           backingImages.Cache(),
+		backingImageDataSourceCache: backingImageDataSources.Cache(),
+		storageClasses:              storageClasses,
+		images:                      images,
+		imageController:             images,
 		httpClient: http.Client{
 			Timeout: 15 * time.Second,
 		},
-		pvcCache: pvcs.Cache(),
+		pvcCache:          pvcs.Cache(),
+		secretCache:       secrets.Cache(),
+		backupTargetCache: management.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache(),
 	}
+	vmImageHandler.verifier = NewVerifier(&vmImageHandler.httpClient, secrets.Cache())
 	backingImageHandler := &backingImageHandler{
 		vmImages:          images,
+		vmImageController: images,
 		vmImageCache:      images.Cache(),
 		backingImages:     backingImages,
 		backingImageCache: backingImages.Cache(),
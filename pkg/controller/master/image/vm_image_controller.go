@@ -28,13 +28,29 @@ import (
 
 // vmImageHandler syncs status on vm image changes, and manage a storageclass & a backingimage per vm image
 type vmImageHandler struct {
-	httpClient        http.Client
+	httpClient http.Client
+	// dataHTTPClient reads and writes the whole image data, it has no timeout,
+	// the requests are bounded by the contexts of the background tasks
+	dataHTTPClient    http.Client
 	storageClasses    ctlstoragev1.StorageClassClient
 	images            ctlharvesterv1.VirtualMachineImageClient
 	imageController   ctlharvesterv1.VirtualMachineImageController
 	backingImages     lhv1beta1.BackingImageClient
 	backingImageCache lhv1beta1.BackingImageCache
-	pvcCache          ctlcorev1.PersistentVolumeClaimCache
+	// backingImageDataSourceCache is used to upload the disks of registry images
+	backingImageDataSourceCache lhv1beta1.BackingImageDataSourceCache
+	pvcCache                    ctlcorev1.PersistentVolumeClaimCache
+	secretCache                 ctlcorev1.SecretCache
+	// backupTargetCache is used to read the images replicated from backup targets
+	backupTargetCache ctlharvesterv1.NamedBackupTargetCache
+	verifier          *Verifier
+	// importing tracks the images imported in the background by the UIDs of their backing image data sources
+	importing util.BackgroundTasks
+	// verifying tracks the images verified in the background by the UIDs of their backing images
+	verifying util.BackgroundTasks
+	// exporting tracks the exported images converted to VMDKs in the background by the UIDs of the data sources
+	// of their VMDK backing images
+	exporting util.BackgroundTasks
 }
 
 func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
@@ -43,6 +59,7 @@ func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineIm
 	}
 
 	if harvesterv1.ImageImported.IsTrue(image) {
+		h.importing.Forget(string(image.UID))
 		// sync display_name to labels in order to list by labelSelector
 		if image.Spec.DisplayName != image.Labels[util.LabelImageDisplayName] {
 			toUpdate := image.DeepCopy()
@@ -53,7 +70,10 @@ func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineIm
 			return h.images.Update(toUpdate)
 		}
 
-		return image, nil
+		if isExportImage(image) {
+			return h.exportVMDK(image)
+		}
+		return h.verify(image)
 	}
 
 	needRetry := false
@@ -81,6 +101,16 @@ func (h *vmImageHandler) OnChanged(_ string, image *harvesterv1.VirtualMachineIm
 		return h.handleRetry(image)
 	}
 
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeRegistry {
+		return h.importInBackground(image, h.pullAndUploadDisk)
+	}
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeBackupTarget {
+		return h.importInBackground(image, h.downloadFromBackupTarget)
+	}
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload && util.NeedImageConversion(image.Status.Format) {
+		return h.importInBackground(image, h.downloadAndConvert)
+	}
+
 	return image, nil
 }
 
@@ -88,6 +118,9 @@ func (h *vmImageHandler) OnRemove(_ string, image *harvesterv1.VirtualMachineIma
 	if image == nil {
 		return nil, nil
 	}
+	h.importing.Forget(string(image.UID))
+	h.verifying.Forget(string(image.UID))
+	h.exporting.Forget(string(image.UID))
 	if err := h.deleteBackingImageAndStorageClass(image); err != nil {
 		return image, err
 	}
@@ -95,6 +128,9 @@ func (h *vmImageHandler) OnRemove(_ string, image *harvesterv1.VirtualMachineIma
 }
 
 func (h *vmImageHandler) initialize(image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	// the data of the backing image is being replaced
+	h.verifying.Forget(string(image.UID))
+	h.exporting.Forget(string(image.UID))
 	if err := h.deleteBackingImageAndStorageClass(image); err != nil {
 		return image, err
 	}
@@ -131,6 +167,15 @@ func (h *vmImageHandler) checkImage(image *harvesterv1.VirtualMachineImage) (*ha
 	if resp.ContentLength > 0 {
 		toUpdate.Status.Size = resp.ContentLength
 	}
+
+	// the servers which reply to HEAD requests may still fail the range requests,
+	// the image is imported as-is by Longhorn if its format can't be detected.
+	format, err := h.detectDownloadFormat(image.Spec.URL)
+	if err != nil {
+		logrus.Warnf("failed to detect the format of image %s/%s, it's imported as-is, err: %v", image.Namespace, image.Name, err)
+		format = harvesterv1.VirtualMachineImageFormatUnknown
+	}
+	toUpdate.Status.Format = format
 	return toUpdate, nil
 }
 
@@ -146,6 +191,12 @@ func (h *vmImageHandler) createBackingImageAndStorageClass(image *harvesterv1.Vi
 	toUpdate.Status.AppliedURL = toUpdate.Spec.URL
 	toUpdate.Status.StorageClassName = util.GetImageStorageClassName(image.Name)
 	toUpdate.Status.Progress = 0
+	// the upload session is lost with the backing image
+	toUpdate.Status.UploadSession = nil
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeUpload {
+		// the format is detected when the file is uploaded
+		toUpdate.Status.Format = ""
+	}
 
 	harvesterv1.ImageImported.Unknown(toUpdate)
 	harvesterv1.ImageImported.Reason(toUpdate, "Importing")
@@ -154,6 +205,12 @@ func (h *vmImageHandler) createBackingImageAndStorageClass(image *harvesterv1.Vi
 	harvesterv1.ImageInitialized.Message(toUpdate, "")
 	harvesterv1.ImageInitialized.Reason(toUpdate, "Initialized")
 	harvesterv1.ImageInitialized.LastUpdated(toUpdate, time.Now().Format(time.RFC3339))
+	if util.NeedImageVerification(toUpdate) {
+		// the image is verified again after it's imported
+		harvesterv1.ImageVerified.Unknown(toUpdate)
+		harvesterv1.ImageVerified.Reason(toUpdate, "")
+		harvesterv1.ImageVerified.Message(toUpdate, "")
+	}
 
 	return h.images.Update(toUpdate)
 }
@@ -168,12 +225,12 @@ func (h *vmImageHandler) createBackingImage(image *harvesterv1.VirtualMachineIma
 			},
 		},
 		Spec: v1beta1.BackingImageSpec{
-			SourceType:       v1beta1.BackingImageDataSourceType(image.Spec.SourceType),
+			SourceType:       getBackingImageSourceType(image),
 			SourceParameters: map[string]string{},
-			Checksum:         image.Spec.Checksum,
+			Checksum:         util.GetImageLonghornChecksum(image),
 		},
 	}
-	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload {
+	if bi.Spec.SourceType == v1beta1.BackingImageDataSourceTypeDownload {
 		bi.Spec.SourceParameters[v1beta1.DataSourceTypeDownloadParameterURL] = image.Spec.URL
 	}
 
@@ -185,12 +242,26 @@ func (h *vmImageHandler) createBackingImage(image *harvesterv1.VirtualMachineIma
 
 		bi.Spec.SourceParameters[lhcontroller.DataSourceTypeExportFromVolumeParameterVolumeName] = pvc.Spec.VolumeName
 		bi.Spec.SourceParameters[lhmanager.DataSourceTypeExportFromVolumeParameterExportType] = lhmanager.DataSourceTypeExportFromVolumeParameterExportTypeRAW
+		if image.Annotations[util.AnnotationImageExportType] == lhmanager.DataSourceTypeExportFromVolumeParameterExportTypeQCOW2 {
+			bi.Spec.SourceParameters[lhmanager.DataSourceTypeExportFromVolumeParameterExportType] = lhmanager.DataSourceTypeExportFromVolumeParameterExportTypeQCOW2
+		}
 	}
 
 	_, err := h.backingImages.Create(bi)
 	return err
 }
 
+// getBackingImageSourceType returns the source type of the backing image, the disks of registry images, the data of
+// images replicated from backup targets and the converted files of download images are uploaded by harvester.
+func getBackingImageSourceType(image *harvesterv1.VirtualMachineImage) v1beta1.BackingImageDataSourceType {
+	if image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeRegistry ||
+		image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeBackupTarget ||
+		(image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeDownload && util.NeedImageConversion(image.Status.Format)) {
+		return v1beta1.BackingImageDataSourceTypeUpload
+	}
+	return v1beta1.BackingImageDataSourceType(image.Spec.SourceType)
+}
+
 func (h *vmImageHandler) createStorageClass(image *harvesterv1.VirtualMachineImage) error {
 	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
 	volumeBindingMode := storagev1.VolumeBindingImmediate
@@ -224,6 +295,9 @@ func (h *vmImageHandler) deleteBackingImageAndStorageClass(image *harvesterv1.Vi
 	if err := h.deleteStorageClass(image); err != nil && !errors.IsNotFound(err) {
 		return err
 	}
+	if isExportImage(image) {
+		return h.deleteExportVMDKBackingImage(image)
+	}
 	return nil
 }
 
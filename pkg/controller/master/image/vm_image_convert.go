@@ -0,0 +1,70 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// detectDownloadFormat detects the format of the image file by the header downloaded from the URL
+func (h *vmImageHandler) detectDownloadFormat(url string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	// the whole file is returned by the servers which don't support range requests
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", util.ImageFormatHeaderSize-1))
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return "", fmt.Errorf("got %d status code from %s", resp.StatusCode, url)
+	}
+
+	header, err := io.ReadAll(io.LimitReader(resp.Body, util.ImageFormatHeaderSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read header of %s: %w", url, err)
+	}
+	return util.DetectImageFormat(header), nil
+}
+
+// downloadAndConvert downloads the image file which can't be consumed by Longhorn as-is, converts it to raw and
+// uploads it to the backing image. The downloaded file is verified before it's converted.
+func (h *vmImageHandler) downloadAndConvert(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image.Spec.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.dataHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", image.Spec.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: got %d status code", image.Spec.URL, resp.StatusCode)
+	}
+
+	var verify func(io.Reader) error
+	if util.NeedImageVerification(image) {
+		verify = func(source io.Reader) error {
+			verifyErr := h.verifier.Verify(image, source)
+			// the failures to read the data are retried with the import
+			if verifyErr != nil && !IsVerificationError(verifyErr) {
+				return verifyErr
+			}
+			if err := h.updateVerifiedCondition(image, verifyErr); err != nil {
+				return err
+			}
+			return verifyErr
+		}
+	}
+	return util.ConvertAndUploadBackingImage(ctx, &h.dataHTTPClient, util.GetBackingImageName(image),
+		path.Base(resp.Request.URL.Path), image.Status.Format, resp.Body, verify)
+}
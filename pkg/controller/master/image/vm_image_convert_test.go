@@ -0,0 +1,56 @@
+package image
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestCheckImageFormat(t *testing.T) {
+	vmdk := []byte("KDMV")
+	var testCases = []struct {
+		name           string
+		rangeStatus    int
+		expectedFormat string
+	}{
+		{
+			name:           "format is detected by the range request",
+			rangeStatus:    http.StatusPartialContent,
+			expectedFormat: harvesterv1.VirtualMachineImageFormatVMDK,
+		},
+		{
+			name:           "format is unknown if the range request fails",
+			rangeStatus:    http.StatusInternalServerError,
+			expectedFormat: harvesterv1.VirtualMachineImageFormatUnknown,
+		},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodHead {
+				return
+			}
+			rw.WriteHeader(tc.rangeStatus)
+			_, _ = rw.Write(vmdk)
+		}))
+		h := &vmImageHandler{}
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image"},
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				SourceType: harvesterv1.VirtualMachineImageSourceTypeDownload,
+				URL:        server.URL + "/disk.vmdk",
+			},
+		}
+		toUpdate, err := h.checkImage(image)
+		server.Close()
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expectedFormat, toUpdate.Status.Format, tc.name)
+		assert.Equal(t, tc.expectedFormat != harvesterv1.VirtualMachineImageFormatUnknown, util.NeedImageConversion(toUpdate.Status.Format), tc.name)
+	}
+}
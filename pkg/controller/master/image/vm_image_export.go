@@ -0,0 +1,131 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// isExportImage returns true if the image is exported from a VM volume by the export action of the VM
+func isExportImage(image *harvesterv1.VirtualMachineImage) bool {
+	return image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeExportVolume && image.Labels[util.LabelVMExport] != ""
+}
+
+// exportVMDK converts the imported image exported from a VM volume to a streamOptimized VMDK once, the VMDK is stored
+// in an upload backing image and served with its size in the OVA package of the VM. The conversion is started again
+// if it's interrupted while the data source of the backing image is pending, and the backing image is recreated to
+// retry after a while if the conversion fails.
+func (h *vmImageHandler) exportVMDK(image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	key := string(image.UID)
+	backingImageName := util.GetExportVMDKBackingImageName(image)
+	backingImage, err := h.backingImageCache.Get(util.LonghornSystemNamespaceName, backingImageName)
+	if apierrors.IsNotFound(err) {
+		if err := h.createExportVMDKBackingImage(image); err != nil && !apierrors.IsAlreadyExists(err) {
+			return image, err
+		}
+		h.imageController.EnqueueAfter(image.Namespace, image.Name, 5*time.Second)
+		return image, nil
+	} else if err != nil {
+		return image, err
+	}
+
+	for _, status := range backingImage.Status.DiskFileStatusMap {
+		switch status.State {
+		case v1beta1.BackingImageStateReady, v1beta1.BackingImageStateReadyForTransfer:
+			h.exporting.Forget(key)
+			return image, nil
+		case v1beta1.BackingImageStateFailed:
+			h.exporting.Forget(key)
+			logrus.Errorf("failed to convert exported image %s/%s to VMDK, err: %s", image.Namespace, image.Name, status.Message)
+			h.imageController.EnqueueAfter(image.Namespace, image.Name, imageTaskRetryInterval)
+			return image, h.deleteExportVMDKBackingImage(image)
+		}
+	}
+
+	// the data source is pending until the VMDK is uploaded
+	ds, err := h.backingImageDataSourceCache.Get(util.LonghornSystemNamespaceName, backingImageName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return image, err
+	}
+	if err != nil || ds.Status.CurrentState == "" || ds.Status.CurrentState == v1beta1.BackingImageStateStarting {
+		h.imageController.EnqueueAfter(image.Namespace, image.Name, 5*time.Second)
+		return image, nil
+	}
+	if ds.Status.CurrentState != v1beta1.BackingImageStatePending {
+		return image, nil
+	}
+
+	h.exporting.Start(key, string(ds.UID), imageTaskTimeout, func(ctx context.Context) error {
+		err := h.convertToVMDK(ctx, image)
+		if err == nil {
+			logrus.Infof("exported image %s/%s is converted to VMDK", image.Namespace, image.Name)
+			return nil
+		}
+		// the conversion is cancelled when the image is removed or its data is replaced
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return err
+		}
+		logrus.Errorf("failed to convert exported image %s/%s to VMDK, err: %v", image.Namespace, image.Name, err)
+		if deleteErr := h.deleteExportVMDKBackingImage(image); deleteErr != nil {
+			logrus.Errorf("failed to delete VMDK backing image of image %s/%s, err: %v", image.Namespace, image.Name, deleteErr)
+		}
+		h.imageController.EnqueueAfter(image.Namespace, image.Name, imageTaskRetryInterval)
+		return err
+	})
+	return image, nil
+}
+
+func (h *vmImageHandler) createExportVMDKBackingImage(image *harvesterv1.VirtualMachineImage) error {
+	_, err := h.backingImages.Create(&v1beta1.BackingImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.GetExportVMDKBackingImageName(image),
+			Namespace: util.LonghornSystemNamespaceName,
+			Annotations: map[string]string{
+				util.AnnotationExportImageID: ref.Construct(image.Namespace, image.Name),
+			},
+		},
+		Spec: v1beta1.BackingImageSpec{
+			SourceType:       v1beta1.BackingImageDataSourceTypeUpload,
+			SourceParameters: map[string]string{},
+		},
+	})
+	return err
+}
+
+func (h *vmImageHandler) deleteExportVMDKBackingImage(image *harvesterv1.VirtualMachineImage) error {
+	err := h.backingImages.Delete(util.LonghornSystemNamespaceName, util.GetExportVMDKBackingImageName(image), &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// convertToVMDK reads the raw data of the exported image from Longhorn and uploads it as a streamOptimized VMDK
+func (h *vmImageHandler) convertToVMDK(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	downloadURL := fmt.Sprintf("%s/backingimages/%s/download", util.LonghornDefaultManagerURL, util.GetBackingImageName(image))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.dataHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to read image data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to read image data: got %d status code from %s", resp.StatusCode, downloadURL)
+	}
+	return util.ConvertRawToVMDKAndUploadBackingImage(ctx, &h.dataHTTPClient, util.GetExportVMDKBackingImageName(image),
+		image.Name+".vmdk", resp.Body, image.Status.Size)
+}
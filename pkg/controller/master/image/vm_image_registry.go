@@ -0,0 +1,345 @@
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/rancher/norman/condition"
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// containerDiskDir is the directory of the disk in a KubeVirt containerdisk image
+const containerDiskDir = "disk"
+
+// importInBackground runs importFunc in the background to upload the image file to the upload backing image
+// once its data source is pending. The disks of registry images are streamed from the image layers,
+// and the files of download images are converted before they are uploaded.
+func (h *vmImageHandler) importInBackground(image *harvesterv1.VirtualMachineImage, importFunc func(context.Context, *harvesterv1.VirtualMachineImage) error) (*harvesterv1.VirtualMachineImage, error) {
+	if !harvesterv1.ImageInitialized.IsTrue(image) || !harvesterv1.ImageImported.IsUnknown(image) {
+		return image, nil
+	}
+
+	// the data source is pending until the file is uploaded
+	ds, err := h.backingImageDataSourceCache.Get(util.LonghornSystemNamespaceName, util.GetBackingImageName(image))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return image, err
+	}
+	if err != nil || ds.Status.CurrentState == "" || ds.Status.CurrentState == v1beta1.BackingImageStateStarting {
+		h.imageController.EnqueueAfter(image.Namespace, image.Name, 5*time.Second)
+		return image, nil
+	}
+	if ds.Status.CurrentState != v1beta1.BackingImageStatePending {
+		return image, nil
+	}
+
+	// the import is tracked after the upload succeeds until the image is imported, so that the data source
+	// which may still be pending in the cache isn't uploaded again. A new data source of the image is uploaded.
+	h.importing.Start(string(image.UID), string(ds.UID), imageTaskTimeout, func(ctx context.Context) error {
+		err := importFunc(ctx, image)
+		if err == nil {
+			logrus.Infof("image %s/%s is imported from %s", image.Namespace, image.Name, image.Spec.URL)
+			return nil
+		}
+		// the import is cancelled when the image is removed or a new data source of the image is uploaded
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return err
+		}
+		logrus.Errorf("failed to import image %s/%s from %s, err: %v", image.Namespace, image.Name, image.Spec.URL, err)
+		// the backing image is recreated to retry
+		if updateErr := h.updateImportFailure(image, err); updateErr != nil {
+			logrus.Errorf("failed to update image %s/%s, err: %v", image.Namespace, image.Name, updateErr)
+		}
+		if deleteErr := h.deleteBackingImage(image); deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			logrus.Errorf("failed to delete backing image of image %s/%s, err: %v", image.Namespace, image.Name, deleteErr)
+		}
+		return err
+	})
+	return image, nil
+}
+
+// pullAndUploadDisk imports the disk of a KubeVirt containerdisk image to the backing image of a registry image.
+// The image is pulled through the mirrors of its registry in the containerd-registry setting like containerd does,
+// with the credentials of the endpoints in the registry secret of the image.
+func (h *vmImageHandler) pullAndUploadDisk(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	ref, err := name.ParseReference(image.Spec.URL)
+	if err != nil {
+		return err
+	}
+	endpoints, err := getRegistryEndpoints(settings.ContainerdRegistry.Get(), ref)
+	if err != nil {
+		return err
+	}
+
+	var img v1.Image
+	errs := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		auth, err := h.getRegistryAuth(image, endpoint.url.Host)
+		if err != nil {
+			return err
+		}
+		img, err = remote.Image(endpoint.ref, remote.WithAuth(auth), remote.WithTransport(endpoint), remote.WithContext(ctx))
+		if err == nil {
+			break
+		}
+		logrus.Warnf("failed to pull image %s from endpoint %s, err: %v", image.Spec.URL, endpoint.url, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", endpoint.url, err))
+	}
+	if img == nil {
+		return fmt.Errorf("failed to pull image %s: %s", image.Spec.URL, strings.Join(errs, "; "))
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("failed to get layers of image %s: %w", image.Spec.URL, err)
+	}
+
+	// the disk is in the top layer which has the disk directory
+	for i := len(layers) - 1; i >= 0; i-- {
+		found, err := h.uploadDiskInLayer(ctx, image, layers[i])
+		if err != nil || found {
+			return err
+		}
+	}
+	return fmt.Errorf("image %s has no disk in the %s directory", image.Spec.URL, containerDiskDir)
+}
+
+// registryEndpoint is an endpoint to pull the image of a reference from, it sends the requests to the registry
+// of the reference to the endpoint.
+type registryEndpoint struct {
+	ref       name.Reference
+	url       *url.URL
+	transport http.RoundTripper
+}
+
+var _ http.RoundTripper = &registryEndpoint{}
+
+func (e *registryEndpoint) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the requests redirected to other hosts, like the token requests of the auth flow, aren't sent to the endpoint
+	if req.URL.Host == e.ref.Context().RegistryStr() && strings.HasPrefix(req.URL.Path, "/v2") {
+		req = req.Clone(req.Context())
+		req.URL.Path = e.url.Path + strings.TrimPrefix(req.URL.Path, "/v2")
+		req.URL.RawPath = ""
+		req.URL.Scheme = e.url.Scheme
+		req.URL.Host = e.url.Host
+		req.Host = e.url.Host
+	}
+	return e.transport.RoundTrip(req)
+}
+
+// getRegistryEndpoints returns the endpoints to pull the image of the reference from in order with the registry
+// configuration of the containerd-registry setting. They are the endpoints of the first mirror of the registry,
+// docker.io or * which is configured, followed by the registry itself. The rewrites of the mirror are applied
+// to the repository of the reference pulled from its endpoints. The TLS files in the setting are paths on the
+// nodes, so only the insecure-skip-verify option is applied.
+func getRegistryEndpoints(registryConfig string, ref name.Reference) ([]*registryEndpoint, error) {
+	registry := &registries.Registry{}
+	if registryConfig != "" {
+		if err := json.Unmarshal([]byte(registryConfig), registry); err != nil {
+			return nil, fmt.Errorf("failed to parse %s setting: %w", settings.ContainerdRegistrySettingName, err)
+		}
+	}
+
+	registryHost := ref.Context().RegistryStr()
+	keys := []string{registryHost}
+	if registryHost == name.DefaultRegistry {
+		keys = append(keys, "docker.io")
+	}
+	keys = append(keys, "*")
+
+	var endpoints []*registryEndpoint
+	for _, key := range keys {
+		mirror, ok := registry.Mirrors[key]
+		if !ok {
+			continue
+		}
+		mirrorRef := rewriteReference(ref, mirror.Rewrites)
+		for _, endpoint := range mirror.Endpoints {
+			endpointURL, err := url.Parse(endpoint)
+			if err != nil || !endpointURL.IsAbs() || endpointURL.Host == "" {
+				logrus.Warnf("ignoring invalid endpoint %q of registry %s", endpoint, key)
+				continue
+			}
+			if endpointURL.Path == "" {
+				endpointURL.Path = "/v2"
+			} else {
+				endpointURL.Path = path.Clean(endpointURL.Path)
+			}
+			endpoints = append(endpoints, newRegistryEndpoint(registry, mirrorRef, endpointURL))
+		}
+		// the registry is pulled through the first mirror configured, even if it has no valid endpoints
+		break
+	}
+	defaultURL := &url.URL{Scheme: "https", Host: registryHost, Path: "/v2"}
+	return append(endpoints, newRegistryEndpoint(registry, ref, defaultURL)), nil
+}
+
+func newRegistryEndpoint(registry *registries.Registry, ref name.Reference, endpointURL *url.URL) *registryEndpoint {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config, ok := registry.Configs[endpointURL.Host]; ok && config.TLS != nil && config.TLS.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	return &registryEndpoint{ref: ref, url: endpointURL, transport: transport}
+}
+
+// rewriteReference returns the reference with the first rewrite matching its repository applied, the rewrites
+// map the regular expressions of the repositories to their replacements.
+func rewriteReference(ref name.Reference, rewrites map[string]string) name.Reference {
+	patterns := make([]string, 0, len(rewrites))
+	for pattern := range rewrites {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	repository := ref.Context().RepositoryStr()
+	for _, pattern := range patterns {
+		exp, err := regexp.Compile(pattern)
+		if err != nil {
+			logrus.Warnf("ignoring invalid rewrite %q of registry %s", pattern, ref.Context().RegistryStr())
+			continue
+		}
+		rewritten := exp.ReplaceAllString(repository, rewrites[pattern])
+		if rewritten == repository {
+			continue
+		}
+		repo, err := name.NewRepository(ref.Context().RegistryStr() + "/" + rewritten)
+		if err != nil {
+			logrus.Warnf("ignoring invalid repository %s rewritten from %s", rewritten, repository)
+			continue
+		}
+		switch r := ref.(type) {
+		case name.Tag:
+			r.Repository = repo
+			return r
+		case name.Digest:
+			r.Repository = repo
+			return r
+		}
+	}
+	return ref
+}
+
+func (h *vmImageHandler) uploadDiskInLayer(ctx context.Context, image *harvesterv1.VirtualMachineImage, layer v1.Layer) (bool, error) {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return false, fmt.Errorf("failed to read layer of image %s: %w", image.Spec.URL, err)
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	header, err := findContainerDisk(tr)
+	if err != nil || header == nil {
+		return false, err
+	}
+	return true, util.UploadBackingImage(ctx, &h.dataHTTPClient, util.GetBackingImageName(image), path.Base(header.Name), tr, header.Size)
+}
+
+// findContainerDisk advances the tar reader to the disk file of a containerdisk image layer,
+// it returns nil if the layer has no disk.
+func findContainerDisk(tr *tar.Reader) (*tar.Header, error) {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read image layer: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		dir, file := path.Split(path.Clean(strings.TrimPrefix(header.Name, "/")))
+		if path.Clean(dir) == containerDiskDir && !strings.HasPrefix(file, ".wh.") && header.Size > 0 {
+			return header, nil
+		}
+	}
+}
+
+// getRegistryAuth returns the authenticator of the registry host with the credentials in the registry secret of the image.
+// The secret is in the namespace of the image, so that the credentials of the cluster, like the ones of the
+// containerd-registry setting, aren't used to pull the images of the users.
+func (h *vmImageHandler) getRegistryAuth(image *harvesterv1.VirtualMachineImage, registry string) (authn.Authenticator, error) {
+	if image.Spec.RegistrySecretName == "" {
+		return authn.Anonymous, nil
+	}
+	secret, err := h.secretCache.Get(image.Namespace, image.Spec.RegistrySecretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry secret %s/%s: %w", image.Namespace, image.Spec.RegistrySecretName, err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return nil, fmt.Errorf("registry secret %s/%s is not of type %s", secret.Namespace, secret.Name, corev1.SecretTypeDockerConfigJson)
+	}
+	authConfig, err := getRegistryAuthConfig(secret.Data[corev1.DockerConfigJsonKey], registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if authConfig == nil {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(*authConfig), nil
+}
+
+// getRegistryAuthConfig returns the credentials of the registry in the docker config JSON, it returns nil if there is none.
+// The keys of the credentials can be hosts or URLs, like https://index.docker.io/v1/ for Docker Hub.
+func getRegistryAuthConfig(dockerConfigJSON []byte, registry string) (*authn.AuthConfig, error) {
+	var dockerConfig struct {
+		Auths map[string]authn.AuthConfig `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfigJSON, &dockerConfig); err != nil {
+		return nil, err
+	}
+	for key, authConfig := range dockerConfig.Auths {
+		host := strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+		host = strings.SplitN(host, "/", 2)[0]
+		// the references to Docker Hub are normalized to index.docker.io
+		if host == "docker.io" {
+			host = name.DefaultRegistry
+		}
+		if host == registry {
+			authConfig := authConfig
+			return &authConfig, nil
+		}
+	}
+	return nil, nil
+}
+
+func (h *vmImageHandler) updateImportFailure(image *harvesterv1.VirtualMachineImage, importErr error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.images.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if current.UID != image.UID || current.DeletionTimestamp != nil {
+			return nil
+		}
+		toUpdate := handleFail(current.DeepCopy(), condition.Cond(harvesterv1.ImageImported), importErr)
+		harvesterv1.ImageImported.Message(toUpdate, importErr.Error())
+		_, err = h.images.Update(toUpdate)
+		return err
+	})
+}
@@ -0,0 +1,215 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindContainerDisk(t *testing.T) {
+	type file struct {
+		name     string
+		typeflag byte
+		content  string
+	}
+	newLayer := func(files ...file) *tar.Reader {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, f := range files {
+			assert.Nil(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: f.typeflag, Size: int64(len(f.content)), Mode: 0644}))
+			_, err := tw.Write([]byte(f.content))
+			assert.Nil(t, err)
+		}
+		assert.Nil(t, tw.Close())
+		return tar.NewReader(&buf)
+	}
+
+	var testCases = []struct {
+		name         string
+		files        []file
+		expectedName string
+		expectedData string
+	}{
+		{
+			name: "disk",
+			files: []file{
+				{name: "disk/", typeflag: tar.TypeDir},
+				{name: "disk/ubuntu.qcow2", typeflag: tar.TypeReg, content: "QFI"},
+			},
+			expectedName: "disk/ubuntu.qcow2",
+			expectedData: "QFI",
+		},
+		{
+			name: "disk with leading dot",
+			files: []file{
+				{name: "./etc/hostname", typeflag: tar.TypeReg, content: "host"},
+				{name: "./disk/disk.img", typeflag: tar.TypeReg, content: "raw"},
+			},
+			expectedName: "./disk/disk.img",
+			expectedData: "raw",
+		},
+		{
+			name: "no disk",
+			files: []file{
+				{name: "disk/.wh.disk.img", typeflag: tar.TypeReg},
+				{name: "disk/sub/disk.img", typeflag: tar.TypeReg, content: "raw"},
+				{name: "data/disk.img", typeflag: tar.TypeReg, content: "raw"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tr := newLayer(tc.files...)
+		header, err := findContainerDisk(tr)
+		assert.Nil(t, err, tc.name)
+		if tc.expectedName == "" {
+			assert.Nil(t, header, tc.name)
+			continue
+		}
+		assert.Equal(t, tc.expectedName, header.Name, tc.name)
+		data, err := io.ReadAll(tr)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expectedData, string(data), tc.name)
+	}
+}
+
+func TestGetRegistryAuthConfig(t *testing.T) {
+	dockerConfigJSON := []byte(`{"auths":{
+		"https://registry.example.com/v1/":{"username":"example","password":"example-password"},
+		"docker.io":{"auth":"dXNlcjpwYXNzd29yZA=="}
+	}}`)
+
+	var testCases = []struct {
+		name     string
+		registry string
+		expected *authn.AuthConfig
+	}{
+		{
+			name:     "registry key with scheme and path",
+			registry: "registry.example.com",
+			expected: &authn.AuthConfig{Username: "example", Password: "example-password"},
+		},
+		{
+			name:     "docker hub",
+			registry: name.DefaultRegistry,
+			expected: &authn.AuthConfig{Auth: "dXNlcjpwYXNzd29yZA=="},
+		},
+		{
+			name:     "registry without credentials",
+			registry: "other.example.com",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		authConfig, err := getRegistryAuthConfig(dockerConfigJSON, tc.registry)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, authConfig, tc.name)
+	}
+
+	_, err := getRegistryAuthConfig([]byte("invalid"), "registry.example.com")
+	assert.NotNil(t, err)
+}
+
+func TestGetRegistryEndpoints(t *testing.T) {
+	registryConfig := `{"Mirrors":{
+		"registry.example.com":{"Endpoints":["https://mirror.example.com:5000","http://cache.example.com/registry/v2"],"Rewrites":{"^library/(.*)$":"mirrored/$1"}},
+		"docker.io":{"Endpoints":["https://hub-mirror.example.com"]},
+		"*":{"Endpoints":["https://any-mirror.example.com"]}
+	}}`
+
+	var testCases = []struct {
+		name         string
+		config       string
+		ref          string
+		expectedURLs []string
+		expectedRefs []string
+	}{
+		{
+			name:         "no mirrors",
+			ref:          "registry.example.com/library/ubuntu:22.04",
+			expectedURLs: []string{"https://registry.example.com/v2"},
+			expectedRefs: []string{"registry.example.com/library/ubuntu:22.04"},
+		},
+		{
+			name:         "mirrored and rewritten reference",
+			config:       registryConfig,
+			ref:          "registry.example.com/library/ubuntu:22.04",
+			expectedURLs: []string{"https://mirror.example.com:5000/v2", "http://cache.example.com/registry/v2", "https://registry.example.com/v2"},
+			expectedRefs: []string{"registry.example.com/mirrored/ubuntu:22.04", "registry.example.com/mirrored/ubuntu:22.04", "registry.example.com/library/ubuntu:22.04"},
+		},
+		{
+			name:         "docker hub mirror",
+			config:       registryConfig,
+			ref:          "ubuntu:22.04",
+			expectedURLs: []string{"https://hub-mirror.example.com/v2", "https://index.docker.io/v2"},
+			expectedRefs: []string{"index.docker.io/library/ubuntu:22.04", "index.docker.io/library/ubuntu:22.04"},
+		},
+		{
+			name:         "wildcard mirror",
+			config:       registryConfig,
+			ref:          "other.example.com/vm/disk:latest",
+			expectedURLs: []string{"https://any-mirror.example.com/v2", "https://other.example.com/v2"},
+			expectedRefs: []string{"other.example.com/vm/disk:latest", "other.example.com/vm/disk:latest"},
+		},
+	}
+
+	for _, tc := range testCases {
+		ref, err := name.ParseReference(tc.ref)
+		assert.Nil(t, err, tc.name)
+		endpoints, err := getRegistryEndpoints(tc.config, ref)
+		assert.Nil(t, err, tc.name)
+		var urls, refs []string
+		for _, endpoint := range endpoints {
+			urls = append(urls, endpoint.url.String())
+			refs = append(refs, endpoint.ref.Name())
+		}
+		assert.Equal(t, tc.expectedURLs, urls, tc.name)
+		assert.Equal(t, tc.expectedRefs, refs, tc.name)
+	}
+
+	_, err := getRegistryEndpoints("invalid", name.MustParseReference("ubuntu"))
+	assert.NotNil(t, err)
+}
+
+func TestPullMirroredReference(t *testing.T) {
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json",` +
+		`"config":{"mediaType":"application/vnd.docker.container.image.v1+json","size":2,` +
+		`"digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"},"layers":[]}`
+	var paths []string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/registry/v2/":
+		case "/registry/v2/mirrored/ubuntu/manifests/22.04":
+			w.Header().Set("Content-Type", string(types.DockerManifestSchema2))
+			fmt.Fprint(w, manifest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	ref := name.MustParseReference("registry.example.com/library/ubuntu:22.04")
+	registryConfig := fmt.Sprintf(`{"Mirrors":{"registry.example.com":{"Endpoints":["%s/registry/v2"],"Rewrites":{"^library/(.*)$":"mirrored/$1"}}}}`, mirror.URL)
+	endpoints, err := getRegistryEndpoints(registryConfig, ref)
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 2)
+
+	// the requests to the registry of the reference are sent to the mirror with the rewritten repository
+	img, err := remote.Image(endpoints[0].ref, remote.WithAuth(authn.Anonymous), remote.WithTransport(endpoints[0]))
+	assert.Nil(t, err)
+	mediaType, err := img.MediaType()
+	assert.Nil(t, err)
+	assert.Equal(t, types.DockerManifestSchema2, mediaType)
+	assert.Equal(t, []string{"/registry/v2/", "/registry/v2/mirrored/ubuntu/manifests/22.04"}, paths)
+}
@@ -0,0 +1,27 @@
+package image
+
+import (
+	"context"
+	"fmt"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+// downloadFromBackupTarget uploads the data of the image published to the backup target by another cluster
+// to the backing image, the checksum of the data is verified once it's imported.
+func (h *vmImageHandler) downloadFromBackupTarget(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	publishedImage := image.Annotations[util.AnnotationPublishedImage]
+	if publishedImage == "" {
+		return fmt.Errorf("image %s/%s has no %s annotation", image.Namespace, image.Name, util.AnnotationPublishedImage)
+	}
+	namespace, name := ref.Parse(publishedImage)
+	metadata, data, err := backup.OpenPublishedImage(h.backupTargetCache, h.secretCache, image.Spec.BackupTargetName, namespace, name)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	return util.UploadBackingImage(ctx, &h.dataHTTPClient, util.GetBackingImageName(image), metadata.DisplayName, data, metadata.Size)
+}
@@ -0,0 +1,364 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	verifiedReasonVerifying = "Verifying"
+	verifiedReasonVerified  = "Verified"
+	verifiedReasonFailed    = "VerificationFailed"
+
+	// maxSignatureSize limits the size of a signature downloaded from a URL
+	maxSignatureSize = 1 << 20
+)
+
+// verificationError is the failure of an image to be verified with its checksum or signature. Unlike the failures
+// to read the image data or the signature, it's recorded in the Verified condition of the image without retrying.
+type verificationError struct {
+	message string
+}
+
+func (e *verificationError) Error() string {
+	return e.message
+}
+
+func newVerificationError(format string, args ...interface{}) error {
+	return &verificationError{message: fmt.Sprintf(format, args...)}
+}
+
+// IsVerificationError returns true if the error is the failure of an image to be verified, not to read it
+func IsVerificationError(err error) bool {
+	var verificationErr *verificationError
+	return errors.As(err, &verificationErr)
+}
+
+// Verifier verifies the checksums and signatures of image files
+type Verifier struct {
+	httpClient  *http.Client
+	secretCache ctlcorev1.SecretCache
+}
+
+func NewVerifier(httpClient *http.Client, secretCache ctlcorev1.SecretCache) *Verifier {
+	return &Verifier{
+		httpClient:  httpClient,
+		secretCache: secretCache,
+	}
+}
+
+// verify verifies the checksum and signature of an imported image in the background, the result is recorded in the
+// Verified condition of the image. The Verifying reason is persisted before the image data is read from Longhorn, so that
+// the verification is started again if it's interrupted. The failures to read the image data, the signature or the
+// public keys are retried after a while.
+func (h *vmImageHandler) verify(image *harvesterv1.VirtualMachineImage) (*harvesterv1.VirtualMachineImage, error) {
+	key := string(image.UID)
+	if !util.NeedImageVerification(image) || harvesterv1.ImageVerified.IsTrue(image) || harvesterv1.ImageVerified.IsFalse(image) {
+		h.verifying.Forget(key)
+		return image, nil
+	}
+	// the source files of converted images are verified before they are converted
+	if util.NeedImageConversion(image.Status.Format) {
+		return image, nil
+	}
+
+	if harvesterv1.ImageVerified.GetReason(image) != verifiedReasonVerifying {
+		toUpdate := image.DeepCopy()
+		harvesterv1.ImageVerified.Unknown(toUpdate)
+		harvesterv1.ImageVerified.Reason(toUpdate, verifiedReasonVerifying)
+		harvesterv1.ImageVerified.Message(toUpdate, "")
+		harvesterv1.ImageVerified.LastUpdated(toUpdate, time.Now().Format(time.RFC3339))
+		return h.images.Update(toUpdate)
+	}
+
+	backingImage, err := h.backingImageCache.Get(util.LonghornSystemNamespaceName, util.GetBackingImageName(image))
+	if err != nil {
+		return image, err
+	}
+	h.verifying.Start(key, string(backingImage.UID), imageTaskTimeout, func(ctx context.Context) error {
+		err := h.verifyImage(ctx, image)
+		// the verification is cancelled when the image is removed or its data is replaced
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return ctx.Err()
+		}
+		if err != nil && !IsVerificationError(err) {
+			logrus.Errorf("failed to verify image %s/%s, err: %v", image.Namespace, image.Name, err)
+			h.imageController.EnqueueAfter(image.Namespace, image.Name, imageTaskRetryInterval)
+			return err
+		}
+		if updateErr := h.updateVerifiedCondition(image, err); updateErr != nil {
+			logrus.Errorf("failed to update verified condition of image %s/%s, err: %v", image.Namespace, image.Name, updateErr)
+			h.imageController.EnqueueAfter(image.Namespace, image.Name, imageTaskRetryInterval)
+			return updateErr
+		}
+		return nil
+	})
+	return image, nil
+}
+
+func (h *vmImageHandler) verifyImage(ctx context.Context, image *harvesterv1.VirtualMachineImage) error {
+	downloadURL := fmt.Sprintf("%s/backingimages/%s/download", util.LonghornDefaultManagerURL, util.GetBackingImageName(image))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.dataHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to read image data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to read image data: got %d status code from %s", resp.StatusCode, downloadURL)
+	}
+	return h.verifier.Verify(image, resp.Body)
+}
+
+// Verify reads the image data and verifies it with the checksum and signature of the image,
+// the checksums verified by Longhorn are skipped.
+func (v *Verifier) Verify(image *harvesterv1.VirtualMachineImage, data io.Reader) error {
+	var (
+		signature  []byte
+		publicKeys [][]byte
+		err        error
+	)
+	if image.Spec.Signature != nil {
+		if signature, err = v.getSignature(image.Spec.Signature); err != nil {
+			return err
+		}
+		if publicKeys, err = v.getPublicKeys(image.Namespace, image.Spec.Signature.PublicKeySecretName); err != nil {
+			return err
+		}
+	}
+
+	checksum := image.Spec.Checksum
+	if util.GetImageLonghornChecksum(image) != "" {
+		checksum = ""
+	}
+	return verifyImageData(data, image.Spec.ChecksumAlgorithm, checksum, image.Spec.Signature, signature, publicKeys)
+}
+
+// getSignature returns the signature downloaded from its URL or the one in the spec
+func (v *Verifier) getSignature(signature *harvesterv1.VirtualMachineImageSignature) ([]byte, error) {
+	if signature.URL == "" {
+		return []byte(signature.Signature), nil
+	}
+	resp, err := v.httpClient.Get(signature.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download signature: got %d status code from %s", resp.StatusCode, signature.URL)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature: %w", err)
+	}
+	if len(data) > maxSignatureSize {
+		return nil, newVerificationError("signature %s is larger than %d bytes", signature.URL, maxSignatureSize)
+	}
+	return data, nil
+}
+
+// getPublicKeys returns the public keys in the secret sorted by their keys
+func (v *Verifier) getPublicKeys(namespace, name string) ([][]byte, error) {
+	secret, err := v.secretCache.Get(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key secret %s/%s: %w", namespace, name, err)
+	}
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	publicKeys := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		publicKeys = append(publicKeys, secret.Data[key])
+	}
+	if len(publicKeys) == 0 {
+		return nil, newVerificationError("public key secret %s/%s is empty", namespace, name)
+	}
+	return publicKeys, nil
+}
+
+func (h *vmImageHandler) updateVerifiedCondition(image *harvesterv1.VirtualMachineImage, verifyErr error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := h.images.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// the image is recreated or deleted
+		if current.UID != image.UID || current.DeletionTimestamp != nil {
+			return nil
+		}
+		toUpdate := current.DeepCopy()
+		SetVerifiedCondition(toUpdate, verifyErr)
+		_, err = h.images.Update(toUpdate)
+		return err
+	})
+}
+
+// SetVerifiedCondition sets the Verified condition of the image with the verification result
+func SetVerifiedCondition(image *harvesterv1.VirtualMachineImage, verifyErr error) {
+	if verifyErr != nil {
+		harvesterv1.ImageVerified.False(image)
+		harvesterv1.ImageVerified.Reason(image, verifiedReasonFailed)
+		harvesterv1.ImageVerified.Message(image, verifyErr.Error())
+	} else {
+		harvesterv1.ImageVerified.True(image)
+		harvesterv1.ImageVerified.Reason(image, verifiedReasonVerified)
+		harvesterv1.ImageVerified.Message(image, "")
+	}
+	harvesterv1.ImageVerified.LastUpdated(image, time.Now().Format(time.RFC3339))
+}
+
+// verifyImageData reads the image data and verifies its checksum of the algorithm, sha512 by default, if it's not empty,
+// and the signature by one of the public keys if the signature spec isn't nil.
+func verifyImageData(data io.Reader, checksumAlgorithm, checksum string, signatureSpec *harvesterv1.VirtualMachineImageSignature, signature []byte, publicKeys [][]byte) error {
+	// the failures to read the image data are told apart from the invalid OpenPGP signatures checked while reading it
+	source := &dataReader{reader: data}
+	data = source
+	// the cosign signatures are made on the SHA-256 digest
+	digest := sha256.New()
+	data = io.TeeReader(data, digest)
+	checksumDigest := digest
+	if checksum != "" && checksumAlgorithm != harvesterv1.VirtualMachineImageChecksumAlgorithmSHA256 {
+		checksumAlgorithm = harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512
+		checksumDigest = sha512.New()
+		data = io.TeeReader(data, checksumDigest)
+	}
+
+	if signatureSpec != nil && signatureSpec.Type == harvesterv1.VirtualMachineImageSignatureTypeGPG {
+		// the OpenPGP signature is checked while the data is read
+		if err := verifyGPGSignature(data, signature, publicKeys); err != nil {
+			if source.err != nil {
+				return fmt.Errorf("failed to read image data: %w", source.err)
+			}
+			return err
+		}
+	}
+	if _, err := io.Copy(io.Discard, data); err != nil {
+		return fmt.Errorf("failed to read image data: %w", err)
+	}
+
+	if checksum != "" {
+		if sum := hex.EncodeToString(checksumDigest.Sum(nil)); !strings.EqualFold(sum, strings.TrimSpace(checksum)) {
+			return newVerificationError("%s checksum mismatch, expected %s, got %s", checksumAlgorithm, checksum, sum)
+		}
+	}
+	if signatureSpec != nil && signatureSpec.Type == harvesterv1.VirtualMachineImageSignatureTypeCosign {
+		return verifyCosignSignature(digest, signature, publicKeys)
+	}
+	return nil
+}
+
+// dataReader records the error to read the data other than io.EOF
+type dataReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *dataReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func verifyGPGSignature(data io.Reader, signature []byte, publicKeys [][]byte) error {
+	var keyring openpgp.EntityList
+	for _, publicKey := range publicKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(publicKey))
+		if err != nil {
+			entities, err = openpgp.ReadKeyRing(bytes.NewReader(publicKey))
+		}
+		if err != nil {
+			return newVerificationError("failed to read OpenPGP public key: %v", err)
+		}
+		keyring = append(keyring, entities...)
+	}
+
+	signature, err := decodeGPGSignature(signature)
+	if err != nil {
+		return err
+	}
+	if _, err := openpgp.CheckDetachedSignature(keyring, data, bytes.NewReader(signature)); err != nil {
+		return newVerificationError("invalid OpenPGP signature: %v", err)
+	}
+	return nil
+}
+
+// decodeGPGSignature returns the binary OpenPGP signature of an armored, base64 encoded or binary signature
+func decodeGPGSignature(signature []byte) ([]byte, error) {
+	if block, err := armor.Decode(bytes.NewReader(signature)); err == nil {
+		return io.ReadAll(block.Body)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		return decoded, nil
+	}
+	return signature, nil
+}
+
+// verifyCosignSignature verifies a signature created by `cosign sign-blob`,
+// it's a base64 encoded ECDSA or RSA PKCS #1 v1.5 signature of the SHA-256 digest of the data.
+func verifyCosignSignature(digest hash.Hash, signature []byte, publicKeys [][]byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return newVerificationError("failed to decode cosign signature: %v", err)
+	}
+	sum := digest.Sum(nil)
+	for _, publicKey := range publicKeys {
+		block, _ := pem.Decode(publicKey)
+		if block == nil {
+			return newVerificationError("failed to decode PEM public key")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return newVerificationError("failed to parse public key: %v", err)
+		}
+		switch key := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, sum, decoded) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum, decoded) == nil {
+				return nil
+			}
+		default:
+			return newVerificationError("unsupported public key type %T", key)
+		}
+	}
+	return newVerificationError("invalid cosign signature: it's not made by any of the public keys")
+}
@@ -0,0 +1,155 @@
+package image
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+var imageData = []byte("harvester vm image data")
+
+func TestVerifyImageDataChecksum(t *testing.T) {
+	sum := sha256.Sum256(imageData)
+	checksum := hex.EncodeToString(sum[:])
+
+	assert.Nil(t, verifyImageData(bytes.NewReader(imageData), harvesterv1.VirtualMachineImageChecksumAlgorithmSHA256, checksum, nil, nil, nil))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader([]byte("tampered")), harvesterv1.VirtualMachineImageChecksumAlgorithmSHA256, checksum, nil, nil, nil)))
+
+	sum512 := sha512.Sum512(imageData)
+	checksum = hex.EncodeToString(sum512[:])
+	assert.Nil(t, verifyImageData(bytes.NewReader(imageData), harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512, checksum, nil, nil, nil))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader([]byte("tampered")), harvesterv1.VirtualMachineImageChecksumAlgorithmSHA512, checksum, nil, nil, nil)))
+}
+
+func TestVerifyImageDataGPGSignature(t *testing.T) {
+	entity, err := openpgp.NewEntity("harvester", "", "harvester@example.com", nil)
+	assert.Nil(t, err)
+	var signature bytes.Buffer
+	assert.Nil(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(imageData), nil))
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, entity.Serialize(w))
+	assert.Nil(t, w.Close())
+
+	other, err := openpgp.NewEntity("other", "", "other@example.com", nil)
+	assert.Nil(t, err)
+	var otherKey bytes.Buffer
+	assert.Nil(t, other.Serialize(&otherKey))
+
+	spec := &harvesterv1.VirtualMachineImageSignature{Type: harvesterv1.VirtualMachineImageSignatureTypeGPG}
+	// the keys can be armored or binary
+	assert.Nil(t, verifyImageData(bytes.NewReader(imageData), "", "", spec, signature.Bytes(), [][]byte{otherKey.Bytes(), publicKey.Bytes()}))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader([]byte("tampered")), "", "", spec, signature.Bytes(), [][]byte{publicKey.Bytes()})))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader(imageData), "", "", spec, signature.Bytes(), [][]byte{otherKey.Bytes()})))
+
+	// the failures to read the image data are retried
+	err = verifyImageData(io.MultiReader(bytes.NewReader(imageData), iotest.ErrReader(errors.New("connection reset"))), "", "", spec, signature.Bytes(), [][]byte{publicKey.Bytes()})
+	assert.NotNil(t, err)
+	assert.False(t, IsVerificationError(err))
+}
+
+func TestVerifyImageDataCosignSignature(t *testing.T) {
+	newKey := func() (*ecdsa.PrivateKey, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.Nil(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		assert.Nil(t, err)
+		return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+	key, publicKey := newKey()
+	_, otherKey := newKey()
+	sum := sha256.Sum256(imageData)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	assert.Nil(t, err)
+	signature := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	spec := &harvesterv1.VirtualMachineImageSignature{Type: harvesterv1.VirtualMachineImageSignatureTypeCosign}
+	assert.Nil(t, verifyImageData(bytes.NewReader(imageData), "", "", spec, signature, [][]byte{otherKey, publicKey}))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader([]byte("tampered")), "", "", spec, signature, [][]byte{publicKey})))
+	assert.True(t, IsVerificationError(verifyImageData(bytes.NewReader(imageData), "", "", spec, signature, [][]byte{otherKey})))
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestVerifyInBackground(t *testing.T) {
+	sum := sha256.Sum256(imageData)
+	image := &harvesterv1.VirtualMachineImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image", UID: "image-uid"},
+		Spec: harvesterv1.VirtualMachineImageSpec{
+			SourceType:        harvesterv1.VirtualMachineImageSourceTypeDownload,
+			Checksum:          hex.EncodeToString(sum[:]),
+			ChecksumAlgorithm: harvesterv1.VirtualMachineImageChecksumAlgorithmSHA256,
+		},
+	}
+	harvesterv1.ImageImported.True(image)
+	backingImage := &lhv1beta1.BackingImage{
+		ObjectMeta: metav1.ObjectMeta{Namespace: util.LonghornSystemNamespaceName, Name: util.GetBackingImageName(image), UID: "backing-image-uid"},
+	}
+	clientSet := fake.NewSimpleClientset(image, backingImage)
+
+	// the stalled reads of the image data are cancelled when the verification is forgotten, e.g. the image is removed
+	stalled := make(chan struct{})
+	cancelled := make(chan struct{})
+	handler := &vmImageHandler{
+		images:            fakeclients.VirtualMachineImageClient(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+		backingImageCache: fakeclients.BackingImageCache(clientSet.LonghornV1beta1().BackingImages),
+		verifier:          NewVerifier(http.DefaultClient, nil),
+	}
+	handler.dataHTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(stalled)
+		<-req.Context().Done()
+		close(cancelled)
+		return nil, req.Context().Err()
+	})
+
+	image, err := handler.verify(image)
+	assert.Nil(t, err)
+	assert.Equal(t, verifiedReasonVerifying, harvesterv1.ImageVerified.GetReason(image))
+	// the handler returns without waiting for the image data
+	image, err = handler.verify(image)
+	assert.Nil(t, err)
+	<-stalled
+	assert.True(t, harvesterv1.ImageVerified.IsUnknown(image))
+	handler.verifying.Forget(string(image.UID))
+	<-cancelled
+
+	// the result is recorded in the Verified condition once the image data is read
+	handler.dataHTTPClient.Transport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(imageData)), Request: req}, nil
+	})
+	_, err = handler.verify(image)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		current, err := handler.images.Get(image.Namespace, image.Name, metav1.GetOptions{})
+		return err == nil && harvesterv1.ImageVerified.IsTrue(current)
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -41,6 +41,9 @@ var registerFuncs = []registerFunc{
 	backup.RegisterRestore,
 	backup.RegisterBackupTarget,
 	backup.RegisterBackupMetadata,
+	backup.RegisterBackupSchedule,
+	backup.RegisterBackupGroup,
+	backup.RegisterImageReplication,
 	supportbundle.Register,
 	rancher.Register,
 	upgrade.Register,
@@ -0,0 +1,216 @@
+package virtualmachine
+
+import (
+	"fmt"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	wranglername "github.com/rancher/wrangler/pkg/name"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	cloneVolumeSnapshotEnqueueInterval = 5 * time.Second
+)
+
+// CloneController copies the VolumeSnapshots taken to clone a VM to another namespace, the PVCs of the clone
+// are restored from the copies in the target namespace. Both the VolumeSnapshots and the copies are removed
+// after the data of the PVCs is restored.
+type CloneController struct {
+	snapshotController   ctlsnapshotv1.VolumeSnapshotController
+	snapshotCache        ctlsnapshotv1.VolumeSnapshotCache
+	snapshotContents     ctlsnapshotv1.VolumeSnapshotContentClient
+	snapshotContentCache ctlsnapshotv1.VolumeSnapshotContentCache
+	pvcCache             ctlcorev1.PersistentVolumeClaimCache
+	volumeCache          ctllonghornv1.VolumeCache
+}
+
+// OnVolumeSnapshotChange copies a VolumeSnapshot to the target namespace of a clone when it's ready,
+// and cleans up the VolumeSnapshot and its copy after the PVC restored from the copy is ready.
+func (h *CloneController) OnVolumeSnapshotChange(_ string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	if snapshot == nil || snapshot.DeletionTimestamp != nil {
+		return snapshot, nil
+	}
+
+	if targetNamespace := snapshot.Annotations[util.AnnotationCloneTargetNamespace]; targetNamespace != "" {
+		return snapshot, h.copyVolumeSnapshot(snapshot, targetNamespace)
+	}
+	if sourceNamespace := snapshot.Annotations[util.AnnotationCloneSourceNamespace]; sourceNamespace != "" {
+		return snapshot, h.cleanupVolumeSnapshots(snapshot, sourceNamespace)
+	}
+	return snapshot, nil
+}
+
+func (h *CloneController) copyVolumeSnapshot(snapshot *snapshotv1.VolumeSnapshot, targetNamespace string) error {
+	if _, err := h.snapshotCache.Get(targetNamespace, snapshot.Name); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse ||
+		snapshot.Status.BoundVolumeSnapshotContentName == nil {
+		return nil
+	}
+	content, err := h.snapshotContentCache.Get(*snapshot.Status.BoundVolumeSnapshotContentName)
+	if err != nil {
+		return err
+	}
+	if content.Status == nil || content.Status.SnapshotHandle == nil {
+		return fmt.Errorf("snapshot handle of VolumeSnapshotContent %s is not set", content.Name)
+	}
+
+	// The copy shares the snapshot of the storage with the source VolumeSnapshot,
+	// use Retain policy so that it's only deleted along with the source VolumeSnapshot.
+	contentName := getCloneVolumeSnapshotContentName(targetNamespace, snapshot.Name)
+	logrus.Debugf("create VolumeSnapshotContent %s", contentName)
+	if _, err := h.snapshotContents.Create(&snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: contentName,
+		},
+		Spec: snapshotv1.VolumeSnapshotContentSpec{
+			Driver:         content.Spec.Driver,
+			DeletionPolicy: snapshotv1.VolumeSnapshotContentRetain,
+			Source: snapshotv1.VolumeSnapshotContentSource{
+				SnapshotHandle: pointer.StringPtr(*content.Status.SnapshotHandle),
+			},
+			VolumeSnapshotClassName: content.Spec.VolumeSnapshotClassName,
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      snapshot.Name,
+				Namespace: targetNamespace,
+			},
+		},
+	}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	annotations := map[string]string{}
+	for key, value := range snapshot.Annotations {
+		annotations[key] = value
+	}
+	delete(annotations, util.AnnotationCloneTargetNamespace)
+	annotations[util.AnnotationCloneSourceNamespace] = snapshot.Namespace
+
+	logrus.Debugf("create VolumeSnapshot %s/%s", targetNamespace, snapshot.Name)
+	_, err = h.snapshotController.Create(&snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        snapshot.Name,
+			Namespace:   targetNamespace,
+			Annotations: annotations,
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				VolumeSnapshotContentName: pointer.StringPtr(contentName),
+			},
+			VolumeSnapshotClassName: snapshot.Spec.VolumeSnapshotClassName,
+		},
+	})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (h *CloneController) cleanupVolumeSnapshots(snapshot *snapshotv1.VolumeSnapshot, sourceNamespace string) error {
+	sourceSnapshot, err := h.getCloneSourceVolumeSnapshot(snapshot, sourceNamespace)
+	if err != nil {
+		return err
+	}
+
+	// the PVC restored from the copy has the same name
+	pvc, err := h.pvcCache.Get(snapshot.Namespace, snapshot.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && !isPVCRestoredFromVolumeSnapshot(pvc, snapshot) {
+		return fmt.Errorf("pvc %s/%s is not restored from VolumeSnapshot %s", pvc.Namespace, pvc.Name, snapshot.Name)
+	}
+	if err != nil || pvc.Status.Phase != corev1.ClaimBound {
+		h.snapshotController.EnqueueAfter(snapshot.Namespace, snapshot.Name, cloneVolumeSnapshotEnqueueInterval)
+		return nil
+	}
+
+	if util.GetProvisionedPVCProvisioner(pvc) == longhorntypes.LonghornDriverName {
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+		if err != nil {
+			return err
+		}
+		if !isLonghornVolumeDataRestored(volume) {
+			h.snapshotController.EnqueueAfter(snapshot.Namespace, snapshot.Name, cloneVolumeSnapshotEnqueueInterval)
+			return nil
+		}
+	}
+
+	logrus.Infof("volume of pvc %s/%s is cloned, remove VolumeSnapshot %s from namespace %s and %s",
+		pvc.Namespace, pvc.Name, snapshot.Name, snapshot.Namespace, sourceNamespace)
+	if err := h.snapshotController.Delete(snapshot.Namespace, snapshot.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	contentName := getCloneVolumeSnapshotContentName(snapshot.Namespace, snapshot.Name)
+	if err := h.snapshotContents.Delete(contentName, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if sourceSnapshot == nil {
+		return nil
+	}
+	if err := h.snapshotController.Delete(sourceNamespace, sourceSnapshot.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &sourceSnapshot.UID},
+	}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// getCloneSourceVolumeSnapshot returns the VolumeSnapshot in the source namespace the copy is made from,
+// or nil if it's removed. It returns an error if the VolumeSnapshot is not taken to clone to the namespace
+// of the copy or the copy is not bound to the VolumeSnapshotContent made for it,
+// so that the controller never deletes VolumeSnapshots of other namespaces it doesn't own.
+func (h *CloneController) getCloneSourceVolumeSnapshot(snapshot *snapshotv1.VolumeSnapshot, sourceNamespace string) (*snapshotv1.VolumeSnapshot, error) {
+	contentName := getCloneVolumeSnapshotContentName(snapshot.Namespace, snapshot.Name)
+	if snapshot.Spec.Source.VolumeSnapshotContentName == nil || *snapshot.Spec.Source.VolumeSnapshotContentName != contentName {
+		return nil, fmt.Errorf("VolumeSnapshot %s/%s is not a clone copy", snapshot.Namespace, snapshot.Name)
+	}
+
+	sourceSnapshot, err := h.snapshotCache.Get(sourceNamespace, snapshot.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if sourceSnapshot.Annotations[util.AnnotationCloneTargetNamespace] != snapshot.Namespace {
+		return nil, fmt.Errorf("VolumeSnapshot %s/%s is not taken to clone to namespace %s", sourceNamespace, sourceSnapshot.Name, snapshot.Namespace)
+	}
+	return sourceSnapshot, nil
+}
+
+func isPVCRestoredFromVolumeSnapshot(pvc *corev1.PersistentVolumeClaim, snapshot *snapshotv1.VolumeSnapshot) bool {
+	dataSource := pvc.Spec.DataSource
+	return dataSource != nil && dataSource.Kind == "VolumeSnapshot" && dataSource.Name == snapshot.Name &&
+		dataSource.APIGroup != nil && *dataSource.APIGroup == snapshotv1.SchemeGroupVersion.Group
+}
+
+// isLonghornVolumeDataRestored checks whether Longhorn finishes copying the data of a volume from its data source
+func isLonghornVolumeDataRestored(volume *lhv1beta1.Volume) bool {
+	switch {
+	case volume.Spec.DataSource != "":
+		return volume.Status.CloneStatus.State == lhv1beta1.VolumeCloneStateCompleted
+	case volume.Spec.FromBackup != "":
+		return volume.Status.RestoreInitiated && !volume.Status.RestoreRequired
+	}
+	return true
+}
+
+func getCloneVolumeSnapshotContentName(namespace, name string) string {
+	return wranglername.SafeConcatName("clone", namespace, name)
+}
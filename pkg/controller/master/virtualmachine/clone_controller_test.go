@@ -0,0 +1,63 @@
+package virtualmachine
+
+import (
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestIsPVCRestoredFromVolumeSnapshot(t *testing.T) {
+	snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "clone-disk-0", Namespace: "target"},
+	}
+
+	var testCases = []struct {
+		name       string
+		dataSource *corev1.TypedLocalObjectReference
+		expected   bool
+	}{
+		{
+			name: "restored from the snapshot",
+			dataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: pointer.StringPtr(snapshotv1.SchemeGroupVersion.Group),
+				Kind:     "VolumeSnapshot",
+				Name:     "clone-disk-0",
+			},
+			expected: true,
+		},
+		{
+			name:     "no data source",
+			expected: false,
+		},
+		{
+			name: "cloned from a pvc of the same name",
+			dataSource: &corev1.TypedLocalObjectReference{
+				Kind: "PersistentVolumeClaim",
+				Name: "clone-disk-0",
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		pvc := &corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{DataSource: tc.dataSource}}
+		assert.Equal(t, tc.expected, isPVCRestoredFromVolumeSnapshot(pvc, snapshot), tc.name)
+	}
+}
+
+func TestGetCloneSourceVolumeSnapshotRejectsOtherSnapshots(t *testing.T) {
+	h := &CloneController{}
+	// a VolumeSnapshot annotated by hand isn't bound to the VolumeSnapshotContent made by the clone controller
+	snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "disk-0", Namespace: "target"},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: pointer.StringPtr("disk-0")},
+		},
+	}
+	_, err := h.getCloneSourceVolumeSnapshot(snapshot, "victim")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,225 @@
+package virtualmachine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	volumeapi "github.com/harvester/harvester/pkg/api/volume"
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	powerActionStart = "start"
+	powerActionStop  = "stop"
+
+	powerScheduleInvalidTimeZoneReason = "InvalidTimeZone"
+	powerScheduleInvalidCronReason     = "InvalidCron"
+	powerScheduleSuspendedReason       = "Suspended"
+	powerSchedulePowerVMsFailedReason  = "PowerVMsFailed"
+)
+
+// PowerScheduleController starts and stops the VMs selected by the VirtualMachinePowerSchedules at the scheduled time.
+// The VMs are started and stopped through the start and stop subresources like the start and stop actions of the VM API,
+// so the run strategy is stored and restored as usual.
+type PowerScheduleController struct {
+	ctx                context.Context
+	schedules          ctlharvesterv1.VirtualMachinePowerScheduleClient
+	scheduleController ctlharvesterv1.VirtualMachinePowerScheduleController
+	vmCache            ctlkubevirtv1.VirtualMachineCache
+	pvcCache           v1.PersistentVolumeClaimCache
+	restClient         rest.Interface
+}
+
+// OnScheduleChange powers the VMs if a start or stop time is due, then requeues the schedule until the next one
+func (h *PowerScheduleController) OnScheduleChange(_ string, schedule *harvesterv1.VirtualMachinePowerSchedule) (*harvesterv1.VirtualMachinePowerSchedule, error) {
+	if schedule == nil || schedule.DeletionTimestamp != nil {
+		return schedule, nil
+	}
+
+	scheduleCpy := schedule.DeepCopy()
+	loc, err := time.LoadLocation(schedule.Spec.TimeZone)
+	if err != nil {
+		return h.setNotReady(schedule, scheduleCpy, powerScheduleInvalidTimeZoneReason, err.Error())
+	}
+	startSched, err := parsePowerScheduleCron(schedule.Spec.StartCron)
+	if err != nil {
+		return h.setNotReady(schedule, scheduleCpy, powerScheduleInvalidCronReason, err.Error())
+	}
+	stopSched, err := parsePowerScheduleCron(schedule.Spec.StopCron)
+	if err != nil {
+		return h.setNotReady(schedule, scheduleCpy, powerScheduleInvalidCronReason, err.Error())
+	}
+	if schedule.Spec.Suspend {
+		return h.setNotReady(schedule, scheduleCpy, powerScheduleSuspendedReason, "schedule is suspended")
+	}
+
+	// the cron expressions are evaluated in the time zone of the schedule
+	now := time.Now().In(loc)
+	dueStart := getDuePowerScheduleTime(startSched, schedule.CreationTimestamp, schedule.Status.LastStartTime, now)
+	dueStop := getDuePowerScheduleTime(stopSched, schedule.CreationTimestamp, schedule.Status.LastStopTime, now)
+
+	// when both are due, e.g. the controller was down for a while, the latest one wins
+	var action string
+	switch {
+	case !dueStart.IsZero() && dueStart.After(dueStop):
+		action = powerActionStart
+	case !dueStop.IsZero():
+		action = powerActionStop
+	}
+	if action != "" {
+		if err := h.powerVMs(schedule, action); err != nil {
+			harvesterv1.PowerScheduleReady.False(scheduleCpy)
+			harvesterv1.PowerScheduleReady.Reason(scheduleCpy, powerSchedulePowerVMsFailedReason)
+			harvesterv1.PowerScheduleReady.Message(scheduleCpy, err.Error())
+			if _, updateErr := h.updateStatus(schedule, scheduleCpy); updateErr != nil {
+				return schedule, updateErr
+			}
+			return schedule, err
+		}
+		if !dueStart.IsZero() {
+			scheduleCpy.Status.LastStartTime = &metav1.Time{Time: dueStart}
+		}
+		if !dueStop.IsZero() {
+			scheduleCpy.Status.LastStopTime = &metav1.Time{Time: dueStop}
+		}
+	}
+
+	scheduleCpy.Status.NextStartTime = getNextPowerScheduleTime(startSched, now)
+	scheduleCpy.Status.NextStopTime = getNextPowerScheduleTime(stopSched, now)
+	if next := getEarliestTime(scheduleCpy.Status.NextStartTime, scheduleCpy.Status.NextStopTime); next != nil {
+		h.scheduleController.EnqueueAfter(schedule.Namespace, schedule.Name, next.Sub(now))
+	}
+	harvesterv1.PowerScheduleReady.True(scheduleCpy)
+	harvesterv1.PowerScheduleReady.Reason(scheduleCpy, "")
+	harvesterv1.PowerScheduleReady.Message(scheduleCpy, "")
+	return h.updateStatus(schedule, scheduleCpy)
+}
+
+// powerVMs starts or stops the selected VMs, the VMs already in the target state are skipped
+func (h *PowerScheduleController) powerVMs(schedule *harvesterv1.VirtualMachinePowerSchedule, action string) error {
+	selector, err := metav1.LabelSelectorAsSelector(&schedule.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	vms, err := h.vmCache.List(schedule.Namespace, selector)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, vm := range vms {
+		if vm.DeletionTimestamp != nil {
+			continue
+		}
+		runStrategy, err := vm.RunStrategy()
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		halted := runStrategy == kubevirtv1.RunStrategyHalted
+		if (action == powerActionStart && !halted) || (action == powerActionStop && halted) {
+			continue
+		}
+		if action == powerActionStart {
+			if err := h.startPreCheck(vm); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+
+		logrus.Infof("%s vm %s/%s by power schedule %s", action, vm.Namespace, vm.Name, schedule.Name)
+		if err := h.restClient.Put().Namespace(vm.Namespace).Resource("virtualmachines").SubResource(action).Name(vm.Name).Do(h.ctx).Error(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s virtual machine %s/%s failed, %v", action, vm.Namespace, vm.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// startPreCheck rejects starting a VM with resizing volumes, the same as the start action of the VM API
+func (h *PowerScheduleController) startPreCheck(vm *kubevirtv1.VirtualMachine) error {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := h.pvcCache.Get(vm.Namespace, volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			return err
+		}
+		if volumeapi.IsResizing(pvc) {
+			return fmt.Errorf("can not start the VM %s/%s which has a resizing volume %s/%s", vm.Namespace, vm.Name, pvc.Namespace, pvc.Name)
+		}
+	}
+	return nil
+}
+
+func (h *PowerScheduleController) setNotReady(schedule, scheduleCpy *harvesterv1.VirtualMachinePowerSchedule, reason, message string) (*harvesterv1.VirtualMachinePowerSchedule, error) {
+	scheduleCpy.Status.NextStartTime = nil
+	scheduleCpy.Status.NextStopTime = nil
+	harvesterv1.PowerScheduleReady.False(scheduleCpy)
+	harvesterv1.PowerScheduleReady.Reason(scheduleCpy, reason)
+	harvesterv1.PowerScheduleReady.Message(scheduleCpy, message)
+	return h.updateStatus(schedule, scheduleCpy)
+}
+
+func (h *PowerScheduleController) updateStatus(schedule, scheduleCpy *harvesterv1.VirtualMachinePowerSchedule) (*harvesterv1.VirtualMachinePowerSchedule, error) {
+	if reflect.DeepEqual(schedule.Status, scheduleCpy.Status) {
+		return schedule, nil
+	}
+	return h.schedules.Update(scheduleCpy)
+}
+
+func parsePowerScheduleCron(spec string) (cron.Schedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	return cron.ParseStandard(spec)
+}
+
+// getDuePowerScheduleTime returns the most recent scheduled time since the last run, or a zero time if there is none
+func getDuePowerScheduleTime(sched cron.Schedule, creationTime metav1.Time, lastTime *metav1.Time, now time.Time) time.Time {
+	if sched == nil {
+		return time.Time{}
+	}
+	last := creationTime.Time
+	if lastTime != nil {
+		last = lastTime.Time
+	}
+	return util.GetMostRecentScheduleTime(sched, last.In(now.Location()), now)
+}
+
+func getNextPowerScheduleTime(sched cron.Schedule, now time.Time) *metav1.Time {
+	if sched == nil {
+		return nil
+	}
+	next := sched.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &metav1.Time{Time: next}
+}
+
+func getEarliestTime(times ...*metav1.Time) *metav1.Time {
+	var earliest *metav1.Time
+	for _, t := range times {
+		if t != nil && (earliest == nil || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	return earliest
+}
@@ -0,0 +1,61 @@
+package virtualmachine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDuePowerScheduleTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+	sched, err := cron.ParseStandard("0 8 * * *")
+	assert.Nil(t, err)
+	creation := metav1.NewTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var testCases = []struct {
+		name     string
+		sched    cron.Schedule
+		last     *metav1.Time
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "no cron",
+			now:      time.Date(2023, 1, 2, 12, 0, 0, 0, berlin),
+			expected: time.Time{},
+		},
+		{
+			name:     "not due since creation",
+			sched:    sched,
+			now:      time.Date(2023, 1, 1, 7, 0, 0, 0, berlin),
+			expected: time.Time{},
+		},
+		{
+			name:     "due in the time zone of the schedule",
+			sched:    sched,
+			now:      time.Date(2023, 1, 1, 8, 0, 0, 0, berlin),
+			expected: time.Date(2023, 1, 1, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "most recent of the missed runs",
+			sched:    sched,
+			now:      time.Date(2023, 1, 3, 12, 0, 0, 0, berlin),
+			expected: time.Date(2023, 1, 3, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "already run",
+			sched:    sched,
+			last:     &metav1.Time{Time: time.Date(2023, 1, 3, 7, 0, 0, 0, time.UTC)},
+			now:      time.Date(2023, 1, 3, 12, 0, 0, 0, berlin),
+			expected: time.Time{},
+		},
+	}
+	for _, tc := range testCases {
+		due := getDuePowerScheduleTime(tc.sched, creation, tc.last, tc.now)
+		assert.True(t, tc.expected.Equal(due), "%s: expected %v, got %v", tc.name, tc.expected, due)
+	}
+}
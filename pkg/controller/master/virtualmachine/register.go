@@ -3,7 +3,12 @@ package virtualmachine
 import (
 	"context"
 
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
 	"github.com/harvester/harvester/pkg/config"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/scheme"
 )
 
 const (
@@ -14,6 +19,10 @@ const (
 	vmControllerStoreRunStrategyControllerName         = "VMController.StoreRunStrategyToAnnotation"
 	vmControllerSyncLabelsToVmi                        = "VMController.SyncLabelsToVmi"
 	vmControllerManagePVCOwnerControllerName           = "VMController.ManageOwnerOfPVCs"
+	cloneControllerCopyVolumeSnapshotsControllerName   = "CloneController.CopyVolumeSnapshots"
+	volumeMigrationControllerName                      = "VolumeMigrationController.MigrateVolume"
+	powerScheduleControllerName                        = "PowerScheduleController.OnScheduleChange"
+	vmMoveControllerName                               = "VMMoveController.MoveVM"
 	harvesterUnsetOwnerOfPVCsFinalizer                 = "harvesterhci.io/VMController.UnsetOwnerOfPVCs"
 	oldWranglerFinalizer                               = "wrangler.cattle.io/VMController.UnsetOwnerOfPVCs"
 )
@@ -32,6 +41,11 @@ func Register(ctx context.Context, management *config.Management, options config
 		vmBackupCache  = vmBackupClient.Cache()
 		snapshotClient = management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshot()
 		snapshotCache  = snapshotClient.Cache()
+		contentClient  = management.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotContent()
+		volumeClient   = management.LonghornFactory.Longhorn().V1beta1().Volume()
+		volumeCache    = volumeClient.Cache()
+		pvClient       = management.CoreFactory.Core().V1().PersistentVolume()
+		secretClient   = management.CoreFactory.Core().V1().Secret()
 	)
 
 	// registers the vm controller
@@ -74,5 +88,64 @@ func Register(ctx context.Context, management *config.Management, options config
 	}
 	virtualMachineInstanceClient.OnChange(ctx, vmControllerSetDefaultManagementNetworkMac, vmNetworkCtl.SetDefaultNetworkMacAddress)
 
+	// register the clone controller to copy the volume snapshots of VMs cloned to other namespaces
+	var cloneCtrl = &CloneController{
+		snapshotController:   snapshotClient,
+		snapshotCache:        snapshotCache,
+		snapshotContents:     contentClient,
+		snapshotContentCache: contentClient.Cache(),
+		pvcCache:             pvcCache,
+		volumeCache:          volumeCache,
+	}
+	snapshotClient.OnChange(ctx, cloneControllerCopyVolumeSnapshotsControllerName, cloneCtrl.OnVolumeSnapshotChange)
+
+	// register the volume migration controller to move VM volumes to other storage classes
+	var volumeMigrationCtrl = &VolumeMigrationController{
+		vmController:  vmClient,
+		vmiCache:      vmiCache,
+		pvcClient:     pvcClient,
+		pvcCache:      pvcCache,
+		snapshots:     snapshotClient,
+		snapshotCache: snapshotCache,
+		volumes:       volumeClient,
+		volumeCache:   volumeCache,
+	}
+	virtualMachineClient.OnChange(ctx, volumeMigrationControllerName, volumeMigrationCtrl.MigrateVolume)
+
+	// register the VM move controller to rename VMs and move them to other namespaces
+	var vmMoveCtrl = &VMMoveController{
+		vmController: vmClient,
+		vmCache:      vmCache,
+		vmiCache:     vmiCache,
+		pvcClient:    pvcClient,
+		pvcCache:     pvcCache,
+		pvClient:     pvClient,
+		pvCache:      pvClient.Cache(),
+		secretClient: secretClient,
+		secretCache:  secretClient.Cache(),
+		sars:         management.ClientSet.AuthorizationV1().SubjectAccessReviews(),
+	}
+	virtualMachineClient.OnChange(ctx, vmMoveControllerName, vmMoveCtrl.MoveVM)
+
+	// register the power schedule controller to start and stop VMs periodically
+	copyConfig := rest.CopyConfig(management.RestConfig)
+	copyConfig.GroupVersion = &k8sschema.GroupVersion{Group: kubevirtv1.SubresourceGroupName, Version: kubevirtv1.ApiLatestVersion}
+	copyConfig.APIPath = "/apis"
+	copyConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	restClient, err := rest.RESTClientFor(copyConfig)
+	if err != nil {
+		return err
+	}
+	var powerScheduleClient = management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachinePowerSchedule()
+	var powerScheduleCtrl = &PowerScheduleController{
+		ctx:                ctx,
+		schedules:          powerScheduleClient,
+		scheduleController: powerScheduleClient,
+		vmCache:            vmCache,
+		pvcCache:           pvcCache,
+		restClient:         restClient,
+	}
+	powerScheduleClient.OnChange(ctx, powerScheduleControllerName, powerScheduleCtrl.OnScheduleChange)
+
 	return nil
 }
@@ -137,6 +137,11 @@ func (h *VMController) SetOwnerOfPVCs(vm *kubevirtv1.VirtualMachine) (*kubevirtv
 		}
 	}
 
+	// the PVCs of a VM being moved are released by the VM move controller for the new VM
+	if _, ok := vm.Annotations[util.AnnotationVMMove]; ok {
+		return vm, nil
+	}
+
 	var pvcNamespace = vm.Namespace
 	for _, pvcName := range pvcNames.List() {
 		var pvc, err = h.pvcCache.Get(pvcNamespace, pvcName)
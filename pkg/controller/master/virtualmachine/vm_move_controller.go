@@ -0,0 +1,429 @@
+package virtualmachine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester/pkg/builder"
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	vmMoveEnqueueInterval = 5 * time.Second
+)
+
+// VMMoveController moves a VM to another name or namespace, it's requested by the move action.
+// For a move to another namespace, the PVs of the volumes are retained and rebound to PVCs of the same names in the target
+// namespace while the VM is stopped. Then the VM is recreated under the new name or namespace with its cloud-init secrets,
+// and the original VM is deleted. The owners of the PVCs in the annotation are managed by the VM controller as usual.
+type VMMoveController struct {
+	vmController ctlkubevirtv1.VirtualMachineController
+	vmCache      ctlkubevirtv1.VirtualMachineCache
+	vmiCache     ctlkubevirtv1.VirtualMachineInstanceCache
+	pvcClient    ctlcorev1.PersistentVolumeClaimClient
+	pvcCache     ctlcorev1.PersistentVolumeClaimCache
+	pvClient     ctlcorev1.PersistentVolumeClient
+	pvCache      ctlcorev1.PersistentVolumeCache
+	secretClient ctlcorev1.SecretClient
+	secretCache  ctlcorev1.SecretCache
+	sars         authorizationv1client.SubjectAccessReviewInterface
+}
+
+// MoveVM reconciles the move recorded in the annotation of a VM
+func (h *VMMoveController) MoveVM(_ string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error) {
+	if vm == nil || vm.DeletionTimestamp != nil || vm.Spec.Template == nil {
+		return vm, nil
+	}
+	value, ok := vm.Annotations[util.AnnotationVMMove]
+	if !ok {
+		return vm, nil
+	}
+	var move util.VMMove
+	if err := json.Unmarshal([]byte(value), &move); err != nil {
+		return vm, fmt.Errorf("failed to unserialize %s, error: %w", util.AnnotationVMMove, err)
+	}
+
+	// the move is done with the permissions of the harvester service account,
+	// so the user who requested it must still be allowed to create VMs in the target namespace
+	if err := h.checkMovePermission(move); err != nil {
+		return vm, err
+	}
+
+	// wait for the VM to be stopped, so that the volumes are detached before the PVCs are moved
+	if _, err := h.vmiCache.Get(vm.Namespace, vm.Name); err == nil {
+		h.vmController.EnqueueAfter(vm.Namespace, vm.Name, vmMoveEnqueueInterval)
+		return vm, nil
+	} else if !apierrors.IsNotFound(err) {
+		return vm, err
+	}
+
+	// the PVCs are released by the original VM, so that they can be used by the new VM
+	if err := h.releasePVCs(vm); err != nil {
+		return vm, err
+	}
+	if move.TargetNamespace != vm.Namespace {
+		moved, err := h.movePVCs(vm, move.TargetNamespace)
+		if err != nil {
+			return vm, err
+		}
+		if !moved {
+			h.vmController.EnqueueAfter(vm.Namespace, vm.Name, vmMoveEnqueueInterval)
+			return vm, nil
+		}
+	}
+
+	target, err := h.vmCache.Get(move.TargetNamespace, move.TargetName)
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("move vm %s/%s to %s/%s", vm.Namespace, vm.Name, move.TargetNamespace, move.TargetName)
+		if target, err = h.vmController.Create(getMovedVM(vm, move)); err != nil {
+			return vm, err
+		}
+	} else if err != nil {
+		return vm, err
+	}
+	if target.Annotations[util.AnnotationMovedFrom] != ref.Construct(vm.Namespace, vm.Name) {
+		return vm, fmt.Errorf("vm %s/%s is not moved from vm %s/%s", target.Namespace, target.Name, vm.Namespace, vm.Name)
+	}
+
+	for _, secretName := range util.GetCloudInitSecretNames(vm) {
+		if err := h.moveSecret(vm, target, secretName); err != nil {
+			return vm, err
+		}
+	}
+
+	// the volumes are kept for the new VM
+	if _, ok := vm.Annotations[util.RemovedPVCsAnnotationKey]; ok {
+		vmCopy := vm.DeepCopy()
+		delete(vmCopy.Annotations, util.RemovedPVCsAnnotationKey)
+		return h.vmController.Update(vmCopy)
+	}
+	logrus.Infof("delete vm %s/%s moved to %s/%s", vm.Namespace, vm.Name, target.Namespace, target.Name)
+	return vm, h.vmController.Delete(vm.Namespace, vm.Name, &metav1.DeleteOptions{})
+}
+
+// checkMovePermission checks that the user who requested the move can create VMs in the target namespace
+func (h *VMMoveController) checkMovePermission(move util.VMMove) error {
+	if move.User == "" {
+		return fmt.Errorf("the user who requested the move to %s/%s is unknown", move.TargetNamespace, move.TargetName)
+	}
+	sar, err := h.sars.Create(context.TODO(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   move.User,
+			UID:    move.UID,
+			Groups: move.Groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: move.TargetNamespace,
+				Verb:      "create",
+				Group:     kubevirtv1.SchemeGroupVersion.Group,
+				Resource:  "virtualmachines",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !sar.Status.Allowed {
+		return fmt.Errorf("user %s is not allowed to create virtual machines in namespace %s: %s", move.User, move.TargetNamespace, sar.Status.Reason)
+	}
+	return nil
+}
+
+func (h *VMMoveController) releasePVCs(vm *kubevirtv1.VirtualMachine) error {
+	for _, claimName := range getPVCNames(&vm.Spec.Template.Spec).List() {
+		pvc, err := h.pvcCache.Get(vm.Namespace, claimName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := unsetBoundedPVCReference(h.pvcClient, pvc, vm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// movePVCs moves the PVCs of the VM to the target namespace, it returns true when all of them are bound in the target namespace
+func (h *VMMoveController) movePVCs(vm *kubevirtv1.VirtualMachine, targetNamespace string) (bool, error) {
+	moved := true
+	for _, claimName := range getPVCNames(&vm.Spec.Template.Spec).List() {
+		pvcMoved, err := h.movePVC(vm, claimName, targetNamespace)
+		if err != nil {
+			return false, err
+		}
+		moved = moved && pvcMoved
+	}
+	return moved, nil
+}
+
+// movePVC moves a PVC in three steps: the PV is retained and the PVC is deleted, the PV is pre-bound to a PVC of the same name
+// created in the target namespace, then the reclaim policy of the PV is restored after the new PVC is bound.
+func (h *VMMoveController) movePVC(vm *kubevirtv1.VirtualMachine, claimName, targetNamespace string) (bool, error) {
+	if targetPVC, err := h.pvcCache.Get(targetNamespace, claimName); err == nil {
+		if targetPVC.Status.Phase != corev1.ClaimBound {
+			return false, nil
+		}
+		return true, h.restorePVReclaimPolicy(targetPVC.Spec.VolumeName)
+	} else if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	if pvc, err := h.pvcCache.Get(vm.Namespace, claimName); err == nil {
+		if pvc.DeletionTimestamp != nil {
+			return false, nil
+		}
+		return false, h.retainPV(pvc, targetNamespace)
+	} else if !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	pv, targetPVC, err := h.getMovedPV(targetNamespace, claimName)
+	if err != nil {
+		return false, err
+	}
+	if claimRef := pv.Spec.ClaimRef; claimRef == nil || claimRef.Namespace != targetNamespace || claimRef.Name != claimName {
+		pvCopy := pv.DeepCopy()
+		pvCopy.Spec.ClaimRef = &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "PersistentVolumeClaim",
+			Namespace:  targetNamespace,
+			Name:       claimName,
+		}
+		if _, err := h.pvClient.Update(pvCopy); err != nil {
+			return false, err
+		}
+	}
+	logrus.Infof("bind pv %s to pvc %s/%s", pv.Name, targetNamespace, claimName)
+	_, err = h.pvcClient.Create(targetPVC)
+	return false, err
+}
+
+// retainPV retains the PV of the PVC and records the PVC to bind it to in the target namespace, then deletes the PVC
+func (h *VMMoveController) retainPV(pvc *corev1.PersistentVolumeClaim, targetNamespace string) error {
+	pv, err := h.pvCache.Get(pvc.Spec.VolumeName)
+	if err != nil {
+		return err
+	}
+	pvCopy, err := getRetainedPV(pv, pvc, targetNamespace)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(pv, pvCopy) {
+		if _, err := h.pvClient.Update(pvCopy); err != nil {
+			return err
+		}
+	}
+
+	logrus.Infof("delete pvc %s/%s to move it to namespace %s", pvc.Namespace, pvc.Name, targetNamespace)
+	return h.pvcClient.Delete(pvc.Namespace, pvc.Name, &metav1.DeleteOptions{})
+}
+
+// getMovedPV returns the PV retained for the PVC in the target namespace and the PVC recorded in its annotation
+func (h *VMMoveController) getMovedPV(namespace, name string) (*corev1.PersistentVolume, *corev1.PersistentVolumeClaim, error) {
+	pvs, err := h.pvCache.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pv := range pvs {
+		value, ok := pv.Annotations[util.AnnotationMoveTargetPVC]
+		if !ok {
+			continue
+		}
+		var pvc corev1.PersistentVolumeClaim
+		if err := json.Unmarshal([]byte(value), &pvc); err != nil {
+			return nil, nil, fmt.Errorf("failed to unserialize %s of pv %s, error: %w", util.AnnotationMoveTargetPVC, pv.Name, err)
+		}
+		if pvc.Namespace == namespace && pvc.Name == name {
+			return pv, &pvc, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("retained pv of pvc %s/%s is not found", namespace, name)
+}
+
+func (h *VMMoveController) restorePVReclaimPolicy(pvName string) error {
+	pv, err := h.pvCache.Get(pvName)
+	if err != nil {
+		return err
+	}
+	reclaimPolicy, ok := pv.Annotations[util.AnnotationMoveReclaimPolicy]
+	if !ok {
+		return nil
+	}
+	pvCopy := pv.DeepCopy()
+	pvCopy.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(reclaimPolicy)
+	delete(pvCopy.Annotations, util.AnnotationMoveReclaimPolicy)
+	delete(pvCopy.Annotations, util.AnnotationMoveTargetPVC)
+	_, err = h.pvClient.Update(pvCopy)
+	return err
+}
+
+// moveSecret moves a cloud-init secret to the new VM. In the same namespace, the secret is owned by the new VM instead of
+// the original one. In another namespace, the secret is copied with the same name and owned by the new VM, the original
+// secret is garbage collected with the original VM if it's owned by it.
+func (h *VMMoveController) moveSecret(vm, target *kubevirtv1.VirtualMachine, secretName string) error {
+	targetOwnerRef := metav1.OwnerReference{
+		APIVersion: kubevirtv1.SchemeGroupVersion.String(),
+		Kind:       kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+		Name:       target.Name,
+		UID:        target.UID,
+	}
+	if target.Namespace == vm.Namespace {
+		secret, err := h.secretCache.Get(vm.Namespace, secretName)
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		secretCopy := secret.DeepCopy()
+		for i, ownerRef := range secretCopy.OwnerReferences {
+			if ownerRef.UID == vm.UID {
+				secretCopy.OwnerReferences[i] = targetOwnerRef
+			}
+		}
+		if reflect.DeepEqual(secret, secretCopy) {
+			return nil
+		}
+		_, err = h.secretClient.Update(secretCopy)
+		return err
+	}
+
+	if _, err := h.secretCache.Get(target.Namespace, secretName); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+	secret, err := h.secretCache.Get(vm.Namespace, secretName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, err = h.secretClient.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secret.Name,
+			Namespace:       target.Namespace,
+			Labels:          secret.Labels,
+			Annotations:     secret.Annotations,
+			OwnerReferences: []metav1.OwnerReference{targetOwnerRef},
+		},
+		Data: secret.Data,
+		Type: secret.Type,
+	})
+	return err
+}
+
+// getRetainedPV returns the PV with the Retain reclaim policy, so that it's kept after the PVC is deleted,
+// the original reclaim policy and the PVC to bind the PV to in the target namespace are recorded in the annotations.
+func getRetainedPV(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim, targetNamespace string) (*corev1.PersistentVolume, error) {
+	targetPVC, err := json.Marshal(getMoveTargetPVC(pvc, targetNamespace))
+	if err != nil {
+		return nil, err
+	}
+
+	pvCopy := pv.DeepCopy()
+	if pvCopy.Annotations == nil {
+		pvCopy.Annotations = map[string]string{}
+	}
+	if _, ok := pvCopy.Annotations[util.AnnotationMoveReclaimPolicy]; !ok {
+		pvCopy.Annotations[util.AnnotationMoveReclaimPolicy] = string(pv.Spec.PersistentVolumeReclaimPolicy)
+	}
+	pvCopy.Annotations[util.AnnotationMoveTargetPVC] = string(targetPVC)
+	pvCopy.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+	return pvCopy, nil
+}
+
+func getMoveTargetPVC(pvc *corev1.PersistentVolumeClaim, targetNamespace string) *corev1.PersistentVolumeClaim {
+	annotations := map[string]string{}
+	for key, value := range pvc.Annotations {
+		// the owners are set by the VMs in the target namespace, and the binding annotations are set by the PV controller
+		if key == ref.AnnotationSchemaOwnerKeyName || strings.HasPrefix(key, "pv.kubernetes.io/") {
+			continue
+		}
+		annotations[key] = value
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pvc.Name,
+			Namespace:   targetNamespace,
+			Labels:      pvc.Labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      pvc.Spec.AccessModes,
+			Resources:        pvc.Spec.Resources,
+			StorageClassName: pvc.Spec.StorageClassName,
+			VolumeMode:       pvc.Spec.VolumeMode,
+			VolumeName:       pvc.Spec.VolumeName,
+		},
+	}
+}
+
+// getMovedVM returns the VM recreated under the new name or namespace. The MAC addresses and the hostname are kept.
+// For a move to another namespace, the networks and the keypairs without a namespace keep referring to the original namespace.
+func getMovedVM(vm *kubevirtv1.VirtualMachine, move util.VMMove) *kubevirtv1.VirtualMachine {
+	annotations := map[string]string{}
+	for key, value := range vm.Annotations {
+		annotations[key] = value
+	}
+	delete(annotations, util.AnnotationVMMove)
+	annotations[util.AnnotationMovedFrom] = ref.Construct(vm.Namespace, vm.Name)
+
+	newVM := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        move.TargetName,
+			Namespace:   move.TargetNamespace,
+			Labels:      vm.Labels,
+			Annotations: annotations,
+		},
+		Spec: *vm.Spec.DeepCopy(),
+	}
+	if _, ok := newVM.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName]; ok {
+		newVM.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName] = newVM.Name
+	}
+
+	if newVM.Namespace != vm.Namespace {
+		for i, network := range newVM.Spec.Template.Spec.Networks {
+			if network.Multus != nil && !strings.Contains(network.Multus.NetworkName, "/") {
+				newVM.Spec.Template.Spec.Networks[i].Multus.NetworkName = fmt.Sprintf("%s/%s", vm.Namespace, network.Multus.NetworkName)
+			}
+		}
+		if sshNamesJSON, ok := newVM.Spec.Template.ObjectMeta.Annotations[builder.AnnotationKeyVirtualMachineSSHNames]; ok {
+			var sshNames []string
+			if err := json.Unmarshal([]byte(sshNamesJSON), &sshNames); err == nil {
+				for i, sshName := range sshNames {
+					if !strings.Contains(sshName, "/") {
+						sshNames[i] = fmt.Sprintf("%s/%s", vm.Namespace, sshName)
+					}
+				}
+				if newSSHNamesJSON, err := json.Marshal(sshNames); err == nil {
+					newVM.Spec.Template.ObjectMeta.Annotations[builder.AnnotationKeyVirtualMachineSSHNames] = string(newSSHNamesJSON)
+				}
+			}
+		}
+	}
+
+	if newVM.Spec.RunStrategy != nil {
+		runStrategy := move.RunStrategy
+		newVM.Spec.RunStrategy = &runStrategy
+	} else {
+		newVM.Spec.Running = pointer.BoolPtr(move.RunStrategy != kubevirtv1.RunStrategyHalted)
+	}
+	return newVM
+}
@@ -0,0 +1,157 @@
+package virtualmachine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester/pkg/builder"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestGetMovedVM(t *testing.T) {
+	halted := kubevirtv1.RunStrategyHalted
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vm",
+			Namespace: "default",
+			Annotations: map[string]string{
+				util.AnnotationVMMove:         `{"targetNamespace":"prod","targetName":"web","runStrategy":"RerunOnFailure"}`,
+				util.RemovedPVCsAnnotationKey: "vm-disk-0-abcde",
+			},
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			RunStrategy: &halted,
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						builder.LabelKeyVirtualMachineName: "vm",
+					},
+					Annotations: map[string]string{
+						builder.AnnotationKeyVirtualMachineSSHNames: `["key","other/key"]`,
+					},
+				},
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Hostname: "vm",
+					Domain: kubevirtv1.DomainSpec{
+						Devices: kubevirtv1.Devices{
+							Interfaces: []kubevirtv1.Interface{
+								{Name: "nic-1", MacAddress: "52:54:00:12:34:56"},
+							},
+						},
+					},
+					Networks: []kubevirtv1.Network{
+						{
+							Name:          "nic-1",
+							NetworkSource: kubevirtv1.NetworkSource{Multus: &kubevirtv1.MultusNetwork{NetworkName: "vlan1"}},
+						},
+					},
+				},
+			},
+		},
+	}
+	move := util.VMMove{
+		TargetNamespace: "prod",
+		TargetName:      "web",
+		RunStrategy:     kubevirtv1.RunStrategyRerunOnFailure,
+	}
+
+	moved := getMovedVM(vm, move)
+	assert.Equal(t, "prod", moved.Namespace)
+	assert.Equal(t, "web", moved.Name)
+	assert.Equal(t, ref.Construct("default", "vm"), moved.Annotations[util.AnnotationMovedFrom])
+	assert.NotContains(t, moved.Annotations, util.AnnotationVMMove)
+	assert.Equal(t, "vm-disk-0-abcde", moved.Annotations[util.RemovedPVCsAnnotationKey])
+	assert.Equal(t, "web", moved.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName])
+	assert.Equal(t, `["default/key","other/key"]`, moved.Spec.Template.ObjectMeta.Annotations[builder.AnnotationKeyVirtualMachineSSHNames])
+	assert.Equal(t, "default/vlan1", moved.Spec.Template.Spec.Networks[0].Multus.NetworkName)
+	assert.Equal(t, "52:54:00:12:34:56", moved.Spec.Template.Spec.Domain.Devices.Interfaces[0].MacAddress)
+	assert.Equal(t, "vm", moved.Spec.Template.Spec.Hostname)
+	assert.Equal(t, kubevirtv1.RunStrategyRerunOnFailure, *moved.Spec.RunStrategy)
+
+	// the original VM is not changed
+	assert.Equal(t, "vm", vm.Spec.Template.ObjectMeta.Labels[builder.LabelKeyVirtualMachineName])
+	assert.Equal(t, "vlan1", vm.Spec.Template.Spec.Networks[0].Multus.NetworkName)
+
+	// a rename in the same namespace keeps the references
+	move.TargetNamespace = "default"
+	moved = getMovedVM(vm, move)
+	assert.Equal(t, `["key","other/key"]`, moved.Spec.Template.ObjectMeta.Annotations[builder.AnnotationKeyVirtualMachineSSHNames])
+	assert.Equal(t, "vlan1", moved.Spec.Template.Spec.Networks[0].Multus.NetworkName)
+}
+
+func TestGetRetainedPV(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-1234"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vm-disk-0-abcde",
+			Namespace: "default",
+			Annotations: map[string]string{
+				ref.AnnotationSchemaOwnerKeyName:  `[{"schema":"kubevirt.io.virtualmachine","refs":["default/vm"]}]`,
+				"pv.kubernetes.io/bind-completed": "yes",
+				util.AnnotationImageID:            "default/image-abcde",
+				util.AnnBetaStorageProvisioner:    "driver.longhorn.io",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			StorageClassName: pointer.StringPtr("longhorn-image-abcde"),
+			VolumeName:       "pvc-1234",
+		},
+	}
+
+	retained, err := getRetainedPV(pv, pvc, "prod")
+	assert.Nil(t, err)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, retained.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, string(corev1.PersistentVolumeReclaimDelete), retained.Annotations[util.AnnotationMoveReclaimPolicy])
+
+	var targetPVC corev1.PersistentVolumeClaim
+	assert.Nil(t, json.Unmarshal([]byte(retained.Annotations[util.AnnotationMoveTargetPVC]), &targetPVC))
+	assert.Equal(t, "prod", targetPVC.Namespace)
+	assert.Equal(t, "vm-disk-0-abcde", targetPVC.Name)
+	assert.Equal(t, "pvc-1234", targetPVC.Spec.VolumeName)
+	assert.Equal(t, map[string]string{
+		util.AnnotationImageID:         "default/image-abcde",
+		util.AnnBetaStorageProvisioner: "driver.longhorn.io",
+	}, targetPVC.Annotations)
+
+	// the original reclaim policy is kept when the PV is retained again
+	retained, err = getRetainedPV(retained, pvc, "prod")
+	assert.Nil(t, err)
+	assert.Equal(t, string(corev1.PersistentVolumeReclaimDelete), retained.Annotations[util.AnnotationMoveReclaimPolicy])
+}
+
+func TestCheckMovePermission(t *testing.T) {
+	clientSet := k8sfake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "alice" && attributes.Namespace == "prod" &&
+			attributes.Verb == "create" && attributes.Group == "kubevirt.io" && attributes.Resource == "virtualmachines"
+		return true, sar, nil
+	})
+	h := &VMMoveController{
+		sars: clientSet.AuthorizationV1().SubjectAccessReviews(),
+	}
+
+	assert.Nil(t, h.checkMovePermission(util.VMMove{TargetNamespace: "prod", TargetName: "web", User: "alice"}))
+	assert.NotNil(t, h.checkMovePermission(util.VMMove{TargetNamespace: "prod", TargetName: "web", User: "bob"}))
+	assert.NotNil(t, h.checkMovePermission(util.VMMove{TargetNamespace: "kube-system", TargetName: "web", User: "alice"}))
+	// the moves requested before the user was recorded are not done
+	assert.NotNil(t, h.checkMovePermission(util.VMMove{TargetNamespace: "prod", TargetName: "web"}))
+}
@@ -0,0 +1,335 @@
+package virtualmachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	lhv1beta1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
+	"github.com/harvester/harvester/pkg/util"
+)
+
+const (
+	volumeMigrationEnqueueInterval = 5 * time.Second
+)
+
+// VolumeMigrationController migrates a volume of a stopped VM to another StorageClass, it's requested by the migrateVolume
+// action. The data is copied to a new PVC through a VolumeSnapshot, then the disk of the VM is switched to the new PVC,
+// and the VolumeSnapshot and the old PVC are removed. If the VM is started before the disk is switched, the copy is
+// stale, it's removed and the data is copied again after the VM is stopped.
+type VolumeMigrationController struct {
+	vmController  ctlkubevirtv1.VirtualMachineController
+	vmiCache      ctlkubevirtv1.VirtualMachineInstanceCache
+	pvcClient     ctlcorev1.PersistentVolumeClaimClient
+	pvcCache      ctlcorev1.PersistentVolumeClaimCache
+	snapshots     ctlsnapshotv1.VolumeSnapshotClient
+	snapshotCache ctlsnapshotv1.VolumeSnapshotCache
+	volumes       ctllonghornv1.VolumeClient
+	volumeCache   ctllonghornv1.VolumeCache
+}
+
+// MigrateVolume reconciles the volume migration recorded in the annotation of a VM
+func (h *VolumeMigrationController) MigrateVolume(_ string, vm *kubevirtv1.VirtualMachine) (*kubevirtv1.VirtualMachine, error) {
+	if vm == nil || vm.DeletionTimestamp != nil || vm.Spec.Template == nil {
+		return vm, nil
+	}
+	value, ok := vm.Annotations[util.AnnotationVolumeMigration]
+	if !ok {
+		return vm, nil
+	}
+	var migration util.VolumeMigration
+	if err := json.Unmarshal([]byte(value), &migration); err != nil {
+		return vm, fmt.Errorf("failed to unserialize %s, error: %w", util.AnnotationVolumeMigration, err)
+	}
+
+	// the disk is already switched to the new PVC, clean up the migration
+	if getVolumeClaimName(vm, migration.DiskName) == migration.TargetPVCName {
+		return h.cleanupVolumeMigration(vm, migration)
+	}
+
+	// wait for the VM to be stopped, so that no data is written to the old PVC after it's copied
+	if vmi, err := h.vmiCache.Get(vm.Namespace, vm.Name); err == nil && !vmi.IsFinal() {
+		if _, err := h.snapshotCache.Get(vm.Namespace, migration.TargetPVCName); err == nil {
+			return h.restartVolumeMigration(vm, migration)
+		} else if !apierrors.IsNotFound(err) {
+			return vm, err
+		}
+		h.vmController.EnqueueAfter(vm.Namespace, vm.Name, volumeMigrationEnqueueInterval)
+		return vm, nil
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return vm, err
+	}
+
+	ready, err := h.reconcileTargetPVC(vm, migration)
+	if err != nil {
+		return vm, err
+	}
+	if !ready {
+		h.vmController.EnqueueAfter(vm.Namespace, vm.Name, volumeMigrationEnqueueInterval)
+		return vm, nil
+	}
+	return h.switchVolume(vm, migration)
+}
+
+// restartVolumeMigration removes the new PVC and the VolumeSnapshot copied before the VM was started,
+// then records the migration to another new PVC, which is copied after the VM is stopped.
+func (h *VolumeMigrationController) restartVolumeMigration(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) (*kubevirtv1.VirtualMachine, error) {
+	targetPVC, err := h.pvcCache.Get(vm.Namespace, migration.TargetPVCName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return vm, err
+	}
+	if err == nil && isMigrationTargetPVC(targetPVC, migration) {
+		if err := h.pvcClient.Delete(vm.Namespace, targetPVC.Name, &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &targetPVC.UID},
+		}); err != nil && !apierrors.IsNotFound(err) {
+			return vm, err
+		}
+	}
+	if err := h.deleteMigrationSnapshot(vm, migration); err != nil {
+		return vm, err
+	}
+
+	logrus.Infof("vm %s/%s is started before volume %s is migrated, copy it again after the vm is stopped", vm.Namespace, vm.Name, migration.DiskName)
+	migration.TargetPVCName = names.SimpleNameGenerator.GenerateName(fmt.Sprintf("%s-%s-", vm.Name, migration.DiskName))
+	value, err := json.Marshal(migration)
+	if err != nil {
+		return vm, err
+	}
+	vmCopy := vm.DeepCopy()
+	vmCopy.Annotations[util.AnnotationVolumeMigration] = string(value)
+	return h.vmController.Update(vmCopy)
+}
+
+// reconcileTargetPVC creates the VolumeSnapshot of the old PVC and the new PVC restored from it,
+// it returns true when the data is copied to the new PVC.
+func (h *VolumeMigrationController) reconcileTargetPVC(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) (bool, error) {
+	sourcePVC, err := h.pvcCache.Get(vm.Namespace, migration.SourcePVCName)
+	if err != nil {
+		return false, err
+	}
+
+	// the VolumeSnapshot and the new PVC have the same name
+	if _, err := h.snapshotCache.Get(vm.Namespace, migration.TargetPVCName); apierrors.IsNotFound(err) {
+		if err := h.createVolumeSnapshot(vm, sourcePVC, migration.TargetPVCName); err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	}
+
+	targetPVC, err := h.pvcCache.Get(vm.Namespace, migration.TargetPVCName)
+	if apierrors.IsNotFound(err) {
+		logrus.Infof("migrate volume %s of vm %s/%s to storage class %s", migration.DiskName, vm.Namespace, vm.Name, migration.StorageClassName)
+		_, err = h.pvcClient.Create(getMigrationTargetPVC(sourcePVC, migration))
+		return false, err
+	} else if err != nil {
+		return false, err
+	}
+
+	if targetPVC.Status.Phase != corev1.ClaimBound {
+		return false, nil
+	}
+	if util.GetProvisionedPVCProvisioner(targetPVC) == longhorntypes.LonghornDriverName {
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, targetPVC.Spec.VolumeName)
+		if err != nil {
+			return false, err
+		}
+		return isLonghornVolumeDataRestored(volume), nil
+	}
+	return true, nil
+}
+
+func (h *VolumeMigrationController) createVolumeSnapshot(vm *kubevirtv1.VirtualMachine, pvc *corev1.PersistentVolumeClaim, name string) error {
+	provisioner := util.GetProvisionedPVCProvisioner(pvc)
+	csiDriverInfo, err := settings.GetCSIDriverInfo(provisioner)
+	if err != nil {
+		return err
+	}
+
+	// Longhorn can only take snapshots of attached volumes
+	if provisioner == longhorntypes.LonghornDriverName {
+		volume, err := h.volumeCache.Get(util.LonghornSystemNamespaceName, pvc.Spec.VolumeName)
+		if err != nil {
+			return fmt.Errorf("failed to get volume %s/%s, error: %s", util.LonghornSystemNamespaceName, pvc.Spec.VolumeName, err.Error())
+		}
+		if volume.Status.State == lhv1beta1.VolumeStateDetached || volume.Status.State == lhv1beta1.VolumeStateDetaching {
+			volCpy := volume.DeepCopy()
+			volCpy.Spec.NodeID = volume.Status.OwnerID
+			logrus.Infof("mount detached volume %s to the node %s", volCpy.Name, volCpy.Spec.NodeID)
+			if _, err = h.volumes.Update(volCpy); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = h.snapshots.Create(&snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: vm.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: kubevirtv1.SchemeGroupVersion.String(),
+					Kind:       kubevirtv1.VirtualMachineGroupVersionKind.Kind,
+					Name:       vm.Name,
+					UID:        vm.UID,
+				},
+			},
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: pointer.StringPtr(pvc.Name),
+			},
+			VolumeSnapshotClassName: pointer.StringPtr(csiDriverInfo.VolumeSnapshotClassName),
+		},
+	})
+	return err
+}
+
+// switchVolume switches the disk of the VM to the new PVC and restores the run strategy of the VM
+func (h *VolumeMigrationController) switchVolume(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) (*kubevirtv1.VirtualMachine, error) {
+	vmCopy, err := getVolumeSwitchedVM(vm, migration)
+	if err != nil {
+		return vm, err
+	}
+
+	logrus.Infof("switch volume %s of vm %s/%s from pvc %s to %s", migration.DiskName, vm.Namespace, vm.Name, migration.SourcePVCName, migration.TargetPVCName)
+	return h.vmController.Update(vmCopy)
+}
+
+func getVolumeSwitchedVM(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) (*kubevirtv1.VirtualMachine, error) {
+	vmCopy := vm.DeepCopy()
+	for i, volume := range vmCopy.Spec.Template.Spec.Volumes {
+		if volume.Name == migration.DiskName && volume.PersistentVolumeClaim != nil {
+			vmCopy.Spec.Template.Spec.Volumes[i].PersistentVolumeClaim.ClaimName = migration.TargetPVCName
+		}
+	}
+
+	if volumeClaimTemplatesJSON, ok := vmCopy.Annotations[util.AnnotationVolumeClaimTemplates]; ok && volumeClaimTemplatesJSON != "" {
+		var volumeClaimTemplates []corev1.PersistentVolumeClaim
+		if err := json.Unmarshal([]byte(volumeClaimTemplatesJSON), &volumeClaimTemplates); err != nil {
+			return nil, fmt.Errorf("failed to unserialize %s, error: %w", util.AnnotationVolumeClaimTemplates, err)
+		}
+		for i, volumeClaimTemplate := range volumeClaimTemplates {
+			if volumeClaimTemplate.Name == migration.SourcePVCName {
+				volumeClaimTemplates[i].Name = migration.TargetPVCName
+				volumeClaimTemplates[i].Spec.StorageClassName = pointer.StringPtr(migration.StorageClassName)
+				volumeClaimTemplates[i].Spec.DataSource = nil
+			}
+		}
+		newVolumeClaimTemplatesJSON, err := json.Marshal(volumeClaimTemplates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize payload %v, error: %w", volumeClaimTemplates, err)
+		}
+		vmCopy.Annotations[util.AnnotationVolumeClaimTemplates] = string(newVolumeClaimTemplatesJSON)
+	}
+	return vmCopy, nil
+}
+
+// cleanupVolumeMigration removes the old PVC and the VolumeSnapshot, then the migration annotation of the VM.
+// Only the PVC recorded when the migration was requested is removed, after its data is copied to the new PVC.
+func (h *VolumeMigrationController) cleanupVolumeMigration(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) (*kubevirtv1.VirtualMachine, error) {
+	targetPVC, err := h.pvcCache.Get(vm.Namespace, migration.TargetPVCName)
+	if err != nil {
+		return vm, err
+	}
+	if !isMigrationTargetPVC(targetPVC, migration) {
+		return vm, fmt.Errorf("pvc %s/%s is not restored from the volume migration snapshot", targetPVC.Namespace, targetPVC.Name)
+	}
+
+	if migration.SourcePVCUID == "" {
+		logrus.Warnf("skip removing pvc %s/%s of vm %s/%s, its UID isn't recorded", vm.Namespace, migration.SourcePVCName, vm.Namespace, vm.Name)
+	} else if getVolumeClaimName(vm, migration.DiskName) != migration.SourcePVCName {
+		if err := h.pvcClient.Delete(vm.Namespace, migration.SourcePVCName, &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &migration.SourcePVCUID},
+		}); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			return vm, err
+		}
+	}
+
+	if err := h.deleteMigrationSnapshot(vm, migration); err != nil {
+		return vm, err
+	}
+
+	vmCopy := vm.DeepCopy()
+	delete(vmCopy.Annotations, util.AnnotationVolumeMigration)
+	return h.vmController.Update(vmCopy)
+}
+
+// deleteMigrationSnapshot deletes the VolumeSnapshot taken by the migration, it has the name of the new PVC
+func (h *VolumeMigrationController) deleteMigrationSnapshot(vm *kubevirtv1.VirtualMachine, migration util.VolumeMigration) error {
+	snapshot, err := h.snapshotCache.Get(vm.Namespace, migration.TargetPVCName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && isOwnedByVM(snapshot.OwnerReferences, vm) {
+		if err := h.snapshots.Delete(vm.Namespace, snapshot.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isMigrationTargetPVC checks whether the PVC is created by the volume migration, it's restored from the VolumeSnapshot of the same name
+func isMigrationTargetPVC(pvc *corev1.PersistentVolumeClaim, migration util.VolumeMigration) bool {
+	dataSource := pvc.Spec.DataSource
+	return dataSource != nil && dataSource.Kind == "VolumeSnapshot" && dataSource.Name == migration.TargetPVCName &&
+		pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == migration.StorageClassName
+}
+
+func isOwnedByVM(ownerReferences []metav1.OwnerReference, vm *kubevirtv1.VirtualMachine) bool {
+	for _, ownerReference := range ownerReferences {
+		if ownerReference.Kind == kubevirtv1.VirtualMachineGroupVersionKind.Kind && ownerReference.UID == vm.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func getMigrationTargetPVC(sourcePVC *corev1.PersistentVolumeClaim, migration util.VolumeMigration) *corev1.PersistentVolumeClaim {
+	annotations := map[string]string{}
+	if imageID, ok := sourcePVC.Annotations[util.AnnotationImageID]; ok {
+		annotations[util.AnnotationImageID] = imageID
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        migration.TargetPVCName,
+			Namespace:   sourcePVC.Namespace,
+			Annotations: annotations,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: sourcePVC.Spec.AccessModes,
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: pointer.StringPtr(snapshotv1.SchemeGroupVersion.Group),
+				Kind:     "VolumeSnapshot",
+				Name:     migration.TargetPVCName,
+			},
+			Resources:        sourcePVC.Spec.Resources,
+			StorageClassName: pointer.StringPtr(migration.StorageClassName),
+			VolumeMode:       sourcePVC.Spec.VolumeMode,
+		},
+	}
+}
+
+func getVolumeClaimName(vm *kubevirtv1.VirtualMachine, volumeName string) string {
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.Name == volumeName && volume.PersistentVolumeClaim != nil {
+			return volume.PersistentVolumeClaim.ClaimName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package virtualmachine
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester/pkg/util"
+)
+
+func TestGetVolumeSwitchedVM(t *testing.T) {
+	volumeClaimTemplates, _ := json.Marshal([]corev1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "vm-disk-0-abcde"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr("hdd")},
+		},
+	})
+	vm := &kubevirtv1.VirtualMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vm",
+			Namespace: "default",
+			Annotations: map[string]string{
+				util.AnnotationVolumeClaimTemplates: string(volumeClaimTemplates),
+			},
+		},
+		Spec: kubevirtv1.VirtualMachineSpec{
+			Running: pointer.BoolPtr(false),
+			Template: &kubevirtv1.VirtualMachineInstanceTemplateSpec{
+				Spec: kubevirtv1.VirtualMachineInstanceSpec{
+					Volumes: []kubevirtv1.Volume{
+						{
+							Name: "disk-0",
+							VolumeSource: kubevirtv1.VolumeSource{
+								PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+									PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: "vm-disk-0-abcde"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	migration := util.VolumeMigration{
+		DiskName:         "disk-0",
+		SourcePVCName:    "vm-disk-0-abcde",
+		TargetPVCName:    "vm-disk-0-fghij",
+		StorageClassName: "ssd",
+	}
+
+	switched, err := getVolumeSwitchedVM(vm, migration)
+	assert.Nil(t, err)
+	assert.Equal(t, "vm-disk-0-fghij", getVolumeClaimName(switched, "disk-0"))
+	// the VM isn't started by the migration
+	assert.Equal(t, pointer.BoolPtr(false), switched.Spec.Running)
+
+	var switchedTemplates []corev1.PersistentVolumeClaim
+	assert.Nil(t, json.Unmarshal([]byte(switched.Annotations[util.AnnotationVolumeClaimTemplates]), &switchedTemplates))
+	assert.Equal(t, "vm-disk-0-fghij", switchedTemplates[0].Name)
+	assert.Equal(t, pointer.StringPtr("ssd"), switchedTemplates[0].Spec.StorageClassName)
+
+	// the source VM is not changed
+	assert.Equal(t, "vm-disk-0-abcde", getVolumeClaimName(vm, "disk-0"))
+}
+
+func TestIsMigrationTargetPVC(t *testing.T) {
+	migration := util.VolumeMigration{
+		DiskName:         "disk-0",
+		SourcePVCName:    "vm-disk-0-abcde",
+		TargetPVCName:    "vm-disk-0-fghij",
+		StorageClassName: "ssd",
+	}
+	sourcePVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "vm-disk-0-abcde", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr("hdd")},
+	}
+	assert.True(t, isMigrationTargetPVC(getMigrationTargetPVC(sourcePVC, migration), migration))
+
+	// a PVC of the target name created by someone else
+	otherPVC := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "vm-disk-0-fghij", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr("ssd")},
+	}
+	assert.False(t, isMigrationTargetPVC(otherPVC, migration))
+}
@@ -25,6 +25,7 @@ func createCRDs(ctx context.Context, restConfig *rest.Config) error {
 	return factory.
 		BatchCreateCRDsIfNotExisted(
 			crd.NonNamespacedFromGV(harvesterv1.SchemeGroupVersion, "Setting", harvesterv1.Setting{}),
+			crd.NonNamespacedFromGV(harvesterv1.SchemeGroupVersion, "NamedBackupTarget", harvesterv1.NamedBackupTarget{}),
 			crd.NonNamespacedFromGV(rancherv3.SchemeGroupVersion, "APIService", rancherv3.APIService{}),
 			crd.NonNamespacedFromGV(rancherv3.SchemeGroupVersion, "Setting", rancherv3.Setting{}),
 			crd.NonNamespacedFromGV(rancherv3.SchemeGroupVersion, "User", rancherv3.User{}),
@@ -46,6 +47,9 @@ func createCRDs(ctx context.Context, restConfig *rest.Config) error {
 			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachineTemplateVersion", harvesterv1.VirtualMachineTemplateVersion{}),
 			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachineBackup", harvesterv1.VirtualMachineBackup{}),
 			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachineRestore", harvesterv1.VirtualMachineRestore{}),
+			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachineBackupSchedule", harvesterv1.VirtualMachineBackupSchedule{}),
+			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachineBackupGroup", harvesterv1.VirtualMachineBackupGroup{}),
+			crd.FromGV(harvesterv1.SchemeGroupVersion, "VirtualMachinePowerSchedule", harvesterv1.VirtualMachinePowerSchedule{}),
 			crd.FromGV(harvesterv1.SchemeGroupVersion, "Preference", harvesterv1.Preference{}),
 			crd.FromGV(harvesterv1.SchemeGroupVersion, "SupportBundle", harvesterv1.SupportBundle{}),
 			// The BackingImage struct is not compatible with wrangler schemas generation, pass nil as the workaround.
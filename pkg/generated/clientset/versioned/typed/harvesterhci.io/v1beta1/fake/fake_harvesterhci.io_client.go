@@ -36,6 +36,10 @@ func (c *FakeHarvesterhciV1beta1) KeyPairs(namespace string) v1beta1.KeyPairInte
 	return &FakeKeyPairs{c, namespace}
 }
 
+func (c *FakeHarvesterhciV1beta1) NamedBackupTargets() v1beta1.NamedBackupTargetInterface {
+	return &FakeNamedBackupTargets{c}
+}
+
 func (c *FakeHarvesterhciV1beta1) Preferences(namespace string) v1beta1.PreferenceInterface {
 	return &FakePreferences{c, namespace}
 }
@@ -64,10 +68,22 @@ func (c *FakeHarvesterhciV1beta1) VirtualMachineBackups(namespace string) v1beta
 	return &FakeVirtualMachineBackups{c, namespace}
 }
 
+func (c *FakeHarvesterhciV1beta1) VirtualMachineBackupGroups(namespace string) v1beta1.VirtualMachineBackupGroupInterface {
+	return &FakeVirtualMachineBackupGroups{c, namespace}
+}
+
+func (c *FakeHarvesterhciV1beta1) VirtualMachineBackupSchedules(namespace string) v1beta1.VirtualMachineBackupScheduleInterface {
+	return &FakeVirtualMachineBackupSchedules{c, namespace}
+}
+
 func (c *FakeHarvesterhciV1beta1) VirtualMachineImages(namespace string) v1beta1.VirtualMachineImageInterface {
 	return &FakeVirtualMachineImages{c, namespace}
 }
 
+func (c *FakeHarvesterhciV1beta1) VirtualMachinePowerSchedules(namespace string) v1beta1.VirtualMachinePowerScheduleInterface {
+	return &FakeVirtualMachinePowerSchedules{c, namespace}
+}
+
 func (c *FakeHarvesterhciV1beta1) VirtualMachineRestores(namespace string) v1beta1.VirtualMachineRestoreInterface {
 	return &FakeVirtualMachineRestores{c, namespace}
 }
@@ -22,6 +22,8 @@ type AddonExpansion interface{}
 
 type KeyPairExpansion interface{}
 
+type NamedBackupTargetExpansion interface{}
+
 type PreferenceExpansion interface{}
 
 type SettingExpansion interface{}
@@ -36,8 +38,14 @@ type VersionExpansion interface{}
 
 type VirtualMachineBackupExpansion interface{}
 
+type VirtualMachineBackupGroupExpansion interface{}
+
+type VirtualMachineBackupScheduleExpansion interface{}
+
 type VirtualMachineImageExpansion interface{}
 
+type VirtualMachinePowerScheduleExpansion interface{}
+
 type VirtualMachineRestoreExpansion interface{}
 
 type VirtualMachineTemplateExpansion interface{}
@@ -30,6 +30,7 @@ type HarvesterhciV1beta1Interface interface {
 	RESTClient() rest.Interface
 	AddonsGetter
 	KeyPairsGetter
+	NamedBackupTargetsGetter
 	PreferencesGetter
 	SettingsGetter
 	SupportBundlesGetter
@@ -37,7 +38,10 @@ type HarvesterhciV1beta1Interface interface {
 	UpgradeLogsGetter
 	VersionsGetter
 	VirtualMachineBackupsGetter
+	VirtualMachineBackupGroupsGetter
+	VirtualMachineBackupSchedulesGetter
 	VirtualMachineImagesGetter
+	VirtualMachinePowerSchedulesGetter
 	VirtualMachineRestoresGetter
 	VirtualMachineTemplatesGetter
 	VirtualMachineTemplateVersionsGetter
@@ -56,6 +60,10 @@ func (c *HarvesterhciV1beta1Client) KeyPairs(namespace string) KeyPairInterface
 	return newKeyPairs(c, namespace)
 }
 
+func (c *HarvesterhciV1beta1Client) NamedBackupTargets() NamedBackupTargetInterface {
+	return newNamedBackupTargets(c)
+}
+
 func (c *HarvesterhciV1beta1Client) Preferences(namespace string) PreferenceInterface {
 	return newPreferences(c, namespace)
 }
@@ -84,10 +92,22 @@ func (c *HarvesterhciV1beta1Client) VirtualMachineBackups(namespace string) Virt
 	return newVirtualMachineBackups(c, namespace)
 }
 
+func (c *HarvesterhciV1beta1Client) VirtualMachineBackupGroups(namespace string) VirtualMachineBackupGroupInterface {
+	return newVirtualMachineBackupGroups(c, namespace)
+}
+
+func (c *HarvesterhciV1beta1Client) VirtualMachineBackupSchedules(namespace string) VirtualMachineBackupScheduleInterface {
+	return newVirtualMachineBackupSchedules(c, namespace)
+}
+
 func (c *HarvesterhciV1beta1Client) VirtualMachineImages(namespace string) VirtualMachineImageInterface {
 	return newVirtualMachineImages(c, namespace)
 }
 
+func (c *HarvesterhciV1beta1Client) VirtualMachinePowerSchedules(namespace string) VirtualMachinePowerScheduleInterface {
+	return newVirtualMachinePowerSchedules(c, namespace)
+}
+
 func (c *HarvesterhciV1beta1Client) VirtualMachineRestores(namespace string) VirtualMachineRestoreInterface {
 	return newVirtualMachineRestores(c, namespace)
 }
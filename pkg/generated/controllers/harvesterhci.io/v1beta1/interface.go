@@ -32,6 +32,7 @@ func init() {
 type Interface interface {
 	Addon() AddonController
 	KeyPair() KeyPairController
+	NamedBackupTarget() NamedBackupTargetController
 	Preference() PreferenceController
 	Setting() SettingController
 	SupportBundle() SupportBundleController
@@ -39,7 +40,10 @@ type Interface interface {
 	UpgradeLog() UpgradeLogController
 	Version() VersionController
 	VirtualMachineBackup() VirtualMachineBackupController
+	VirtualMachineBackupGroup() VirtualMachineBackupGroupController
+	VirtualMachineBackupSchedule() VirtualMachineBackupScheduleController
 	VirtualMachineImage() VirtualMachineImageController
+	VirtualMachinePowerSchedule() VirtualMachinePowerScheduleController
 	VirtualMachineRestore() VirtualMachineRestoreController
 	VirtualMachineTemplate() VirtualMachineTemplateController
 	VirtualMachineTemplateVersion() VirtualMachineTemplateVersionController
@@ -61,6 +65,9 @@ func (c *version) Addon() AddonController {
 func (c *version) KeyPair() KeyPairController {
 	return NewKeyPairController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "KeyPair"}, "keypairs", true, c.controllerFactory)
 }
+func (c *version) NamedBackupTarget() NamedBackupTargetController {
+	return NewNamedBackupTargetController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "NamedBackupTarget"}, "namedbackuptargets", false, c.controllerFactory)
+}
 func (c *version) Preference() PreferenceController {
 	return NewPreferenceController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "Preference"}, "preferences", true, c.controllerFactory)
 }
@@ -82,9 +89,18 @@ func (c *version) Version() VersionController {
 func (c *version) VirtualMachineBackup() VirtualMachineBackupController {
 	return NewVirtualMachineBackupController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineBackup"}, "virtualmachinebackups", true, c.controllerFactory)
 }
+func (c *version) VirtualMachineBackupGroup() VirtualMachineBackupGroupController {
+	return NewVirtualMachineBackupGroupController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineBackupGroup"}, "virtualmachinebackupgroups", true, c.controllerFactory)
+}
+func (c *version) VirtualMachineBackupSchedule() VirtualMachineBackupScheduleController {
+	return NewVirtualMachineBackupScheduleController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineBackupSchedule"}, "virtualmachinebackupschedules", true, c.controllerFactory)
+}
 func (c *version) VirtualMachineImage() VirtualMachineImageController {
 	return NewVirtualMachineImageController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineImage"}, "virtualmachineimages", true, c.controllerFactory)
 }
+func (c *version) VirtualMachinePowerSchedule() VirtualMachinePowerScheduleController {
+	return NewVirtualMachinePowerScheduleController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachinePowerSchedule"}, "virtualmachinepowerschedules", true, c.controllerFactory)
+}
 func (c *version) VirtualMachineRestore() VirtualMachineRestoreController {
 	return NewVirtualMachineRestoreController(schema.GroupVersionKind{Group: "harvesterhci.io", Version: "v1beta1", Kind: "VirtualMachineRestore"}, "virtualmachinerestores", true, c.controllerFactory)
 }
@@ -33,6 +33,7 @@ type Interface interface {
 	BackingImage() BackingImageController
 	BackingImageDataSource() BackingImageDataSourceController
 	Backup() BackupController
+	Engine() EngineController
 	Replica() ReplicaController
 	Setting() SettingController
 	Volume() VolumeController
@@ -57,6 +58,9 @@ func (c *version) BackingImageDataSource() BackingImageDataSourceController {
 func (c *version) Backup() BackupController {
 	return NewBackupController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Backup"}, "backups", true, c.controllerFactory)
 }
+func (c *version) Engine() EngineController {
+	return NewEngineController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Engine"}, "engines", true, c.controllerFactory)
+}
 func (c *version) Replica() ReplicaController {
 	return NewReplicaController(schema.GroupVersionKind{Group: "longhorn.io", Version: "v1beta1", Kind: "Replica"}, "replicas", true, c.controllerFactory)
 }
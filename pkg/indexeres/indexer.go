@@ -26,6 +26,7 @@ const (
 	VolumeByNodeIndex                  = "harvesterhci.io/volume-by-node"
 	VMBackupBySourceVMUIDIndex         = "harvesterhci.io/vmbackup-by-source-vm-uid"
 	VMBackupBySourceVMNameIndex        = "harvesterhci.io/vmbackup-by-source-vm-name"
+	VMBackupByParentSnapshotIndex      = "harvesterhci.io/vmbackup-by-parent-snapshot"
 	VMTemplateVersionByImageIDIndex    = "harvesterhci.io/vmtemplateversion-by-image-id"
 	VolumeSnapshotBySourcePVCIndex     = "harvesterhci.io/volumesnapshot-by-source-pvc"
 )
@@ -51,6 +52,7 @@ func Setup(ctx context.Context, server *server.Server, controllers *server.Contr
 	vmBackupInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache()
 	vmBackupInformer.AddIndexer(VMBackupBySourceVMNameIndex, VMBackupBySourceVMName)
 	vmBackupInformer.AddIndexer(VMBackupBySourceVMUIDIndex, VMBackupBySourceVMUID)
+	vmBackupInformer.AddIndexer(VMBackupByParentSnapshotIndex, VMBackupByParentSnapshot)
 
 	vmTemplateVersionInformer := management.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion().Cache()
 	vmTemplateVersionInformer.AddIndexer(VMTemplateVersionByImageIDIndex, VMTemplateVersionByImageID)
@@ -109,6 +111,15 @@ func VMBackupBySourceVMName(obj *harvesterv1.VirtualMachineBackup) ([]string, er
 	return []string{obj.Spec.Source.Name}, nil
 }
 
+// VMBackupByParentSnapshot indexes the snapshots by the namespaced name of their parent snapshot
+func VMBackupByParentSnapshot(obj *harvesterv1.VirtualMachineBackup) ([]string, error) {
+	parent, ok := obj.Annotations[util.AnnotationParentSnapshot]
+	if !ok || obj.Spec.Type != harvesterv1.Snapshot {
+		return []string{}, nil
+	}
+	return []string{ref.Construct(obj.Namespace, parent)}, nil
+}
+
 func VMTemplateVersionByImageID(obj *harvesterv1.VirtualMachineTemplateVersion) ([]string, error) {
 	volumeClaimTemplateStr, ok := obj.Spec.VM.ObjectMeta.Annotations[util.AnnotationVolumeClaimTemplates]
 	if !ok || volumeClaimTemplateStr == "" {
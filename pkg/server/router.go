@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/rancher/apiserver/pkg/urlbuilder"
+	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/server/router"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/rest"
@@ -20,16 +21,18 @@ import (
 )
 
 type Router struct {
-	scaled     *config.Scaled
-	restConfig *rest.Config
-	options    config.Options
+	scaled          *config.Scaled
+	restConfig      *rest.Config
+	options         config.Options
+	accessSetLookup accesscontrol.AccessSetLookup
 }
 
-func NewRouter(scaled *config.Scaled, restConfig *rest.Config, options config.Options) (*Router, error) {
+func NewRouter(scaled *config.Scaled, restConfig *rest.Config, options config.Options, accessSetLookup accesscontrol.AccessSetLookup) (*Router, error) {
 	return &Router{
-		scaled:     scaled,
-		restConfig: restConfig,
-		options:    options,
+		scaled:          scaled,
+		restConfig:      restConfig,
+		options:         options,
+		accessSetLookup: accessSetLookup,
 	}, nil
 }
 
@@ -57,6 +60,8 @@ func (r *Router) Routes(h router.Handlers) http.Handler {
 
 	btHealthyHandler := backuptarget.NewHealthyHandler(r.scaled)
 	m.Path("/v1/harvester/backuptarget/healthz").Methods("GET").Handler(btHealthyHandler)
+	btCatalogHandler := backuptarget.NewCatalogHandler(r.scaled, r.accessSetLookup)
+	m.Path("/v1/harvester/backuptarget/catalog").Methods("GET").Handler(btCatalogHandler)
 	// --- END of preposition routes ---
 
 	// adds collection action support
@@ -213,7 +213,7 @@ func (s *HarvesterServer) generateSteveServer(options config.Options) error {
 		return err
 	}
 
-	router, err := NewRouter(scaled, s.RESTConfig, options)
+	router, err := NewRouter(scaled, s.RESTConfig, options, s.ASL)
 	if err != nil {
 		return err
 	}
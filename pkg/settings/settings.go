@@ -48,6 +48,8 @@ var (
 	ContainerdRegistry      = NewSetting(ContainerdRegistrySettingName, "")
 	StorageNetwork          = NewSetting(StorageNetworkName, "")
 	RancherManagerSupport   = NewSetting(RancherManagerSupportSettingName, "false")
+	// ImageSubscription is a JSON string of ImageSubscriptionConfig, the published images are replicated from its backup targets
+	ImageSubscription = NewSetting(ImageSubscriptionSettingName, "")
 
 	// HarvesterCSICCMVersion this is the chart version from https://github.com/harvester/charts instead of image versions
 	HarvesterCSICCMVersion = NewSetting(HarvesterCSICCMSettingName, `{"harvester-cloud-provider":">=0.0.1 <0.2.0","harvester-csi-provider":">=0.0.1 <0.2.0"}`)
@@ -77,6 +79,7 @@ const (
 	HarvesterCSICCMSettingName        = "harvester-csi-ccm-versions"
 	StorageNetworkName                = "storage-network"
 	RancherManagerSupportSettingName  = "rancher-manager-support"
+	ImageSubscriptionSettingName      = "image-subscription"
 )
 
 func init() {
@@ -193,6 +196,10 @@ type BackupTarget struct {
 	BucketRegion       string     `json:"bucketRegion"`
 	Cert               string     `json:"cert"`
 	VirtualHostedStyle bool       `json:"virtualHostedStyle"`
+	// EncryptionSecret refers to the secret containing the key to encrypt the VM backup metadata
+	EncryptionSecret *corev1.SecretReference `json:"encryptionSecret,omitempty"`
+	// EncryptionKey is loaded from the EncryptionSecret and is never saved in the setting
+	EncryptionKey string `json:"-"`
 }
 
 type VMForceResetPolicy struct {
@@ -252,6 +259,25 @@ func DecodeVMForceResetPolicy(value string) (*VMForceResetPolicy, error) {
 	return policy, nil
 }
 
+// ImageSubscriptionConfig is the value of the image-subscription setting
+type ImageSubscriptionConfig struct {
+	// BackupTargets are the names of the backup targets the images published by other clusters are replicated from
+	BackupTargets []string `json:"backupTargets"`
+	// Namespace is the namespace the images are replicated to, it's decided by this cluster
+	// instead of the namespace of the published images.
+	Namespace string `json:"namespace"`
+}
+
+func DecodeImageSubscription(value string) (*ImageSubscriptionConfig, error) {
+	config := &ImageSubscriptionConfig{}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), config); err != nil {
+			return nil, fmt.Errorf("unmarshal failed, error: %w, value: %s", err, value)
+		}
+	}
+	return config, nil
+}
+
 type Overcommit struct {
 	CPU     int `json:"cpu"`
 	Memory  int `json:"memory"`
@@ -15,6 +15,9 @@ const (
 	AnnotationHash                 = prefix + "/hash"
 	AnnotationRunStrategy          = prefix + "/vmRunStrategy"
 	LabelImageDisplayName          = prefix + "/imageDisplayName"
+	LabelVMBackupSchedule          = prefix + "/vmBackupSchedule"
+	LabelVMBackupGroup             = prefix + "/vmBackupGroup"
+	LabelVMExport                  = prefix + "/vmExport"
 
 	AnnotationStorageClassName          = prefix + "/storageClassName"
 	AnnotationStorageProvisioner        = prefix + "/storageProvisioner"
@@ -22,6 +25,50 @@ const (
 
 	AnnotationDefaultUserdataSecret = prefix + "/default-userdata-secret"
 
+	// AnnotationBackupPreHook and AnnotationBackupPostHook are VM annotations of JSON string arrays,
+	// the commands are run in the guest through the guest agent around freezing the guest filesystems.
+	// Setting them requires the guestexec permission of the VM.
+	AnnotationBackupPreHook  = prefix + "/backupPreHook"
+	AnnotationBackupPostHook = prefix + "/backupPostHook"
+	// AnnotationBackupVerifyRequest requests verifying a VM backup, the value is the sandbox namespace
+	// to run a test restore in, the test restore is skipped if it's empty.
+	AnnotationBackupVerifyRequest = prefix + "/backupVerifyRequest"
+	// AnnotationCloneTargetNamespace is set on the VolumeSnapshots taken to clone a VM to another namespace,
+	// the VolumeSnapshots are copied to the target namespace to restore the volumes of the clone from.
+	AnnotationCloneTargetNamespace = prefix + "/cloneTargetNamespace"
+	// AnnotationCloneSourceNamespace is set on the copies of the VolumeSnapshots in the target namespace.
+	AnnotationCloneSourceNamespace = prefix + "/cloneSourceNamespace"
+	// AnnotationParentSnapshot is set on a VM snapshot, the value is the name of the snapshot the VM was derived from
+	// when the snapshot was taken, the snapshots of a VM form a tree through it.
+	AnnotationParentSnapshot = prefix + "/parentSnapshot"
+	// AnnotationCurrentSnapshot is set on a VM, the value is the name of the last snapshot taken of the VM or reverted to.
+	AnnotationCurrentSnapshot = prefix + "/currentSnapshot"
+	// AnnotationDeleteAfterSnapshots is set on a PVC replaced by reverting a VM to a snapshot,
+	// the PVC is deleted after the VolumeSnapshots taken from it are deleted.
+	AnnotationDeleteAfterSnapshots = prefix + "/deleteAfterSnapshots"
+	// AnnotationVolumeMigration records the migration of a VM volume to another StorageClass in progress,
+	// the value is a JSON string of VolumeMigration.
+	AnnotationVolumeMigration = prefix + "/volumeMigration"
+	// AnnotationVMMove records the move of a VM to another name or namespace in progress,
+	// the value is a JSON string of VMMove.
+	AnnotationVMMove = prefix + "/vmMove"
+	// AnnotationPublishedImage is set on an image replicated from a backup target,
+	// the value is the namespace/name of the image published to the backup target by another cluster.
+	AnnotationPublishedImage = prefix + "/publishedImage"
+	// AnnotationMovedFrom is set on a VM recreated by a move, the value is the namespace/name of the original VM
+	AnnotationMovedFrom = prefix + "/movedFrom"
+	// AnnotationMoveReclaimPolicy records the reclaim policy of a PV before it's retained to move its PVC to another namespace
+	AnnotationMoveReclaimPolicy = prefix + "/moveReclaimPolicy"
+	// AnnotationMoveTargetPVC records the PVC to bind a PV to after its PVC is deleted, the value is a JSON string of the PVC.
+	AnnotationMoveTargetPVC = prefix + "/moveTargetPVC"
+	// AnnotationVMExport records the images exported from the volumes of a VM by the export action
+	AnnotationVMExport = prefix + "/vmExport"
+	// AnnotationImageExportType is the file format of an image exported from a volume, raw or qcow2, default to raw
+	AnnotationImageExportType = prefix + "/exportType"
+	// AnnotationExportImageID is set on the backing image of the VMDK converted from an exported image,
+	// the value is the namespace/name of the image.
+	AnnotationExportImageID = prefix + "/exportImageId"
+
 	ContainerdRegistrySecretName = "harvester-containerd-registry"
 	ContainerdRegistryFileName   = "registries.yaml"
 
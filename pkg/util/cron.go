@@ -0,0 +1,27 @@
+package util
+
+import (
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxMissedScheduleIterations = 10000
+)
+
+// GetMostRecentScheduleTime returns the latest schedule time in (last, now], or a zero time if there is none.
+// Missed runs are not caught up, only the most recent one is returned.
+// The schedule is evaluated in the location of last.
+func GetMostRecentScheduleTime(sched cron.Schedule, last, now time.Time) time.Time {
+	var due time.Time
+	for i, t := 0, sched.Next(last); !t.IsZero() && !t.After(now); i, t = i+1, sched.Next(t) {
+		due = t
+		if i >= maxMissedScheduleIterations {
+			logrus.Warnf("too many missed schedules since %s, use %s as the most recent one", last, due)
+			break
+		}
+	}
+	return due
+}
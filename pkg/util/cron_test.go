@@ -0,0 +1,41 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMostRecentScheduleTime(t *testing.T) {
+	sched, err := cron.ParseStandard("0 * * * *")
+	assert.Nil(t, err)
+
+	last := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "not due",
+			now:      last.Add(30 * time.Minute),
+			expected: time.Time{},
+		},
+		{
+			name:     "due",
+			now:      last.Add(time.Hour),
+			expected: last.Add(time.Hour),
+		},
+		{
+			name:     "missed schedules only return the most recent one",
+			now:      last.Add(5*time.Hour + 10*time.Minute),
+			expected: last.Add(5 * time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, GetMostRecentScheduleTime(sched, last, tc.now), tc.name)
+	}
+}
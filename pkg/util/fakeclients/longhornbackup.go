@@ -0,0 +1,41 @@
+package fakeclients
+
+import (
+	"context"
+
+	longhornv1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	lhtype "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/longhorn.io/v1beta1"
+	longhornv1ctl "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+)
+
+type LonghornBackupCache func(string) lhtype.BackupInterface
+
+func (c LonghornBackupCache) Get(namespace, name string) (*longhornv1.Backup, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c LonghornBackupCache) List(namespace string, selector labels.Selector) ([]*longhornv1.Backup, error) {
+	backupList, err := c(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	returnBackups := make([]*longhornv1.Backup, 0, len(backupList.Items))
+	for i := range backupList.Items {
+		returnBackups = append(returnBackups, &backupList.Items[i])
+	}
+
+	return returnBackups, nil
+}
+
+func (c LonghornBackupCache) AddIndexer(indexName string, indexer longhornv1ctl.BackupIndexer) {
+	panic("implement me")
+}
+
+func (c LonghornBackupCache) GetByIndex(indexName, key string) ([]*longhornv1.Backup, error) {
+	panic("implement me")
+}
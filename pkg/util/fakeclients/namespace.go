@@ -0,0 +1,39 @@
+package fakeclients
+
+import (
+	"context"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1type "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+type NamespaceCache func() corev1type.NamespaceInterface
+
+func (c NamespaceCache) Get(name string) (*v1.Namespace, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c NamespaceCache) List(selector labels.Selector) ([]*v1.Namespace, error) {
+	list, err := c().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*v1.Namespace, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+
+func (c NamespaceCache) AddIndexer(indexName string, indexer ctlcorev1.NamespaceIndexer) {
+	panic("implement me")
+}
+
+func (c NamespaceCache) GetByIndex(indexName, key string) ([]*v1.Namespace, error) {
+	panic("implement me")
+}
@@ -0,0 +1,37 @@
+package fakeclients
+
+import (
+	"context"
+
+	ctlv1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+type PodCache func(string) v1.PodInterface
+
+func (c PodCache) Get(namespace, name string) (*corev1.Pod, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c PodCache) List(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*corev1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, nil
+}
+
+func (c PodCache) AddIndexer(indexName string, indexer ctlv1.PodIndexer) {
+	panic("implement me")
+}
+
+func (c PodCache) GetByIndex(indexName, key string) ([]*corev1.Pod, error) {
+	panic("implement me")
+}
@@ -30,7 +30,7 @@ func (c VirtualMachineImageClient) Create(virtualMachineImage *harvesterv1.Virtu
 	return c(virtualMachineImage.Namespace).Create(context.TODO(), virtualMachineImage, metav1.CreateOptions{})
 }
 func (c VirtualMachineImageClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
-	panic("implement me")
+	return c(namespace).Delete(context.TODO(), name, *options)
 }
 func (c VirtualMachineImageClient) List(namespace string, opts metav1.ListOptions) (*harvesterv1.VirtualMachineImageList, error) {
 	panic("implement me")
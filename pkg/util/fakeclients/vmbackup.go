@@ -56,7 +56,15 @@ func (c VMBackupCache) Get(namespace, name string) (*harvesterv1beta1.VirtualMac
 }
 
 func (c VMBackupCache) List(namespace string, selector labels.Selector) ([]*harvesterv1beta1.VirtualMachineBackup, error) {
-	panic("implement me")
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1beta1.VirtualMachineBackup, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
 }
 
 func (c VMBackupCache) AddIndexer(indexName string, indexer harvesterv1ctl.VirtualMachineBackupIndexer) {
@@ -78,6 +86,19 @@ func (c VMBackupCache) GetByIndex(indexName, key string) ([]*harvesterv1beta1.Vi
 			}
 		}
 		return backups, nil
+	case indexeres.VMBackupByParentSnapshotIndex:
+		namespace, _ := ref.Parse(key)
+		backupList, err := c(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var backups []*harvesterv1beta1.VirtualMachineBackup
+		for i := range backupList.Items {
+			if keys, _ := indexeres.VMBackupByParentSnapshot(&backupList.Items[i]); len(keys) > 0 && keys[0] == key {
+				backups = append(backups, &backupList.Items[i])
+			}
+		}
+		return backups, nil
 	default:
 		return nil, nil
 	}
@@ -0,0 +1,38 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	harvesterv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harvestertype "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+	harvesterv1ctl "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+)
+
+type VMBackupGroupCache func(string) harvestertype.VirtualMachineBackupGroupInterface
+
+func (c VMBackupGroupCache) Get(namespace, name string) (*harvesterv1beta1.VirtualMachineBackupGroup, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c VMBackupGroupCache) List(namespace string, selector labels.Selector) ([]*harvesterv1beta1.VirtualMachineBackupGroup, error) {
+	list, err := c(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*harvesterv1beta1.VirtualMachineBackupGroup, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, err
+}
+
+func (c VMBackupGroupCache) AddIndexer(indexName string, indexer harvesterv1ctl.VirtualMachineBackupGroupIndexer) {
+	panic("implement me")
+}
+
+func (c VMBackupGroupCache) GetByIndex(indexName, key string) ([]*harvesterv1beta1.VirtualMachineBackupGroup, error) {
+	panic("implement me")
+}
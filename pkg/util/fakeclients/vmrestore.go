@@ -0,0 +1,46 @@
+package fakeclients
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	harvesterv1beta1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	harvestertype "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/harvesterhci.io/v1beta1"
+)
+
+type VMRestoreClient func(string) harvestertype.VirtualMachineRestoreInterface
+
+func (c VMRestoreClient) Create(vmRestore *harvesterv1beta1.VirtualMachineRestore) (*harvesterv1beta1.VirtualMachineRestore, error) {
+	return c(vmRestore.Namespace).Create(context.TODO(), vmRestore, metav1.CreateOptions{})
+}
+
+func (c VMRestoreClient) Update(vmRestore *harvesterv1beta1.VirtualMachineRestore) (*harvesterv1beta1.VirtualMachineRestore, error) {
+	return c(vmRestore.Namespace).Update(context.TODO(), vmRestore, metav1.UpdateOptions{})
+}
+
+func (c VMRestoreClient) UpdateStatus(vmRestore *harvesterv1beta1.VirtualMachineRestore) (*harvesterv1beta1.VirtualMachineRestore, error) {
+	panic("implement me")
+}
+
+func (c VMRestoreClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	return c(namespace).Delete(context.TODO(), name, *options)
+}
+
+func (c VMRestoreClient) Get(namespace, name string, options metav1.GetOptions) (*harvesterv1beta1.VirtualMachineRestore, error) {
+	return c(namespace).Get(context.TODO(), name, options)
+}
+
+func (c VMRestoreClient) List(namespace string, opts metav1.ListOptions) (*harvesterv1beta1.VirtualMachineRestoreList, error) {
+	return c(namespace).List(context.TODO(), opts)
+}
+
+func (c VMRestoreClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return c(namespace).Watch(context.TODO(), opts)
+}
+
+func (c VMRestoreClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (result *harvesterv1beta1.VirtualMachineRestore, err error) {
+	return c(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{}, subresources...)
+}
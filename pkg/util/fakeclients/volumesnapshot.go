@@ -0,0 +1,70 @@
+package fakeclients
+
+import (
+	"context"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	snapshottype "github.com/harvester/harvester/pkg/generated/clientset/versioned/typed/snapshot.storage.k8s.io/v1beta1"
+	snapshotctl "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
+)
+
+type VolumeSnapshotCache func(string) snapshottype.VolumeSnapshotInterface
+
+func (c VolumeSnapshotCache) Get(namespace, name string) (*snapshotv1.VolumeSnapshot, error) {
+	return c(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c VolumeSnapshotCache) List(namespace string, selector labels.Selector) ([]*snapshotv1.VolumeSnapshot, error) {
+	snapshotList, err := c(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	returnSnapshots := make([]*snapshotv1.VolumeSnapshot, 0, len(snapshotList.Items))
+	for i := range snapshotList.Items {
+		returnSnapshots = append(returnSnapshots, &snapshotList.Items[i])
+	}
+
+	return returnSnapshots, nil
+}
+
+func (c VolumeSnapshotCache) AddIndexer(indexName string, indexer snapshotctl.VolumeSnapshotIndexer) {
+	panic("implement me")
+}
+
+func (c VolumeSnapshotCache) GetByIndex(indexName, key string) ([]*snapshotv1.VolumeSnapshot, error) {
+	panic("implement me")
+}
+
+type VolumeSnapshotContentCache func() snapshottype.VolumeSnapshotContentInterface
+
+func (c VolumeSnapshotContentCache) Get(name string) (*snapshotv1.VolumeSnapshotContent, error) {
+	return c().Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+func (c VolumeSnapshotContentCache) List(selector labels.Selector) ([]*snapshotv1.VolumeSnapshotContent, error) {
+	contentList, err := c().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	returnContents := make([]*snapshotv1.VolumeSnapshotContent, 0, len(contentList.Items))
+	for i := range contentList.Items {
+		returnContents = append(returnContents, &contentList.Items[i])
+	}
+
+	return returnContents, nil
+}
+
+func (c VolumeSnapshotContentCache) AddIndexer(indexName string, indexer snapshotctl.VolumeSnapshotContentIndexer) {
+	panic("implement me")
+}
+
+func (c VolumeSnapshotContentCache) GetByIndex(indexName, key string) ([]*snapshotv1.VolumeSnapshotContent, error) {
+	panic("implement me")
+}
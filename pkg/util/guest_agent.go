@@ -0,0 +1,119 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	guestAgentCommandTimeout = 30 * time.Second
+	guestExecPollInterval    = 500 * time.Millisecond
+)
+
+type GuestAgentCommand struct {
+	Execute   string      `json:"execute"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type GuestExecArguments struct {
+	Path          string   `json:"path"`
+	Arg           []string `json:"arg,omitempty"`
+	CaptureOutput bool     `json:"capture-output"`
+}
+
+type guestExecStatusArguments struct {
+	PID int `json:"pid"`
+}
+
+type guestExecResult struct {
+	Return struct {
+		PID int `json:"pid"`
+	} `json:"return"`
+}
+
+// GuestExecStatus is the result of the guest-exec-status agent command, the output is base64 encoded
+type GuestExecStatus struct {
+	Exited       bool   `json:"exited"`
+	ExitCode     int    `json:"exitcode"`
+	OutData      string `json:"out-data"`
+	ErrData      string `json:"err-data"`
+	OutTruncated bool   `json:"out-truncated"`
+	ErrTruncated bool   `json:"err-truncated"`
+}
+
+type GuestExecStatusResult struct {
+	Return GuestExecStatus `json:"return"`
+}
+
+// GuestExec runs the command in the guest with the guest-exec agent command,
+// and polls its status with the guest-exec-status agent command until it exits, the timeout is reached or the context is done.
+func GuestExec(ctx context.Context, restConfig *rest.Config, clientSet kubernetes.Interface, pod *corev1.Pod, vmi *kubevirtv1.VirtualMachineInstance,
+	path string, args []string, timeout time.Duration) (*GuestExecStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result guestExecResult
+	if err := RunGuestAgentCommand(ctx, restConfig, clientSet, pod, vmi, GuestAgentCommand{
+		Execute: "guest-exec",
+		Arguments: GuestExecArguments{
+			Path:          path,
+			Arg:           args,
+			CaptureOutput: true,
+		},
+	}, &result); err != nil {
+		return nil, err
+	}
+
+	for {
+		var status GuestExecStatusResult
+		if err := RunGuestAgentCommand(ctx, restConfig, clientSet, pod, vmi, GuestAgentCommand{
+			Execute:   "guest-exec-status",
+			Arguments: guestExecStatusArguments{PID: result.Return.PID},
+		}, &status); err != nil {
+			return nil, err
+		}
+		if status.Return.Exited {
+			return &status.Return, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("command %s in vmi %s/%s doesn't exit in %v: %v", path, vmi.Namespace, vmi.Name, timeout, ctx.Err())
+		case <-time.After(guestExecPollInterval):
+		}
+	}
+}
+
+// RunGuestAgentCommand sends the command to the guest agent with virsh in the compute container of the virt-launcher pod
+func RunGuestAgentCommand(ctx context.Context, restConfig *rest.Config, clientSet kubernetes.Interface, pod *corev1.Pod, vmi *kubevirtv1.VirtualMachineInstance,
+	command GuestAgentCommand, result interface{}) error {
+	args, err := BuildGuestAgentCommand(vmi, command)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, guestAgentCommandTimeout)
+	defer cancel()
+	stdout, stderr, err := ExecPodCommand(ctx, restConfig, clientSet, pod, LauncherComputeContainerName, args)
+	if err != nil {
+		return fmt.Errorf("guest agent command %s failed: %v, stderr: %s", command.Execute, err, strings.TrimSpace(stderr))
+	}
+	return json.Unmarshal([]byte(stdout), result)
+}
+
+func BuildGuestAgentCommand(vmi *kubevirtv1.VirtualMachineInstance, command GuestAgentCommand) ([]string, error) {
+	body, err := json.Marshal(command)
+	if err != nil {
+		return nil, err
+	}
+	// the libvirt domain name is <namespace>_<name>, virsh gives up by itself if the exec is closed before the agent replies
+	return []string{"virsh", "qemu-agent-command", "--timeout", strconv.Itoa(int(guestAgentCommandTimeout.Seconds())),
+		fmt.Sprintf("%s_%s", vmi.Namespace, vmi.Name), string(body)}, nil
+}
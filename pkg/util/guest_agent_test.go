@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func TestBuildGuestAgentCommand(t *testing.T) {
+	vmi := &kubevirtv1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "vm",
+		},
+	}
+	args, err := BuildGuestAgentCommand(vmi, GuestAgentCommand{
+		Execute: "guest-exec",
+		Arguments: GuestExecArguments{
+			Path:          "/bin/df",
+			Arg:           []string{"-h"},
+			CaptureOutput: true,
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"virsh", "qemu-agent-command", "--timeout", "30", "default_vm",
+		`{"execute":"guest-exec","arguments":{"path":"/bin/df","arg":["-h"],"capture-output":true}}`,
+	}, args)
+}
@@ -1,7 +1,11 @@
 package util
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
 
 	longhorntypes "github.com/longhorn/longhorn-manager/types"
 
@@ -12,6 +16,12 @@ func GetBackingImageName(image *harvesterv1.VirtualMachineImage) string {
 	return fmt.Sprintf("%s-%s", image.Namespace, image.Name)
 }
 
+// GetExportVMDKBackingImageName returns the name of the backing image of the streamOptimized VMDK converted from an
+// image exported from a VM volume, the VMDK is served in the OVA package of the VM.
+func GetExportVMDKBackingImageName(image *harvesterv1.VirtualMachineImage) string {
+	return GetBackingImageName(image) + "-vmdk"
+}
+
 func GetImageStorageClassName(imageName string) string {
 	return fmt.Sprintf("longhorn-%s", imageName)
 }
@@ -33,3 +43,62 @@ func GetImageDefaultStorageClassParameters() map[string]string {
 		LonghornOptionMigratable:                "true",
 	}
 }
+
+// NeedImageVerification returns true if the image has a checksum or a signature verified by harvester,
+// the SHA-512 checksums of the files imported as-is by Longhorn are verified by Longhorn.
+func NeedImageVerification(image *harvesterv1.VirtualMachineImage) bool {
+	return (image.Spec.Checksum != "" && GetImageLonghornChecksum(image) == "") || image.Spec.Signature != nil
+}
+
+// GetImageLonghornChecksum returns the checksum verified by Longhorn while importing the image. The upload images
+// may be converted after they are created, so their checksums are verified by harvester.
+func GetImageLonghornChecksum(image *harvesterv1.VirtualMachineImage) string {
+	if image.Spec.ChecksumAlgorithm == harvesterv1.VirtualMachineImageChecksumAlgorithmSHA256 ||
+		image.Spec.SourceType == harvesterv1.VirtualMachineImageSourceTypeUpload ||
+		NeedImageConversion(image.Status.Format) {
+		return ""
+	}
+	return image.Spec.Checksum
+}
+
+// IsImageVerified returns true if the image doesn't need to be verified by harvester or it's verified
+func IsImageVerified(image *harvesterv1.VirtualMachineImage) bool {
+	return !NeedImageVerification(image) || harvesterv1.ImageVerified.IsTrue(image)
+}
+
+// UploadBackingImage uploads the file of the size to the data source of an upload backing image,
+// the data source must be pending for the upload.
+func UploadBackingImage(ctx context.Context, client *http.Client, backingImageName, fileName string, reader io.Reader, size int64) error {
+	// the backing image data source takes the file from the form field "chunk"
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreateFormFile("chunk", fileName)
+		if err == nil {
+			_, err = io.CopyN(part, reader, size)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	uploadURL := fmt.Sprintf("%s/backingimages/%s?action=upload&size=%d", LonghornDefaultManagerURL, backingImageName, size)
+	uploadReq, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, pr)
+	if err != nil {
+		pr.Close()
+		return fmt.Errorf("failed to create the upload request: %w", err)
+	}
+	uploadReq.Header.Set("Content-Type", mw.FormDataContentType())
+	uploadResp, err := client.Do(uploadReq)
+	if err != nil {
+		pr.Close()
+		return fmt.Errorf("failed to upload %s: %w", fileName, err)
+	}
+	defer uploadResp.Body.Close()
+	if uploadResp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(uploadResp.Body)
+		return fmt.Errorf("failed to upload %s: %s", fileName, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/vmdk"
+)
+
+// ImageFormatHeaderSize is the size of the header to detect the format of an image file,
+// it covers the primary volume descriptor of ISO 9660 images.
+const ImageFormatHeaderSize = 64 * 1024
+
+const (
+	qcow2Magic          = "QFI\xfb"
+	vmdkMagic           = "KDMV"
+	vmdkDescriptorMagic = "# Disk DescriptorFile"
+	vhdxMagic           = "vhdxfile"
+	vhdMagic            = "conectix"
+	isoMagic            = "CD001"
+	isoMagicOffset      = 0x8001
+)
+
+// qemuImgFormats are the qemu-img formats of the image formats converted to raw
+var qemuImgFormats = map[string]string{
+	harvesterv1.VirtualMachineImageFormatVMDK: "vmdk",
+	harvesterv1.VirtualMachineImageFormatVHDX: "vhdx",
+	harvesterv1.VirtualMachineImageFormatVHD:  "vpc",
+}
+
+// DetectImageFormat returns the format of an image file by the magic number in its header,
+// the files of unknown formats are raw images.
+func DetectImageFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte(qcow2Magic)):
+		return harvesterv1.VirtualMachineImageFormatQCOW2
+	case bytes.HasPrefix(header, []byte(vmdkMagic)), bytes.HasPrefix(header, []byte(vmdkDescriptorMagic)):
+		return harvesterv1.VirtualMachineImageFormatVMDK
+	case bytes.HasPrefix(header, []byte(vhdxMagic)):
+		return harvesterv1.VirtualMachineImageFormatVHDX
+	case bytes.HasPrefix(header, []byte(vhdMagic)):
+		// fixed VHD images only have the footer at the end, they are raw images with a footer
+		return harvesterv1.VirtualMachineImageFormatVHD
+	case len(header) >= isoMagicOffset+len(isoMagic) && string(header[isoMagicOffset:isoMagicOffset+len(isoMagic)]) == isoMagic:
+		return harvesterv1.VirtualMachineImageFormatISO
+	}
+	return harvesterv1.VirtualMachineImageFormatRaw
+}
+
+// NeedImageConversion returns true if the image of the format can't be consumed by Longhorn as-is
+func NeedImageConversion(format string) bool {
+	_, ok := qemuImgFormats[format]
+	return ok
+}
+
+// ConvertImageToRaw converts the image file of the format to a sparse raw image file with qemu-img,
+// the zeroed and unallocated areas of the image aren't written to the raw image file.
+func ConvertImageToRaw(ctx context.Context, format, src, dst string) error {
+	qemuImgFormat, ok := qemuImgFormats[format]
+	if !ok {
+		return fmt.Errorf("converting %s images is not supported", format)
+	}
+	output, err := exec.CommandContext(ctx, "qemu-img", "convert", "-f", qemuImgFormat, "-O", "raw", "-S", "4k", src, dst).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to convert %s image to raw: %w, %s", format, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// imageAllocatedSize returns the size of the data allocated in the image file of the format,
+// it's the space taken by the sparse raw image file the image file is converted to.
+func imageAllocatedSize(ctx context.Context, format, src string) (int64, error) {
+	output, err := exec.CommandContext(ctx, "qemu-img", "map", "--output=json", "-f", qemuImgFormats[format], src).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the allocated size of %s image: %w", format, err)
+	}
+	size, err := parseAllocatedSize(output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the map of %s image: %w", format, err)
+	}
+	return size, nil
+}
+
+// parseAllocatedSize returns the size of the extents with data in the JSON output of qemu-img map
+func parseAllocatedSize(mapOutput []byte) (int64, error) {
+	var extents []struct {
+		Length int64 `json:"length"`
+		Data   bool  `json:"data"`
+		Zero   bool  `json:"zero"`
+	}
+	if err := json.Unmarshal(mapOutput, &extents); err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, extent := range extents {
+		if extent.Data && !extent.Zero {
+			size += extent.Length
+		}
+	}
+	return size, nil
+}
+
+// ConvertAndUploadBackingImage converts the image data of the format to raw and uploads it to the data source
+// of an upload backing image. qemu-img can't convert the formats from a stream, so the data is written to the
+// scratch directory for the conversion, and the conversion fails if the files don't fit in the size limit of the
+// scratch directory. The conversion takes the size of the source file plus the size of the data allocated in it,
+// since the raw image file is sparse. verify is called with the file before it's converted if it's not nil.
+func ConvertAndUploadBackingImage(ctx context.Context, client *http.Client, backingImageName, fileName, format string,
+	data io.Reader, verify func(source io.Reader) error) error {
+	reservation := &scratchReservation{scratch: imageConversionScratch}
+	defer reservation.release()
+	dir, err := os.MkdirTemp(imageConversionScratch.getDir(), "image-conversion-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	sourcePath := filepath.Join(dir, "source")
+	if err := writeFile(sourcePath, data, reservation); err != nil {
+		return fmt.Errorf("failed to receive %s: %w", fileName, err)
+	}
+	if verify != nil {
+		source, err := os.Open(sourcePath)
+		if err != nil {
+			return err
+		}
+		err = verify(source)
+		source.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	allocatedSize, err := imageAllocatedSize(ctx, format, sourcePath)
+	if err != nil {
+		return err
+	}
+	if err := reservation.reserve(allocatedSize); err != nil {
+		return fmt.Errorf("failed to convert %s: %w", fileName, err)
+	}
+	rawPath := filepath.Join(dir, "disk.raw")
+	if err := ConvertImageToRaw(ctx, format, sourcePath, rawPath); err != nil {
+		return err
+	}
+	// the source isn't needed after the conversion
+	if err := os.Remove(sourcePath); err != nil {
+		return err
+	}
+
+	raw, err := os.Open(rawPath)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+	info, err := raw.Stat()
+	if err != nil {
+		return err
+	}
+	rawFileName := strings.TrimSuffix(fileName, path.Ext(fileName)) + ".raw"
+	return UploadBackingImage(ctx, client, backingImageName, rawFileName, raw, info.Size())
+}
+
+// ConvertRawToVMDKAndUploadBackingImage converts the raw image data of the size to a streamOptimized VMDK and uploads
+// it to the data source of an upload backing image. The size of the VMDK is only known after the conversion, so it's
+// written to the scratch directory, and the conversion fails if it doesn't fit in the size limit of the scratch directory.
+func ConvertRawToVMDKAndUploadBackingImage(ctx context.Context, client *http.Client, backingImageName, fileName string,
+	data io.Reader, size int64) error {
+	reservation := &scratchReservation{scratch: imageConversionScratch}
+	defer reservation.release()
+	dir, err := os.MkdirTemp(imageConversionScratch.getDir(), "image-conversion-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	vmdkPath := filepath.Join(dir, "disk.vmdk")
+	if err := writeVMDKFile(vmdkPath, fileName, data, size, reservation); err != nil {
+		return fmt.Errorf("failed to convert %s to VMDK: %w", fileName, err)
+	}
+
+	file, err := os.Open(vmdkPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return UploadBackingImage(ctx, client, backingImageName, fileName, file, info.Size())
+}
+
+func writeVMDKFile(name, fileName string, data io.Reader, size int64, reservation *scratchReservation) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	vmdkWriter, err := vmdk.NewStreamWriter(&reservedWriter{writer: file, reservation: reservation}, size, fileName)
+	if err == nil {
+		_, err = io.CopyN(vmdkWriter, data, size)
+	}
+	if err == nil {
+		err = vmdkWriter.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeFile(name string, data io.Reader, reservation *scratchReservation) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(&reservedWriter{writer: file, reservation: reservation}, data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
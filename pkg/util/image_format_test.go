@@ -0,0 +1,81 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/util/vmdk"
+)
+
+func TestDetectImageFormat(t *testing.T) {
+	iso := make([]byte, ImageFormatHeaderSize)
+	copy(iso[isoMagicOffset:], isoMagic)
+
+	var testCases = []struct {
+		name     string
+		header   []byte
+		expected string
+	}{
+		{name: "qcow2", header: []byte("QFI\xfb\x00\x00\x00\x03"), expected: harvesterv1.VirtualMachineImageFormatQCOW2},
+		{name: "sparse vmdk", header: []byte("KDMV\x01\x00\x00\x00"), expected: harvesterv1.VirtualMachineImageFormatVMDK},
+		{name: "vmdk descriptor", header: []byte("# Disk DescriptorFile\nversion=1\n"), expected: harvesterv1.VirtualMachineImageFormatVMDK},
+		{name: "vhdx", header: []byte("vhdxfile\x00M\x00i"), expected: harvesterv1.VirtualMachineImageFormatVHDX},
+		{name: "dynamic vhd", header: []byte("conectix\x00\x00\x00\x02"), expected: harvesterv1.VirtualMachineImageFormatVHD},
+		{name: "iso", header: iso, expected: harvesterv1.VirtualMachineImageFormatISO},
+		{name: "raw", header: make([]byte, 512), expected: harvesterv1.VirtualMachineImageFormatRaw},
+		{name: "empty", header: nil, expected: harvesterv1.VirtualMachineImageFormatRaw},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, DetectImageFormat(tc.header), tc.name)
+	}
+}
+
+func TestParseAllocatedSize(t *testing.T) {
+	// the map of a 1 GiB disk with 64 KiB of data, a zeroed extent of 4 KiB and unallocated extents
+	output := []byte(`[
+		{"start": 0, "length": 65536, "depth": 0, "present": true, "zero": false, "data": true, "offset": 327680},
+		{"start": 65536, "length": 4096, "depth": 0, "present": true, "zero": true, "data": true, "offset": 393216},
+		{"start": 69632, "length": 1073672192, "depth": 0, "present": false, "zero": true, "data": false}
+	]`)
+	size, err := parseAllocatedSize(output)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(65536), size)
+
+	_, err = parseAllocatedSize([]byte("invalid"))
+	assert.NotNil(t, err)
+}
+
+func TestWriteVMDKFile(t *testing.T) {
+	dir := t.TempDir()
+	raw := make([]byte, 1<<20)
+	copy(raw[4096:], bytes.Repeat([]byte("harvester"), 100))
+
+	// the VMDK file is reserved in the scratch directory while it's written
+	reservation := &scratchReservation{scratch: &conversionScratch{limit: 1 << 20}}
+	vmdkPath := filepath.Join(dir, "disk.vmdk")
+	assert.Nil(t, writeVMDKFile(vmdkPath, "disk.vmdk", bytes.NewReader(raw), int64(len(raw)), reservation))
+	info, err := os.Stat(vmdkPath)
+	assert.Nil(t, err)
+	assert.Equal(t, info.Size(), reservation.size)
+
+	file, err := os.Open(vmdkPath)
+	assert.Nil(t, err)
+	defer file.Close()
+	reader, err := vmdk.NewStreamReader(file)
+	assert.Nil(t, err)
+	content, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, raw, content)
+
+	// the conversion fails if the VMDK doesn't fit in the scratch directory
+	reservation = &scratchReservation{scratch: &conversionScratch{limit: 1024}}
+	err = writeVMDKFile(filepath.Join(dir, "full.vmdk"), "full.vmdk", bytes.NewReader(raw), int64(len(raw)), reservation)
+	assert.True(t, errors.Is(err, errScratchFull))
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DefaultImageConversionSizeLimit is the default size limit of the scratch directory the image files are converted in
+const DefaultImageConversionSizeLimit = "20Gi"
+
+var errScratchFull = errors.New("not enough space in the image conversion scratch directory")
+
+// imageConversionScratch is the scratch directory the image files are converted in. The conversions reserve the
+// space of their files before they are written, so that they fail instead of exceeding the size limit of the volume
+// of the directory, which gets the pod evicted, or filling the disk of the node.
+var imageConversionScratch = &conversionScratch{limit: 20 << 30}
+
+type conversionScratch struct {
+	sync.Mutex
+	dir   string
+	limit int64
+	used  int64
+}
+
+// SetImageConversionScratch sets the scratch directory the image files are converted in and its size limit,
+// the default directory for temporary files is used if dir is empty.
+func SetImageConversionScratch(dir, sizeLimit string) error {
+	limit, err := resource.ParseQuantity(sizeLimit)
+	if err != nil {
+		return fmt.Errorf("invalid image conversion size limit %q: %w", sizeLimit, err)
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+	imageConversionScratch.Lock()
+	defer imageConversionScratch.Unlock()
+	imageConversionScratch.dir = dir
+	imageConversionScratch.limit = limit.Value()
+	return nil
+}
+
+func (s *conversionScratch) getDir() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.dir
+}
+
+func (s *conversionScratch) reserve(size int64) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.used+size > s.limit {
+		return fmt.Errorf("%w: %d bytes are needed, %d of %d bytes are available", errScratchFull, size, s.limit-s.used, s.limit)
+	}
+	s.used += size
+	return nil
+}
+
+func (s *conversionScratch) release(size int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.used -= size
+}
+
+// scratchReservation is the space reserved in the scratch directory by a conversion
+type scratchReservation struct {
+	scratch *conversionScratch
+	size    int64
+}
+
+func (r *scratchReservation) reserve(size int64) error {
+	if err := r.scratch.reserve(size); err != nil {
+		return err
+	}
+	r.size += size
+	return nil
+}
+
+func (r *scratchReservation) release() {
+	r.scratch.release(r.size)
+	r.size = 0
+}
+
+// reservedWriter reserves the space of the data in the scratch directory before it's written
+type reservedWriter struct {
+	writer      io.Writer
+	reservation *scratchReservation
+}
+
+func (w *reservedWriter) Write(p []byte) (int, error) {
+	if err := w.reservation.reserve(int64(len(p))); err != nil {
+		return 0, err
+	}
+	return w.writer.Write(p)
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScratchReservation(t *testing.T) {
+	scratch := &conversionScratch{limit: 10}
+	first := &scratchReservation{scratch: scratch}
+	second := &scratchReservation{scratch: scratch}
+
+	var buf bytes.Buffer
+	_, err := io.Copy(&reservedWriter{writer: &buf, reservation: first}, strings.NewReader("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, "123456", buf.String())
+
+	// the data which doesn't fit in the limit isn't written
+	buf.Reset()
+	_, err = io.Copy(&reservedWriter{writer: &buf, reservation: second}, strings.NewReader("12345"))
+	assert.True(t, errors.Is(err, errScratchFull))
+	assert.Equal(t, 0, buf.Len())
+	assert.Nil(t, second.reserve(4))
+
+	first.release()
+	assert.Equal(t, int64(4), scratch.used)
+	assert.Nil(t, second.reserve(6))
+	second.release()
+	assert.Equal(t, int64(0), scratch.used)
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+const (
+	LauncherComputeContainerName = "compute"
+)
+
+// GetLauncherPod returns the running virt-launcher pod of the VMI
+func GetLauncherPod(podCache ctlcorev1.PodCache, vmi *kubevirtv1.VirtualMachineInstance) (*corev1.Pod, error) {
+	pods, err := podCache.List(vmi.Namespace, labels.SelectorFromSet(labels.Set{
+		kubevirtv1.CreatedByLabel: string(vmi.UID),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pod := range pods {
+		// the migration target pod runs on another node
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil && pod.Spec.NodeName == vmi.Status.NodeName {
+			return pod, nil
+		}
+	}
+	return nil, fmt.Errorf("running virt-launcher pod of vmi %s/%s is not found", vmi.Namespace, vmi.Name)
+}
+
+// ExecPodCommand runs the command in the container of the pod and returns its stdout and stderr.
+// The exec connection is closed when the context is done, so the stream doesn't outlive the caller.
+func ExecPodCommand(ctx context.Context, restConfig *rest.Config, clientSet kubernetes.Interface, pod *corev1.Pod, container string, command []string) (string, string, error) {
+	req := clientSet.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).Resource("pods").Name(pod.Name).SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return "", "", err
+	}
+	executor, err := remotecommand.NewSPDYExecutorForTransports(transport, &contextUpgrader{ctx: ctx, upgrader: upgrader}, http.MethodPost, req.URL())
+	if err != nil {
+		return "", "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr})
+	}()
+
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		return "", "", fmt.Errorf("command %v is stopped: %v", command, ctx.Err())
+	}
+	return stdout.String(), stderr.String(), err
+}
+
+// contextUpgrader closes the upgraded exec connection when the context is done,
+// the vendored executor has no context and would stream until the command exits.
+type contextUpgrader struct {
+	ctx      context.Context
+	upgrader spdy.Upgrader
+}
+
+func (u *contextUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-u.ctx.Done():
+			conn.Close()
+		case <-conn.CloseChan():
+		}
+	}()
+	return conn, nil
+}
+
+// IsGuestAgentConnected returns true if the guest agent of the VMI is connected
+func IsGuestAgentConnected(vmi *kubevirtv1.VirtualMachineInstance) bool {
+	for _, c := range vmi.Status.Conditions {
+		if c.Type == kubevirtv1.VirtualMachineInstanceAgentConnected {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
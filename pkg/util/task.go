@@ -0,0 +1,84 @@
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BackgroundTasks runs the long-running tasks of the controllers, like reading the image data, in goroutines so that
+// they don't hold the controller workers. A task is tracked by a key, e.g. the UID of the object, and an ID, e.g. the
+// UID of the data source it works on. The controllers persist the state of the tasks in the object status, so that
+// the tasks in progress are started again if harvester is restarted or the leader is changed.
+type BackgroundTasks struct {
+	mu    sync.Mutex
+	tasks map[string]*backgroundTask
+}
+
+type backgroundTask struct {
+	id     string
+	cancel context.CancelFunc
+	done   bool
+}
+
+// Start runs fn in a goroutine with a context which is cancelled after the timeout, by Cancel or by Forget.
+// It returns false without running fn if a task of the key with the same ID is tracked,
+// a task of the key with another ID is cancelled.
+// The task stays tracked after fn succeeds, until it's forgotten, so that it isn't started again by the controller
+// with a stale cache. It's forgotten if fn fails, so that it can be retried.
+func (t *BackgroundTasks) Start(key, id string, timeout time.Duration, fn func(ctx context.Context) error) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tasks == nil {
+		t.tasks = map[string]*backgroundTask{}
+	}
+	if existing, ok := t.tasks[key]; ok {
+		if existing.id == id {
+			return false
+		}
+		existing.cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	task := &backgroundTask{id: id, cancel: cancel}
+	t.tasks[key] = task
+	go func() {
+		defer cancel()
+		err := fn(ctx)
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		task.done = true
+		if err != nil && t.tasks[key] == task {
+			delete(t.tasks, key)
+		}
+	}()
+	return true
+}
+
+// IsRunning returns true if the task of the key is running
+func (t *BackgroundTasks) IsRunning(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	task, ok := t.tasks[key]
+	return ok && !task.done
+}
+
+// Cancel cancels the task of the key if it's running, it's still tracked until fn returns
+func (t *BackgroundTasks) Cancel(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if task, ok := t.tasks[key]; ok {
+		task.cancel()
+	}
+}
+
+// Forget cancels the task of the key if it's running and stops tracking it
+func (t *BackgroundTasks) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if task, ok := t.tasks[key]; ok {
+		task.cancel()
+		delete(t.tasks, key)
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackgroundTasks(t *testing.T) {
+	var tasks BackgroundTasks
+
+	// a running task isn't started again with the same ID
+	release := make(chan struct{})
+	finished := make(chan error, 1)
+	assert.True(t, tasks.Start("image", "ds1", time.Minute, func(ctx context.Context) error {
+		<-release
+		finished <- nil
+		return nil
+	}))
+	assert.True(t, tasks.IsRunning("image"))
+	assert.False(t, tasks.Start("image", "ds1", time.Minute, func(ctx context.Context) error { return nil }))
+	close(release)
+	<-finished
+	assert.Eventually(t, func() bool { return !tasks.IsRunning("image") }, time.Second, 10*time.Millisecond)
+
+	// the succeeded task stays tracked until it's forgotten
+	assert.False(t, tasks.Start("image", "ds1", time.Minute, func(ctx context.Context) error { return nil }))
+	tasks.Forget("image")
+
+	// the task of another ID cancels the running one
+	cancelled := make(chan error, 1)
+	assert.True(t, tasks.Start("image", "ds1", time.Minute, func(ctx context.Context) error {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	}))
+	assert.True(t, tasks.Start("image", "ds2", time.Minute, func(ctx context.Context) error { return nil }))
+	assert.Equal(t, context.Canceled, <-cancelled)
+
+	// the failed task is forgotten to be retried
+	failed := make(chan struct{})
+	assert.True(t, tasks.Start("failed", "ds1", time.Minute, func(ctx context.Context) error {
+		defer close(failed)
+		return errors.New("failed to read image data")
+	}))
+	<-failed
+	assert.Eventually(t, func() bool {
+		return tasks.Start("failed", "ds1", time.Minute, func(ctx context.Context) error { return nil })
+	}, time.Second, 10*time.Millisecond)
+
+	// the task is cancelled after the timeout
+	timedOut := make(chan error, 1)
+	assert.True(t, tasks.Start("timeout", "ds1", 10*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		timedOut <- ctx.Err()
+		return ctx.Err()
+	}))
+	assert.Equal(t, context.DeadlineExceeded, <-timedOut)
+}
@@ -0,0 +1,229 @@
+// Package vmdk converts VMDK disks in the streamOptimized format, which is the format of the disks in OVA packages,
+// to raw disks and back. The conversion is done in a single pass over the input, so the disks can be converted while
+// they're being uploaded or downloaded without being stored in a temporary file.
+package vmdk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	sectorSize = 512
+
+	magicNumber = 0x564d444b // "KDMV"
+
+	flagCompressed = 1 << 16
+	flagMarkers    = 1 << 17
+
+	compressionDeflate = 1
+
+	markerEOS    = 0
+	markerGT     = 1
+	markerGD     = 2
+	markerFooter = 3
+
+	// the size of a grain is at most 128 MiB, it's 64 KiB for disks created by VMware
+	maxGrainSize = 128 << 20
+)
+
+// sparseExtentHeader is the header of a sparse extent, it's the first sector of a streamOptimized VMDK
+type sparseExtentHeader struct {
+	MagicNumber        uint32
+	Version            uint32
+	Flags              uint32
+	Capacity           uint64
+	GrainSize          uint64
+	DescriptorOffset   uint64
+	DescriptorSize     uint64
+	NumGTEsPerGT       uint32
+	RgdOffset          uint64
+	GdOffset           uint64
+	OverHead           uint64
+	UncleanShutdown    uint8
+	SingleEndLineChar  uint8
+	NonEndLineChar     uint8
+	DoubleEndLineChar1 uint8
+	DoubleEndLineChar2 uint8
+	CompressAlgorithm  uint16
+}
+
+// StreamReader reads the raw content of a streamOptimized VMDK.
+// The grains of the VMDK must be in the order of their LBAs as they are written by VMware.
+type StreamReader struct {
+	r         io.Reader
+	offset    int64
+	capacity  int64
+	grainSize int64
+	// pos is the position of the raw content to read
+	pos int64
+	// grain is the data of the grain to read at grainPos
+	grain    []byte
+	grainPos int64
+	eos      bool
+}
+
+// NewStreamReader reads the header of a streamOptimized VMDK and returns a reader of the raw content
+func NewStreamReader(r io.Reader) (*StreamReader, error) {
+	sector := make([]byte, sectorSize)
+	if _, err := io.ReadFull(r, sector); err != nil {
+		return nil, fmt.Errorf("failed to read VMDK header: %w", err)
+	}
+	var header sparseExtentHeader
+	if err := binary.Read(bytes.NewReader(sector), binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	if header.MagicNumber != magicNumber {
+		return nil, errors.New("not a sparse VMDK, the magic number doesn't match")
+	}
+	if header.Flags&flagCompressed == 0 || header.Flags&flagMarkers == 0 || header.CompressAlgorithm != compressionDeflate {
+		return nil, errors.New("only streamOptimized VMDK is supported")
+	}
+	if header.GrainSize == 0 || header.GrainSize*sectorSize > maxGrainSize {
+		return nil, fmt.Errorf("invalid grain size %d", header.GrainSize)
+	}
+
+	s := &StreamReader{
+		r:         r,
+		offset:    sectorSize,
+		capacity:  int64(header.Capacity) * sectorSize,
+		grainSize: int64(header.GrainSize) * sectorSize,
+	}
+	// skip the descriptor and the metadata before the first grain
+	if err := s.skip(int64(header.OverHead)*sectorSize - s.offset); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Size returns the size of the raw content, it's the virtual size of the disk
+func (s *StreamReader) Size() int64 {
+	return s.capacity
+}
+
+// Read reads the raw content, the unallocated grains are read as zeros
+func (s *StreamReader) Read(p []byte) (int, error) {
+	if s.pos >= s.capacity {
+		return 0, io.EOF
+	}
+	if s.grain == nil && !s.eos {
+		if err := s.nextGrain(); err != nil {
+			return 0, err
+		}
+	}
+
+	end := s.capacity
+	if len(p) < int(end-s.pos) {
+		end = s.pos + int64(len(p))
+	}
+	var n int
+	switch {
+	case s.grain != nil && s.pos >= s.grainPos:
+		n = copy(p[:end-s.pos], s.grain[s.pos-s.grainPos:])
+		if s.pos+int64(n) >= s.grainPos+int64(len(s.grain)) {
+			s.grain = nil
+		}
+	default:
+		if s.grain != nil && s.grainPos < end {
+			end = s.grainPos
+		}
+		n = int(end - s.pos)
+		for i := range p[:n] {
+			p[i] = 0
+		}
+	}
+	s.pos += int64(n)
+	return n, nil
+}
+
+// nextGrain reads the markers until the next grain or the end of stream
+func (s *StreamReader) nextGrain() error {
+	marker := make([]byte, 12)
+	for {
+		if err := s.read(marker); err != nil {
+			return fmt.Errorf("failed to read VMDK marker: %w", err)
+		}
+		value := int64(binary.LittleEndian.Uint64(marker[:8]))
+		size := int64(binary.LittleEndian.Uint32(marker[8:]))
+
+		if size > 0 {
+			return s.readGrain(value, size)
+		}
+
+		// a metadata marker takes a whole sector and is followed by the metadata of value sectors
+		markerType := make([]byte, 4)
+		if err := s.read(markerType); err != nil {
+			return err
+		}
+		if err := s.skip(sectorSize - 16); err != nil {
+			return err
+		}
+		switch binary.LittleEndian.Uint32(markerType) {
+		case markerEOS:
+			s.eos = true
+			return nil
+		case markerGT, markerGD, markerFooter:
+			if err := s.skip(value * sectorSize); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown VMDK marker type %d", binary.LittleEndian.Uint32(markerType))
+		}
+	}
+}
+
+func (s *StreamReader) readGrain(lba, size int64) error {
+	grainPos := lba * sectorSize
+	if grainPos < s.pos {
+		return fmt.Errorf("grain at LBA %d is out of order", lba)
+	}
+	if size > s.grainSize*2 {
+		return fmt.Errorf("invalid size %d of the grain at LBA %d", size, lba)
+	}
+
+	compressed := make([]byte, size)
+	if err := s.read(compressed); err != nil {
+		return fmt.Errorf("failed to read the grain at LBA %d: %w", lba, err)
+	}
+	// grains are padded to sectors
+	if padding := (12 + size) % sectorSize; padding != 0 {
+		if err := s.skip(sectorSize - padding); err != nil {
+			return err
+		}
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("failed to decompress the grain at LBA %d: %w", lba, err)
+	}
+	defer zr.Close()
+	grain, err := io.ReadAll(io.LimitReader(zr, s.grainSize))
+	if err != nil {
+		return fmt.Errorf("failed to decompress the grain at LBA %d: %w", lba, err)
+	}
+	if len(grain) == 0 {
+		return s.nextGrain()
+	}
+	s.grain = grain
+	s.grainPos = grainPos
+	return nil
+}
+
+func (s *StreamReader) read(p []byte) error {
+	n, err := io.ReadFull(s.r, p)
+	s.offset += int64(n)
+	return err
+}
+
+func (s *StreamReader) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	written, err := io.CopyN(io.Discard, s.r, n)
+	s.offset += written
+	return err
+}
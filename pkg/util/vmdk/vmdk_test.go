@@ -0,0 +1,161 @@
+package vmdk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testGrainSectors = 8
+	testOverHead     = 2
+)
+
+func writeMarker(buf *bytes.Buffer, value uint64, markerType uint32) {
+	sector := make([]byte, sectorSize)
+	binary.LittleEndian.PutUint64(sector, value)
+	binary.LittleEndian.PutUint32(sector[12:], markerType)
+	buf.Write(sector)
+}
+
+func writeGrain(t *testing.T, buf *bytes.Buffer, lba uint64, data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, zw.Close())
+
+	marker := make([]byte, 12)
+	binary.LittleEndian.PutUint64(marker, lba)
+	binary.LittleEndian.PutUint32(marker[8:], uint32(compressed.Len()))
+	buf.Write(marker)
+	buf.Write(compressed.Bytes())
+	if padding := (12 + compressed.Len()) % sectorSize; padding != 0 {
+		buf.Write(make([]byte, sectorSize-padding))
+	}
+}
+
+func buildStreamOptimizedVMDK(t *testing.T, capacity uint64, grains map[uint64][]byte, lbas []uint64) []byte {
+	var buf bytes.Buffer
+	header := sparseExtentHeader{
+		MagicNumber:       magicNumber,
+		Version:           3,
+		Flags:             flagCompressed | flagMarkers | 1,
+		Capacity:          capacity,
+		GrainSize:         testGrainSectors,
+		DescriptorOffset:  1,
+		DescriptorSize:    1,
+		NumGTEsPerGT:      512,
+		GdOffset:          ^uint64(0),
+		OverHead:          testOverHead,
+		CompressAlgorithm: compressionDeflate,
+	}
+	assert.Nil(t, binary.Write(&buf, binary.LittleEndian, header))
+	buf.Write(make([]byte, testOverHead*sectorSize-buf.Len()))
+
+	for _, lba := range lbas {
+		writeGrain(t, &buf, lba, grains[lba])
+	}
+	// grain table and grain directory
+	writeMarker(&buf, 1, markerGT)
+	buf.Write(make([]byte, sectorSize))
+	writeMarker(&buf, 1, markerGD)
+	buf.Write(make([]byte, sectorSize))
+	writeMarker(&buf, 1, markerFooter)
+	buf.Write(make([]byte, sectorSize))
+	writeMarker(&buf, 0, markerEOS)
+	return buf.Bytes()
+}
+
+func TestStreamReader(t *testing.T) {
+	grainSize := testGrainSectors * sectorSize
+	grains := map[uint64][]byte{
+		0:  bytes.Repeat([]byte{1}, grainSize),
+		16: bytes.Repeat([]byte{2}, grainSize),
+	}
+	const capacity = 32
+	vmdk := buildStreamOptimizedVMDK(t, capacity, grains, []uint64{0, 16})
+
+	expected := make([]byte, capacity*sectorSize)
+	copy(expected, grains[0])
+	copy(expected[16*sectorSize:], grains[16])
+
+	r, err := NewStreamReader(bytes.NewReader(vmdk))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(capacity*sectorSize), r.Size())
+	raw, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, raw)
+}
+
+func TestStreamReaderErrors(t *testing.T) {
+	grainSize := testGrainSectors * sectorSize
+	grains := map[uint64][]byte{
+		0:  bytes.Repeat([]byte{1}, grainSize),
+		16: bytes.Repeat([]byte{2}, grainSize),
+	}
+	vmdk := buildStreamOptimizedVMDK(t, 32, grains, []uint64{16, 0})
+	r, err := NewStreamReader(bytes.NewReader(vmdk))
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+
+	_, err = NewStreamReader(bytes.NewReader(make([]byte, sectorSize)))
+	assert.NotNil(t, err)
+
+	monolithicSparse := sparseExtentHeader{MagicNumber: magicNumber, Version: 1, Flags: 1, Capacity: 32, GrainSize: testGrainSectors}
+	var buf bytes.Buffer
+	assert.Nil(t, binary.Write(&buf, binary.LittleEndian, monolithicSparse))
+	buf.Write(make([]byte, sectorSize))
+	_, err = NewStreamReader(&buf)
+	assert.NotNil(t, err)
+}
+
+func TestStreamWriter(t *testing.T) {
+	grainSize := writerGrainSectors * sectorSize
+	// three grains and a partial one, the second grain is zeros
+	raw := make([]byte, 3*grainSize+5*sectorSize)
+	copy(raw, bytes.Repeat([]byte{1}, grainSize))
+	copy(raw[2*grainSize:], bytes.Repeat([]byte{2}, grainSize))
+	copy(raw[3*grainSize:], bytes.Repeat([]byte{3}, 5*sectorSize))
+
+	var buf bytes.Buffer
+	w, err := NewStreamWriter(&buf, int64(len(raw)), "disk.vmdk")
+	assert.Nil(t, err)
+	// write in chunks unaligned to the grains
+	for data := raw; len(data) > 0; {
+		n := 1000
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := w.Write(data[:n])
+		assert.Nil(t, err)
+		data = data[n:]
+	}
+	assert.Nil(t, w.Close())
+	assert.Equal(t, 0, buf.Len()%sectorSize)
+	assert.Contains(t, buf.String(), `createType="streamOptimized"`)
+
+	r, err := NewStreamReader(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(raw)), r.Size())
+	converted, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, raw, converted)
+
+	// the footer has the offset of the grain directory
+	footer := buf.Bytes()[buf.Len()-2*sectorSize:]
+	var header sparseExtentHeader
+	assert.Nil(t, binary.Read(bytes.NewReader(footer), binary.LittleEndian, &header))
+	assert.Equal(t, uint32(magicNumber), header.MagicNumber)
+	assert.NotEqual(t, gdAtEnd, header.GdOffset)
+
+	w, err = NewStreamWriter(io.Discard, sectorSize, "disk.vmdk")
+	assert.Nil(t, err)
+	_, err = w.Write(make([]byte, 2*sectorSize))
+	assert.NotNil(t, err)
+}
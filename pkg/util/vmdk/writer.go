@@ -0,0 +1,292 @@
+package vmdk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+const (
+	// writerGrainSectors is the grain size of the VMDKs written by StreamWriter, it's the grain size used by VMware
+	writerGrainSectors = 128
+	writerNumGTEsPerGT = 512
+
+	gdAtEnd = ^uint64(0)
+)
+
+var descriptorTemplate = template.Must(template.New("descriptor").Parse(`# Disk DescriptorFile
+version=1
+CID=fffffffe
+parentCID=ffffffff
+createType="streamOptimized"
+
+# Extent description
+RW {{ .Sectors }} SPARSE "{{ .FileName }}"
+
+# The Disk Data Base
+#DDB
+
+ddb.adapterType = "lsilogic"
+ddb.geometry.cylinders = "{{ .Cylinders }}"
+ddb.geometry.heads = "255"
+ddb.geometry.sectors = "63"
+ddb.virtualHWVersion = "10"
+`))
+
+// StreamWriter writes raw content as a streamOptimized VMDK in a single pass over the content.
+// The grains of zeros are not stored, the other grains are compressed with deflate,
+// and the grain tables and the grain directory are written after all the grains.
+type StreamWriter struct {
+	w       io.Writer
+	offset  int64
+	header  sparseExtentHeader
+	sectors int64
+	// grain is the buffer of the grain at grainIndex
+	grain      []byte
+	grainIndex int64
+	// grainTable is the sector offset of the marker of each grain, it's 0 for the grains not stored
+	grainTable []uint32
+	zw         *zlib.Writer
+	compressed bytes.Buffer
+	err        error
+}
+
+// NewStreamWriter writes the header and the descriptor of a streamOptimized VMDK of the size,
+// fileName is the name of the VMDK file in the descriptor. The size is rounded up to sectors.
+func NewStreamWriter(w io.Writer, size int64, fileName string) (*StreamWriter, error) {
+	sectors := (size + sectorSize - 1) / sectorSize
+	grains := (sectors + writerGrainSectors - 1) / writerGrainSectors
+	if grains > int64(^uint32(0)) {
+		return nil, fmt.Errorf("size %d is too large", size)
+	}
+
+	var descriptor bytes.Buffer
+	if err := descriptorTemplate.Execute(&descriptor, map[string]interface{}{
+		"Sectors":   sectors,
+		"FileName":  fileName,
+		"Cylinders": sectors / (255 * 63),
+	}); err != nil {
+		return nil, err
+	}
+	descriptorSectors := (int64(descriptor.Len()) + sectorSize - 1) / sectorSize
+
+	s := &StreamWriter{
+		w: w,
+		header: sparseExtentHeader{
+			MagicNumber:        magicNumber,
+			Version:            3,
+			Flags:              flagCompressed | flagMarkers | 1,
+			Capacity:           uint64(sectors),
+			GrainSize:          writerGrainSectors,
+			DescriptorOffset:   1,
+			DescriptorSize:     uint64(descriptorSectors),
+			NumGTEsPerGT:       writerNumGTEsPerGT,
+			GdOffset:           gdAtEnd,
+			OverHead:           uint64(1 + descriptorSectors),
+			SingleEndLineChar:  '\n',
+			NonEndLineChar:     ' ',
+			DoubleEndLineChar1: '\r',
+			DoubleEndLineChar2: '\n',
+			CompressAlgorithm:  compressionDeflate,
+		},
+		sectors:    sectors,
+		grain:      make([]byte, 0, writerGrainSectors*sectorSize),
+		grainTable: make([]uint32, grains),
+	}
+	s.zw = zlib.NewWriter(&s.compressed)
+
+	if err := s.writeHeader(s.header); err != nil {
+		return nil, err
+	}
+	if err := s.write(descriptor.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := s.pad(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write writes the raw content, the content beyond the size is rejected
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	written := 0
+	for len(p) > 0 {
+		if s.grainIndex >= int64(len(s.grainTable)) {
+			return written, fmt.Errorf("content exceeds the VMDK size of %d sectors", s.sectors)
+		}
+		n := copy(s.grain[len(s.grain):s.grainEnd()], p)
+		s.grain = s.grain[:len(s.grain)+n]
+		p = p[n:]
+		written += n
+		if int64(len(s.grain)) == s.grainEnd() {
+			if err := s.flushGrain(); err != nil {
+				s.err = err
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close writes the last grain, the grain tables, the grain directory, the footer and the end-of-stream marker.
+// The content not written is zeros.
+func (s *StreamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.grain) > 0 {
+		if err := s.flushGrain(); err != nil {
+			return err
+		}
+	}
+
+	// grain tables
+	numGTs := (len(s.grainTable) + writerNumGTEsPerGT - 1) / writerNumGTEsPerGT
+	gtSectors := uint64(writerNumGTEsPerGT * 4 / sectorSize)
+	grainDirectory := make([]uint32, numGTs)
+	for i := 0; i < numGTs; i++ {
+		start, end := i*writerNumGTEsPerGT, (i+1)*writerNumGTEsPerGT
+		if end > len(s.grainTable) {
+			end = len(s.grainTable)
+		}
+		if isEmptyGrainTable(s.grainTable[start:end]) {
+			continue
+		}
+		if err := s.writeMarker(gtSectors, markerGT); err != nil {
+			return err
+		}
+		grainDirectory[i] = uint32(s.offset / sectorSize)
+		table := make([]uint32, writerNumGTEsPerGT)
+		copy(table, s.grainTable[start:end])
+		if err := s.writeEntries(table); err != nil {
+			return err
+		}
+	}
+
+	// grain directory
+	gdSectors := (uint64(numGTs)*4 + sectorSize - 1) / sectorSize
+	if err := s.writeMarker(gdSectors, markerGD); err != nil {
+		return err
+	}
+	footer := s.header
+	footer.GdOffset = uint64(s.offset / sectorSize)
+	if err := s.writeEntries(grainDirectory); err != nil {
+		return err
+	}
+
+	if err := s.writeMarker(1, markerFooter); err != nil {
+		return err
+	}
+	if err := s.writeHeader(footer); err != nil {
+		return err
+	}
+	return s.writeMarker(0, markerEOS)
+}
+
+// grainEnd returns the size of the current grain, the last grain is smaller if the size isn't aligned to grains
+func (s *StreamWriter) grainEnd() int64 {
+	remaining := (s.sectors - s.grainIndex*writerGrainSectors) * sectorSize
+	if remaining < int64(cap(s.grain)) {
+		return remaining
+	}
+	return int64(cap(s.grain))
+}
+
+func (s *StreamWriter) flushGrain() error {
+	defer func() {
+		s.grain = s.grain[:0]
+		s.grainIndex++
+	}()
+	if isZeros(s.grain) {
+		return nil
+	}
+
+	s.compressed.Reset()
+	s.zw.Reset(&s.compressed)
+	if _, err := s.zw.Write(s.grain); err != nil {
+		return err
+	}
+	if err := s.zw.Close(); err != nil {
+		return err
+	}
+
+	s.grainTable[s.grainIndex] = uint32(s.offset / sectorSize)
+	marker := make([]byte, 12)
+	binary.LittleEndian.PutUint64(marker, uint64(s.grainIndex*writerGrainSectors))
+	binary.LittleEndian.PutUint32(marker[8:], uint32(s.compressed.Len()))
+	if err := s.write(marker); err != nil {
+		return err
+	}
+	if err := s.write(s.compressed.Bytes()); err != nil {
+		return err
+	}
+	return s.pad()
+}
+
+func (s *StreamWriter) writeHeader(header sparseExtentHeader) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if err := s.write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.pad()
+}
+
+// writeMarker writes a metadata marker, which takes a whole sector
+func (s *StreamWriter) writeMarker(value uint64, markerType uint32) error {
+	sector := make([]byte, sectorSize)
+	binary.LittleEndian.PutUint64(sector, value)
+	binary.LittleEndian.PutUint32(sector[12:], markerType)
+	return s.write(sector)
+}
+
+func (s *StreamWriter) writeEntries(entries []uint32) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, entries); err != nil {
+		return err
+	}
+	if err := s.write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.pad()
+}
+
+func (s *StreamWriter) write(p []byte) error {
+	n, err := s.w.Write(p)
+	s.offset += int64(n)
+	return err
+}
+
+// pad pads the data written to sectors
+func (s *StreamWriter) pad() error {
+	if padding := s.offset % sectorSize; padding != 0 {
+		return s.write(make([]byte, sectorSize-padding))
+	}
+	return nil
+}
+
+func isZeros(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isEmptyGrainTable(table []uint32) bool {
+	for _, entry := range table {
+		if entry != 0 {
+			return false
+		}
+	}
+	return true
+}
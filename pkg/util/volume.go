@@ -6,6 +6,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester/pkg/settings"
@@ -20,6 +22,29 @@ var (
 	PersistentVolumeClaimsKind = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}
 )
 
+// VolumeMigration is the offline migration of a VM volume to a new PVC of another StorageClass
+type VolumeMigration struct {
+	DiskName      string `json:"diskName"`
+	SourcePVCName string `json:"sourcePVCName"`
+	// SourcePVCUID is the UID of the source PVC, only this PVC is deleted after the migration
+	SourcePVCUID     types.UID `json:"sourcePVCUID"`
+	TargetPVCName    string    `json:"targetPVCName"`
+	StorageClassName string    `json:"storageClassName"`
+}
+
+// VMMove is the move of a VM to another name or namespace
+type VMMove struct {
+	TargetNamespace string `json:"targetNamespace"`
+	TargetName      string `json:"targetName"`
+	// RunStrategy is the run strategy of the VM before the move, the VM is stopped during the move
+	RunStrategy kubevirtv1.VirtualMachineRunStrategy `json:"runStrategy"`
+	// User, UID and Groups identify the user who requested the move, the controller checks that the user
+	// can create VMs in the target namespace before moving the VM
+	User   string   `json:"user"`
+	UID    string   `json:"uid,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
 // GetProvisionedPVCProvisioner do not use this function when the PVC is just created
 func GetProvisionedPVCProvisioner(pvc *corev1.PersistentVolumeClaim) string {
 	provisioner, ok := pvc.Annotations[AnnBetaStorageProvisioner]
@@ -45,3 +70,57 @@ func LoadCSIDriverConfig(settingCache ctlharvesterv1.SettingCache) (map[string]s
 	}
 	return csiDriverConfig, nil
 }
+
+// NewHotplugVolumeOptions returns the options of the KubeVirt addvolume subresource to hot-plug the PVC as a disk.
+func NewHotplugVolumeOptions(diskName, claimName string) *kubevirtv1.AddVolumeOptions {
+	// Restrict the flexibility of disk options here but future extension may be possible.
+	return &kubevirtv1.AddVolumeOptions{
+		Name: diskName,
+		Disk: &kubevirtv1.Disk{
+			DiskDevice: kubevirtv1.DiskDevice{
+				Disk: &kubevirtv1.DiskTarget{
+					// KubeVirt only support SCSI for hotplug volume.
+					Bus: "scsi",
+				},
+			},
+		},
+		VolumeSource: &kubevirtv1.HotplugVolumeSource{
+			PersistentVolumeClaim: &kubevirtv1.PersistentVolumeClaimVolumeSource{
+				PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: claimName,
+				},
+				Hotpluggable: true,
+			},
+		},
+	}
+}
+
+// GetCloudInitSecretNames returns the names of the secrets referenced by the cloud-init volumes of a VM
+func GetCloudInitSecretNames(vm *kubevirtv1.VirtualMachine) []string {
+	var secretNames []string
+	addSecretName := func(secretRef *corev1.LocalObjectReference) {
+		if secretRef == nil || secretRef.Name == "" {
+			return
+		}
+		for _, name := range secretNames {
+			if name == secretRef.Name {
+				return
+			}
+		}
+		secretNames = append(secretNames, secretRef.Name)
+	}
+	if vm.Spec.Template == nil {
+		return nil
+	}
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.CloudInitNoCloud != nil {
+			addSecretName(volume.CloudInitNoCloud.UserDataSecretRef)
+			addSecretName(volume.CloudInitNoCloud.NetworkDataSecretRef)
+		}
+		if volume.CloudInitConfigDrive != nil {
+			addSecretName(volume.CloudInitConfigDrive.UserDataSecretRef)
+			addSecretName(volume.CloudInitConfigDrive.NetworkDataSecretRef)
+		}
+	}
+	return secretNames
+}
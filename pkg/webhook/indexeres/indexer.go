@@ -9,6 +9,7 @@ import (
 
 const (
 	VMBackupBySourceUIDIndex            = "harvesterhci.io/vmbackup-by-source-uid"
+	VMBackupByBackupTargetNameIndex     = "harvesterhci.io/vmbackup-by-backup-target-name"
 	VMRestoreByTargetNamespaceAndName   = "harvesterhci.io/vmrestore-by-target-namespace-and-name"
 	VMRestoreByVMBackupNamespaceAndName = "harvesterhci.io/vmrestore-by-vmbackup-namespace-and-name"
 )
@@ -17,6 +18,7 @@ func RegisterIndexers(clients *clients.Clients) {
 	vmBackupCache := clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache()
 	vmRestoreCache := clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore().Cache()
 	vmBackupCache.AddIndexer(VMBackupBySourceUIDIndex, vmBackupBySourceUID)
+	vmBackupCache.AddIndexer(VMBackupByBackupTargetNameIndex, vmBackupByBackupTargetName)
 	vmRestoreCache.AddIndexer(VMRestoreByTargetNamespaceAndName, vmRestoreByTargetNamespaceAndName)
 	vmRestoreCache.AddIndexer(VMRestoreByVMBackupNamespaceAndName, vmRestoreByVMBackupNamespaceAndName)
 }
@@ -28,6 +30,13 @@ func vmBackupBySourceUID(obj *harvesterv1.VirtualMachineBackup) ([]string, error
 	return []string{}, nil
 }
 
+func vmBackupByBackupTargetName(obj *harvesterv1.VirtualMachineBackup) ([]string, error) {
+	if obj != nil && obj.Spec.BackupTargetName != "" {
+		return []string{obj.Spec.BackupTargetName}, nil
+	}
+	return []string{}, nil
+}
+
 func vmRestoreByTargetNamespaceAndName(obj *harvesterv1.VirtualMachineRestore) ([]string, error) {
 	if obj == nil {
 		return []string{}, nil
@@ -0,0 +1,136 @@
+package namedbackuptarget
+
+import (
+	"fmt"
+
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/controller/master/backup"
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/indexeres"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldName             = "metadata.name"
+	fieldType             = "spec.type"
+	fieldEndpoint         = "spec.endpoint"
+	fieldBucketName       = "spec.bucketName"
+	fieldBucketRegion     = "spec.bucketRegion"
+	fieldCredentialSecret = "spec.credentialSecret"
+	fieldEncryptionSecret = "spec.encryptionSecret"
+)
+
+func NewValidator(secrets ctlcorev1.SecretCache, vmBackups ctlharvesterv1.VirtualMachineBackupCache) types.Validator {
+	return &namedBackupTargetValidator{
+		secrets:   secrets,
+		vmBackups: vmBackups,
+	}
+}
+
+type namedBackupTargetValidator struct {
+	types.DefaultValidator
+	secrets   ctlcorev1.SecretCache
+	vmBackups ctlharvesterv1.VirtualMachineBackupCache
+}
+
+func (v *namedBackupTargetValidator) Resource() types.Resource {
+	return types.Resource{
+		Names:      []string{v1beta1.NamedBackupTargetResourceName},
+		Scope:      admissionregv1.ClusterScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.NamedBackupTarget{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *namedBackupTargetValidator) Create(request *types.Request, newObj runtime.Object) error {
+	backupTarget := newObj.(*v1beta1.NamedBackupTarget)
+
+	if backupTarget.Name == v1beta1.DefaultBackupTargetName {
+		return werror.NewInvalidError(fmt.Sprintf("%s is reserved for the backup-target setting", v1beta1.DefaultBackupTargetName), fieldName)
+	}
+	return v.validateSpec(backupTarget)
+}
+
+func (v *namedBackupTargetValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	oldBackupTarget := oldObj.(*v1beta1.NamedBackupTarget)
+	newBackupTarget := newObj.(*v1beta1.NamedBackupTarget)
+
+	if newBackupTarget.DeletionTimestamp != nil {
+		return nil
+	}
+
+	// the vm backups record the endpoint of the backup target in their status
+	if oldBackupTarget.Spec.Endpoint != newBackupTarget.Spec.Endpoint ||
+		oldBackupTarget.Spec.BucketName != newBackupTarget.Spec.BucketName ||
+		oldBackupTarget.Spec.BucketRegion != newBackupTarget.Spec.BucketRegion {
+		if err := v.checkNotInUse(newBackupTarget.Name); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldEndpoint)
+		}
+	}
+	return v.validateSpec(newBackupTarget)
+}
+
+func (v *namedBackupTargetValidator) validateSpec(backupTarget *v1beta1.NamedBackupTarget) error {
+	switch backupTarget.Spec.Type {
+	case v1beta1.BackupTargetTypeNFS:
+		if backupTarget.Spec.Endpoint == "" {
+			return werror.NewInvalidError("endpoint is required for nfs backup target", fieldEndpoint)
+		}
+	case v1beta1.BackupTargetTypeS3:
+		if backupTarget.Spec.BucketName == "" {
+			return werror.NewInvalidError("bucket name is required for s3 backup target", fieldBucketName)
+		}
+		if backupTarget.Spec.BucketRegion == "" {
+			return werror.NewInvalidError("bucket region is required for s3 backup target", fieldBucketRegion)
+		}
+		secretRef := backupTarget.Spec.CredentialSecret
+		if secretRef == nil || secretRef.Namespace == "" || secretRef.Name == "" {
+			return werror.NewInvalidError("credential secret namespace and name are required for s3 backup target", fieldCredentialSecret)
+		}
+		if _, err := v.secrets.Get(secretRef.Namespace, secretRef.Name); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("can't get credential secret %s/%s, err: %v", secretRef.Namespace, secretRef.Name, err), fieldCredentialSecret)
+		}
+	default:
+		return werror.NewInvalidError(fmt.Sprintf("invalid backup target type %s", backupTarget.Spec.Type), fieldType)
+	}
+	return v.validateEncryptionSecret(backupTarget.Spec.EncryptionSecret)
+}
+
+func (v *namedBackupTargetValidator) validateEncryptionSecret(secretRef *corev1.SecretReference) error {
+	if secretRef == nil {
+		return nil
+	}
+	if secretRef.Namespace == "" || secretRef.Name == "" {
+		return werror.NewInvalidError("encryption secret namespace and name are required", fieldEncryptionSecret)
+	}
+	secret, err := v.secrets.Get(secretRef.Namespace, secretRef.Name)
+	if err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("can't get encryption secret %s/%s, err: %v", secretRef.Namespace, secretRef.Name, err), fieldEncryptionSecret)
+	}
+	if len(secret.Data[backup.EncryptionKey]) == 0 {
+		return werror.NewInvalidError(fmt.Sprintf("encryption secret %s/%s should have %s", secretRef.Namespace, secretRef.Name, backup.EncryptionKey), fieldEncryptionSecret)
+	}
+	return nil
+}
+
+func (v *namedBackupTargetValidator) checkNotInUse(name string) error {
+	vmBackups, err := v.vmBackups.GetByIndex(indexeres.VMBackupByBackupTargetNameIndex, name)
+	if err != nil {
+		return err
+	}
+	if len(vmBackups) > 0 {
+		return fmt.Errorf("backup target %s is used by vm backup %s/%s", name, vmBackups[0].Namespace, vmBackups[0].Name)
+	}
+	return nil
+}
@@ -14,19 +14,22 @@ import (
 	"github.com/harvester/harvester/pkg/ref"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
 	"github.com/harvester/harvester/pkg/webhook/types"
+	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
 )
 
-func NewValidator(pvcCache v1.PersistentVolumeClaimCache, vmCache ctlkv1.VirtualMachineCache) types.Validator {
+func NewValidator(pvcCache v1.PersistentVolumeClaimCache, vmCache ctlkv1.VirtualMachineCache, imageChecker *webhookutil.ImageVerificationChecker) types.Validator {
 	return &pvcValidator{
-		pvcCache: pvcCache,
-		vmCache:  vmCache,
+		pvcCache:     pvcCache,
+		vmCache:      vmCache,
+		imageChecker: imageChecker,
 	}
 }
 
 type pvcValidator struct {
 	types.DefaultValidator
-	pvcCache v1.PersistentVolumeClaimCache
-	vmCache  ctlkv1.VirtualMachineCache
+	pvcCache     v1.PersistentVolumeClaimCache
+	vmCache      ctlkv1.VirtualMachineCache
+	imageChecker *webhookutil.ImageVerificationChecker
 }
 
 func (v *pvcValidator) Resource() types.Resource {
@@ -37,12 +40,17 @@ func (v *pvcValidator) Resource() types.Resource {
 		APIVersion: corev1.SchemeGroupVersion.Version,
 		ObjectType: &corev1.PersistentVolumeClaim{},
 		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
 			admissionregv1.Delete,
 			admissionregv1.Update,
 		},
 	}
 }
 
+func (v *pvcValidator) Create(request *types.Request, newObj runtime.Object) error {
+	return v.imageChecker.CheckPVC(newObj.(*corev1.PersistentVolumeClaim))
+}
+
 func (v *pvcValidator) Delete(request *types.Request, oldObj runtime.Object) error {
 	if request.IsGarbageCollection() {
 		return nil
@@ -20,6 +20,7 @@ import (
 	_ "github.com/longhorn/backupstore/s3"  //nolint
 	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	"github.com/rancher/wharfie/pkg/registries"
+	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/slice"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/http/httpproxy"
@@ -92,6 +93,9 @@ func NewValidator(
 	vmRestoreCache ctlv1beta1.VirtualMachineRestoreCache,
 	vmis ctlkubevirtv1.VirtualMachineInstanceCache,
 	featureCache mgmtv3.FeatureCache,
+	secretCache ctlcorev1.SecretCache,
+	backupTargetCache ctlv1beta1.NamedBackupTargetCache,
+	namespaceCache ctlcorev1.NamespaceCache,
 ) types.Validator {
 	validator := &settingValidator{
 		settingCache:       settingCache,
@@ -100,6 +104,9 @@ func NewValidator(
 		vmRestoreCache:     vmRestoreCache,
 		vmis:               vmis,
 		featureCache:       featureCache,
+		secretCache:        secretCache,
+		backupTargetCache:  backupTargetCache,
+		namespaceCache:     namespaceCache,
 	}
 	validateSettingFuncs[settings.BackupTargetSettingName] = validator.validateBackupTarget
 	validateSettingFuncs[settings.VolumeSnapshotClassSettingName] = validator.validateVolumeSnapshotClass
@@ -107,6 +114,9 @@ func NewValidator(
 	validateSettingUpdateFuncs[settings.VolumeSnapshotClassSettingName] = validator.validateUpdateVolumeSnapshotClass
 	validateSettingUpdateFuncs[settings.RancherManagerSupportSettingName] = validator.validateUpdateRancherManagerSupport
 
+	validateSettingFuncs[settings.ImageSubscriptionSettingName] = validator.validateImageSubscription
+	validateSettingUpdateFuncs[settings.ImageSubscriptionSettingName] = validator.validateUpdateImageSubscription
+
 	validateSettingFuncs[settings.StorageNetworkName] = validator.validateStorageNetwork
 	validateSettingUpdateFuncs[settings.StorageNetworkName] = validator.validateUpdateStorageNetwork
 	validateSettingDeleteFuncs[settings.StorageNetworkName] = validator.validateDeleteStorageNetwork
@@ -122,6 +132,9 @@ type settingValidator struct {
 	vmRestoreCache     ctlv1beta1.VirtualMachineRestoreCache
 	vmis               ctlkubevirtv1.VirtualMachineInstanceCache
 	featureCache       mgmtv3.FeatureCache
+	secretCache        ctlcorev1.SecretCache
+	backupTargetCache  ctlv1beta1.NamedBackupTargetCache
+	namespaceCache     ctlcorev1.NamespaceCache
 }
 
 func (v *settingValidator) Resource() types.Resource {
@@ -294,6 +307,25 @@ func (v *settingValidator) validateBackupTargetFields(target *settings.BackupTar
 	return nil
 }
 
+func (v *settingValidator) validateBackupTargetEncryptionSecret(target *settings.BackupTarget) error {
+	if target.EncryptionSecret == nil {
+		return nil
+	}
+
+	secretRef := target.EncryptionSecret
+	if secretRef.Namespace == "" || secretRef.Name == "" {
+		return werror.NewInvalidError("encryption secret namespace and name are required", "value")
+	}
+	secret, err := v.secretCache.Get(secretRef.Namespace, secretRef.Name)
+	if err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("can't get encryption secret %s/%s, err: %v", secretRef.Namespace, secretRef.Name, err), "value")
+	}
+	if len(secret.Data[backup.EncryptionKey]) == 0 {
+		return werror.NewInvalidError(fmt.Sprintf("encryption secret %s/%s should have %s", secretRef.Namespace, secretRef.Name, backup.EncryptionKey), "value")
+	}
+	return nil
+}
+
 func (v *settingValidator) validateUpdateBackupTarget(oldSetting *v1beta1.Setting, newSetting *v1beta1.Setting) error {
 	return v.validateBackupTarget(newSetting)
 }
@@ -341,6 +373,10 @@ func (v *settingValidator) validateBackupTarget(setting *v1beta1.Setting) error
 		return err
 	}
 
+	if err = v.validateBackupTargetEncryptionSecret(target); err != nil {
+		return err
+	}
+
 	if target.Type == settings.S3BackupType {
 		// Set OS environment variables for S3
 		os.Setenv(backup.AWSAccessKey, target.AccessKeyID)
@@ -702,3 +738,33 @@ func (v *settingValidator) validateUpdateRancherManagerSupport(oldSetting *v1bet
 
 	return nil
 }
+
+func (v *settingValidator) validateImageSubscription(setting *v1beta1.Setting) error {
+	subscription, err := settings.DecodeImageSubscription(setting.Value)
+	if err != nil {
+		return werror.NewInvalidError(err.Error(), "value")
+	}
+	if len(subscription.BackupTargets) == 0 {
+		return nil
+	}
+	// the images are only replicated to an existing namespace chosen by this cluster
+	if subscription.Namespace == "" {
+		return werror.NewInvalidError("namespace is required to replicate images to", "value")
+	}
+	if _, err := v.namespaceCache.Get(subscription.Namespace); err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("can't get namespace %s, err: %v", subscription.Namespace, err), "value")
+	}
+	for _, name := range subscription.BackupTargets {
+		if name == v1beta1.DefaultBackupTargetName {
+			continue
+		}
+		if _, err := v.backupTargetCache.Get(name); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("can't get backup target %s, err: %v", name, err), "value")
+		}
+	}
+	return nil
+}
+
+func (v *settingValidator) validateUpdateImageSubscription(oldSetting *v1beta1.Setting, newSetting *v1beta1.Setting) error {
+	return v.validateImageSubscription(newSetting)
+}
@@ -7,10 +7,12 @@ import (
 
 	v1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
@@ -21,13 +23,31 @@ import (
 	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
 )
 
+// controllerAnnotations are set by the harvester API after checking the permissions of the user,
+// the controllers act on them with the permissions of the harvester service account.
+var controllerAnnotations = []string{
+	util.AnnotationVolumeMigration,
+	util.AnnotationVMMove,
+}
+
+// backupHookAnnotations are the commands the backup controller runs in the guest before and after freezing it,
+// so they can only be set by the users who can run commands in the guest.
+var backupHookAnnotations = []string{
+	util.AnnotationBackupPreHook,
+	util.AnnotationBackupPostHook,
+}
+
 func NewValidator(
 	pvcCache v1.PersistentVolumeClaimCache,
 	vmBackupCache ctlharvesterv1.VirtualMachineBackupCache,
+	imageChecker *webhookutil.ImageVerificationChecker,
+	sars authorizationv1client.SubjectAccessReviewInterface,
 ) types.Validator {
 	return &vmValidator{
 		pvcCache:      pvcCache,
 		vmBackupCache: vmBackupCache,
+		imageChecker:  imageChecker,
+		sars:          sars,
 	}
 }
 
@@ -35,6 +55,8 @@ type vmValidator struct {
 	types.DefaultValidator
 	pvcCache      v1.PersistentVolumeClaimCache
 	vmBackupCache ctlharvesterv1.VirtualMachineBackupCache
+	imageChecker  *webhookutil.ImageVerificationChecker
+	sars          authorizationv1client.SubjectAccessReviewInterface
 }
 
 func (v *vmValidator) Resource() types.Resource {
@@ -57,6 +79,12 @@ func (v *vmValidator) Create(request *types.Request, newObj runtime.Object) erro
 		return nil
 	}
 
+	if err := webhookutil.CheckControllerAnnotations(request, nil, vm, controllerAnnotations...); err != nil {
+		return err
+	}
+	if err := v.checkBackupHookAnnotations(request, nil, vm); err != nil {
+		return err
+	}
 	if err := v.checkVMSpec(vm); err != nil {
 		return err
 	}
@@ -78,6 +106,13 @@ func (v *vmValidator) Update(request *types.Request, oldObj runtime.Object, newO
 		return nil
 	}
 
+	if err := webhookutil.CheckControllerAnnotations(request, oldVM, newVM, controllerAnnotations...); err != nil {
+		return err
+	}
+	if err := v.checkBackupHookAnnotations(request, oldVM, newVM); err != nil {
+		return err
+	}
+
 	// Prevent users to stop/restart VM when there is VMBackup in progress.
 	if v.checkVMStoppingStatus(oldVM, newVM) {
 		if err := v.checkVMBackup(newVM); err != nil {
@@ -97,12 +132,54 @@ func (v *vmValidator) checkVMSpec(vm *kubevirtv1.VirtualMachine) error {
 	if err := v.checkOccupiedPVCs(vm); err != nil {
 		return err
 	}
+	if err := v.checkVolumeClaimTemplatesImages(vm); err != nil {
+		return err
+	}
 	if err := v.checkReservedMemoryAnnotation(vm); err != nil {
 		return err
 	}
 	return nil
 }
 
+// checkBackupHookAnnotations checks that the user who sets the backup hooks can run commands in the guest,
+// otherwise the user could run commands in the guest with the guest agent through the backups of the VM.
+// The hooks can be removed by the users who can update the VM.
+func (v *vmValidator) checkBackupHookAnnotations(request *types.Request, oldVM, newVM *kubevirtv1.VirtualMachine) error {
+	if request.IsFromController() {
+		return nil
+	}
+
+	changed := false
+	for _, annotation := range backupHookAnnotations {
+		newValue := newVM.Annotations[annotation]
+		if newValue != "" && (oldVM == nil || oldVM.Annotations[annotation] != newValue) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	allowed, err := webhookutil.CanUserDo(request.Context, v.sars, request, &authorizationv1.ResourceAttributes{
+		Namespace: newVM.Namespace,
+		Verb:      "guestexec",
+		Group:     kubevirtv1.SchemeGroupVersion.Group,
+		Version:   "*",
+		Resource:  "virtualmachines",
+		Name:      newVM.Name,
+	})
+	if err != nil {
+		message := fmt.Sprintf("failed to check user permission, error: %s", err.Error())
+		return werror.NewInvalidError(message, "")
+	}
+	if !allowed {
+		message := fmt.Sprintf("user has no permission to run commands in the guest of VM %s/%s, which is required to set the backup hooks",
+			newVM.Namespace, newVM.Name)
+		return werror.NewInvalidError(message, "metadata.annotations")
+	}
+	return nil
+}
+
 func (v *vmValidator) checkVMStoppingStatus(oldVM *kubevirtv1.VirtualMachine, newVM *kubevirtv1.VirtualMachine) bool {
 	oldRunStrategy, _ := oldVM.RunStrategy()
 	newRunStrategy, _ := newVM.RunStrategy()
@@ -140,6 +217,30 @@ func (v *vmValidator) checkVolumeClaimTemplatesAnnotation(vm *kubevirtv1.Virtual
 	return nil
 }
 
+// checkVolumeClaimTemplatesImages checks that the PVCs to be created from the volumeClaimTemplates annotation
+// are not created from images which aren't verified, the PVCs are created by the VM controller.
+func (v *vmValidator) checkVolumeClaimTemplatesImages(vm *kubevirtv1.VirtualMachine) error {
+	volumeClaimTemplates := vm.Annotations[util.AnnotationVolumeClaimTemplates]
+	if volumeClaimTemplates == "" {
+		return nil
+	}
+	var pvcs []*corev1.PersistentVolumeClaim
+	if err := json.Unmarshal([]byte(volumeClaimTemplates), &pvcs); err != nil {
+		return err
+	}
+	for _, pvc := range pvcs {
+		if _, err := v.pvcCache.Get(vm.Namespace, pvc.Name); err == nil {
+			continue
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := v.imageChecker.CheckPVC(pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (v *vmValidator) checkResizeVolumes(oldVM, newVM *kubevirtv1.VirtualMachine) error {
 	if oldVM.Annotations[util.AnnotationVolumeClaimTemplates] == "" || newVM.Annotations[util.AnnotationVolumeClaimTemplates] == "" {
 		return nil
@@ -0,0 +1,96 @@
+package virtualmachine
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/webhook/config"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+func TestCheckBackupHookAnnotations(t *testing.T) {
+	clientSet := k8sfake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := sar.Spec.ResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "admin" && attributes.Verb == "guestexec" &&
+			attributes.Group == kubevirtv1.SchemeGroupVersion.Group && attributes.Resource == "virtualmachines" &&
+			attributes.Namespace == "default" && attributes.Name == "vm"
+		return true, sar, nil
+	})
+	validator := &vmValidator{sars: clientSet.AuthorizationV1().SubjectAccessReviews()}
+	newRequest := func(username string) *types.Request {
+		return types.NewRequest(&webhook.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: username}},
+		}, &config.Options{HarvesterControllerUsername: "harvester"})
+	}
+	newVM := func(annotations map[string]string) *kubevirtv1.VirtualMachine {
+		return &kubevirtv1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vm", Annotations: annotations}}
+	}
+	hooks := map[string]string{util.AnnotationBackupPreHook: `["/sbin/prepare"]`}
+
+	var testCases = []struct {
+		name        string
+		username    string
+		oldVM       *kubevirtv1.VirtualMachine
+		newVM       *kubevirtv1.VirtualMachine
+		expectError bool
+	}{
+		{
+			name:     "no hooks",
+			username: "user",
+			newVM:    newVM(nil),
+		},
+		{
+			name:        "hooks set without guestexec permission",
+			username:    "user",
+			newVM:       newVM(hooks),
+			expectError: true,
+		},
+		{
+			name:        "hooks changed without guestexec permission",
+			username:    "user",
+			oldVM:       newVM(hooks),
+			newVM:       newVM(map[string]string{util.AnnotationBackupPreHook: `["/sbin/other"]`}),
+			expectError: true,
+		},
+		{
+			name:     "hooks unchanged without guestexec permission",
+			username: "user",
+			oldVM:    newVM(hooks),
+			newVM:    newVM(hooks),
+		},
+		{
+			name:     "hooks removed without guestexec permission",
+			username: "user",
+			oldVM:    newVM(hooks),
+			newVM:    newVM(nil),
+		},
+		{
+			name:     "hooks set with guestexec permission",
+			username: "admin",
+			newVM:    newVM(hooks),
+		},
+		{
+			name:     "hooks set by the controller, e.g. restoring the VM",
+			username: "harvester",
+			newVM:    newVM(hooks),
+		},
+	}
+
+	for _, tc := range testCases {
+		err := validator.checkBackupHookAnnotations(newRequest(tc.username), tc.oldVM, tc.newVM)
+		assert.Equal(t, tc.expectError, err != nil, tc.name)
+	}
+}
@@ -3,6 +3,7 @@ package virtualmachinebackup
 import (
 	"fmt"
 
+	longhorntypes "github.com/longhorn/longhorn-manager/types"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -17,11 +18,13 @@ import (
 	werror "github.com/harvester/harvester/pkg/webhook/error"
 	"github.com/harvester/harvester/pkg/webhook/indexeres"
 	"github.com/harvester/harvester/pkg/webhook/types"
+	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
 )
 
 const (
-	fieldSourceName = "spec.source.name"
-	fieldTypeName   = "spec.type"
+	fieldSourceName       = "spec.source.name"
+	fieldTypeName         = "spec.type"
+	fieldBackupTargetName = "spec.backupTargetName"
 )
 
 func NewValidator(
@@ -29,22 +32,25 @@ func NewValidator(
 	setting ctlharvesterv1.SettingCache,
 	vmrestores ctlharvesterv1.VirtualMachineRestoreCache,
 	pvcCache ctlcorev1.PersistentVolumeClaimCache,
+	backupTargets ctlharvesterv1.NamedBackupTargetCache,
 ) types.Validator {
 	return &virtualMachineBackupValidator{
-		vms:        vms,
-		setting:    setting,
-		vmrestores: vmrestores,
-		pvcCache:   pvcCache,
+		vms:           vms,
+		setting:       setting,
+		vmrestores:    vmrestores,
+		pvcCache:      pvcCache,
+		backupTargets: backupTargets,
 	}
 }
 
 type virtualMachineBackupValidator struct {
 	types.DefaultValidator
 
-	vms        ctlkubevirtv1.VirtualMachineCache
-	setting    ctlharvesterv1.SettingCache
-	vmrestores ctlharvesterv1.VirtualMachineRestoreCache
-	pvcCache   ctlcorev1.PersistentVolumeClaimCache
+	vms           ctlkubevirtv1.VirtualMachineCache
+	setting       ctlharvesterv1.SettingCache
+	vmrestores    ctlharvesterv1.VirtualMachineRestoreCache
+	pvcCache      ctlcorev1.PersistentVolumeClaimCache
+	backupTargets ctlharvesterv1.NamedBackupTargetCache
 }
 
 func (v *virtualMachineBackupValidator) Resource() types.Resource {
@@ -56,6 +62,7 @@ func (v *virtualMachineBackupValidator) Resource() types.Resource {
 		ObjectType: &v1beta1.VirtualMachineBackup{},
 		OperationTypes: []admissionregv1.OperationType{
 			admissionregv1.Create,
+			admissionregv1.Update,
 			admissionregv1.Delete,
 		},
 	}
@@ -68,6 +75,10 @@ func (v *virtualMachineBackupValidator) Create(request *types.Request, newObj ru
 		return werror.NewInvalidError("source VM name is empty", fieldSourceName)
 	}
 
+	if err := webhookutil.CheckControllerAnnotations(request, nil, newVMBackup, util.AnnotationBackupVerifyRequest); err != nil {
+		return err
+	}
+
 	var err error
 
 	// If VMBackup is from metadata in backup target, we don't check whether the VM is existent,
@@ -82,6 +93,13 @@ func (v *virtualMachineBackupValidator) Create(request *types.Request, newObj ru
 		}
 	}
 
+	if isNamedBackupTarget(newVMBackup) {
+		if err = v.checkNamedBackupTarget(newVMBackup); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldBackupTargetName)
+		}
+		return nil
+	}
+
 	if newVMBackup.Spec.Type == v1beta1.Backup {
 		err = v.checkBackupTarget()
 	}
@@ -92,6 +110,58 @@ func (v *virtualMachineBackupValidator) Create(request *types.Request, newObj ru
 	return nil
 }
 
+func (v *virtualMachineBackupValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	oldVMBackup := oldObj.(*v1beta1.VirtualMachineBackup)
+	newVMBackup := newObj.(*v1beta1.VirtualMachineBackup)
+
+	// the verify request annotation makes the controller restore the backup in the sandbox namespace
+	return webhookutil.CheckControllerAnnotations(request, oldVMBackup, newVMBackup, util.AnnotationBackupVerifyRequest)
+}
+
+func isNamedBackupTarget(vmBackup *v1beta1.VirtualMachineBackup) bool {
+	return vmBackup.Spec.BackupTargetName != "" && vmBackup.Spec.BackupTargetName != v1beta1.DefaultBackupTargetName
+}
+
+func (v *virtualMachineBackupValidator) checkNamedBackupTarget(vmBackup *v1beta1.VirtualMachineBackup) error {
+	if vmBackup.Spec.Type != v1beta1.Backup {
+		return fmt.Errorf("backup target can only be set for %s type", v1beta1.Backup)
+	}
+
+	if _, err := v.backupTargets.Get(vmBackup.Spec.BackupTargetName); err != nil {
+		return fmt.Errorf("can't get backup target %s, err: %w", vmBackup.Spec.BackupTargetName, err)
+	}
+
+	// VMBackup from metadata in backup target has been created, its volumes are checked already.
+	if vmBackup.Status != nil {
+		return nil
+	}
+
+	// Longhorn only backs up volumes to the backup target in the backup-target setting,
+	// then the Longhorn backups are copied to the named backup target.
+	vm, err := v.vms.Get(vmBackup.Namespace, vmBackup.Spec.Source.Name)
+	if err != nil {
+		return err
+	}
+	for _, volume := range vm.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := v.pvcCache.Get(vm.Namespace, volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			return fmt.Errorf("failed to get PVC %s/%s, err: %w", vm.Namespace, volume.PersistentVolumeClaim.ClaimName, err)
+		}
+		if util.GetProvisionedPVCProvisioner(pvc) != longhorntypes.LonghornDriverName {
+			continue
+		}
+		if err := v.checkBackupTarget(); err != nil {
+			return fmt.Errorf("volume %s is provisioned by %s, which is backed up to the %s backup target first, err: %w",
+				volume.Name, longhorntypes.LonghornDriverName, v1beta1.DefaultBackupTargetName, err)
+		}
+		return nil
+	}
+	return nil
+}
+
 // checkBackupVolumeSnapshotClass checks if the volumeSnapshotClassName is configured for the provisioner used by the PVCs in the VirtualMachine.
 func (v *virtualMachineBackupValidator) checkBackupVolumeSnapshotClass(vm *kubevirtv1.VirtualMachine, newVMBackup *v1beta1.VirtualMachineBackup) error {
 	csiDriverConfig, err := util.LoadCSIDriverConfig(v.setting)
@@ -0,0 +1,67 @@
+package virtualmachinebackupgroup
+
+import (
+	"reflect"
+
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldSpec     = "spec"
+	fieldSelector = "spec.selector"
+)
+
+func NewValidator() types.Validator {
+	return &virtualMachineBackupGroupValidator{}
+}
+
+type virtualMachineBackupGroupValidator struct {
+	types.DefaultValidator
+}
+
+func (v *virtualMachineBackupGroupValidator) Resource() types.Resource {
+	return types.Resource{
+		Names:      []string{v1beta1.VirtualMachineBackupGroupResourceName},
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.VirtualMachineBackupGroup{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *virtualMachineBackupGroupValidator) Create(request *types.Request, newObj runtime.Object) error {
+	group := newObj.(*v1beta1.VirtualMachineBackupGroup)
+
+	selector := group.Spec.Selector
+	isSelectorEmpty := selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+	if isSelectorEmpty && len(group.Spec.VMNames) == 0 {
+		return werror.NewInvalidError("either selector or vmNames is required", fieldSpec)
+	}
+	if !isSelectorEmpty {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldSelector)
+		}
+	}
+	return nil
+}
+
+// Update rejects changing the spec, the VMs of the group are backed up once when the group is created
+func (v *virtualMachineBackupGroupValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	oldGroup := oldObj.(*v1beta1.VirtualMachineBackupGroup)
+	newGroup := newObj.(*v1beta1.VirtualMachineBackupGroup)
+
+	if newGroup.DeletionTimestamp == nil && !reflect.DeepEqual(oldGroup.Spec, newGroup.Spec) {
+		return werror.NewInvalidError("spec of a backup group can't be changed", fieldSpec)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package virtualmachinebackupschedule
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldCron     = "spec.cron"
+	fieldSelector = "spec.selector"
+	fieldRetain   = "spec.retain"
+	fieldMaxAge   = "spec.maxAge"
+)
+
+func NewValidator() types.Validator {
+	return &virtualMachineBackupScheduleValidator{}
+}
+
+type virtualMachineBackupScheduleValidator struct {
+	types.DefaultValidator
+}
+
+func (v *virtualMachineBackupScheduleValidator) Resource() types.Resource {
+	return types.Resource{
+		Names:      []string{v1beta1.VirtualMachineBackupScheduleResourceName},
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.VirtualMachineBackupSchedule{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *virtualMachineBackupScheduleValidator) Create(request *types.Request, newObj runtime.Object) error {
+	return validateSchedule(newObj.(*v1beta1.VirtualMachineBackupSchedule))
+}
+
+func (v *virtualMachineBackupScheduleValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	return validateSchedule(newObj.(*v1beta1.VirtualMachineBackupSchedule))
+}
+
+func validateSchedule(schedule *v1beta1.VirtualMachineBackupSchedule) error {
+	if schedule.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if schedule.Spec.Cron == "" {
+		return werror.NewInvalidError("cron is required", fieldCron)
+	}
+	if _, err := cron.ParseStandard(schedule.Spec.Cron); err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("invalid cron %q: %v", schedule.Spec.Cron, err), fieldCron)
+	}
+
+	if len(schedule.Spec.Selector.MatchLabels) == 0 && len(schedule.Spec.Selector.MatchExpressions) == 0 {
+		return werror.NewInvalidError("selector can't be empty", fieldSelector)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(&schedule.Spec.Selector); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldSelector)
+	}
+
+	if schedule.Spec.Retain < 0 {
+		return werror.NewInvalidError("retain can't be negative", fieldRetain)
+	}
+	if schedule.Spec.MaxAge != nil && schedule.Spec.MaxAge.Duration <= 0 {
+		return werror.NewInvalidError("maxAge must be positive", fieldMaxAge)
+	}
+
+	return nil
+}
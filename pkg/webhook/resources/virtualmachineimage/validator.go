@@ -1,9 +1,13 @@
 package virtualmachineimage
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	ctlcorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
@@ -16,25 +20,34 @@ import (
 
 	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/settings"
 	"github.com/harvester/harvester/pkg/util"
 	werror "github.com/harvester/harvester/pkg/webhook/error"
 	"github.com/harvester/harvester/pkg/webhook/types"
+	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
 )
 
 const (
-	fieldDisplayName = "spec.displayName"
+	fieldDisplayName      = "spec.displayName"
+	fieldBackupTargetName = "spec.backupTargetName"
+	fieldPublishTo        = "spec.publishTo"
+	fieldRegistrySecret   = "spec.registrySecretName"
 )
 
 func NewValidator(
 	vmimages ctlharvesterv1.VirtualMachineImageCache,
 	pvcCache ctlcorev1.PersistentVolumeClaimCache,
 	ssar authorizationv1client.SelfSubjectAccessReviewInterface,
-	vmTemplateVersionCache ctlharvesterv1.VirtualMachineTemplateVersionCache) types.Validator {
+	sars authorizationv1client.SubjectAccessReviewInterface,
+	vmTemplateVersionCache ctlharvesterv1.VirtualMachineTemplateVersionCache,
+	backupTargets ctlharvesterv1.NamedBackupTargetCache) types.Validator {
 	return &virtualMachineImageValidator{
 		vmimages:               vmimages,
 		pvcCache:               pvcCache,
 		ssar:                   ssar,
+		sars:                   sars,
 		vmTemplateVersionCache: vmTemplateVersionCache,
+		backupTargets:          backupTargets,
 	}
 }
 
@@ -44,7 +57,9 @@ type virtualMachineImageValidator struct {
 	vmimages               ctlharvesterv1.VirtualMachineImageCache
 	pvcCache               ctlcorev1.PersistentVolumeClaimCache
 	ssar                   authorizationv1client.SelfSubjectAccessReviewInterface
+	sars                   authorizationv1client.SubjectAccessReviewInterface
 	vmTemplateVersionCache ctlharvesterv1.VirtualMachineTemplateVersionCache
+	backupTargets          ctlharvesterv1.NamedBackupTargetCache
 }
 
 func (v *virtualMachineImageValidator) Resource() types.Resource {
@@ -68,9 +83,147 @@ func (v *virtualMachineImageValidator) Create(request *types.Request, newObj run
 		return err
 	}
 
+	if err := v.CheckImageChecksumAndSignature(newImage); err != nil {
+		return err
+	}
+
+	if err := webhookutil.CheckControllerAnnotations(request, nil, newImage, util.AnnotationPublishedImage); err != nil {
+		return err
+	}
+
+	if err := v.CheckImageBackupTargets(request, newImage); err != nil {
+		return err
+	}
+
+	if err := v.CheckImageRegistrySecret(request, newImage); err != nil {
+		return err
+	}
+
 	return v.CheckImagePVC(request, newImage)
 }
 
+// CheckImageRegistrySecret checks that the user can read the secret the image is pulled from the registry with,
+// otherwise the user could pull the images of a registry with the credentials of another user.
+func (v *virtualMachineImageValidator) CheckImageRegistrySecret(request *types.Request, newImage *v1beta1.VirtualMachineImage) error {
+	if newImage.Spec.RegistrySecretName == "" {
+		return nil
+	}
+	if newImage.Spec.SourceType != v1beta1.VirtualMachineImageSourceTypeRegistry {
+		return werror.NewInvalidError(`registrySecretName should be empty when image source type is not "registry"`, fieldRegistrySecret)
+	}
+
+	allowed, err := webhookutil.CanUserDo(request.Context, v.sars, request, &authorizationv1.ResourceAttributes{
+		Namespace: newImage.Namespace,
+		Verb:      "get",
+		Group:     "",
+		Version:   "*",
+		Resource:  "secrets",
+		Name:      newImage.Spec.RegistrySecretName,
+	})
+	if err != nil {
+		message := fmt.Sprintf("failed to check user permission, error: %s", err.Error())
+		return werror.NewInvalidError(message, "")
+	}
+
+	if !allowed {
+		message := fmt.Sprintf("user has no permission to get the secret %s/%s", newImage.Namespace, newImage.Spec.RegistrySecretName)
+		return werror.NewInvalidError(message, fieldRegistrySecret)
+	}
+
+	return nil
+}
+
+// CheckImageBackupTargets checks the backup target the image is replicated from and the one it's published to.
+// The images are only replicated from backup targets by the image-subscription setting to the namespace in it,
+// so the users can't read the images published to the backup targets in other namespaces.
+func (v *virtualMachineImageValidator) CheckImageBackupTargets(request *types.Request, newImage *v1beta1.VirtualMachineImage) error {
+	if newImage.Spec.SourceType == v1beta1.VirtualMachineImageSourceTypeBackupTarget {
+		if !request.IsFromController() {
+			return werror.NewInvalidError(`images of the "backup-target" source type are only created by the image-subscription setting`, "spec.sourceType")
+		}
+		if newImage.Spec.BackupTargetName == "" {
+			return werror.NewInvalidError(`backupTargetName is required when image source type is "backup-target"`, fieldBackupTargetName)
+		}
+		if err := v.checkBackupTarget(newImage.Spec.BackupTargetName); err != nil {
+			return werror.NewInvalidError(err.Error(), fieldBackupTargetName)
+		}
+	} else if newImage.Spec.BackupTargetName != "" {
+		return werror.NewInvalidError(`backupTargetName should be empty when image source type is not "backup-target"`, fieldBackupTargetName)
+	}
+
+	if newImage.Spec.PublishTo != "" {
+		return v.checkPublishTo(request, newImage.Spec.PublishTo)
+	}
+	return nil
+}
+
+// checkPublishTo checks the backup target the image is published to exists and the user can update it,
+// since the image is written to the backup target with the credentials of the backup target.
+func (v *virtualMachineImageValidator) checkPublishTo(request *types.Request, name string) error {
+	if err := v.checkBackupTarget(name); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldPublishTo)
+	}
+
+	// the default backup target is the backup-target setting
+	attributes := &authorizationv1.ResourceAttributes{
+		Verb:     "update",
+		Group:    v1beta1.SchemeGroupVersion.Group,
+		Version:  "*",
+		Resource: "namedbackuptargets",
+		Name:     name,
+	}
+	if name == v1beta1.DefaultBackupTargetName {
+		attributes.Resource = "settings"
+		attributes.Name = settings.BackupTargetSettingName
+	}
+	allowed, err := webhookutil.CanUserDo(request.Context, v.sars, request, attributes)
+	if err != nil {
+		message := fmt.Sprintf("failed to check user permission, error: %s", err.Error())
+		return werror.NewInvalidError(message, "")
+	}
+	if !allowed {
+		return werror.NewInvalidError(fmt.Sprintf("user has no permission to publish images to backup target %s", name), fieldPublishTo)
+	}
+	return nil
+}
+
+func (v *virtualMachineImageValidator) checkBackupTarget(name string) error {
+	if name == v1beta1.DefaultBackupTargetName {
+		return nil
+	}
+	if _, err := v.backupTargets.Get(name); err != nil {
+		return fmt.Errorf("can't get backup target %s, err: %w", name, err)
+	}
+	return nil
+}
+
+func (v *virtualMachineImageValidator) CheckImageChecksumAndSignature(newImage *v1beta1.VirtualMachineImage) error {
+	if newImage.Spec.Checksum != "" && newImage.Spec.ChecksumAlgorithm != "" {
+		size := sha512.Size
+		if newImage.Spec.ChecksumAlgorithm == v1beta1.VirtualMachineImageChecksumAlgorithmSHA256 {
+			size = sha256.Size
+		}
+		if sum, err := hex.DecodeString(newImage.Spec.Checksum); err != nil || len(sum) != size {
+			return werror.NewInvalidError(fmt.Sprintf("checksum is not a valid %s checksum", newImage.Spec.ChecksumAlgorithm), "spec.checksum")
+		}
+	}
+
+	signature := newImage.Spec.Signature
+	if signature == nil {
+		return nil
+	}
+	if signature.Type != v1beta1.VirtualMachineImageSignatureTypeGPG && signature.Type != v1beta1.VirtualMachineImageSignatureTypeCosign {
+		return werror.NewInvalidError(fmt.Sprintf("signature type must be %q or %q", v1beta1.VirtualMachineImageSignatureTypeGPG, v1beta1.VirtualMachineImageSignatureTypeCosign), "spec.signature.type")
+	}
+	if (signature.URL == "") == (signature.Signature == "") {
+		return werror.NewInvalidError("one of url and signature is required", "spec.signature")
+	}
+	if signature.PublicKeySecretName == "" {
+		return werror.NewInvalidError("publicKeySecretName is required", "spec.signature.publicKeySecretName")
+	}
+	return nil
+}
+
 func (v *virtualMachineImageValidator) CheckImageDisplayNameAndURL(newImage *v1beta1.VirtualMachineImage) error {
 	if newImage.Spec.DisplayName == "" {
 		return werror.NewInvalidError("displayName is required", fieldDisplayName)
@@ -89,12 +242,22 @@ func (v *virtualMachineImageValidator) CheckImageDisplayNameAndURL(newImage *v1b
 		return werror.NewConflict("A resource with the same name exists")
 	}
 
-	if newImage.Spec.SourceType == v1beta1.VirtualMachineImageSourceTypeDownload && newImage.Spec.URL == "" {
-		return werror.NewInvalidError(`url is required when image source type is "download"`, "spec.url")
-	}
-
-	if newImage.Spec.SourceType != v1beta1.VirtualMachineImageSourceTypeDownload && newImage.Spec.URL != "" {
-		return werror.NewInvalidError(`url should be empty when image source type is not "download"`, "spec.url")
+	switch newImage.Spec.SourceType {
+	case v1beta1.VirtualMachineImageSourceTypeDownload:
+		if newImage.Spec.URL == "" {
+			return werror.NewInvalidError(`url is required when image source type is "download"`, "spec.url")
+		}
+	case v1beta1.VirtualMachineImageSourceTypeRegistry:
+		if newImage.Spec.URL == "" {
+			return werror.NewInvalidError(`url is required when image source type is "registry"`, "spec.url")
+		}
+		if _, err := name.ParseReference(newImage.Spec.URL); err != nil {
+			return werror.NewInvalidError(fmt.Sprintf("url is not a valid image reference: %v", err), "spec.url")
+		}
+	default:
+		if newImage.Spec.URL != "" {
+			return werror.NewInvalidError(`url should be empty when image source type is not "download" or "registry"`, "spec.url")
+		}
 	}
 
 	return nil
@@ -151,6 +314,10 @@ func (v *virtualMachineImageValidator) Update(request *types.Request, oldObj run
 		return nil
 	}
 
+	if err := webhookutil.CheckControllerAnnotations(request, oldImage, newImage, util.AnnotationPublishedImage); err != nil {
+		return err
+	}
+
 	if !reflect.DeepEqual(newImage.Spec.StorageClassParameters, oldImage.Spec.StorageClassParameters) {
 		return werror.NewInvalidError("storageClassParameters of the VM Image cannot be modified", "spec.storageClassParameters")
 	}
@@ -171,6 +338,26 @@ func (v *virtualMachineImageValidator) Update(request *types.Request, oldObj run
 	if oldImage.Spec.URL != newImage.Spec.URL {
 		return werror.NewInvalidError("url cannot be modified", "spec.url")
 	}
+	if oldImage.Spec.RegistrySecretName != newImage.Spec.RegistrySecretName {
+		return werror.NewInvalidError("registrySecretName cannot be modified", fieldRegistrySecret)
+	}
+
+	// the image is verified once it's imported
+	if oldImage.Spec.Checksum != newImage.Spec.Checksum || oldImage.Spec.ChecksumAlgorithm != newImage.Spec.ChecksumAlgorithm {
+		return werror.NewInvalidError("checksum and checksumAlgorithm cannot be modified", "spec.checksum")
+	}
+	if !reflect.DeepEqual(oldImage.Spec.Signature, newImage.Spec.Signature) {
+		return werror.NewInvalidError("signature cannot be modified", "spec.signature")
+	}
+
+	if oldImage.Spec.BackupTargetName != newImage.Spec.BackupTargetName {
+		return werror.NewInvalidError("backupTargetName cannot be modified", fieldBackupTargetName)
+	}
+	if oldImage.Spec.PublishTo != newImage.Spec.PublishTo && newImage.Spec.PublishTo != "" {
+		if err := v.checkPublishTo(request, newImage.Spec.PublishTo); err != nil {
+			return err
+		}
+	}
 
 	return v.CheckImageDisplayNameAndURL(newImage)
 }
@@ -0,0 +1,91 @@
+package virtualmachinepowerschedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const (
+	fieldStartCron = "spec.startCron"
+	fieldStopCron  = "spec.stopCron"
+	fieldTimeZone  = "spec.timeZone"
+	fieldSelector  = "spec.selector"
+)
+
+func NewValidator() types.Validator {
+	return &virtualMachinePowerScheduleValidator{}
+}
+
+type virtualMachinePowerScheduleValidator struct {
+	types.DefaultValidator
+}
+
+func (v *virtualMachinePowerScheduleValidator) Resource() types.Resource {
+	return types.Resource{
+		Names:      []string{v1beta1.VirtualMachinePowerScheduleResourceName},
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   v1beta1.SchemeGroupVersion.Group,
+		APIVersion: v1beta1.SchemeGroupVersion.Version,
+		ObjectType: &v1beta1.VirtualMachinePowerSchedule{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+func (v *virtualMachinePowerScheduleValidator) Create(request *types.Request, newObj runtime.Object) error {
+	return validateSchedule(newObj.(*v1beta1.VirtualMachinePowerSchedule))
+}
+
+func (v *virtualMachinePowerScheduleValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	return validateSchedule(newObj.(*v1beta1.VirtualMachinePowerSchedule))
+}
+
+func validateSchedule(schedule *v1beta1.VirtualMachinePowerSchedule) error {
+	if schedule.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if schedule.Spec.StartCron == "" && schedule.Spec.StopCron == "" {
+		return werror.NewInvalidError("at least one of startCron and stopCron is required", fieldStartCron)
+	}
+	if err := validateCron(schedule.Spec.StartCron, fieldStartCron); err != nil {
+		return err
+	}
+	if err := validateCron(schedule.Spec.StopCron, fieldStopCron); err != nil {
+		return err
+	}
+
+	if _, err := time.LoadLocation(schedule.Spec.TimeZone); err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("invalid time zone %q: %v", schedule.Spec.TimeZone, err), fieldTimeZone)
+	}
+
+	if len(schedule.Spec.Selector.MatchLabels) == 0 && len(schedule.Spec.Selector.MatchExpressions) == 0 {
+		return werror.NewInvalidError("selector can't be empty", fieldSelector)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(&schedule.Spec.Selector); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldSelector)
+	}
+
+	return nil
+}
+
+func validateCron(spec, field string) error {
+	if spec == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return werror.NewInvalidError(fmt.Sprintf("invalid cron %q: %v", spec, err), field)
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package virtualmachinerestore
 
 import (
 	"fmt"
+	"strings"
 
+	ctlstoragev1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -10,6 +12,7 @@ import (
 	"github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
 	ctlbackup "github.com/harvester/harvester/pkg/controller/master/backup"
 	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctlcniv1 "github.com/harvester/harvester/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester/pkg/generated/controllers/kubevirt.io/v1"
 	ctlsnapshotv1 "github.com/harvester/harvester/pkg/generated/controllers/snapshot.storage.k8s.io/v1beta1"
 	"github.com/harvester/harvester/pkg/settings"
@@ -22,6 +25,9 @@ const (
 	fieldTargetName               = "spec.target.name"
 	fieldVirtualMachineBackupName = "spec.virtualMachineBackupName"
 	fieldNewVM                    = "spec.newVM"
+	fieldVolumes                  = "spec.volumes"
+	fieldStorageClassMappings     = "spec.storageClassMappings"
+	fieldNetworkMappings          = "spec.networkMappings"
 )
 
 func NewValidator(
@@ -30,24 +36,33 @@ func NewValidator(
 	vmBackup ctlharvesterv1.VirtualMachineBackupCache,
 	vmRestore ctlharvesterv1.VirtualMachineRestoreCache,
 	snapshotClass ctlsnapshotv1.VolumeSnapshotClassCache,
+	backupTargets ctlharvesterv1.NamedBackupTargetCache,
+	storageClasses ctlstoragev1.StorageClassCache,
+	nads ctlcniv1.NetworkAttachmentDefinitionCache,
 ) types.Validator {
 	return &restoreValidator{
-		vms:           vms,
-		setting:       setting,
-		vmBackup:      vmBackup,
-		vmRestore:     vmRestore,
-		snapshotClass: snapshotClass,
+		vms:            vms,
+		setting:        setting,
+		vmBackup:       vmBackup,
+		vmRestore:      vmRestore,
+		snapshotClass:  snapshotClass,
+		backupTargets:  backupTargets,
+		storageClasses: storageClasses,
+		nads:           nads,
 	}
 }
 
 type restoreValidator struct {
 	types.DefaultValidator
 
-	vms           ctlkubevirtv1.VirtualMachineCache
-	setting       ctlharvesterv1.SettingCache
-	vmBackup      ctlharvesterv1.VirtualMachineBackupCache
-	vmRestore     ctlharvesterv1.VirtualMachineRestoreCache
-	snapshotClass ctlsnapshotv1.VolumeSnapshotClassCache
+	vms            ctlkubevirtv1.VirtualMachineCache
+	setting        ctlharvesterv1.SettingCache
+	vmBackup       ctlharvesterv1.VirtualMachineBackupCache
+	vmRestore      ctlharvesterv1.VirtualMachineRestoreCache
+	snapshotClass  ctlsnapshotv1.VolumeSnapshotClassCache
+	backupTargets  ctlharvesterv1.NamedBackupTargetCache
+	storageClasses ctlstoragev1.StorageClassCache
+	nads           ctlcniv1.NetworkAttachmentDefinitionCache
 }
 
 func (v *restoreValidator) Resource() types.Resource {
@@ -82,8 +97,14 @@ func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object)
 		return werror.NewInvalidError(err.Error(), fieldVirtualMachineBackupName)
 	}
 
+	if err := v.checkVolumes(newRestore, vmBackup); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldVolumes)
+	}
+
 	if vmBackup.Spec.Type == v1beta1.Backup {
-		err = v.checkBackupTarget(vmBackup)
+		if err = v.checkBackupTarget(vmBackup); err == nil {
+			err = v.checkCrossNamespace(newRestore, vmBackup)
+		}
 	} else {
 		err = v.checkSnapshot(newRestore, vmBackup)
 	}
@@ -91,6 +112,14 @@ func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object)
 		return werror.NewInvalidError(err.Error(), fieldVirtualMachineBackupName)
 	}
 
+	if err := v.checkStorageClassMappings(newRestore, vmBackup); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldStorageClassMappings)
+	}
+
+	if err := v.checkNetworkMappings(newRestore); err != nil {
+		return werror.NewInvalidError(err.Error(), fieldNetworkMappings)
+	}
+
 	vm, err := v.vms.Get(newRestore.Namespace, targetVM)
 	if err != nil {
 		if newVM && apierrors.IsNotFound(err) {
@@ -104,8 +133,8 @@ func (v *restoreValidator) Create(request *types.Request, newObj runtime.Object)
 		return werror.NewInvalidError(fmt.Sprintf("VM %s is already exists", vm.Name), fieldNewVM)
 	}
 
-	// restore an existing vm but the vm is still running
-	if !newVM && vm.Status.Ready {
+	// restore an existing vm but the vm is still running, the volumes can be restored next to the running vm
+	if !newVM && !ctlbackup.IsVolumeOnlyRestore(newRestore) && vm.Status.Ready {
 		return werror.NewInvalidError(fmt.Sprintf("Please stop the VM %q before doing a restore", vm.Name), fieldTargetName)
 	}
 
@@ -139,7 +168,7 @@ func (v *restoreValidator) checkSnapshot(vmRestore *v1beta1.VirtualMachineRestor
 	if vmRestore.Namespace != vmBackup.Namespace {
 		return fmt.Errorf("Restore to other namespace with backup type snapshot is not supported")
 	}
-	if !vmRestore.Spec.NewVM && vmRestore.Spec.DeletionPolicy != v1beta1.VirtualMachineRestoreRetain {
+	if !vmRestore.Spec.NewVM && !ctlbackup.IsVolumeOnlyRestore(vmRestore) && vmRestore.Spec.DeletionPolicy != v1beta1.VirtualMachineRestoreRetain {
 		// We don't allow users to use "delete" policy for replacing a VM when the backup type is snapshot.
 		// This will also remove the VMBackup when VMRestore is finished.
 		return fmt.Errorf("Delete policy with backup type snapshot for replacing VM is not supported")
@@ -147,7 +176,88 @@ func (v *restoreValidator) checkSnapshot(vmRestore *v1beta1.VirtualMachineRestor
 	return nil
 }
 
+// checkVolumes makes sure the selected volumes are in the backup, and only restored without restoring the vm
+func (v *restoreValidator) checkVolumes(vmRestore *v1beta1.VirtualMachineRestore, vmBackup *v1beta1.VirtualMachineBackup) error {
+	if ctlbackup.IsVolumeOnlyRestore(vmRestore) {
+		if vmRestore.Spec.NewVM {
+			return fmt.Errorf("Volume restore mode %s can't restore a new VM", vmRestore.Spec.VolumeRestoreMode)
+		}
+	} else if len(vmRestore.Spec.Volumes) > 0 {
+		return fmt.Errorf("Restore part of the volumes with the VM is not supported, please use volume restore mode %s or %s",
+			v1beta1.VolumeRestoreModePVC, v1beta1.VolumeRestoreModeHotplug)
+	}
+
+	for _, volumeName := range vmRestore.Spec.Volumes {
+		found := false
+		for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+			if volumeBackup.VolumeName == volumeName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("volume %s is not found in vmbackup %s/%s", volumeName, vmBackup.Namespace, vmBackup.Name)
+		}
+	}
+	return nil
+}
+
+func (v *restoreValidator) checkCrossNamespace(vmRestore *v1beta1.VirtualMachineRestore, vmBackup *v1beta1.VirtualMachineBackup) error {
+	if vmRestore.Namespace == vmBackup.Namespace {
+		return nil
+	}
+	// the volume snapshots in other namespaces are recreated from the Longhorn backups
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		if volumeBackup.LonghornBackupName == nil {
+			return fmt.Errorf("Restore volume %s to other namespace is not supported, it's not backed up by Longhorn", volumeBackup.VolumeName)
+		}
+	}
+	return nil
+}
+
+// checkStorageClassMappings makes sure the mapped StorageClasses exist and use the same CSI drivers as the backup volumes
+func (v *restoreValidator) checkStorageClassMappings(vmRestore *v1beta1.VirtualMachineRestore, vmBackup *v1beta1.VirtualMachineBackup) error {
+	for _, volumeBackup := range vmBackup.Status.VolumeBackups {
+		storageClassName := volumeBackup.PersistentVolumeClaim.Spec.StorageClassName
+		if storageClassName == nil {
+			continue
+		}
+		newStorageClassName, ok := vmRestore.Spec.StorageClassMappings[*storageClassName]
+		if !ok {
+			continue
+		}
+
+		storageClass, err := v.storageClasses.Get(newStorageClassName)
+		if err != nil {
+			return fmt.Errorf("can't get storage class %s, err: %w", newStorageClassName, err)
+		}
+		if storageClass.Provisioner != volumeBackup.CSIDriverName {
+			return fmt.Errorf("storage class %s of provisioner %s can't restore volume %s of CSI driver %s",
+				newStorageClassName, storageClass.Provisioner, volumeBackup.VolumeName, volumeBackup.CSIDriverName)
+		}
+	}
+	return nil
+}
+
+func (v *restoreValidator) checkNetworkMappings(vmRestore *v1beta1.VirtualMachineRestore) error {
+	for oldName, newName := range vmRestore.Spec.NetworkMappings {
+		oldParts := strings.Split(oldName, "/")
+		newParts := strings.Split(newName, "/")
+		if len(oldParts) != 2 || len(newParts) != 2 {
+			return fmt.Errorf("network mapping %s: %s is not in the <namespace>/<name> format", oldName, newName)
+		}
+		if _, err := v.nads.Get(newParts[0], newParts[1]); err != nil {
+			return fmt.Errorf("can't get network attachment definition %s, err: %w", newName, err)
+		}
+	}
+	return nil
+}
+
 func (v *restoreValidator) checkBackupTarget(vmBackup *v1beta1.VirtualMachineBackup) error {
+	if name := vmBackup.Spec.BackupTargetName; name != "" && name != v1beta1.DefaultBackupTargetName {
+		return v.checkNamedBackupTarget(vmBackup)
+	}
+
 	backupTargetSetting, err := v.setting.Get(settings.BackupTargetSettingName)
 	if err != nil {
 		return fmt.Errorf("Can't get backup target setting, err: %w", err)
@@ -168,6 +278,24 @@ func (v *restoreValidator) checkBackupTarget(vmBackup *v1beta1.VirtualMachineBac
 	return nil
 }
 
+func (v *restoreValidator) checkNamedBackupTarget(vmBackup *v1beta1.VirtualMachineBackup) error {
+	backupTarget, err := v.backupTargets.Get(vmBackup.Spec.BackupTargetName)
+	if err != nil {
+		return fmt.Errorf("can't get backup target %s, err: %w", vmBackup.Spec.BackupTargetName, err)
+	}
+
+	target := &settings.BackupTarget{
+		Endpoint:     backupTarget.Spec.Endpoint,
+		BucketName:   backupTarget.Spec.BucketName,
+		BucketRegion: backupTarget.Spec.BucketRegion,
+	}
+	if !ctlbackup.IsBackupTargetSame(vmBackup.Status.BackupTarget, target) {
+		return fmt.Errorf("backup target %s is not matched in vmBackup %s/%s", backupTarget.Name, vmBackup.Namespace, vmBackup.Name)
+	}
+
+	return nil
+}
+
 func (v *restoreValidator) checkVolumeSnapshotClass(vmBackup *v1beta1.VirtualMachineBackup) error {
 	for csiDriverName, volumeSnapshotClassName := range vmBackup.Status.CSIDriverVolumeSnapshotClassNames {
 		_, err := v.snapshotClass.Get(volumeSnapshotClassName)
@@ -0,0 +1,48 @@
+package volumesnapshot
+
+import (
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/v2/pkg/apis/volumesnapshot/v1beta1"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/webhook/types"
+	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
+)
+
+func NewValidator() types.Validator {
+	return &volumeSnapshotValidator{}
+}
+
+type volumeSnapshotValidator struct {
+	types.DefaultValidator
+}
+
+func (v *volumeSnapshotValidator) Resource() types.Resource {
+	return types.Resource{
+		Names:      []string{"volumesnapshots"},
+		Scope:      admissionregv1.NamespacedScope,
+		APIGroup:   snapshotv1.SchemeGroupVersion.Group,
+		APIVersion: snapshotv1.SchemeGroupVersion.Version,
+		ObjectType: &snapshotv1.VolumeSnapshot{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
+
+// Create rejects the clone annotations set by users, the clone controller copies and deletes the VolumeSnapshots
+// across namespaces with the permissions of the harvester service account.
+func (v *volumeSnapshotValidator) Create(request *types.Request, newObj runtime.Object) error {
+	snapshot := newObj.(*snapshotv1.VolumeSnapshot)
+	return webhookutil.CheckControllerAnnotations(request, nil, snapshot,
+		util.AnnotationCloneTargetNamespace, util.AnnotationCloneSourceNamespace)
+}
+
+func (v *volumeSnapshotValidator) Update(request *types.Request, oldObj runtime.Object, newObj runtime.Object) error {
+	oldSnapshot := oldObj.(*snapshotv1.VolumeSnapshot)
+	newSnapshot := newObj.(*snapshotv1.VolumeSnapshot)
+	return webhookutil.CheckControllerAnnotations(request, oldSnapshot, newSnapshot,
+		util.AnnotationCloneTargetNamespace, util.AnnotationCloneSourceNamespace)
+}
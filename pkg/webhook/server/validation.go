@@ -11,6 +11,7 @@ import (
 	"github.com/harvester/harvester/pkg/webhook/resources/bundledeployment"
 	"github.com/harvester/harvester/pkg/webhook/resources/keypair"
 	"github.com/harvester/harvester/pkg/webhook/resources/managedchart"
+	"github.com/harvester/harvester/pkg/webhook/resources/namedbackuptarget"
 	"github.com/harvester/harvester/pkg/webhook/resources/node"
 	"github.com/harvester/harvester/pkg/webhook/resources/persistentvolumeclaim"
 	"github.com/harvester/harvester/pkg/webhook/resources/setting"
@@ -19,25 +20,41 @@ import (
 	"github.com/harvester/harvester/pkg/webhook/resources/upgrade"
 	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachine"
 	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachinebackup"
+	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachinebackupgroup"
+	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachinebackupschedule"
 	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachineimage"
+	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachinepowerschedule"
 	"github.com/harvester/harvester/pkg/webhook/resources/virtualmachinerestore"
+	"github.com/harvester/harvester/pkg/webhook/resources/volumesnapshot"
 	"github.com/harvester/harvester/pkg/webhook/types"
+	webhookutil "github.com/harvester/harvester/pkg/webhook/util"
 )
 
 func Validation(clients *clients.Clients, options *config.Options) (http.Handler, []types.Resource, error) {
 	resources := []types.Resource{}
+	imageChecker := webhookutil.NewImageVerificationChecker(
+		clients.StorageFactory.Storage().V1().StorageClass().Cache(),
+		clients.LonghornFactory.Longhorn().V1beta1().BackingImage().Cache(),
+		clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache())
 	validators := []types.Validator{
 		node.NewValidator(clients.Core.Node().Cache()),
-		persistentvolumeclaim.NewValidator(clients.Core.PersistentVolumeClaim().Cache(), clients.KubevirtFactory.Kubevirt().V1().VirtualMachine().Cache()),
+		persistentvolumeclaim.NewValidator(
+			clients.Core.PersistentVolumeClaim().Cache(),
+			clients.KubevirtFactory.Kubevirt().V1().VirtualMachine().Cache(),
+			imageChecker),
 		keypair.NewValidator(clients.HarvesterFactory.Harvesterhci().V1beta1().KeyPair().Cache()),
 		virtualmachine.NewValidator(
 			clients.Core.PersistentVolumeClaim().Cache(),
-			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache()),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
+			imageChecker,
+			clients.K8s.AuthorizationV1().SubjectAccessReviews()),
 		virtualmachineimage.NewValidator(
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineImage().Cache(),
 			clients.Core.PersistentVolumeClaim().Cache(),
 			clients.K8s.AuthorizationV1().SelfSubjectAccessReviews(),
-			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion().Cache()),
+			clients.K8s.AuthorizationV1().SubjectAccessReviews(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplateVersion().Cache(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache()),
 		upgrade.NewValidator(
 			clients.HarvesterFactory.Harvesterhci().V1beta1().Upgrade().Cache(),
 			clients.Core.Node().Cache(),
@@ -51,6 +68,14 @@ func Validation(clients *clients.Clients, options *config.Options) (http.Handler
 			clients.HarvesterFactory.Harvesterhci().V1beta1().Setting().Cache(),
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore().Cache(),
 			clients.CoreFactory.Core().V1().PersistentVolumeClaim().Cache(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache(),
+		),
+		virtualmachinebackupschedule.NewValidator(),
+		virtualmachinebackupgroup.NewValidator(),
+		virtualmachinepowerschedule.NewValidator(),
+		namedbackuptarget.NewValidator(
+			clients.Core.Secret().Cache(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
 		),
 		virtualmachinerestore.NewValidator(
 			clients.KubevirtFactory.Kubevirt().V1().VirtualMachine().Cache(),
@@ -58,6 +83,9 @@ func Validation(clients *clients.Clients, options *config.Options) (http.Handler
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineBackup().Cache(),
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore().Cache(),
 			clients.SnapshotFactory.Snapshot().V1beta1().VolumeSnapshotClass().Cache(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache(),
+			clients.StorageFactory.Storage().V1().StorageClass().Cache(),
+			clients.CNIFactory.K8s().V1().NetworkAttachmentDefinition().Cache(),
 		),
 		setting.NewValidator(
 			clients.HarvesterFactory.Harvesterhci().V1beta1().Setting().Cache(),
@@ -66,6 +94,9 @@ func Validation(clients *clients.Clients, options *config.Options) (http.Handler
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineRestore().Cache(),
 			clients.KubevirtFactory.Kubevirt().V1().VirtualMachineInstance().Cache(),
 			clients.RancherManagementFactory.Management().V3().Feature().Cache(),
+			clients.Core.Secret().Cache(),
+			clients.HarvesterFactory.Harvesterhci().V1beta1().NamedBackupTarget().Cache(),
+			clients.Core.Namespace().Cache(),
 		),
 		templateversion.NewValidator(
 			clients.HarvesterFactory.Harvesterhci().V1beta1().VirtualMachineTemplate().Cache(),
@@ -77,6 +108,7 @@ func Validation(clients *clients.Clients, options *config.Options) (http.Handler
 			clients.FleetFactory.Fleet().V1alpha1().Cluster().Cache(),
 		),
 		storageclass.NewValidator(clients.StorageFactory.Storage().V1().StorageClass().Cache()),
+		volumesnapshot.NewValidator(),
 	}
 
 	router := webhook.NewRouter()
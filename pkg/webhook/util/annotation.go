@@ -0,0 +1,38 @@
+package util
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+const fieldAnnotations = "metadata.annotations"
+
+// CheckControllerAnnotations returns an error if any of the annotations is added, changed or removed by anyone but
+// the harvester service account. These annotations are set by the harvester API after checking the permissions of
+// the user, and the controllers act on them with the permissions of the harvester service account.
+// The oldObj is nil for the Create operation.
+func CheckControllerAnnotations(request *types.Request, oldObj, newObj metav1.Object, annotations ...string) error {
+	if request.IsFromController() {
+		return nil
+	}
+
+	var oldAnnotations, newAnnotations map[string]string
+	if oldObj != nil {
+		oldAnnotations = oldObj.GetAnnotations()
+	}
+	if newObj != nil {
+		newAnnotations = newObj.GetAnnotations()
+	}
+	for _, annotation := range annotations {
+		oldValue, oldOK := oldAnnotations[annotation]
+		newValue, newOK := newAnnotations[annotation]
+		if oldOK != newOK || oldValue != newValue {
+			return werror.NewInvalidError(fmt.Sprintf("annotation %s can only be set by harvester", annotation), fieldAnnotations)
+		}
+	}
+	return nil
+}
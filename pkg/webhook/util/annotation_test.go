@@ -0,0 +1,86 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/harvester/harvester/pkg/webhook/config"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+func TestCheckControllerAnnotations(t *testing.T) {
+	const (
+		annotation     = "harvesterhci.io/test"
+		controllerUser = "system:serviceaccount:harvester-system:harvester"
+	)
+	newRequest := func(username string) *types.Request {
+		return types.NewRequest(&webhook.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: username},
+			},
+		}, &config.Options{HarvesterControllerUsername: controllerUser})
+	}
+	newObj := func(annotations map[string]string) metav1.Object {
+		return &metav1.ObjectMeta{Annotations: annotations}
+	}
+
+	var testCases = []struct {
+		name      string
+		username  string
+		oldObj    metav1.Object
+		newObj    metav1.Object
+		expectErr bool
+	}{
+		{
+			name:      "user creates with the annotation",
+			username:  "user",
+			newObj:    newObj(map[string]string{annotation: "a"}),
+			expectErr: true,
+		},
+		{
+			name:     "user creates without the annotation",
+			username: "user",
+			newObj:   newObj(map[string]string{"other": "a"}),
+		},
+		{
+			name:      "user changes the annotation",
+			username:  "user",
+			oldObj:    newObj(map[string]string{annotation: "a"}),
+			newObj:    newObj(map[string]string{annotation: "b"}),
+			expectErr: true,
+		},
+		{
+			name:      "user removes the annotation",
+			username:  "user",
+			oldObj:    newObj(map[string]string{annotation: "a"}),
+			newObj:    newObj(nil),
+			expectErr: true,
+		},
+		{
+			name:     "user keeps the annotation",
+			username: "user",
+			oldObj:   newObj(map[string]string{annotation: "a"}),
+			newObj:   newObj(map[string]string{annotation: "a", "other": "b"}),
+		},
+		{
+			name:     "controller sets the annotation",
+			username: controllerUser,
+			oldObj:   newObj(nil),
+			newObj:   newObj(map[string]string{annotation: "a"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		err := CheckControllerAnnotations(newRequest(tc.username), tc.oldObj, tc.newObj, annotation)
+		if tc.expectErr {
+			assert.NotNil(t, err, tc.name)
+		} else {
+			assert.Nil(t, err, tc.name)
+		}
+	}
+}
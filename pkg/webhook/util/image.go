@@ -0,0 +1,75 @@
+package util
+
+import (
+	"fmt"
+
+	ctlstoragev1 "github.com/rancher/wrangler/pkg/generated/controllers/storage/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	ctlharvesterv1 "github.com/harvester/harvester/pkg/generated/controllers/harvesterhci.io/v1beta1"
+	ctllonghornv1 "github.com/harvester/harvester/pkg/generated/controllers/longhorn.io/v1beta1"
+	"github.com/harvester/harvester/pkg/ref"
+	"github.com/harvester/harvester/pkg/util"
+	werror "github.com/harvester/harvester/pkg/webhook/error"
+)
+
+// ImageVerificationChecker checks that the volumes created from VM images are not created before the images are verified
+type ImageVerificationChecker struct {
+	storageClassCache ctlstoragev1.StorageClassCache
+	backingImageCache ctllonghornv1.BackingImageCache
+	vmImageCache      ctlharvesterv1.VirtualMachineImageCache
+}
+
+func NewImageVerificationChecker(
+	storageClassCache ctlstoragev1.StorageClassCache,
+	backingImageCache ctllonghornv1.BackingImageCache,
+	vmImageCache ctlharvesterv1.VirtualMachineImageCache,
+) *ImageVerificationChecker {
+	return &ImageVerificationChecker{
+		storageClassCache: storageClassCache,
+		backingImageCache: backingImageCache,
+		vmImageCache:      vmImageCache,
+	}
+}
+
+// CheckPVC returns an error if the PVC is provisioned from a VM image with a checksum or a signature which isn't verified.
+// The image is found through the backing image of the storage class, so it can't be bypassed by the PVC annotations.
+// The PVCs bound to existing PVs or restored from data sources don't read the image data, so they are not checked.
+func (c *ImageVerificationChecker) CheckPVC(pvc *corev1.PersistentVolumeClaim) error {
+	if pvc.Spec.StorageClassName == nil || pvc.Spec.VolumeName != "" || pvc.Spec.DataSource != nil {
+		return nil
+	}
+	storageClass, err := c.storageClassCache.Get(*pvc.Spec.StorageClassName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	backingImageName := storageClass.Parameters[util.LonghornOptionBackingImageName]
+	if backingImageName == "" {
+		return nil
+	}
+	backingImage, err := c.backingImageCache.Get(util.LonghornSystemNamespaceName, backingImageName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	imageID := backingImage.Annotations[util.AnnotationImageID]
+	if imageID == "" {
+		return nil
+	}
+	namespace, name := ref.Parse(imageID)
+	image, err := c.vmImageCache.Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !util.IsImageVerified(image) {
+		message := fmt.Sprintf("volume %s can't be created from image %s which isn't verified", pvc.Name, image.Spec.DisplayName)
+		return werror.NewInvalidError(message, "spec.storageClassName")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"testing"
+
+	longhornv1 "github.com/longhorn/longhorn-manager/k8s/pkg/apis/longhorn/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+
+	harvesterv1 "github.com/harvester/harvester/pkg/apis/harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester/pkg/util"
+	"github.com/harvester/harvester/pkg/util/fakeclients"
+)
+
+func TestImageVerificationCheckerCheckPVC(t *testing.T) {
+	newImage := func(name string, verified corev1.ConditionStatus) *harvesterv1.VirtualMachineImage {
+		image := &harvesterv1.VirtualMachineImage{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: harvesterv1.VirtualMachineImageSpec{
+				DisplayName: name,
+				SourceType:  harvesterv1.VirtualMachineImageSourceTypeDownload,
+				Checksum:    "abc",
+				Signature: &harvesterv1.VirtualMachineImageSignature{
+					Type:      harvesterv1.VirtualMachineImageSignatureTypeCosign,
+					Signature: "sig",
+				},
+			},
+		}
+		if verified != "" {
+			harvesterv1.ImageVerified.SetStatus(image, string(verified))
+		}
+		return image
+	}
+	newStorageClass := func(image *harvesterv1.VirtualMachineImage) *storagev1.StorageClass {
+		return &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{Name: util.GetImageStorageClassName(image.Name)},
+			Parameters: util.GetImageStorageClassParameters(image),
+		}
+	}
+	newBackingImage := func(image *harvesterv1.VirtualMachineImage) *longhornv1.BackingImage {
+		return &longhornv1.BackingImage{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   util.LonghornSystemNamespaceName,
+				Name:        util.GetBackingImageName(image),
+				Annotations: map[string]string{util.AnnotationImageID: image.Namespace + "/" + image.Name},
+			},
+		}
+	}
+	newPVC := func(storageClassName string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "disk"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.StringPtr(storageClassName)},
+		}
+	}
+
+	verified := newImage("verified", corev1.ConditionTrue)
+	verifying := newImage("verifying", corev1.ConditionUnknown)
+	failed := newImage("failed", corev1.ConditionFalse)
+	k8sClientSet := k8sfake.NewSimpleClientset(newStorageClass(verified), newStorageClass(verifying), newStorageClass(failed))
+	clientSet := fake.NewSimpleClientset(verified, verifying, failed, newBackingImage(verified), newBackingImage(verifying), newBackingImage(failed))
+	checker := NewImageVerificationChecker(
+		fakeclients.StorageClassCache(k8sClientSet.StorageV1().StorageClasses),
+		fakeclients.BackingImageCache(clientSet.LonghornV1beta1().BackingImages),
+		fakeclients.VirtualMachineImageCache(clientSet.HarvesterhciV1beta1().VirtualMachineImages),
+	)
+
+	assert.Nil(t, checker.CheckPVC(newPVC(util.GetImageStorageClassName(verified.Name))))
+	assert.NotNil(t, checker.CheckPVC(newPVC(util.GetImageStorageClassName(verifying.Name))))
+	assert.NotNil(t, checker.CheckPVC(newPVC(util.GetImageStorageClassName(failed.Name))))
+	assert.Nil(t, checker.CheckPVC(newPVC("longhorn")))
+
+	// the PVCs restored from snapshots don't read the image data
+	restored := newPVC(util.GetImageStorageClassName(failed.Name))
+	restored.Spec.DataSource = &corev1.TypedLocalObjectReference{Kind: "VolumeSnapshot", Name: "snapshot"}
+	assert.Nil(t, checker.CheckPVC(restored))
+}
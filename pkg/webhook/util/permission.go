@@ -0,0 +1,36 @@
+package util
+
+import (
+	"context"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+// CanUserDo returns true if the user of the request is allowed to do the action on the resource.
+// The webhook runs as the harvester service account, so the permissions of the user are checked
+// with a SubjectAccessReview of the user instead of a SelfSubjectAccessReview.
+func CanUserDo(ctx context.Context, sars authorizationv1client.SubjectAccessReviewInterface, request *types.Request,
+	attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	userInfo := request.UserInfo
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, value := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	sar, err := sars.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: attributes,
+			User:               userInfo.Username,
+			UID:                userInfo.UID,
+			Groups:             userInfo.Groups,
+			Extra:              extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed && !sar.Status.Denied, nil
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/wrangler/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/harvester/harvester/pkg/webhook/config"
+	"github.com/harvester/harvester/pkg/webhook/types"
+)
+
+func TestCanUserDo(t *testing.T) {
+	clientSet := k8sfake.NewSimpleClientset()
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		// the permissions can be granted to the groups of the user
+		sar.Status.Allowed = len(sar.Spec.Groups) == 1 && sar.Spec.Groups[0] == "admins" &&
+			sar.Spec.Extra["scope"][0] == "harvester" && sar.Spec.ResourceAttributes.Resource == "secrets"
+		return true, sar, nil
+	})
+	newRequest := func(groups ...string) *types.Request {
+		return types.NewRequest(&webhook.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{
+					Username: "alice",
+					Groups:   groups,
+					Extra:    map[string]authenticationv1.ExtraValue{"scope": {"harvester"}},
+				},
+			},
+		}, &config.Options{})
+	}
+	attributes := &authorizationv1.ResourceAttributes{Namespace: "default", Verb: "get", Resource: "secrets", Name: "registry"}
+	sars := clientSet.AuthorizationV1().SubjectAccessReviews()
+
+	allowed, err := CanUserDo(context.Background(), sars, newRequest("admins"), attributes)
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = CanUserDo(context.Background(), sars, newRequest("users"), attributes)
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}